@@ -0,0 +1,237 @@
+// Command loadgen soak-tests the service in-process. An embedded dev chain mines
+// synthetic blocks at a fixed rate for a parser to index, while readers query the HTTP
+// API at a fixed rate. At the end it reports indexing throughput and per-route latency
+// percentiles, to validate performance changes before they reach production.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+	"github.com/bhaweshksingh/tx-parser-svc/internal/txparser"
+)
+
+// settings are the load parameters, set by flags.
+type settings struct {
+	blocksPerSec float64
+	txsPerBlock  int
+	addresses    int
+	subscribers  int
+	readQPS      float64
+	duration     time.Duration
+	maxQueries   int
+	dbPath       string
+	seed         uint64
+}
+
+func main() {
+	var s settings
+	flag.Float64Var(&s.blocksPerSec, "blocks-per-sec", 2, "blocks mined per second by the dev chain")
+	flag.IntVar(&s.txsPerBlock, "txs-per-block", 100, "maximum transfers per block; each block holds 0 to n, n/2 on average")
+	flag.IntVar(&s.addresses, "addresses", 1000, "addresses transfers are generated between")
+	flag.IntVar(&s.subscribers, "subscribers", 500, "addresses subscribed before the run, at most -addresses")
+	flag.Float64Var(&s.readQPS, "read-qps", 50, "GET /transactions requests per second for random subscribed addresses")
+	flag.DurationVar(&s.duration, "duration", time.Minute, "how long to generate load")
+	flag.IntVar(&s.maxQueries, "max-queries", 16, "concurrent expensive queries served; 0 for no limit")
+	flag.StringVar(&s.dbPath, "db", "", "BoltDB file for the parser; empty keeps state in memory")
+	flag.Uint64Var(&s.seed, "seed", 1, "seed for generated transfers and reader address choice")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	if err := s.validate(); err != nil {
+		logger.Error("Invalid settings", "err", err)
+		os.Exit(2)
+	}
+	if err := run(s, logger); err != nil {
+		logger.Error("Load test failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// validate reports every invalid setting at once.
+func (s settings) validate() error {
+	var errs []error
+	if s.blocksPerSec <= 0 || s.readQPS < 0 {
+		errs = append(errs, errors.New("blocks-per-sec must be positive and read-qps not negative"))
+	}
+	if s.txsPerBlock < 0 {
+		errs = append(errs, errors.New("txs-per-block must not be negative"))
+	}
+	if s.addresses < 2 || s.subscribers < 1 || s.subscribers > s.addresses {
+		errs = append(errs, errors.New("addresses must be at least 2 and subscribers between 1 and addresses"))
+	}
+	if s.subscribers > txparser.MaxBatchItems {
+		errs = append(errs, fmt.Errorf("subscribers must be at most %d", txparser.MaxBatchItems))
+	}
+	if s.duration <= 0 {
+		errs = append(errs, errors.New("duration must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// run serves the API on a loopback port, subscribes, and generates load for the duration.
+func run(s settings, logger *slog.Logger) error {
+	addresses := make([]string, s.addresses)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("0x%040x", i+1)
+	}
+	chain := txparser.NewDevChain(addresses, s.seed)
+	chain.SetMaxTransactions(s.txsPerBlock)
+
+	var store txparser.Store = txparser.NewMemoryStore()
+	if s.dbPath != "" {
+		boltStore, err := txparser.OpenBoltStore(s.dbPath, logger)
+		if err != nil {
+			return fmt.Errorf("opening bolt store failed: %w", err)
+		}
+		defer boltStore.Close()
+		store = boltStore
+	}
+	parser := txparser.NewEthParser(chain, store, logger)
+	parser.SetCatchUp(20)
+	server := txparser.NewHTTPServer(parser, logger)
+	server.SetQueryLimit(s.maxQueries, 2*time.Second)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("listen failed: %w", err)
+	}
+	srv := &http.Server{Handler: server.Router()}
+	go srv.Serve(listener)
+	defer srv.Close()
+	baseURL := "http://" + listener.Addr().String()
+	client := &http.Client{Timeout: 10 * time.Second}
+	stats := newLatencyStats()
+
+	subscribed := addresses[:s.subscribers]
+	body, _ := json.Marshal(subscribed)
+	if err := stats.do(client, "POST /subscribe/batch", http.MethodPost, baseURL+"/subscribe/batch", body); err != nil {
+		return fmt.Errorf("subscribing failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.duration)
+	defer cancel()
+	start := time.Now()
+	go chain.StartMining(ctx, time.Duration(float64(time.Second)/s.blocksPerSec))
+	go parser.StartParsing(ctx, 100*time.Millisecond)
+
+	var readers sync.WaitGroup
+	if s.readQPS > 0 {
+		rng := rand.New(rand.NewPCG(s.seed, s.seed+1))
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / s.readQPS))
+	reading:
+		for {
+			select {
+			case <-ctx.Done():
+				break reading
+			case <-ticker.C:
+				address := subscribed[rng.IntN(len(subscribed))]
+				readers.Add(1)
+				go func() {
+					defer readers.Done()
+					stats.do(client, "GET /transactions", http.MethodGet, baseURL+"/transactions?address="+address+"&limit=100", nil)
+				}()
+			}
+		}
+		ticker.Stop()
+	} else {
+		<-ctx.Done()
+	}
+	elapsed := time.Since(start)
+	readers.Wait()
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stopCancel()
+	if err := parser.Stop(stopCtx); err != nil {
+		return err
+	}
+
+	tip, _ := chain.BlockNumber(context.Background())
+	fmt.Printf("duration %s, chain tip %s, parsed %d blocks (%.1f blocks/s)\n",
+		elapsed.Round(time.Millisecond), tip, parser.GetCurrentBlock(), float64(parser.GetCurrentBlock())/elapsed.Seconds())
+	stats.report(os.Stdout, elapsed)
+	return nil
+}
+
+// latencyStats collects request latencies and failures per route.
+type latencyStats struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	failures  map[string]int
+}
+
+func newLatencyStats() *latencyStats {
+	return &latencyStats{latencies: make(map[string][]time.Duration), failures: make(map[string]int)}
+}
+
+// do sends one request and records its latency under route. Transport errors and
+// non-2xx responses count as failures.
+func (l *latencyStats) do(client *http.Client, route, method, url string, body []byte) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err == nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+			if apiErr, decodeErr := apierror.Decode(resp.Body); decodeErr == nil {
+				err = fmt.Errorf("unexpected status code %d: %w", resp.StatusCode, apiErr)
+			}
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	latency := time.Since(start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.latencies[route] = append(l.latencies[route], latency)
+	if err != nil {
+		l.failures[route]++
+	}
+	return err
+}
+
+// report writes a table of request rate, failures and latency percentiles per route.
+func (l *latencyStats) report(w io.Writer, elapsed time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	routes := make([]string, 0, len(l.latencies))
+	for route := range l.latencies {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "route\trequests\treq/s\tfailed\tp50\tp90\tp99\tmax\t")
+	for _, route := range routes {
+		latencies := l.latencies[route]
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%s\t%s\t%s\t%s\t\n", route, len(latencies),
+			float64(len(latencies))/elapsed.Seconds(), l.failures[route],
+			percentile(latencies, 0.5), percentile(latencies, 0.9), percentile(latencies, 0.99), latencies[len(latencies)-1].Round(time.Microsecond))
+	}
+	tw.Flush()
+}
+
+// percentile returns the p-th quantile of sorted, which must not be empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	return sorted[int(p*float64(len(sorted)-1))].Round(time.Microsecond)
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,6 +14,7 @@ import (
 
 	"log/slog"
 
+	"github.com/bhaweshksingh/tx-parser-svc/internal/config"
 	"github.com/bhaweshksingh/tx-parser-svc/internal/txparser"
 )
 
@@ -27,39 +30,450 @@ func main() {
 		Level:     slog.LevelInfo,
 	}))
 
-	logger.Info("Starting Ethereum TX Parser...")
+	// Load settings from flags and TXPARSER_* environment variables.
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "err", err)
+		os.Exit(2)
+	}
 
-	// Create an in-memory store to track subscriptions and transactions.
+	logger.Info("Starting Ethereum TX Parser...",
+		"poll_interval", cfg.PollInterval.String(),
+		"listen_addr", cfg.ListenAddr,
+	)
+
+	// Track subscriptions and transactions in memory, in BoltDB when a db path is set, or
+	// in Redis shared with other replicas.
 	store := txparser.NewMemoryStore()
+	if cfg.RedisURL != "" {
+		redisStore, err := txparser.OpenRedisStore(cfg.RedisURL, cfg.RedisPrefix(""), logger)
+		if err != nil {
+			logger.Error("Failed to open redis store", "err", err)
+			os.Exit(1)
+		}
+		defer redisStore.Close()
+		store = redisStore
+		logger.Info("Using shared redis store", "prefix", cfg.RedisPrefix(""))
+	}
+	if cfg.DBPath != "" {
+		boltStore, err := txparser.OpenBoltStore(cfg.DBPath, logger)
+		if err != nil {
+			logger.Error("Failed to open bolt store", "path", cfg.DBPath, "err", err)
+			os.Exit(1)
+		}
+		defer boltStore.Close()
+		store = boltStore
+		logger.Info("Using persistent bolt store", "path", cfg.DBPath)
+	}
+	memory, hasMemory := store.(txparser.MemoryReporter)
 
-	// Create a JSON-RPC client for Ethereum (points to a public node).
-	client := txparser.NewJSONRPCClient("https://ethereum-rpc.publicnode.com")
+	// Mirror writes to a candidate bolt store and compare reads when shadowing.
+	var shadow *txparser.ShadowStore
+	if cfg.ShadowDBPath != "" {
+		candidate, err := txparser.OpenBoltStore(cfg.ShadowDBPath, logger)
+		if err != nil {
+			logger.Error("Failed to open shadow bolt store", "path", cfg.ShadowDBPath, "err", err)
+			os.Exit(1)
+		}
+		defer candidate.Close()
+		shadow = txparser.NewShadowStore(store, candidate, logger)
+		store = shadow
+		logger.Info("Shadowing store writes to candidate bolt store", "path", cfg.ShadowDBPath)
+	}
 
-	// Create a parser instance that uses the JSON-RPC client and memory store.
-	parser := txparser.NewEthParser(client, store, logger)
+	// Create a JSON-RPC client for the configured Ethereum endpoint, an embedded fake
+	// chain with synthetic transfers in dev mode, or a replay of captured blocks.
+	var client txparser.JSONRPCClient
+	var devChain *txparser.DevChain
+	live := !cfg.Dev && cfg.ReplayDir == ""
+	if cfg.Dev {
+		devChain = txparser.NewDevChain(nil, uint64(time.Now().UnixNano()))
+		client = devChain
+		logger.Info("Dev mode: using embedded fake chain", "addresses", devChain.Addresses())
+	} else if cfg.ReplayDir != "" {
+		replay, err := txparser.NewFileBlockSource(cfg.ReplayDir)
+		if err != nil {
+			logger.Error("Failed to open replay directory", "dir", cfg.ReplayDir, "err", err)
+			os.Exit(1)
+		}
+		client = replay
+		logger.Info("Replaying captured blocks", "dir", cfg.ReplayDir)
+	} else {
+		endpoints := cfg.RPCEndpoints()
+		client = txparser.NewJSONRPCClient(endpoints[0], endpoints[1:]...)
+	}
+
+	// Collect parser, RPC and HTTP metrics for Prometheus on /metrics.
+	metrics := txparser.NewPrometheusMetrics()
+	if instrumented, ok := client.(interface{ SetMetrics(txparser.Metrics) }); ok {
+		instrumented.SetMetrics(metrics)
+	}
+	configureRetries(client, cfg.RetryPolicy(), logger)
+	configureRateLimit(client, cfg.RPCRateLimit, cfg.RPCBurst)
 
-	// Create a cancellable context for controlling the background parser loop.
+	// Create a cancellable context for the background loops and in-flight RPC calls.
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Start the background routine to parse blocks every 3 seconds.
-	go parser.StartParsing(ctx, 3*time.Second)
+	// Probe the endpoint and the ws-url for optional features. Receipt enrichment uses
+	// eth_getBlockReceipts when supported; all of them are reported on /status.
+	capabilities := client.DetectCapabilities(ctx)
+	if cfg.WSURL != "" && live {
+		capabilities.WebSockets = txparser.ProbeWebSocket(ctx, cfg.WSURL)
+		if !capabilities.WebSockets {
+			logger.Warn("WebSocket endpoint refused the handshake, new heads are polled until it accepts one", "ws_url", cfg.WSURL)
+		}
+	}
+	logger.Info("Detected RPC capabilities",
+		"trace", capabilities.Trace,
+		"blockReceipts", capabilities.BlockReceipts,
+		"feeHistory", capabilities.FeeHistory,
+		"websockets", capabilities.WebSockets,
+	)
+
+	// Load the settings every parser shares: the primary one, and those of additional
+	// chains and projects.
+	serveOnly, _ := txparser.ParseVisibility(cfg.ServeOnly)    // validated by config.Load
+	discoveryContracts, _ := cfg.DiscoveryAddresses()          // validated by config.Load
+	disabled, _ := txparser.ParseFeatures(cfg.DisableFeatures) // validated by config.Load
+	features := txparser.NewFeatureFlags(disabled...)
+	if cfg.Audit {
+		logger.Warn("Audit mode enabled, store invariant violations will panic")
+	}
+	var tagRules []txparser.Rule
+	if cfg.Rules != "" {
+		tagRules, err = txparser.LoadRules(cfg.Rules)
+		if err != nil {
+			logger.Error("Failed to load rules", "path", cfg.Rules, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Tagging matched transactions", "rules", len(tagRules))
+	}
+	var priorityRules []txparser.Rule
+	if cfg.PriorityRules != "" {
+		priorityRules, err = txparser.LoadRules(cfg.PriorityRules)
+		if err != nil {
+			logger.Error("Failed to load priority rules", "path", cfg.PriorityRules, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Routing priority transactions to the priority inbox", "rules", len(priorityRules), "size", cfg.PriorityInboxSize)
+	}
+
+	// Forward rule-tagged transactions of every parser to a SIEM collector.
+	var siem *txparser.SIEMExporter
+	if cfg.SIEMAddr != "" {
+		siemCfg, _ := cfg.SIEM() // validated by config.Load
+		siem, err = txparser.DialSIEM(siemCfg, logger)
+		if err != nil {
+			logger.Error("Failed to connect to SIEM collector", "addr", cfg.SIEMAddr, "err", err)
+			os.Exit(1)
+		}
+		defer siem.Close()
+		logger.Info("Exporting tagged transactions to SIEM collector", "addr", cfg.SIEMAddr, "format", cfg.SIEMFormat)
+	}
+
+	// Deliver matched transactions to subscriber webhook URLs in the background. Delivery
+	// outlives ctx, so the queue can be drained after parsing stops.
+	deliveryCtx, cancelDelivery := context.WithCancel(context.Background())
+	defer cancelDelivery()
+	webhooks := txparser.NewWebhookNotifier(logger)
+	webhooks.SetMetrics(metrics)
+	webhooks.SetAllowPrivateTargets(cfg.WebhookAllowPrivate)
+	webhooks.Start(deliveryCtx, 4)
+
+	shared := parserSettings{
+		cfg:           cfg,
+		serveOnly:     serveOnly,
+		metrics:       metrics,
+		features:      features,
+		webhooks:      webhooks,
+		tagRules:      tagRules,
+		priorityRules: priorityRules,
+		discovery:     discoveryContracts,
+	}
+	if siem != nil {
+		shared.exporter = siem
+	}
+	if cfg.RiskURL != "" {
+		// Score counterparties of matched transactions, caching each score for an hour.
+		shared.risk = txparser.NewHTTPRiskScorer(cfg.RiskURL, time.Hour)
+	}
+
+	// Create a parser instance that uses the JSON-RPC client and the store.
+	parser := newParser(client, store, capabilities.BlockReceipts, shared, logger)
+	if cfg.ArchiveDir != "" {
+		archiver, err := txparser.NewDirArchiver(cfg.ArchiveDir, cfg.ArchiveCompress, cfg.ArchiveMaxFiles)
+		if err != nil {
+			logger.Error("Failed to create block archive", "dir", cfg.ArchiveDir, "err", err)
+			os.Exit(1)
+		}
+		parser.SetArchiver(archiver)
+		logger.Info("Archiving fetched blocks", "dir", cfg.ArchiveDir, "compress", cfg.ArchiveCompress, "max_files", cfg.ArchiveMaxFiles)
+	}
+	if cfg.ErrorLog != "" {
+		// Mirror the errors listed at /admin/errors to a file surviving restarts.
+		errHistory, err := txparser.NewErrorHistory(txparser.DefaultErrorHistorySize, cfg.ErrorLog)
+		if err != nil {
+			logger.Error("Failed to open error log", "path", cfg.ErrorLog, "err", err)
+			os.Exit(1)
+		}
+		defer errHistory.Close()
+		parser.SetErrorHistory(errHistory)
+	}
+
+	// Probe every endpoint so failed ones return to rotation once they recover.
+	if multi, ok := client.(*txparser.MultiClient); ok {
+		go multi.StartProbing(ctx, 15*time.Second)
+	}
+	if shadow != nil {
+		shadow.Start(ctx)
+	}
+
+	// Restore a checkpoint into a fresh or outdated store before parsing resumes, and
+	// write new ones periodically.
+	if cfg.RestoreCheckpoint != "" {
+		checkpoint, err := txparser.ReadCheckpoint(cfg.RestoreCheckpoint)
+		if err != nil {
+			logger.Error("Failed to read checkpoint", "path", cfg.RestoreCheckpoint, "err", err)
+			os.Exit(1)
+		}
+		switch err := parser.RestoreCheckpoint(checkpoint); {
+		case errors.Is(err, txparser.ErrCheckpointStale):
+			logger.Info("Skipping checkpoint restore", "reason", err)
+		case err != nil:
+			logger.Error("Failed to restore checkpoint", "path", cfg.RestoreCheckpoint, "err", err)
+			os.Exit(1)
+		default:
+			logger.Info("Restored checkpoint", "path", cfg.RestoreCheckpoint, "block", checkpoint.Block, "subscriptions", len(checkpoint.Subscriptions))
+		}
+	}
+	if cfg.Checkpoint != "" {
+		go parser.StartCheckpoints(ctx, cfg.Checkpoint, cfg.CheckpointInterval)
+	}
+
+	// Start the background routine to parse blocks every poll interval. Replicas sharing
+	// a Redis store take turns through a lease, so only one advances the store.
+	leader := electLeader(ctx, store, cfg.LeaderLease, logger)
+	parser.SetLeaderElector(leader)
+	go parser.StartParsing(ctx, cfg.PollInterval)
+	if cfg.WSURL != "" && live {
+		// Poll as soon as a block is announced; missed heads are backfilled over HTTP.
+		go txparser.NewHeadSubscriber(cfg.WSURL, parser, logger).Run(ctx)
+	}
+	if devChain != nil {
+		go devChain.StartMining(ctx, txparser.DevBlockTime)
+	}
+
+	// Prune transactions past the retention policy, which can be changed at /admin/retention.
+	retention, err := txparser.NewRetentionManager(store, cfg.Retention(), cfg.RetentionFile, logger)
+	if err != nil {
+		logger.Error("Failed to load retention policy", "path", cfg.RetentionFile, "err", err)
+		os.Exit(1)
+	}
+	retention.SetMetrics(metrics)
+	retention.Start(ctx, txparser.DefaultPruneInterval)
+
+	// Create the job manager for long-running operations, persisting its records when
+	// a jobs file or db is configured.
+	jobs, err := txparser.NewJobManager(cfg.JobsPath(), 2, logger)
+	if err != nil {
+		logger.Error("Failed to create job manager", "path", cfg.JobsPath(), "err", err)
+		os.Exit(1)
+	}
+	parser.RegisterJobs(jobs)
+	jobs.Start(ctx)
 
 	// Create our HTTP server using the parser and logger.
 	server := txparser.NewHTTPServer(parser, logger)
+	server.SetCapabilities(capabilities)
+	server.SetServeOnly(serveOnly)
+	server.SetJobManager(jobs)
+	server.SetWebhookNotifier(webhooks)
+	server.SetFeatureFlags(features)
+	server.SetRetentionManager(retention)
+	server.SetLeaderElector(leader)
+	keys, err := cfg.AuthKeys()
+	if err != nil {
+		logger.Error("Failed to load API keys", "err", err)
+		os.Exit(1)
+	}
+	var auth *txparser.Authenticator
+	if len(keys) > 0 {
+		auth, err = txparser.NewAuthenticator(keys)
+		if err != nil {
+			logger.Error("Invalid API keys", "err", err)
+			os.Exit(1)
+		}
+		server.SetAuthenticator(auth)
+		logger.Info("Requiring API keys", "keys", len(keys))
+	} else {
+		logger.Warn("No API keys configured, the HTTP and gRPC APIs are open")
+	}
+	if auth != nil || cfg.APIRateLimit > 0 || cfg.APIMonthlyQuota > 0 {
+		// Account requests per accepted API key at /usage, enforcing the key limits.
+		usage := txparser.NewUsageTracker(cfg.UsageLimits())
+		usage.SetAuthenticator(auth)
+		server.SetUsageTracker(usage)
+	}
+	if devChain != nil {
+		server.SetDevChain(devChain)
+	}
+	if shadow != nil {
+		server.SetShadowStore(shadow)
+	}
+	if checker, ok := client.(txparser.HealthChecker); ok {
+		server.AddReadinessCheck("rpc", checker)
+	}
+	if checker, ok := store.(txparser.HealthChecker); ok {
+		server.AddReadinessCheck("store", checker)
+	}
+	if hasMemory {
+		server.SetMemoryReporter(memory)
+		metrics.SetMemoryReporter(memory)
+	}
+	server.SetQueryLimit(cfg.MaxQueries, cfg.QueryQueue)
+	server.SetMetrics(metrics)
+
+	// Index each additional chain with its own client, store and parser, served to
+	// requests with ?chain=name. They share the primary chain's parser settings.
+	server.SetChainName(cfg.Chain)
+	parsers := []*txparser.EthParser{parser}
+	chains, _ := cfg.ChainConfigs() // validated by config.Load
+	for _, chain := range chains {
+		chainLogger := logger.With("chain", chain.Name)
+		chainStore := txparser.NewMemoryStore()
+		if path := cfg.ChainDBPath(chain.Name); path != "" {
+			boltStore, err := txparser.OpenBoltStore(path, chainLogger)
+			if err != nil {
+				chainLogger.Error("Failed to open bolt store", "path", path, "err", err)
+				os.Exit(1)
+			}
+			defer boltStore.Close()
+			chainStore = boltStore
+		} else if cfg.RedisURL != "" {
+			redisStore, err := txparser.OpenRedisStore(cfg.RedisURL, cfg.RedisPrefix(chain.Name), chainLogger)
+			if err != nil {
+				chainLogger.Error("Failed to open redis store", "err", err)
+				os.Exit(1)
+			}
+			defer redisStore.Close()
+			chainStore = redisStore
+		}
+		endpoints := chain.RPCEndpoints()
+		chainClient := txparser.NewJSONRPCClient(endpoints[0], endpoints[1:]...)
+		configureRetries(chainClient, cfg.RetryPolicy(), chainLogger)
+		configureRateLimit(chainClient, cfg.RPCRateLimit, cfg.RPCBurst)
+		blockReceipts := cfg.Receipts && chainClient.DetectCapabilities(ctx).BlockReceipts
+		chainParser := newParser(chainClient, chainStore, blockReceipts, shared.secondary(), chainLogger)
+		chainParser.SetLeaderElector(electLeader(ctx, chainStore, cfg.LeaderLease, chainLogger))
+		go chainParser.StartParsing(ctx, chain.PollInterval)
+		startRetention(ctx, chainStore, cfg, metrics, chainLogger)
+		server.AddChain(chain.Name, chainParser)
+		parsers = append(parsers, chainParser)
+		if checker, ok := chainClient.(txparser.HealthChecker); ok {
+			server.AddReadinessCheck("rpc-"+chain.Name, checker)
+		}
+		logger.Info("Indexing additional chain", "chain", chain.Name, "poll_interval", chain.PollInterval.String())
+	}
+
+	// Index each project, an independent watch list on the primary chain, with its own
+	// store, rules and parser, served under /projects/{name}/. Projects share the primary
+	// client, so each one adds its own block fetches.
+	projects, _ := cfg.ProjectConfigs() // validated by config.Load
+	for _, project := range projects {
+		projectLogger := logger.With("project", project.Name)
+		projectStore := txparser.NewMemoryStore()
+		if path := cfg.ProjectDBPath(project.Name); path != "" {
+			boltStore, err := txparser.OpenBoltStore(path, projectLogger)
+			if err != nil {
+				projectLogger.Error("Failed to open bolt store", "path", path, "err", err)
+				os.Exit(1)
+			}
+			defer boltStore.Close()
+			projectStore = boltStore
+		} else if cfg.RedisURL != "" {
+			redisStore, err := txparser.OpenRedisStore(cfg.RedisURL, cfg.RedisPrefix("project-"+project.Name), projectLogger)
+			if err != nil {
+				projectLogger.Error("Failed to open redis store", "err", err)
+				os.Exit(1)
+			}
+			defer redisStore.Close()
+			projectStore = redisStore
+		}
+		projectSettings := shared.secondary()
+		projectSettings.tagRules = nil // projects tag with their own rules only
+		if project.Rules != "" {
+			projectSettings.tagRules, err = txparser.LoadRules(project.Rules)
+			if err != nil {
+				projectLogger.Error("Failed to load rules", "path", project.Rules, "err", err)
+				os.Exit(1)
+			}
+		}
+		projectParser := newParser(client, projectStore, capabilities.BlockReceipts, projectSettings, projectLogger)
+		projectLeader := electLeader(ctx, projectStore, cfg.LeaderLease, projectLogger)
+		projectParser.SetLeaderElector(projectLeader)
+		go projectParser.StartParsing(ctx, cfg.PollInterval)
+		projectServer := txparser.NewHTTPServer(projectParser, projectLogger)
+		projectServer.SetRetentionManager(startRetention(ctx, projectStore, cfg, metrics, projectLogger))
+		projectServer.SetLeaderElector(projectLeader)
+		projectServer.SetServeOnly(serveOnly)
+		projectServer.SetWebhookNotifier(webhooks)
+		projectServer.SetQueryLimit(cfg.MaxQueries, cfg.QueryQueue)
+		server.AddProject(project.Name, projectServer)
+		parsers = append(parsers, projectParser)
+		logger.Info("Indexing project", "project", project.Name)
+	}
+
 	srv := &http.Server{
-		Addr:    ":8080",
+		Addr:    cfg.ListenAddr,
 		Handler: server.Router(),
+		// Request contexts derive from ctx, so shutdown aborts their RPC calls too.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	// Listen before serving so connection limits apply from the first accept.
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		logger.Error("Failed to listen", "addr", cfg.ListenAddr, "err", err)
+		os.Exit(1)
+	}
+	if cfg.MaxConns > 0 {
+		listener = txparser.LimitListener(listener, cfg.MaxConns, logger)
 	}
 
 	// Start the HTTP server in a separate goroutine.
 	go func() {
 		logger.Info("Starting HTTP server", "addr", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Error("HTTP server error", "err", err)
 			cancel()
 		}
 	}()
 
+	// Serve the gRPC API on its own port, for consumers preferring it to HTTP.
+	var grpcServer *txparser.GRPCServer
+	if cfg.GRPCAddr != "" {
+		grpcServer = txparser.NewGRPCServer(parser, logger)
+		grpcServer.SetServeOnly(serveOnly)
+		if auth != nil {
+			grpcServer.SetAuthenticator(auth)
+		}
+		grpcListener, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			logger.Error("Failed to listen", "addr", cfg.GRPCAddr, "err", err)
+			os.Exit(1)
+		}
+		go func() {
+			logger.Info("Starting gRPC server", "addr", cfg.GRPCAddr)
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				logger.Error("gRPC server error", "err", err)
+				cancel()
+			}
+		}()
+	}
+
 	// Listen for system interrupts (Ctrl+C, SIGTERM) to allow graceful shutdown.
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -67,17 +481,159 @@ func main() {
 	<-sigChan
 	logger.Info("Received shutdown signal, attempting graceful shutdown...")
 
-	// Cancel the background parser context.
+	// Refuse new writes and fail readiness first, so no subscription lands after the
+	// state below is persisted.
+	server.Drain()
+
+	// Cancel the background loops and the RPC calls of in-flight requests. Parsers
+	// finish the block in progress first.
 	cancel()
 
-	// Gracefully shut down the HTTP server with a 5-second timeout.
+	// Gracefully shut down the HTTP server and parsers with a 5-second timeout, so the
+	// stores are flushed before their deferred Close.
 	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelShutdown()
 
 	if err := srv.Shutdown(ctxShutdown); err != nil {
 		logger.Error("Server shutdown error", "err", err)
 	}
+	if grpcServer != nil {
+		grpcServer.Shutdown(ctxShutdown)
+	}
+	for _, p := range parsers {
+		if err := p.Stop(ctxShutdown); err != nil {
+			logger.Error("Parser shutdown error", "err", err)
+		}
+	}
+
+	// With parsing stopped, no notifications are added; deliver the queued ones.
+	ctxDrain, cancelDrain := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancelDrain()
+	if err := webhooks.Drain(ctxDrain); err != nil {
+		logger.Warn("Dropping undelivered webhooks", "err", err)
+	}
+	cancelDelivery()
+
+	// A final checkpoint records the position the flushed store resumes from.
+	if cfg.Checkpoint != "" {
+		checkpoint, err := parser.Checkpoint()
+		if err == nil {
+			err = txparser.WriteCheckpoint(cfg.Checkpoint, checkpoint)
+		}
+		if err != nil {
+			logger.Error("Failed to write checkpoint", "path", cfg.Checkpoint, "err", err)
+		}
+	}
 
 	logger.Info("Shutdown complete. Goodbye!")
 	fmt.Println("Exiting.")
 }
+
+// parserSettings are the settings shared by the primary parser and the parsers of
+// additional chains and projects.
+type parserSettings struct {
+	cfg           config.Config
+	serveOnly     txparser.Visibility
+	metrics       txparser.Metrics
+	features      *txparser.FeatureFlags
+	webhooks      *txparser.WebhookNotifier
+	exporter      txparser.FlaggedExporter // nil unless a SIEM collector is configured
+	risk          txparser.RiskScorer      // nil unless a risk endpoint is configured
+	tagRules      []txparser.Rule
+	priorityRules []txparser.Rule
+	discovery     []string
+}
+
+// secondary returns the settings for a parser other than the primary one, which
+// leaves the primary chain's block and lag metrics alone.
+func (s parserSettings) secondary() parserSettings {
+	s.metrics = txparser.WithoutChainGauges(s.metrics)
+	return s
+}
+
+// newParser creates a parser for client and store configured with s. blockReceipts
+// reports whether client supports eth_getBlockReceipts.
+func newParser(client txparser.BlockSource, store txparser.Store, blockReceipts bool, s parserSettings, logger *slog.Logger) *txparser.EthParser {
+	cfg := s.cfg
+	parser := txparser.NewEthParser(client, store, logger)
+	parser.SetCatchUp(cfg.CatchUpBatch)
+	parser.SetPrefetchDepth(cfg.PrefetchDepth)
+	parser.SetMetrics(s.metrics)
+	parser.SetTokenTracking(cfg.TrackTokens)
+	parser.SetReceiptEnrichment(cfg.Receipts)
+	parser.SetBlockReceipts(blockReceipts)
+	parser.SetLogMatching(cfg.MatchLogTopics)
+	parser.SetInputMatching(cfg.MatchInput)
+	parser.SetIndexConfirmations(cfg.Confirmations)
+	parser.SetConfirmations(cfg.ServeConfirmations)
+	parser.SetFinalizedTracking(s.serveOnly == txparser.VisibilityFinalized)
+	parser.SetLowMemory(cfg.LowMemory)
+	parser.SetAuditMode(cfg.Audit)
+	parser.SetAnomalyDetection(cfg.AnomalySensitivity)
+	parser.SetDeploymentSubscription(cfg.SubscribeDeployments)
+	if len(s.discovery) > 0 {
+		parser.SetAutoDiscovery(s.discovery, cfg.DiscoveryTTL)
+	}
+	parser.SetFeatureFlags(s.features)
+	parser.SetWebhookNotifier(s.webhooks)
+	if cfg.StartBlock != "" {
+		startBlock, _ := txparser.ParseStartBlock(cfg.StartBlock) // validated by config.Load
+		parser.SetStartBlock(startBlock)
+	}
+	if len(s.tagRules) > 0 {
+		parser.SetRules(s.tagRules)
+	}
+	if len(s.priorityRules) > 0 {
+		parser.SetPriorityRules(s.priorityRules, cfg.PriorityInboxSize)
+	}
+	if s.exporter != nil {
+		parser.SetFlaggedExporter(s.exporter)
+	}
+	if s.risk != nil {
+		parser.SetRiskScorer(s.risk)
+	}
+	return parser
+}
+
+// startRetention prunes the store of an additional chain or project in the background
+// under the configured retention policy. Policy changes at /admin/retention are not saved.
+func startRetention(ctx context.Context, store txparser.Store, cfg config.Config, metrics txparser.Metrics, logger *slog.Logger) *txparser.RetentionManager {
+	retention, _ := txparser.NewRetentionManager(store, cfg.Retention(), "", logger) // validated by config.Load
+	retention.SetMetrics(metrics)
+	retention.Start(ctx, txparser.DefaultPruneInterval)
+	return retention
+}
+
+// electLeader competes for the parsing lease of a store shared between replicas until
+// ctx is canceled. It returns nil, letting the parser run unconditionally, if the store
+// is not shared or the lease is disabled.
+func electLeader(ctx context.Context, store txparser.Store, ttl time.Duration, logger *slog.Logger) *txparser.LeaderElector {
+	leases, ok := store.(txparser.LeaseStore)
+	if !ok || ttl == 0 {
+		return nil
+	}
+	elector := txparser.NewLeaderElector(leases, "parser", txparser.ReplicaID(), ttl, logger)
+	go elector.Run(ctx)
+	return elector
+}
+
+// configureRetries applies the retry policy to a live JSON-RPC client, logging its
+// retries. The dev chain never fails, so it has no policy.
+func configureRetries(client txparser.JSONRPCClient, policy txparser.RetryPolicy, logger *slog.Logger) {
+	retrier, ok := client.(interface {
+		SetRetryPolicy(txparser.RetryPolicy)
+		SetLogger(*slog.Logger)
+	})
+	if !ok {
+		return
+	}
+	retrier.SetRetryPolicy(policy)
+	retrier.SetLogger(logger)
+}
+
+// configureRateLimit caps the requests a live JSON-RPC client sends to each endpoint.
+func configureRateLimit(client txparser.JSONRPCClient, rps float64, burst int) {
+	if limited, ok := client.(interface{ SetRateLimit(float64, int) }); ok {
+		limited.SetRateLimit(rps, burst)
+	}
+}
@@ -1,3 +1,16 @@
 module github.com/bhaweshksingh/tx-parser-svc
 
 go 1.23
+
+require (
+	go.etcd.io/bbolt v1.3.11
+	google.golang.org/grpc v1.72.2
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250218202821-56aae31c358a // indirect
+)
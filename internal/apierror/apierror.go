@@ -0,0 +1,82 @@
+// Package apierror defines the JSON error envelope returned by the HTTP API:
+//
+//	{"error": {"code": "INVALID_ADDRESS", "message": "invalid address \"0xzz\": ..."}}
+//
+// Codes are stable and meant to be matched by clients; messages are for humans and
+// may change.
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Code identifies the kind of an API error.
+type Code string
+
+// Codes returned by the API. Generic codes cover request and server conditions;
+// the others name a specific domain error.
+const (
+	CodeInvalidRequest      Code = "INVALID_REQUEST"       // malformed body or parameter
+	CodeInvalidAddress      Code = "INVALID_ADDRESS"       // address is not 0x-prefixed hex or fails its checksum
+	CodeInvalidFilter       Code = "INVALID_FILTER"        // q filter expression cannot be parsed
+	CodeUnknownFeature      Code = "UNKNOWN_FEATURE"       // feature flag name is not known
+	CodeUnknownChain        Code = "UNKNOWN_CHAIN"         // chain parameter names no configured chain
+	CodeMethodNotAllowed    Code = "METHOD_NOT_ALLOWED"    // route does not accept the HTTP method
+	CodeUnauthorized        Code = "UNAUTHORIZED"          // API key is missing or invalid
+	CodeForbidden           Code = "FORBIDDEN"             // API key may not use the route
+	CodeNotFound            Code = "NOT_FOUND"             // requested resource does not exist
+	CodeNotSubscribed       Code = "NOT_SUBSCRIBED"        // address is not subscribed
+	CodeJobNotFound         Code = "JOB_NOT_FOUND"         // background job does not exist
+	CodeTransactionNotFound Code = "TRANSACTION_NOT_FOUND" // transaction is unknown to the node
+	CodeDeliveryNotFound    Code = "DELIVERY_NOT_FOUND"    // webhook delivery does not exist
+	CodeConflict            Code = "CONFLICT"              // resource is not in a state allowing the request
+	CodeCursorExpired       Code = "CURSOR_EXPIRED"        // cursor is older than the retained event log
+	CodePayloadTooLarge     Code = "PAYLOAD_TOO_LARGE"     // request exceeds a size limit
+	CodeRateLimited         Code = "RATE_LIMITED"          // API key exceeded its quota or rate
+	CodeOverloaded          Code = "OVERLOADED"            // server is busy; retry later
+	CodeNotImplemented      Code = "NOT_IMPLEMENTED"       // configured backends do not support the request
+	CodeUpstreamError       Code = "UPSTREAM_ERROR"        // the Ethereum node failed
+	CodeStoreUnavailable    Code = "STORE_UNAVAILABLE"     // transaction store cannot serve requests
+	CodeShuttingDown        Code = "SHUTTING_DOWN"         // server is draining
+	CodeNotLeader           Code = "NOT_LEADER"            // route is served by the replica holding the parsing lease
+	CodeNotReady            Code = "NOT_READY"             // chain state the request depends on is not known yet
+	CodeInternal            Code = "INTERNAL"              // unexpected server error
+)
+
+// Error is the body of an error response.
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Envelope is the JSON document of an error response.
+type Envelope struct {
+	Error *Error `json:"error"`
+}
+
+// Write replies to a request with status and an envelope holding code and message.
+func Write(w http.ResponseWriter, status int, code Code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Envelope{Error: &Error{Code: code, Message: message}})
+}
+
+// Decode reads an error response body, returning an error if it is not an envelope.
+func Decode(r io.Reader) (*Error, error) {
+	var env Envelope
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("decode error response: %w", err)
+	}
+	if env.Error == nil || env.Error.Code == "" {
+		return nil, fmt.Errorf("decode error response: no error code")
+	}
+	return env.Error, nil
+}
@@ -0,0 +1,31 @@
+package apierror
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWriteDecode verifies that a written envelope decodes back to its code and message.
+func TestWriteDecode(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Write(rec, http.StatusBadRequest, CodeInvalidAddress, `invalid address "0xzz"`)
+
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if want := `{"error":{"code":"INVALID_ADDRESS","message":"invalid address \"0xzz\""}}`; strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("body = %s, want %s", rec.Body, want)
+	}
+	got, err := Decode(rec.Body)
+	if err != nil || got.Code != CodeInvalidAddress || got.Message != `invalid address "0xzz"` {
+		t.Errorf("Decode = %+v, %v", got, err)
+	}
+
+	for _, body := range []string{"404 page not found\n", `{"error":{}}`, `{}`} {
+		if got, err := Decode(strings.NewReader(body)); err == nil {
+			t.Errorf("Decode(%q) = %+v, want an error", body, got)
+		}
+	}
+}
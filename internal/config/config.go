@@ -0,0 +1,847 @@
+// Package config loads the service configuration from flags and environment variables.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/txparser"
+)
+
+// Environment variables read by Load. Flags take precedence over them.
+const (
+	EnvRPCURL       = "TXPARSER_RPC_URL"
+	EnvPollInterval = "TXPARSER_POLL_INTERVAL"
+	EnvListenAddr   = "TXPARSER_LISTEN_ADDR"
+	EnvDBPath       = "TXPARSER_DB_PATH"
+	EnvShadowDBPath = "TXPARSER_SHADOW_DB_PATH"
+	EnvRedisURL     = "TXPARSER_REDIS_URL"
+	EnvLeaderLease  = "TXPARSER_LEADER_LEASE"
+	EnvCatchUpBatch = "TXPARSER_CATCH_UP_BATCH"
+	EnvDev          = "TXPARSER_DEV"
+	EnvRiskURL      = "TXPARSER_RISK_URL"
+	EnvTrackTokens  = "TXPARSER_TRACK_TOKENS"
+	EnvStartBlock   = "TXPARSER_START_BLOCK"
+	EnvMaxConns     = "TXPARSER_MAX_CONNECTIONS"
+	EnvMaxQueries   = "TXPARSER_MAX_QUERIES"
+	EnvQueryQueue   = "TXPARSER_QUERY_QUEUE_TIMEOUT"
+
+	EnvAnomalySensitivity   = "TXPARSER_ANOMALY_SENSITIVITY"
+	EnvSubscribeDeployments = "TXPARSER_SUBSCRIBE_DEPLOYMENTS"
+	EnvReceipts             = "TXPARSER_RECEIPTS"
+	EnvMatchLogTopics       = "TXPARSER_MATCH_LOG_TOPICS"
+	EnvMatchInput           = "TXPARSER_MATCH_INPUT"
+	EnvConfirmations        = "TXPARSER_CONFIRMATIONS"
+	EnvLowMemory            = "TXPARSER_LOW_MEMORY"
+	EnvServeOnly            = "TXPARSER_SERVE_ONLY"
+	EnvServeConfirmations   = "TXPARSER_SERVE_CONFIRMATIONS"
+	EnvChain                = "TXPARSER_CHAIN"
+	EnvChains               = "TXPARSER_CHAINS"
+	EnvPriorityRules        = "TXPARSER_PRIORITY_RULES"
+	EnvRules                = "TXPARSER_RULES"
+	EnvPriorityInboxSize    = "TXPARSER_PRIORITY_INBOX_SIZE"
+	EnvRPCMaxAttempts       = "TXPARSER_RPC_MAX_ATTEMPTS"
+	EnvRPCRetryBackoff      = "TXPARSER_RPC_RETRY_BACKOFF"
+	EnvCheckpoint           = "TXPARSER_CHECKPOINT"
+	EnvCheckpointInterval   = "TXPARSER_CHECKPOINT_INTERVAL"
+	EnvRestoreCheckpoint    = "TXPARSER_RESTORE_CHECKPOINT"
+	EnvDisableFeatures      = "TXPARSER_DISABLE_FEATURES"
+	EnvDrainTimeout         = "TXPARSER_DRAIN_TIMEOUT"
+	EnvRPCRateLimit         = "TXPARSER_RPC_RATE_LIMIT"
+	EnvRPCBurst             = "TXPARSER_RPC_BURST"
+	EnvAPIKeys              = "TXPARSER_API_KEYS"
+	EnvAPIKeysFile          = "TXPARSER_API_KEYS_FILE"
+	EnvAPIRateLimit         = "TXPARSER_API_RATE_LIMIT"
+	EnvAPIBurst             = "TXPARSER_API_BURST"
+	EnvAPIMonthlyQuota      = "TXPARSER_API_MONTHLY_QUOTA"
+	EnvProjects             = "TXPARSER_PROJECTS"
+	EnvRetentionBlocks      = "TXPARSER_RETENTION_BLOCKS"
+	EnvMemoryBudget         = "TXPARSER_MEMORY_BUDGET_BYTES"
+	EnvRetentionFile        = "TXPARSER_RETENTION_FILE"
+	EnvJobsFile             = "TXPARSER_JOBS_FILE"
+	EnvArchiveDir           = "TXPARSER_ARCHIVE_DIR"
+	EnvReplayDir            = "TXPARSER_REPLAY_DIR"
+	EnvArchiveCompress      = "TXPARSER_ARCHIVE_COMPRESS"
+	EnvArchiveMaxFiles      = "TXPARSER_ARCHIVE_MAX_FILES"
+	EnvSIEMAddr             = "TXPARSER_SIEM_ADDR"
+	EnvSIEMNetwork          = "TXPARSER_SIEM_NETWORK"
+	EnvSIEMFormat           = "TXPARSER_SIEM_FORMAT"
+	EnvSIEMTags             = "TXPARSER_SIEM_TAGS"
+	EnvSIEMFields           = "TXPARSER_SIEM_FIELDS"
+	EnvMaxTxsPerAddress     = "TXPARSER_MAX_TXS_PER_ADDRESS"
+	EnvGRPCAddr             = "TXPARSER_GRPC_ADDR"
+	EnvWSURL                = "TXPARSER_WS_URL"
+	EnvDiscoveryContracts   = "TXPARSER_DISCOVERY_CONTRACTS"
+	EnvDiscoveryTTL         = "TXPARSER_DISCOVERY_TTL"
+	EnvPrefetchDepth        = "TXPARSER_PREFETCH_DEPTH"
+	EnvAudit                = "TXPARSER_AUDIT"
+	EnvWebhookAllowPrivate  = "TXPARSER_WEBHOOK_ALLOW_PRIVATE"
+	EnvErrorLog             = "TXPARSER_ERROR_LOG"
+)
+
+// Defaults used when neither a flag nor an environment variable is set.
+const (
+	DefaultRPCURL       = "https://ethereum-rpc.publicnode.com"
+	DefaultPollInterval = 3 * time.Second
+	DefaultListenAddr   = ":8080"
+	DefaultCatchUpBatch = 20
+	DefaultMaxConns     = 1000
+	DefaultMaxQueries   = 16
+	DefaultQueryQueue   = 2 * time.Second
+	DefaultChain        = "mainnet"
+
+	DefaultCheckpointInterval = 5 * time.Minute
+	DefaultDrainTimeout       = 10 * time.Second
+	DefaultRPCBurst           = 10
+	DefaultAPIBurst           = 20
+	DefaultDiscoveryTTL       = 24 * time.Hour
+)
+
+// MaxCatchUpBatch bounds the catch-up batch size; providers commonly cap batches near 100.
+const MaxCatchUpBatch = 100
+
+// Config holds the settings needed to start the service.
+type Config struct {
+	RPCURL       string        // JSON-RPC endpoint, or comma-separated endpoints to fail over between
+	PollInterval time.Duration // delay between chain tip polls
+	ListenAddr   string        // HTTP listen address
+	GRPCAddr     string        // gRPC listen address; empty disables the gRPC API
+	DBPath       string        // BoltDB file; empty keeps state in memory
+	ShadowDBPath string        // candidate BoltDB file shadowing the primary store; empty disables
+	RedisURL     string        // Redis server shared by replicas, instead of DBPath; empty disables
+	CatchUpBatch int           // blocks per batch request while behind the tip; 0 disables catch-up
+	Dev          bool          // use an embedded fake chain instead of RPCURL
+	RiskURL      string        // counterparty risk provider; empty disables scoring
+	TrackTokens  bool          // also store ERC-20 transfers found with eth_getLogs
+	StartBlock   string        // latest, latest-N or a block number; empty starts from genesis
+	MaxConns     int           // concurrent HTTP connections; 0 for no limit
+	MaxQueries   int           // concurrent expensive queries; 0 for no limit
+	QueryQueue   time.Duration // how long an expensive query waits for a slot before a 503
+
+	// PrefetchDepth is how many blocks are fetched ahead concurrently while behind the
+	// tip, when blocks are not fetched in catch-up batches; 0 disables prefetching.
+	PrefetchDepth int
+	// AnomalySensitivity is how many standard deviations from the rolling mean a
+	// transaction rate must lie to raise an anomaly event; 0 disables detection.
+	AnomalySensitivity float64
+	// SubscribeDeployments subscribes contracts deployed by subscribed addresses.
+	SubscribeDeployments bool
+	// Receipts adds receipt status, gas used and fee to matched transactions.
+	Receipts bool
+	// MatchLogTopics also stores transactions whose events carry a subscribed address
+	// as an indexed topic.
+	MatchLogTopics bool
+	// MatchInput also stores transactions whose calldata carries a subscribed address
+	// as an argument, e.g. batched payouts.
+	MatchInput bool
+	// Confirmations is how many blocks behind the chain tip a block must be before it
+	// is indexed; 0 indexes blocks as they appear.
+	Confirmations int
+	// LowMemory decodes blocks one transaction at a time, holding only matching ones,
+	// instead of materializing them; blocks are then not batched, prefetched or archived.
+	LowMemory bool
+	// Audit verifies store invariants after every processed block, panicking on a
+	// violation; meant for tests and staging, not production.
+	Audit bool
+	// ServeOnly is the default visibility of served transactions: all, confirmed or
+	// finalized. Clients can override it per request.
+	ServeOnly string
+	// ServeConfirmations is how many blocks behind the chain tip a block must be for
+	// confirmed visibility.
+	ServeConfirmations int
+	// Chain names the chain at RPCURL in the chain request parameter.
+	Chain string
+	// Chains lists additional chains to index, separated by spaces, each as
+	// name=url[,url...][;poll=interval]; see ChainConfigs.
+	Chains string
+	// Projects lists independent watch lists on the primary chain, separated by spaces,
+	// each as name[;rules=file]; see ProjectConfigs.
+	Projects string
+	// DiscoveryContracts lists contracts on the primary chain, comma-separated, whose
+	// counterparties are subscribed automatically; see DiscoveryAddresses.
+	DiscoveryContracts string
+	// DiscoveryTTL is how long an auto-subscription lasts without new activity.
+	DiscoveryTTL time.Duration
+	// PriorityRules is a JSON rules file; matched transactions satisfying any rule are
+	// also kept in the priority inbox. Empty disables the inbox.
+	PriorityRules string
+	// Rules is a JSON rules file tagging matched transactions on the primary and
+	// additional chains; subscribers can filter notifications on the tags.
+	Rules string
+	// PriorityInboxSize is how many of the newest priority transactions are kept.
+	PriorityInboxSize int
+	// RPCMaxAttempts is how many times a JSON-RPC request failing with HTTP 429, 5xx or
+	// a transport error is attempted on an endpoint; 1 disables retries.
+	RPCMaxAttempts int
+	// RPCRetryBackoff is the delay before the first retry, doubled for each further one.
+	RPCRetryBackoff time.Duration
+	// RPCRateLimit caps the JSON-RPC requests per second sent to each endpoint, shared by
+	// block, receipt and log fetches; 0 disables the limit.
+	RPCRateLimit float64
+	// RPCBurst is how many requests may be sent at once above RPCRateLimit.
+	RPCBurst int
+	// APIRateLimit caps the API requests per second of each API key, or of all
+	// requests without one; 0 disables the limit.
+	APIRateLimit float64
+	// APIBurst is how many API requests a key may send at once above APIRateLimit.
+	APIBurst int
+	// APIMonthlyQuota caps the API requests of each key per calendar month; 0 disables
+	// the quota.
+	APIMonthlyQuota int64
+	// Checkpoint is the file periodically receiving a compact recovery point; empty
+	// disables checkpoints.
+	Checkpoint string
+	// CheckpointInterval is the delay between checkpoints.
+	CheckpointInterval time.Duration
+	// RestoreCheckpoint is a checkpoint file to restore at startup, unless the store
+	// is already past it.
+	RestoreCheckpoint string
+	// DisableFeatures lists optional subsystems switched off at startup, comma-separated;
+	// they can be switched back on at /admin/features.
+	DisableFeatures string
+	// APIKeys lists static API keys as comma-separated key:scope entries; with APIKeysFile
+	// empty too, the HTTP API is open.
+	APIKeys string
+	// APIKeysFile is a JSON file of API keys with names and scopes.
+	APIKeysFile string
+	// WSURL is a ws:// or wss:// endpoint whose eth_subscribe newHeads notifications
+	// trigger polls as blocks are mined; empty polls on PollInterval only.
+	WSURL string
+	// WebhookAllowPrivate permits webhooks to loopback, private and link-local hosts,
+	// which are refused by default.
+	WebhookAllowPrivate bool
+	// DrainTimeout bounds how long shutdown waits for pending webhook deliveries.
+	DrainTimeout time.Duration
+	// LeaderLease is the TTL of the lease in RedisURL that lets one replica at a time
+	// parse blocks; 0 lets every replica parse.
+	LeaderLease time.Duration
+	// RetentionBlocks is how many blocks behind the current one transactions are kept;
+	// 0 keeps every block.
+	RetentionBlocks int64
+	// MemoryBudget caps the estimated bytes of transactions in the in-memory store,
+	// evicting the oldest beyond it; 0 disables the cap.
+	MemoryBudget int64
+	// MaxTxsPerAddress keeps only the newest transactions of each address; 0 keeps all.
+	MaxTxsPerAddress int
+	// RetentionFile persists the retention policy changed at /admin/retention, taking
+	// precedence over RetentionBlocks, MemoryBudget and MaxTxsPerAddress once written;
+	// empty keeps changes until restart.
+	RetentionFile string
+	// ErrorLog receives the primary parser's errors listed at /admin/errors as JSON
+	// lines; empty keeps them in memory only.
+	ErrorLog string
+	// JobsFile persists background job records, so unfinished jobs resume after a
+	// restart; see JobsPath.
+	JobsFile string
+	// ArchiveDir receives the raw JSON of every block fetched on the primary chain;
+	// empty disables archiving.
+	ArchiveDir string
+	// ArchiveCompress gzips archived blocks.
+	ArchiveCompress bool
+	// ArchiveMaxFiles keeps only the newest archived blocks; 0 keeps all.
+	ArchiveMaxFiles int
+	// ReplayDir replays captured blocks, such as an ArchiveDir, instead of fetching
+	// them from RPCURL.
+	ReplayDir string
+	// SIEMAddr is the host:port of a syslog or CEF collector receiving rule-tagged
+	// transactions; empty disables the export. See SIEM for the other SIEM settings.
+	SIEMAddr    string
+	SIEMNetwork string // udp or tcp
+	SIEMFormat  string // syslog or cef
+	SIEMTags    string // comma-separated tags selecting exported transactions; empty exports all
+	SIEMFields  string // comma-separated key=field output mapping; empty uses the default
+}
+
+// ChainConfig is an additional EVM chain indexed alongside the one at RPCURL,
+// with its own client, store and parser.
+type ChainConfig struct {
+	Name         string        // value of the chain request parameter
+	RPCURL       string        // JSON-RPC endpoint, or comma-separated endpoints to fail over between
+	PollInterval time.Duration // delay between chain tip polls, the primary chain's by default
+}
+
+// RPCEndpoints splits RPCURL into its endpoints, in failover order.
+func (c ChainConfig) RPCEndpoints() []string {
+	return Config{RPCURL: c.RPCURL}.RPCEndpoints()
+}
+
+// Load builds a Config from defaults, then environment variables, then command-line
+// flags in args (without the program name), and validates the result.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Config{
+		RPCURL:       DefaultRPCURL,
+		PollInterval: DefaultPollInterval,
+		ListenAddr:   DefaultListenAddr,
+		Chain:        DefaultChain,
+		CatchUpBatch: DefaultCatchUpBatch,
+		MaxConns:     DefaultMaxConns,
+		MaxQueries:   DefaultMaxQueries,
+		QueryQueue:   DefaultQueryQueue,
+
+		PriorityInboxSize: txparser.DefaultPriorityInboxSize,
+		RPCMaxAttempts:    txparser.DefaultRetryPolicy.MaxAttempts,
+		RPCRetryBackoff:   txparser.DefaultRetryPolicy.BaseDelay,
+
+		CheckpointInterval: DefaultCheckpointInterval,
+		DrainTimeout:       DefaultDrainTimeout,
+		RPCBurst:           DefaultRPCBurst,
+		APIBurst:           DefaultAPIBurst,
+		LeaderLease:        txparser.DefaultLeaderLease,
+		ServeOnly:          string(txparser.VisibilityAll),
+		SIEMNetwork:        "udp",
+		SIEMFormat:         txparser.SIEMFormatSyslog,
+		ServeConfirmations: txparser.DefaultConfirmations,
+		DiscoveryTTL:       DefaultDiscoveryTTL,
+	}
+
+	if v := getenv(EnvRPCURL); v != "" {
+		cfg.RPCURL = v
+	}
+	if v := getenv(EnvPollInterval); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", EnvPollInterval, err)
+		}
+		cfg.PollInterval = d
+	}
+	if v := getenv(EnvListenAddr); v != "" {
+		cfg.ListenAddr = v
+	}
+	if v := getenv(EnvWSURL); v != "" {
+		cfg.WSURL = v
+	}
+	if v := getenv(EnvGRPCAddr); v != "" {
+		cfg.GRPCAddr = v
+	}
+	if v := getenv(EnvDBPath); v != "" {
+		cfg.DBPath = v
+	}
+	if v := getenv(EnvShadowDBPath); v != "" {
+		cfg.ShadowDBPath = v
+	}
+	if v := getenv(EnvRedisURL); v != "" {
+		cfg.RedisURL = v
+	}
+	if v := getenv(EnvCatchUpBatch); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", EnvCatchUpBatch, err)
+		}
+		cfg.CatchUpBatch = n
+	}
+	for name, dst := range map[string]*int{EnvMaxConns: &cfg.MaxConns, EnvMaxQueries: &cfg.MaxQueries, EnvPriorityInboxSize: &cfg.PriorityInboxSize, EnvRPCMaxAttempts: &cfg.RPCMaxAttempts, EnvRPCBurst: &cfg.RPCBurst, EnvAPIBurst: &cfg.APIBurst, EnvMaxTxsPerAddress: &cfg.MaxTxsPerAddress, EnvConfirmations: &cfg.Confirmations, EnvServeConfirmations: &cfg.ServeConfirmations, EnvArchiveMaxFiles: &cfg.ArchiveMaxFiles, EnvPrefetchDepth: &cfg.PrefetchDepth} {
+		if v := getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+	for name, dst := range map[string]*time.Duration{EnvQueryQueue: &cfg.QueryQueue, EnvRPCRetryBackoff: &cfg.RPCRetryBackoff, EnvCheckpointInterval: &cfg.CheckpointInterval, EnvDrainTimeout: &cfg.DrainTimeout, EnvLeaderLease: &cfg.LeaderLease, EnvDiscoveryTTL: &cfg.DiscoveryTTL} {
+		if v := getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = d
+		}
+	}
+	for name, dst := range map[string]*int64{EnvRetentionBlocks: &cfg.RetentionBlocks, EnvMemoryBudget: &cfg.MemoryBudget, EnvAPIMonthlyQuota: &cfg.APIMonthlyQuota} {
+		if v := getenv(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+	if v := getenv(EnvAnomalySensitivity); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", EnvAnomalySensitivity, err)
+		}
+		cfg.AnomalySensitivity = f
+	}
+	if v := getenv(EnvRPCRateLimit); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", EnvRPCRateLimit, err)
+		}
+		cfg.RPCRateLimit = f
+	}
+	if v := getenv(EnvAPIRateLimit); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", EnvAPIRateLimit, err)
+		}
+		cfg.APIRateLimit = f
+	}
+	if v := getenv(EnvChain); v != "" {
+		cfg.Chain = v
+	}
+	if v := getenv(EnvChains); v != "" {
+		cfg.Chains = v
+	}
+	if v := getenv(EnvProjects); v != "" {
+		cfg.Projects = v
+	}
+	if v := getenv(EnvDiscoveryContracts); v != "" {
+		cfg.DiscoveryContracts = v
+	}
+	if v := getenv(EnvPriorityRules); v != "" {
+		cfg.PriorityRules = v
+	}
+	if v := getenv(EnvRules); v != "" {
+		cfg.Rules = v
+	}
+	if v := getenv(EnvCheckpoint); v != "" {
+		cfg.Checkpoint = v
+	}
+	if v := getenv(EnvRetentionFile); v != "" {
+		cfg.RetentionFile = v
+	}
+	if v := getenv(EnvErrorLog); v != "" {
+		cfg.ErrorLog = v
+	}
+	if v := getenv(EnvJobsFile); v != "" {
+		cfg.JobsFile = v
+	}
+	if v := getenv(EnvArchiveDir); v != "" {
+		cfg.ArchiveDir = v
+	}
+	if v := getenv(EnvReplayDir); v != "" {
+		cfg.ReplayDir = v
+	}
+	for name, dst := range map[string]*string{EnvSIEMAddr: &cfg.SIEMAddr, EnvSIEMNetwork: &cfg.SIEMNetwork, EnvSIEMFormat: &cfg.SIEMFormat, EnvSIEMTags: &cfg.SIEMTags, EnvSIEMFields: &cfg.SIEMFields} {
+		if v := getenv(name); v != "" {
+			*dst = v
+		}
+	}
+	if v := getenv(EnvRestoreCheckpoint); v != "" {
+		cfg.RestoreCheckpoint = v
+	}
+	if v := getenv(EnvAPIKeys); v != "" {
+		cfg.APIKeys = v
+	}
+	if v := getenv(EnvAPIKeysFile); v != "" {
+		cfg.APIKeysFile = v
+	}
+	if v := getenv(EnvDisableFeatures); v != "" {
+		cfg.DisableFeatures = v
+	}
+	if v := getenv(EnvStartBlock); v != "" {
+		cfg.StartBlock = v
+	}
+	if v := getenv(EnvRiskURL); v != "" {
+		cfg.RiskURL = v
+	}
+	if v := getenv(EnvServeOnly); v != "" {
+		cfg.ServeOnly = v
+	}
+	for name, dst := range map[string]*bool{EnvDev: &cfg.Dev, EnvTrackTokens: &cfg.TrackTokens, EnvSubscribeDeployments: &cfg.SubscribeDeployments, EnvReceipts: &cfg.Receipts, EnvMatchLogTopics: &cfg.MatchLogTopics, EnvMatchInput: &cfg.MatchInput, EnvLowMemory: &cfg.LowMemory, EnvArchiveCompress: &cfg.ArchiveCompress, EnvAudit: &cfg.Audit, EnvWebhookAllowPrivate: &cfg.WebhookAllowPrivate} {
+		if v := getenv(name); v != "" {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = enabled
+		}
+	}
+
+	fs := flag.NewFlagSet("parser", flag.ContinueOnError)
+	fs.StringVar(&cfg.RPCURL, "rpc-url", cfg.RPCURL, "Ethereum JSON-RPC endpoint, or comma-separated endpoints to fail over between (env "+EnvRPCURL+")")
+	fs.DurationVar(&cfg.PollInterval, "poll-interval", cfg.PollInterval, "delay between chain tip polls (env "+EnvPollInterval+")")
+	fs.StringVar(&cfg.ListenAddr, "listen-addr", cfg.ListenAddr, "HTTP listen address (env "+EnvListenAddr+")")
+	fs.StringVar(&cfg.WSURL, "ws-url", cfg.WSURL, "ws:// or wss:// endpoint whose newHeads subscription wakes the parser as blocks are mined, reconnecting with backoff; empty polls only (env "+EnvWSURL+")")
+	fs.StringVar(&cfg.GRPCAddr, "grpc-addr", cfg.GRPCAddr, "gRPC listen address serving the TxParser service; empty disables (env "+EnvGRPCAddr+")")
+	fs.StringVar(&cfg.DBPath, "db", cfg.DBPath, "BoltDB file for persistent state; empty keeps state in memory (env "+EnvDBPath+")")
+	fs.StringVar(&cfg.RedisURL, "redis-url", cfg.RedisURL, "redis:// or rediss:// server keeping state shared by replicas, e.g. redis://:password@host:6379/0; empty keeps state in memory or -db (env "+EnvRedisURL+")")
+	fs.StringVar(&cfg.ShadowDBPath, "shadow-db", cfg.ShadowDBPath, "candidate BoltDB file receiving shadow writes and compared reads; empty disables (env "+EnvShadowDBPath+")")
+	fs.IntVar(&cfg.CatchUpBatch, "catch-up-batch", cfg.CatchUpBatch, "blocks fetched per batch request while behind the chain tip; 0 disables catch-up (env "+EnvCatchUpBatch+")")
+	fs.IntVar(&cfg.PrefetchDepth, "prefetch-depth", cfg.PrefetchDepth, "blocks fetched ahead concurrently while behind the chain tip, when blocks are not fetched in catch-up batches; 0 disables (env "+EnvPrefetchDepth+")")
+	fs.IntVar(&cfg.MaxConns, "max-connections", cfg.MaxConns, "concurrent HTTP connections before new ones get 503; 0 for no limit (env "+EnvMaxConns+")")
+	fs.IntVar(&cfg.MaxQueries, "max-queries", cfg.MaxQueries, "concurrent expensive queries (transactions, timelines, sweeps); 0 for no limit (env "+EnvMaxQueries+")")
+	fs.DurationVar(&cfg.QueryQueue, "query-queue-timeout", cfg.QueryQueue, "how long a query waits for a slot before a 503 (env "+EnvQueryQueue+")")
+	fs.StringVar(&cfg.StartBlock, "start-block", cfg.StartBlock, "where to begin indexing without stored progress: latest, latest-N or a block number (env "+EnvStartBlock+")")
+	fs.StringVar(&cfg.RiskURL, "risk-url", cfg.RiskURL, "HTTP risk provider scoring counterparties; empty disables scoring (env "+EnvRiskURL+")")
+	fs.BoolVar(&cfg.TrackTokens, "track-tokens", cfg.TrackTokens, "also track ERC-20 transfers of subscribed addresses via eth_getLogs (env "+EnvTrackTokens+")")
+	fs.Float64Var(&cfg.AnomalySensitivity, "anomaly-sensitivity", cfg.AnomalySensitivity, "standard deviations from the rolling mean that flag a spike or silence in transaction rate, e.g. 3; 0 disables (env "+EnvAnomalySensitivity+")")
+	fs.BoolVar(&cfg.Receipts, "receipts", cfg.Receipts, "fetch receipts of matched transactions for status, gas used and fee (env "+EnvReceipts+")")
+	fs.IntVar(&cfg.Confirmations, "confirmations", cfg.Confirmations, "blocks behind the chain tip a block must be before it is indexed, so reorgs rarely remove stored transactions; 0 indexes the tip (env "+EnvConfirmations+")")
+	fs.StringVar(&cfg.ServeOnly, "serve-only", cfg.ServeOnly, "default visibility of served transactions: all, confirmed (see -serve-confirmations) or finalized; overridden per request with ?serveOnly= (env "+EnvServeOnly+")")
+	fs.IntVar(&cfg.ServeConfirmations, "serve-confirmations", cfg.ServeConfirmations, "blocks behind the chain tip a block must be for its transactions to be served as confirmed (env "+EnvServeConfirmations+")")
+	fs.BoolVar(&cfg.LowMemory, "low-memory", cfg.LowMemory, "stream each block one transaction at a time, holding only matching ones, instead of decoding it whole; disables catch-up batches, prefetching and archiving (env "+EnvLowMemory+")")
+	fs.BoolVar(&cfg.MatchLogTopics, "match-log-topics", cfg.MatchLogTopics, "also store transactions emitting events with a subscribed address as an indexed topic, e.g. deposits and claims; fetches every log of each block (env "+EnvMatchLogTopics+")")
+	fs.BoolVar(&cfg.MatchInput, "match-input", cfg.MatchInput, "also store transactions whose calldata carries a subscribed address as an argument, e.g. batched payouts; can be switched off at /admin/features as input-matching (env "+EnvMatchInput+")")
+	fs.BoolVar(&cfg.SubscribeDeployments, "subscribe-deployments", cfg.SubscribeDeployments, "also subscribe contracts deployed by subscribed addresses (env "+EnvSubscribeDeployments+")")
+	fs.StringVar(&cfg.Chain, "chain", cfg.Chain, "name of the chain at the RPC URL, selected with ?chain=name (env "+EnvChain+")")
+	fs.StringVar(&cfg.Chains, "chains", cfg.Chains, "additional chains to index, space-separated name=url[,url...][;poll=interval] entries (env "+EnvChains+")")
+	fs.StringVar(&cfg.Projects, "projects", cfg.Projects, "independent watch lists served under /projects/{name}/, space-separated name[;rules=file] entries (env "+EnvProjects+")")
+	fs.StringVar(&cfg.Rules, "rules", cfg.Rules, "JSON rules file tagging matched transactions, e.g. denylist hits; notifications can require tags and tagged transactions go to the SIEM export; empty disables (env "+EnvRules+")")
+	fs.StringVar(&cfg.DiscoveryContracts, "discovery-contracts", cfg.DiscoveryContracts, "comma-separated contracts on the primary chain whose counterparties are subscribed automatically, e.g. a DEX router; empty disables (env "+EnvDiscoveryContracts+")")
+	fs.DurationVar(&cfg.DiscoveryTTL, "discovery-ttl", cfg.DiscoveryTTL, "how long an automatic subscription lasts without new activity with a discovery contract (env "+EnvDiscoveryTTL+")")
+	fs.StringVar(&cfg.PriorityRules, "priority-rules", cfg.PriorityRules, "JSON rules file routing matching transactions into the priority inbox served at /priority-transactions; empty disables (env "+EnvPriorityRules+")")
+	fs.IntVar(&cfg.PriorityInboxSize, "priority-inbox-size", cfg.PriorityInboxSize, "newest priority transactions kept in the priority inbox (env "+EnvPriorityInboxSize+")")
+	fs.IntVar(&cfg.RPCMaxAttempts, "rpc-max-attempts", cfg.RPCMaxAttempts, "attempts per endpoint of a JSON-RPC request failing with HTTP 429, 5xx or a transport error; 1 disables retries (env "+EnvRPCMaxAttempts+")")
+	fs.DurationVar(&cfg.RPCRetryBackoff, "rpc-retry-backoff", cfg.RPCRetryBackoff, "delay before the first JSON-RPC retry, doubled for each further one with jitter (env "+EnvRPCRetryBackoff+")")
+	fs.StringVar(&cfg.Checkpoint, "checkpoint", cfg.Checkpoint, "file periodically receiving a compact checkpoint for disaster recovery; empty disables (env "+EnvCheckpoint+")")
+	fs.DurationVar(&cfg.CheckpointInterval, "checkpoint-interval", cfg.CheckpointInterval, "delay between checkpoints (env "+EnvCheckpointInterval+")")
+	fs.StringVar(&cfg.RestoreCheckpoint, "restore-checkpoint", cfg.RestoreCheckpoint, "checkpoint file restored at startup unless the store is already past it (env "+EnvRestoreCheckpoint+")")
+	fs.Float64Var(&cfg.RPCRateLimit, "rpc-rate-limit", cfg.RPCRateLimit, "JSON-RPC requests per second sent to each endpoint; 0 disables the limit (env "+EnvRPCRateLimit+")")
+	fs.IntVar(&cfg.RPCBurst, "rpc-burst", cfg.RPCBurst, "JSON-RPC requests sent at once above the rate limit (env "+EnvRPCBurst+")")
+	fs.Float64Var(&cfg.APIRateLimit, "api-rate-limit", cfg.APIRateLimit, "API requests per second of each API key, or shared by requests without an accepted key; 0 disables the limit (env "+EnvAPIRateLimit+")")
+	fs.IntVar(&cfg.APIBurst, "api-burst", cfg.APIBurst, "API requests a key may send at once above its rate limit (env "+EnvAPIBurst+")")
+	fs.Int64Var(&cfg.APIMonthlyQuota, "api-monthly-quota", cfg.APIMonthlyQuota, "API requests of each key per calendar month (UTC), reported at /usage; 0 disables the quota (env "+EnvAPIMonthlyQuota+")")
+	fs.StringVar(&cfg.APIKeys, "api-keys", cfg.APIKeys, "comma-separated key:scope API keys required in the X-API-Key header; scopes are read and subscribe, joined with + (env "+EnvAPIKeys+")")
+	fs.StringVar(&cfg.APIKeysFile, "api-keys-file", cfg.APIKeysFile, `JSON file of API keys, e.g. [{"name":"ops","key":"...","scopes":["subscribe"]}] (env `+EnvAPIKeysFile+")")
+	fs.Int64Var(&cfg.RetentionBlocks, "retention-blocks", cfg.RetentionBlocks, "blocks behind the current one transactions are kept before pruning; 0 keeps every block (env "+EnvRetentionBlocks+")")
+	fs.Int64Var(&cfg.MemoryBudget, "memory-budget-bytes", cfg.MemoryBudget, "estimated bytes of transactions the in-memory store keeps before evicting the oldest; 0 disables (env "+EnvMemoryBudget+")")
+	fs.IntVar(&cfg.MaxTxsPerAddress, "max-txs-per-address", cfg.MaxTxsPerAddress, "newest transactions kept per address before pruning older ones; 0 keeps all (env "+EnvMaxTxsPerAddress+")")
+	fs.StringVar(&cfg.SIEMAddr, "siem-addr", cfg.SIEMAddr, "host:port of a syslog or CEF collector receiving rule-tagged transactions; empty disables (env "+EnvSIEMAddr+")")
+	fs.StringVar(&cfg.SIEMNetwork, "siem-network", cfg.SIEMNetwork, "transport to the SIEM collector: udp or tcp (env "+EnvSIEMNetwork+")")
+	fs.StringVar(&cfg.SIEMFormat, "siem-format", cfg.SIEMFormat, "SIEM record format: syslog (RFC 5424) or cef (env "+EnvSIEMFormat+")")
+	fs.StringVar(&cfg.SIEMTags, "siem-tags", cfg.SIEMTags, "comma-separated rule tags a transaction needs one of to be exported, e.g. denylist,threshold; empty exports every tagged transaction (env "+EnvSIEMTags+")")
+	fs.StringVar(&cfg.SIEMFields, "siem-fields", cfg.SIEMFields, "comma-separated key=field SIEM output mapping, e.g. suser=from,cs1=hash; empty uses CEF extension defaults (env "+EnvSIEMFields+")")
+	fs.StringVar(&cfg.ArchiveDir, "archive-dir", cfg.ArchiveDir, "directory receiving the raw JSON of every block fetched on the primary chain, for replays; empty disables (env "+EnvArchiveDir+")")
+	fs.BoolVar(&cfg.ArchiveCompress, "archive-compress", cfg.ArchiveCompress, "gzip archived blocks (env "+EnvArchiveCompress+")")
+	fs.IntVar(&cfg.ArchiveMaxFiles, "archive-max-files", cfg.ArchiveMaxFiles, "archived blocks kept, deleting the oldest beyond it; 0 keeps all (env "+EnvArchiveMaxFiles+")")
+	fs.StringVar(&cfg.ReplayDir, "replay-dir", cfg.ReplayDir, "directory of captured blocks, e.g. an -archive-dir, replayed instead of fetching from the RPC URL; combine with -start-block at the first captured block (env "+EnvReplayDir+")")
+	fs.StringVar(&cfg.ErrorLog, "error-log", cfg.ErrorLog, "file appended with the primary parser's errors listed at /admin/errors, as JSON lines with endpoint URLs reduced to their host; empty keeps them in memory (env "+EnvErrorLog+")")
+	fs.StringVar(&cfg.JobsFile, "jobs-file", cfg.JobsFile, "file persisting background job records; defaults to a file next to -db, and keeps jobs in memory without one (env "+EnvJobsFile+")")
+	fs.StringVar(&cfg.RetentionFile, "retention-file", cfg.RetentionFile, "file persisting the retention policy changed at /admin/retention; empty keeps changes until restart (env "+EnvRetentionFile+")")
+	fs.DurationVar(&cfg.LeaderLease, "leader-lease", cfg.LeaderLease, "lease TTL electing the one replica sharing -redis-url that parses blocks; 0 lets every replica parse (env "+EnvLeaderLease+")")
+	fs.BoolVar(&cfg.WebhookAllowPrivate, "webhook-allow-private", cfg.WebhookAllowPrivate, "allow webhooks to loopback, private and link-local hosts, e.g. on a trusted internal network; refused by default (env "+EnvWebhookAllowPrivate+")")
+	fs.DurationVar(&cfg.DrainTimeout, "drain-timeout", cfg.DrainTimeout, "how long shutdown waits for pending webhook deliveries (env "+EnvDrainTimeout+")")
+	fs.StringVar(&cfg.DisableFeatures, "disable-features", cfg.DisableFeatures, "comma-separated subsystems to switch off at startup: tokens, receipts, input-matching, log-matching, notifications, anomalies; toggled at runtime via /admin/features (env "+EnvDisableFeatures+")")
+	fs.BoolVar(&cfg.Audit, "audit", cfg.Audit, "verify store invariants after every processed block and panic on a violation; for staging with the race detector, not production (env "+EnvAudit+")")
+	fs.BoolVar(&cfg.Dev, "dev", cfg.Dev, "run against an embedded fake chain with synthetic transfers; the RPC URL is ignored (env "+EnvDev+")")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// RPCEndpoints splits RPCURL into its endpoints, in failover order.
+func (c Config) RPCEndpoints() []string {
+	endpoints := strings.Split(c.RPCURL, ",")
+	for i, endpoint := range endpoints {
+		endpoints[i] = strings.TrimSpace(endpoint)
+	}
+	return endpoints
+}
+
+// Retention returns the retention policy in effect until one is saved at /admin/retention.
+func (c Config) Retention() txparser.RetentionPolicy {
+	return txparser.RetentionPolicy{MaxBlockAge: c.RetentionBlocks, MemoryBudgetBytes: c.MemoryBudget, MaxTransactionsPerAddress: c.MaxTxsPerAddress}
+}
+
+// UsageLimits returns the per-key API rate limit and monthly quota.
+func (c Config) UsageLimits() txparser.UsageLimits {
+	return txparser.UsageLimits{RatePerSecond: c.APIRateLimit, Burst: c.APIBurst, MonthlyQuota: c.APIMonthlyQuota}
+}
+
+// RetryPolicy returns the JSON-RPC retry policy: txparser.DefaultRetryPolicy with the
+// configured attempts and backoff.
+func (c Config) RetryPolicy() txparser.RetryPolicy {
+	policy := txparser.DefaultRetryPolicy
+	policy.MaxAttempts = c.RPCMaxAttempts
+	policy.BaseDelay = c.RPCRetryBackoff
+	return policy
+}
+
+// AuthKeys returns the API keys of APIKeys and APIKeysFile. None means the API is open.
+func (c Config) AuthKeys() ([]txparser.APIKey, error) {
+	keys, err := txparser.ParseAPIKeys(c.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	if c.APIKeysFile != "" {
+		fileKeys, err := txparser.LoadAPIKeys(c.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	return keys, nil
+}
+
+// ChainConfigs parses Chains. Entries without a poll interval use PollInterval.
+func (c Config) ChainConfigs() ([]ChainConfig, error) {
+	var chains []ChainConfig
+	for _, entry := range strings.Fields(c.Chains) {
+		name, rest, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("chain %q must be name=url", entry)
+		}
+		chain := ChainConfig{Name: name, PollInterval: c.PollInterval}
+		rpcURL, options, _ := strings.Cut(rest, ";")
+		chain.RPCURL = rpcURL
+		if options != "" {
+			poll, ok := strings.CutPrefix(options, "poll=")
+			if !ok {
+				return nil, fmt.Errorf("chain %s: unknown option %q, expected poll=interval", name, options)
+			}
+			d, err := time.ParseDuration(poll)
+			if err != nil {
+				return nil, fmt.Errorf("chain %s: %w", name, err)
+			}
+			chain.PollInterval = d
+		}
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
+
+// SIEM returns the configuration of the SIEM export from the SIEM* settings.
+func (c Config) SIEM() (txparser.SIEMConfig, error) {
+	siem := txparser.SIEMConfig{Network: c.SIEMNetwork, Address: c.SIEMAddr, Format: c.SIEMFormat}
+	if c.SIEMNetwork != "udp" && c.SIEMNetwork != "tcp" {
+		return siem, fmt.Errorf("network %q must be udp or tcp", c.SIEMNetwork)
+	}
+	if _, _, err := net.SplitHostPort(c.SIEMAddr); err != nil {
+		return siem, fmt.Errorf("address %q: %w", c.SIEMAddr, err)
+	}
+	for _, tag := range strings.Split(c.SIEMTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			siem.Tags = append(siem.Tags, tag)
+		}
+	}
+	for _, entry := range strings.Split(c.SIEMFields, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		key, field, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return siem, fmt.Errorf("field mapping %q must be key=field", entry)
+		}
+		if siem.Fields == nil {
+			siem.Fields = make(map[string]string)
+		}
+		siem.Fields[key] = field
+	}
+	return siem, siem.Validate()
+}
+
+// DiscoveryAddresses parses DiscoveryContracts.
+func (c Config) DiscoveryAddresses() ([]string, error) {
+	return txparser.ParseAddresses(c.DiscoveryContracts)
+}
+
+// ProjectConfig is a watch list with its own subscriptions, rules and webhooks.
+type ProjectConfig struct {
+	Name  string // path segment under /projects/
+	Rules string // JSON tagging rules file, empty for none
+}
+
+// ProjectConfigs parses Projects.
+func (c Config) ProjectConfigs() ([]ProjectConfig, error) {
+	var projects []ProjectConfig
+	for _, entry := range strings.Fields(c.Projects) {
+		name, options, _ := strings.Cut(entry, ";")
+		project := ProjectConfig{Name: name}
+		if options != "" {
+			rules, ok := strings.CutPrefix(options, "rules=")
+			if !ok {
+				return nil, fmt.Errorf("project %s: unknown option %q, expected rules=file", name, options)
+			}
+			project.Rules = rules
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// ProjectDBPath returns the BoltDB file of a project, next to DBPath with the project
+// name before the extension, e.g. parser.project-staging.db. It is empty if DBPath is.
+func (c Config) ProjectDBPath(name string) string {
+	return c.ChainDBPath("project-" + name)
+}
+
+// RedisPrefix returns the key prefix in RedisURL of the primary store for an empty
+// name, or of the additional chain or project-prefixed project name, e.g.
+// txparser:polygon:.
+func (c Config) RedisPrefix(name string) string {
+	if name == "" {
+		return "txparser:"
+	}
+	return "txparser:" + name + ":"
+}
+
+// ChainDBPath returns the BoltDB file of an additional chain, next to DBPath with the
+// chain name before the extension, e.g. parser.polygon.db. It is empty if DBPath is.
+func (c Config) ChainDBPath(name string) string {
+	if c.DBPath == "" {
+		return ""
+	}
+	ext := filepath.Ext(c.DBPath)
+	return strings.TrimSuffix(c.DBPath, ext) + "." + name + ext
+}
+
+// JobsPath returns JobsFile, or a file next to DBPath named after it, e.g.
+// parser.jobs.json. It is empty, keeping jobs in memory, if neither is set.
+func (c Config) JobsPath() string {
+	if c.JobsFile != "" || c.DBPath == "" {
+		return c.JobsFile
+	}
+	return strings.TrimSuffix(c.DBPath, filepath.Ext(c.DBPath)) + ".jobs.json"
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	for _, endpoint := range c.RPCEndpoints() {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("rpc url %q must be an http(s) URL", endpoint))
+		}
+	}
+	if c.PollInterval < 100*time.Millisecond {
+		errs = append(errs, fmt.Errorf("poll interval %s must be at least 100ms", c.PollInterval))
+	}
+	if u, err := url.Parse(c.RiskURL); c.RiskURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		errs = append(errs, fmt.Errorf("risk url %q must be an http(s) URL", c.RiskURL))
+	}
+	if _, _, err := net.SplitHostPort(c.ListenAddr); err != nil {
+		errs = append(errs, fmt.Errorf("listen address %q: %w", c.ListenAddr, err))
+	}
+	if c.WSURL != "" {
+		if u, err := url.Parse(c.WSURL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("ws url %q must be a ws(s) URL", c.WSURL))
+		}
+	}
+	if c.GRPCAddr != "" {
+		if _, _, err := net.SplitHostPort(c.GRPCAddr); err != nil {
+			errs = append(errs, fmt.Errorf("grpc address %q: %w", c.GRPCAddr, err))
+		} else if c.GRPCAddr == c.ListenAddr {
+			errs = append(errs, fmt.Errorf("grpc address %q must differ from the listen address", c.GRPCAddr))
+		}
+	}
+	if c.RedisURL != "" {
+		if u, err := url.Parse(c.RedisURL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("redis url %q must be a redis(s) URL", c.RedisURL))
+		} else if c.DBPath != "" {
+			errs = append(errs, errors.New("redis url and db are mutually exclusive"))
+		}
+	}
+	if c.ShadowDBPath != "" && c.ShadowDBPath == c.DBPath {
+		errs = append(errs, fmt.Errorf("shadow db %q must differ from the primary db", c.ShadowDBPath))
+	}
+	if c.StartBlock != "" {
+		if _, err := txparser.ParseStartBlock(c.StartBlock); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.Confirmations < 0 {
+		errs = append(errs, fmt.Errorf("confirmations %d must not be negative", c.Confirmations))
+	}
+	if _, err := txparser.ParseVisibility(c.ServeOnly); err != nil {
+		errs = append(errs, fmt.Errorf("serve only: %w", err))
+	}
+	if c.ServeConfirmations < 0 {
+		errs = append(errs, fmt.Errorf("serve confirmations %d must not be negative", c.ServeConfirmations))
+	}
+	if c.MaxConns < 0 || c.MaxQueries < 0 || c.QueryQueue < 0 {
+		errs = append(errs, errors.New("connection and query limits must not be negative"))
+	}
+	chains, err := c.ChainConfigs()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	seen := map[string]bool{c.Chain: true}
+	for _, chain := range chains {
+		if seen[chain.Name] {
+			errs = append(errs, fmt.Errorf("chain name %q is used twice", chain.Name))
+		}
+		seen[chain.Name] = true
+		for _, endpoint := range chain.RPCEndpoints() {
+			if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("chain %s: rpc url %q must be an http(s) URL", chain.Name, endpoint))
+			}
+		}
+		if chain.PollInterval < 100*time.Millisecond {
+			errs = append(errs, fmt.Errorf("chain %s: poll interval %s must be at least 100ms", chain.Name, chain.PollInterval))
+		}
+	}
+	projects, err := c.ProjectConfigs()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	seenProjects := make(map[string]bool)
+	for _, project := range projects {
+		if !txparser.ValidProjectName(project.Name) {
+			errs = append(errs, fmt.Errorf("project name %q must be lowercase letters, digits and dashes", project.Name))
+		}
+		if seenProjects[project.Name] {
+			errs = append(errs, fmt.Errorf("project name %q is used twice", project.Name))
+		}
+		seenProjects[project.Name] = true
+	}
+	if c.AnomalySensitivity < 0 {
+		errs = append(errs, fmt.Errorf("anomaly sensitivity %g must not be negative", c.AnomalySensitivity))
+	}
+	if c.RPCMaxAttempts < 1 || c.RPCRetryBackoff < 0 {
+		errs = append(errs, fmt.Errorf("rpc retries: max attempts %d must be positive and backoff %s not negative", c.RPCMaxAttempts, c.RPCRetryBackoff))
+	}
+	if c.Checkpoint != "" && c.CheckpointInterval < time.Second {
+		errs = append(errs, fmt.Errorf("checkpoint interval %s must be at least 1s", c.CheckpointInterval))
+	}
+	if c.RPCRateLimit < 0 || c.RPCBurst < 1 {
+		errs = append(errs, fmt.Errorf("rpc rate limit: rate %g must not be negative and burst %d must be positive", c.RPCRateLimit, c.RPCBurst))
+	}
+	if _, err := c.DiscoveryAddresses(); err != nil {
+		errs = append(errs, fmt.Errorf("discovery contracts: %w", err))
+	}
+	if c.DiscoveryTTL <= 0 {
+		errs = append(errs, fmt.Errorf("discovery ttl %s must be positive", c.DiscoveryTTL))
+	}
+	if c.ReplayDir != "" && c.Dev {
+		errs = append(errs, errors.New("replay dir and dev are mutually exclusive"))
+	}
+	if c.ArchiveMaxFiles < 0 {
+		errs = append(errs, fmt.Errorf("archive max files %d must not be negative", c.ArchiveMaxFiles))
+	}
+	if c.SIEMAddr != "" {
+		if _, err := c.SIEM(); err != nil {
+			errs = append(errs, fmt.Errorf("siem: %w", err))
+		}
+	}
+	if c.APIRateLimit < 0 || c.APIBurst < 1 || c.APIMonthlyQuota < 0 {
+		errs = append(errs, fmt.Errorf("api limits: rate %g and monthly quota %d must not be negative and burst %d must be positive", c.APIRateLimit, c.APIMonthlyQuota, c.APIBurst))
+	}
+	if _, err := txparser.ParseAPIKeys(c.APIKeys); err != nil {
+		errs = append(errs, fmt.Errorf("api keys: %w", err))
+	}
+	if err := c.Retention().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("retention: %w", err))
+	}
+	if c.DrainTimeout < 0 {
+		errs = append(errs, fmt.Errorf("drain timeout %s must not be negative", c.DrainTimeout))
+	}
+	if c.LeaderLease != 0 && c.LeaderLease < time.Second {
+		errs = append(errs, fmt.Errorf("leader lease %s must be 0 or at least 1s", c.LeaderLease))
+	}
+	if _, err := txparser.ParseFeatures(c.DisableFeatures); err != nil {
+		errs = append(errs, fmt.Errorf("disabled features: %w", err))
+	}
+	if c.PriorityInboxSize < 1 {
+		errs = append(errs, fmt.Errorf("priority inbox size %d must be positive", c.PriorityInboxSize))
+	}
+	if c.PrefetchDepth < 0 {
+		errs = append(errs, fmt.Errorf("prefetch depth %d must not be negative", c.PrefetchDepth))
+	}
+	if c.CatchUpBatch < 0 || c.CatchUpBatch > MaxCatchUpBatch {
+		errs = append(errs, fmt.Errorf("catch-up batch %d must be between 0 and %d", c.CatchUpBatch, MaxCatchUpBatch))
+	}
+	return errors.Join(errs...)
+}
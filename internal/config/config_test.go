@@ -0,0 +1,141 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/txparser"
+)
+
+// TestLoad verifies defaults, environment overrides, flag precedence and validation.
+func TestLoad(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(k string) string { return env[k] }
+
+	cfg, err := Load(nil, getenv)
+	if err != nil {
+		t.Fatalf("Load defaults error: %v", err)
+	}
+	if cfg.RPCURL != DefaultRPCURL || cfg.PollInterval != DefaultPollInterval || cfg.ListenAddr != DefaultListenAddr {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+
+	env[EnvRPCURL] = "http://localhost:8545"
+	env[EnvPollInterval] = "12s"
+	env[EnvListenAddr] = ":9090"
+	env[EnvRetentionBlocks] = "5000"
+	env[EnvMaxTxsPerAddress] = "200"
+	env[EnvMatchLogTopics] = "true"
+	env[EnvMatchInput] = "true"
+	env[EnvRules] = "rules.json"
+	env[EnvArchiveDir] = "/var/lib/txparser/blocks"
+	env[EnvArchiveMaxFiles] = "1000"
+	env[EnvConfirmations] = "6"
+	env[EnvLowMemory] = "true"
+	env[EnvAudit] = "true"
+	env[EnvErrorLog] = "/var/log/txparser/errors.jsonl"
+	env[EnvWebhookAllowPrivate] = "true"
+	env[EnvServeOnly] = "confirmed"
+	env[EnvServeConfirmations] = "3"
+	env[EnvAPIRateLimit] = "2.5"
+	env[EnvAPIMonthlyQuota] = "100000"
+	env[EnvSIEMAddr] = "siem.local:514"
+	env[EnvSIEMTags] = "denylist, threshold"
+	env[EnvPrefetchDepth] = "4"
+	env[EnvDiscoveryContracts] = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+	cfg, err = Load([]string{"-listen-addr", "127.0.0.1:7000"}, getenv)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	want := Config{RPCURL: "http://localhost:8545", PollInterval: 12 * time.Second, ListenAddr: "127.0.0.1:7000", CatchUpBatch: DefaultCatchUpBatch, PrefetchDepth: 4,
+		MaxConns: DefaultMaxConns, MaxQueries: DefaultMaxQueries, QueryQueue: DefaultQueryQueue, Chain: DefaultChain,
+		PriorityInboxSize: txparser.DefaultPriorityInboxSize, RPCMaxAttempts: txparser.DefaultRetryPolicy.MaxAttempts, RPCRetryBackoff: txparser.DefaultRetryPolicy.BaseDelay,
+		CheckpointInterval: DefaultCheckpointInterval, DrainTimeout: DefaultDrainTimeout,
+		RPCBurst: DefaultRPCBurst, RetentionBlocks: 5000, MaxTxsPerAddress: 200, LeaderLease: txparser.DefaultLeaderLease, MatchLogTopics: true, MatchInput: true, Rules: "rules.json", Confirmations: 6, LowMemory: true, Audit: true, WebhookAllowPrivate: true, ErrorLog: "/var/log/txparser/errors.jsonl",
+		ServeOnly: "confirmed", ServeConfirmations: 3, APIRateLimit: 2.5, APIBurst: DefaultAPIBurst, APIMonthlyQuota: 100000,
+		ArchiveDir: "/var/lib/txparser/blocks", ArchiveMaxFiles: 1000,
+		SIEMAddr: "siem.local:514", SIEMNetwork: "udp", SIEMFormat: txparser.SIEMFormatSyslog, SIEMTags: "denylist, threshold",
+		DiscoveryContracts: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d", DiscoveryTTL: DefaultDiscoveryTTL}
+	if cfg != want {
+		t.Errorf("expected %+v, got %+v", want, cfg)
+	}
+	if contracts, err := cfg.DiscoveryAddresses(); err != nil || len(contracts) != 1 {
+		t.Errorf("unexpected discovery contracts %v, %v", contracts, err)
+	}
+	if siem, err := cfg.SIEM(); err != nil || len(siem.Tags) != 2 || siem.Tags[1] != "threshold" || siem.Address != "siem.local:514" {
+		t.Errorf("unexpected SIEM config %+v, %v", siem, err)
+	}
+
+	_, err = Load([]string{"-rpc-url", "ftp://node", "-poll-interval", "1ms", "-listen-addr", "8080", "-catch-up-batch", "1000", "-start-block", "latest+5", "-max-queries", "-1", "-anomaly-sensitivity", "-2", "-priority-inbox-size", "0", "-rpc-max-attempts", "0", "-checkpoint", "cp.json", "-checkpoint-interval", "1ms", "-disable-features", "tokens,mempool", "-drain-timeout", "-1s", "-leader-lease", "1ms", "-rpc-burst", "0", "-api-keys", "k1:read,k2:write", "-memory-budget-bytes", "-1", "-redis-url", "localhost:6379", "-max-txs-per-address", "-1", "-grpc-addr", "9090", "-ws-url", "http://node", "-confirmations", "-1", "-serve-only", "safe", "-serve-confirmations", "-1", "-api-burst", "0", "-siem-format", "json", "-siem-fields", "cs1=gasPrice", "-archive-max-files", "-1", "-replay-dir", "blocks", "-discovery-contracts", "0xdapp", "-discovery-ttl", "0s", "-prefetch-depth", "-1", "-dev"}, getenv)
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, part := range []string{"rpc url", "poll interval", "listen address", "catch-up batch", "start block", "query limits", "anomaly sensitivity", "priority inbox", "rpc retries", "checkpoint interval", "disabled features", "drain timeout", "rpc rate limit", "api keys", "retention", "grpc address", "ws url", "redis url", "leader lease", "confirmations", "serve only", "serve confirmations", "api limits", "siem", "archive max files", "replay dir", "discovery contracts", "discovery ttl", "prefetch depth"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("expected error to mention %s, got %v", part, err)
+		}
+	}
+
+	cfg.RPCURL = "http://a:8545, https://b"
+	if endpoints := cfg.RPCEndpoints(); len(endpoints) != 2 || endpoints[1] != "https://b" {
+		t.Errorf("unexpected endpoints %v", endpoints)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected comma-separated endpoints to validate, got %v", err)
+	}
+
+	cfg.Chains = "polygon=https://polygon-rpc.com;poll=2s arbitrum=https://a,https://b"
+	chains, err := cfg.ChainConfigs()
+	if err != nil || len(chains) != 2 || chains[0].PollInterval != 2*time.Second || chains[1].PollInterval != cfg.PollInterval || len(chains[1].RPCEndpoints()) != 2 {
+		t.Errorf("unexpected chains %+v, %v", chains, err)
+	}
+	cfg.Chains = "mainnet=https://a polygon=ftp://b;poll=1ms"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "used twice") || !strings.Contains(err.Error(), "chain polygon: rpc url") || !strings.Contains(err.Error(), "chain polygon: poll interval") {
+		t.Errorf("expected invalid chains to be reported, got %v", err)
+	}
+	cfg.Chains = ""
+	if cfg.DBPath = "/data/parser.db"; cfg.ChainDBPath("polygon") != "/data/parser.polygon.db" {
+		t.Errorf("unexpected chain db path %s", cfg.ChainDBPath("polygon"))
+	}
+	if cfg.JobsPath() != "/data/parser.jobs.json" {
+		t.Errorf("unexpected jobs path %s", cfg.JobsPath())
+	}
+	if cfg.JobsFile = "/jobs/all.json"; cfg.JobsPath() != cfg.JobsFile {
+		t.Errorf("expected the jobs file to take precedence, got %s", cfg.JobsPath())
+	}
+	cfg.JobsFile = ""
+	if prefix := cfg.RedisPrefix("project-staging"); prefix != "txparser:project-staging:" {
+		t.Errorf("unexpected redis prefix %s", prefix)
+	}
+	cfg.DBPath = ""
+
+	cfg.Projects = "staging;rules=staging.json production"
+	projects, err := cfg.ProjectConfigs()
+	if want := []ProjectConfig{{Name: "staging", Rules: "staging.json"}, {Name: "production"}}; err != nil || !reflect.DeepEqual(projects, want) {
+		t.Errorf("expected projects %+v, got %+v, %v", want, projects, err)
+	}
+	cfg.Projects = "Staging staging staging;tags=x"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "project staging: unknown option") {
+		t.Errorf("expected an unknown project option to be reported, got %v", err)
+	}
+	cfg.Projects = "Staging staging staging"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `"Staging" must be lowercase`) || !strings.Contains(err.Error(), "used twice") {
+		t.Errorf("expected invalid projects to be reported, got %v", err)
+	}
+	cfg.Projects = ""
+
+	env[EnvDev] = "true"
+	if cfg, err := Load(nil, getenv); err != nil || !cfg.Dev {
+		t.Errorf("expected dev mode from env, got %+v, %v", cfg, err)
+	}
+	if cfg, err := Load([]string{"-dev=false"}, getenv); err != nil || cfg.Dev {
+		t.Errorf("expected -dev=false to override env, got %+v, %v", cfg, err)
+	}
+
+	env[EnvPollInterval] = "soon"
+	if _, err := Load(nil, getenv); err == nil || !strings.Contains(err.Error(), EnvPollInterval) {
+		t.Errorf("expected invalid env duration to be reported, got %v", err)
+	}
+}
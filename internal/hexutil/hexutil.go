@@ -0,0 +1,146 @@
+// Package hexutil decodes and encodes the two hex formats of the Ethereum JSON-RPC API.
+//
+// A quantity is an integer as "0x" followed by its shortest hex digits: "0x0" for zero,
+// "0x41" for 65, never "0x" or "0x041". Data is a byte string as "0x" followed by two hex
+// digits per byte, so "0x" is empty data and odd lengths are invalid.
+package hexutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Errors returned for malformed hex strings.
+var (
+	ErrEmptyString   = errors.New("empty hex string")
+	ErrMissingPrefix = errors.New("hex string without 0x prefix")
+	ErrSyntax        = errors.New("invalid hex string")
+	ErrOddLength     = errors.New("hex data of odd length")
+	ErrEmptyNumber   = errors.New("hex quantity without digits")
+	ErrLeadingZero   = errors.New("hex quantity with leading zero digits")
+	ErrRange         = errors.New("hex quantity out of range")
+)
+
+// maxBigBits bounds big quantities to the EVM word size.
+const maxBigBits = 256
+
+// Decode decodes hex data into bytes.
+func Decode(s string) ([]byte, error) {
+	digits, err := trimPrefix(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(digits)%2 != 0 {
+		return nil, ErrOddLength
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, ErrSyntax
+	}
+	return b, nil
+}
+
+// Encode encodes bytes as hex data.
+func Encode(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// DecodeUint64 decodes a quantity that fits in 64 bits.
+func DecodeUint64(s string) (uint64, error) {
+	digits, err := quantityDigits(s)
+	if err != nil {
+		return 0, err
+	}
+	if len(digits) > 16 {
+		return 0, ErrRange
+	}
+	n, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		return 0, ErrSyntax
+	}
+	return n, nil
+}
+
+// EncodeUint64 encodes n as a quantity.
+func EncodeUint64(n uint64) string {
+	return "0x" + strconv.FormatUint(n, 16)
+}
+
+// DecodeInt64 decodes a quantity of at most math.MaxInt64, such as a block number or
+// timestamp.
+func DecodeInt64(s string) (int64, error) {
+	n, err := DecodeUint64(s)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt64 {
+		return 0, ErrRange
+	}
+	return int64(n), nil
+}
+
+// EncodeInt64 encodes n as a quantity. Quantities are unsigned, so n must not be negative.
+func EncodeInt64(n int64) string {
+	if n < 0 {
+		panic("hexutil: negative quantity")
+	}
+	return EncodeUint64(uint64(n))
+}
+
+// DecodeBig decodes a quantity of at most 256 bits, such as a wei amount.
+func DecodeBig(s string) (*big.Int, error) {
+	digits, err := quantityDigits(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(digits) > maxBigBits/4 {
+		return nil, ErrRange
+	}
+	n, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, ErrSyntax
+	}
+	return n, nil
+}
+
+// EncodeBig encodes a non-negative n as a quantity.
+func EncodeBig(n *big.Int) string {
+	if n.Sign() < 0 {
+		panic("hexutil: negative quantity")
+	}
+	return "0x" + n.Text(16)
+}
+
+// trimPrefix returns the digits of s after its 0x prefix.
+func trimPrefix(s string) (string, error) {
+	if s == "" {
+		return "", ErrEmptyString
+	}
+	if len(s) < 2 || s[0] != '0' || (s[1] != 'x' && s[1] != 'X') {
+		return "", ErrMissingPrefix
+	}
+	return s[2:], nil
+}
+
+// quantityDigits returns the hex digits of a quantity, rejecting missing and leading zero digits.
+func quantityDigits(s string) (string, error) {
+	digits, err := trimPrefix(s)
+	if err != nil {
+		return "", err
+	}
+	if digits == "" {
+		return "", ErrEmptyNumber
+	}
+	for _, c := range digits {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return "", ErrSyntax
+		}
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return "", ErrLeadingZero
+	}
+	return digits, nil
+}
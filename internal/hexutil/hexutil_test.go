@@ -0,0 +1,72 @@
+package hexutil
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestDecodeQuantities verifies quantity decoding and its error cases.
+func TestDecodeQuantities(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want uint64
+		err  error
+	}{
+		{"0x0", 0, nil},
+		{"0x41", 65, nil},
+		{"0X1f", 31, nil},
+		{"0xffffffffffffffff", 1<<64 - 1, nil},
+		{"", 0, ErrEmptyString},
+		{"41", 0, ErrMissingPrefix},
+		{"0x", 0, ErrEmptyNumber},
+		{"0x041", 0, ErrLeadingZero},
+		{"0x-1", 0, ErrSyntax},
+		{"0xzz", 0, ErrSyntax},
+		{"0x10000000000000000", 0, ErrRange},
+	} {
+		got, err := DecodeUint64(tc.in)
+		if got != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("DecodeUint64(%q) = %d, %v, want %d, %v", tc.in, got, err, tc.want, tc.err)
+		}
+	}
+
+	if _, err := DecodeInt64("0x8000000000000000"); !errors.Is(err, ErrRange) {
+		t.Errorf("expected ErrRange above MaxInt64, got %v", err)
+	}
+	if n, err := DecodeInt64("0x12a05f200"); n != 5000000000 || err != nil {
+		t.Errorf("DecodeInt64 = %d, %v", n, err)
+	}
+
+	wei, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	if n, err := DecodeBig("0x3635c9adc5dea00000"); err != nil || n.Cmp(wei) != 0 {
+		t.Errorf("DecodeBig = %v, %v", n, err)
+	}
+	if _, err := DecodeBig("0x10000000000000000000000000000000000000000000000000000000000000000"); !errors.Is(err, ErrRange) {
+		t.Errorf("expected ErrRange above 256 bits, got %v", err)
+	}
+	if s := EncodeBig(wei); s != "0x3635c9adc5dea00000" {
+		t.Errorf("EncodeBig = %s", s)
+	}
+	if s := EncodeInt64(0); s != "0x0" {
+		t.Errorf("EncodeInt64(0) = %s", s)
+	}
+}
+
+// TestDecodeData verifies data decoding and its error cases.
+func TestDecodeData(t *testing.T) {
+	if b, err := Decode("0x"); err != nil || len(b) != 0 {
+		t.Errorf("Decode(0x) = %v, %v", b, err)
+	}
+	if b, err := Decode("0x00ff"); err != nil || len(b) != 2 || b[1] != 0xff {
+		t.Errorf("Decode(0x00ff) = %v, %v", b, err)
+	}
+	for in, want := range map[string]error{"": ErrEmptyString, "00": ErrMissingPrefix, "0x0": ErrOddLength, "0xzz": ErrSyntax} {
+		if _, err := Decode(in); !errors.Is(err, want) {
+			t.Errorf("Decode(%q) error = %v, want %v", in, err, want)
+		}
+	}
+	if s := Encode([]byte{0, 0xab}); s != "0x00ab" {
+		t.Errorf("Encode = %s", s)
+	}
+}
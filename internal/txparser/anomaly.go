@@ -0,0 +1,134 @@
+package txparser
+
+import (
+	"math"
+	"sync"
+)
+
+// Defaults for activity anomaly detection.
+const (
+	DefaultAnomalyWindow  = 100 // blocks per rate sample, about 20 minutes on mainnet
+	DefaultAnomalyHistory = 24  // samples in the rolling baseline
+	anomalyMinHistory     = 6   // samples required before a rate is judged
+)
+
+// Anomaly kinds.
+const (
+	AnomalySpike   = "spike"   // far more matched transactions than usual
+	AnomalySilence = "silence" // far fewer, typically none, for a usually busy address
+)
+
+// Anomaly reports a window of blocks whose matched transaction count deviated from the
+// rolling baseline by at least the configured number of standard deviations.
+type Anomaly struct {
+	Kind      string  `json:"kind"`
+	Address   string  `json:"address,omitempty"` // empty for the global rate
+	FromBlock int     `json:"fromBlock"`
+	ToBlock   int     `json:"toBlock"`
+	Count     int     `json:"count"`
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"stddev"`
+	Score     float64 `json:"score"` // (count - mean) / stddev
+}
+
+// anomalyDetector keeps per-address and global transaction counts for consecutive
+// windows of blocks and compares each closed window to the previous ones.
+type anomalyDetector struct {
+	mu          sync.Mutex
+	sensitivity float64
+	window      int
+	start       int            // first block of the open window, 0 before the first block
+	counts      map[string]int // matches in the open window; "" is the global count
+	history     map[string][]int
+}
+
+func newAnomalyDetector(sensitivity float64, window int) *anomalyDetector {
+	return &anomalyDetector{
+		sensitivity: sensitivity,
+		window:      window,
+		counts:      make(map[string]int),
+		history:     make(map[string][]int),
+	}
+}
+
+// observe counts a transaction stored for address in the open window.
+func (d *anomalyDetector) observe(address string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.counts[address]++
+	d.counts[""]++
+}
+
+// advance records that block was committed, returning the anomalies of the window it closes.
+func (d *anomalyDetector) advance(block int) []Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.start == 0 {
+		d.start = block
+	}
+	if block-d.start+1 < d.window {
+		return nil
+	}
+
+	var anomalies []Anomaly
+	for address := range d.history {
+		if _, ok := d.counts[address]; !ok {
+			d.counts[address] = 0
+		}
+	}
+	for address, count := range d.counts {
+		history := d.history[address]
+		if a, ok := d.judge(history, count); ok {
+			a.Address, a.FromBlock, a.ToBlock = address, d.start, block
+			anomalies = append(anomalies, a)
+		}
+		history = append(history, count)
+		if len(history) > DefaultAnomalyHistory {
+			history = history[1:]
+		}
+		if idle(history) {
+			delete(d.history, address) // forget addresses without recent activity
+		} else {
+			d.history[address] = history
+		}
+	}
+	d.counts = make(map[string]int)
+	d.start = block + 1
+	return anomalies
+}
+
+// judge compares count to the mean and standard deviation of history. The deviation is
+// floored at one transaction, so a steady address does not alert on a single extra one.
+func (d *anomalyDetector) judge(history []int, count int) (Anomaly, bool) {
+	if len(history) < anomalyMinHistory {
+		return Anomaly{}, false
+	}
+	var sum, squares float64
+	for _, n := range history {
+		sum += float64(n)
+		squares += float64(n) * float64(n)
+	}
+	mean := sum / float64(len(history))
+	stddev := math.Sqrt(max(squares/float64(len(history))-mean*mean, 0))
+	score := (float64(count) - mean) / max(stddev, 1)
+	a := Anomaly{Count: count, Mean: mean, StdDev: stddev, Score: score}
+	switch {
+	case score >= d.sensitivity:
+		a.Kind = AnomalySpike
+	case score <= -d.sensitivity:
+		a.Kind = AnomalySilence
+	default:
+		return Anomaly{}, false
+	}
+	return a, true
+}
+
+// idle reports whether every window in history was empty.
+func idle(history []int) bool {
+	for _, n := range history {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
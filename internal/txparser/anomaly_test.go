@@ -0,0 +1,43 @@
+package txparser
+
+import "testing"
+
+// TestAnomalyDetector verifies spikes and silence are flagged against the rolling baseline.
+func TestAnomalyDetector(t *testing.T) {
+	d := newAnomalyDetector(3, 2)
+	block := 0
+	window := func(counts map[string]int) []Anomaly {
+		for address, n := range counts {
+			for range n {
+				d.observe(address)
+			}
+		}
+		block += 2
+		if got := d.advance(block - 1); got != nil {
+			t.Fatalf("window closed after one block: %+v", got)
+		}
+		return d.advance(block)
+	}
+
+	for i := range anomalyMinHistory {
+		counts := map[string]int{"0xa": 4 + i%2, "0xb": 1}
+		if got := window(counts); len(got) != 0 {
+			t.Fatalf("unexpected anomalies while building the baseline: %+v", got)
+		}
+	}
+
+	got := window(map[string]int{"0xa": 5, "0xb": 30})
+	if len(got) != 2 {
+		t.Fatalf("expected a spike for 0xb and the global rate, got %+v", got)
+	}
+	for _, a := range got {
+		if a.Kind != AnomalySpike || (a.Address != "0xb" && a.Address != "") || a.FromBlock != block-1 || a.ToBlock != block {
+			t.Errorf("unexpected anomaly %+v", a)
+		}
+	}
+
+	got = window(map[string]int{"0xb": 1})
+	if len(got) != 1 || got[0].Kind != AnomalySilence || got[0].Address != "0xa" || got[0].Count != 0 {
+		t.Errorf("expected silence for 0xa, got %+v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package txparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// InvariantChecker is implemented by stores that can verify their internal consistency.
+type InvariantChecker interface {
+	CheckInvariants() error
+}
+
+// SetAuditMode enables cross-checking of store invariants after every processed block.
+// A violation panics, so concurrency bugs surface immediately in tests and staging;
+// it is meant to be combined with the race detector, not enabled in production.
+func (p *EthParser) SetAuditMode(enabled bool) {
+	p.audit = enabled
+	p.auditedBlock = p.GetCurrentBlock()
+}
+
+// auditBlock verifies invariants after block was processed or rolled back to.
+func (p *EthParser) auditBlock(block int, rollback bool) {
+	if !p.audit {
+		return
+	}
+	if !rollback && block <= p.auditedBlock {
+		panic(fmt.Sprintf("audit: current block went from %d to %d", p.auditedBlock, block))
+	}
+	p.auditedBlock = block
+	if current := p.store.GetCurrentBlock(); current != block {
+		panic(fmt.Sprintf("audit: store current block is %d after processing block %d", current, block))
+	}
+	if checker, ok := p.store.(InvariantChecker); ok {
+		if err := checker.CheckInvariants(); err != nil {
+			panic(fmt.Sprintf("audit: store invariant violated at block %d: %v", block, err))
+		}
+	}
+}
+
+// CheckInvariants verifies that every address's transactions are in block order,
+// not duplicated and not above the current block, that no block hash is recorded
+// above the current block, and that the memory accounting matches the contents.
+func (m *MemoryStore) CheckInvariants() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var usedBytes int64
+	for address, txs := range m.transactions {
+		if !sort.SliceIsSorted(txs, func(i, j int) bool { return txs[i].Block < txs[j].Block }) {
+			return fmt.Errorf("transactions of %s are not in block order", address)
+		}
+		seen := make(map[string]bool, len(txs))
+		for _, tx := range txs {
+			if seen[tx.Hash] {
+				return fmt.Errorf("duplicate transaction %s for %s", tx.Hash, address)
+			}
+			seen[tx.Hash] = true
+			if tx.Block > int64(m.CurrentBlock) {
+				return fmt.Errorf("transaction %s in block %d is above current block %d", tx.Hash, tx.Block, m.CurrentBlock)
+			}
+			usedBytes += estimateTxBytes(tx)
+		}
+	}
+	if usedBytes != m.usedBytes {
+		return fmt.Errorf("memory accounting drifted: recorded %d bytes, contents use %d", m.usedBytes, usedBytes)
+	}
+	for block := range m.blockHashes {
+		if block > m.CurrentBlock {
+			return fmt.Errorf("block hash recorded for %d above current block %d", block, m.CurrentBlock)
+		}
+	}
+	return nil
+}
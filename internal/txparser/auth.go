@@ -0,0 +1,164 @@
+package txparser
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+// Scopes of API keys. ScopeSubscribe implies ScopeRead.
+const (
+	ScopeRead      Scope = "read"      // GET and HEAD requests
+	ScopeSubscribe Scope = "subscribe" // every other method: subscriptions, jobs, admin changes
+)
+
+// APIKey is a static API key with its scopes.
+type APIKey struct {
+	Name   string  `json:"name,omitempty"` // shown in logs instead of the key
+	Key    string  `json:"key"`
+	Scopes []Scope `json:"scopes"`
+}
+
+// allows reports whether the key grants scope.
+func (k APIKey) allows(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) || (scope == ScopeRead && slices.Contains(k.Scopes, ScopeSubscribe))
+}
+
+// LoadAPIKeys reads a JSON array of API keys.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading API keys file failed: %w", err)
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("API keys unmarshal failed: %w", err)
+	}
+	return keys, validateAPIKeys(keys)
+}
+
+// ParseAPIKeys parses comma-separated key:scope entries, e.g. "k1:read,k2:subscribe";
+// several scopes of one key are joined with "+". An empty string yields no keys.
+func ParseAPIKeys(s string) ([]APIKey, error) {
+	var keys []APIKey
+	for i, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, scopes, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("API key %d: expected key:scope", i+1)
+		}
+		k := APIKey{Name: fmt.Sprintf("key-%d", i+1), Key: key}
+		for _, scope := range strings.Split(scopes, "+") {
+			k.Scopes = append(k.Scopes, Scope(scope))
+		}
+		keys = append(keys, k)
+	}
+	return keys, validateAPIKeys(keys)
+}
+
+// validateAPIKeys checks that keys are non-empty and unique, with known scopes.
+func validateAPIKeys(keys []APIKey) error {
+	seen := make(map[string]bool, len(keys))
+	for i, k := range keys {
+		if k.Key == "" {
+			return fmt.Errorf("API key %d: key is required", i+1)
+		}
+		if seen[k.Key] {
+			return fmt.Errorf("API key %d: duplicate key", i+1)
+		}
+		seen[k.Key] = true
+		if len(k.Scopes) == 0 {
+			return fmt.Errorf("API key %d: at least one scope is required", i+1)
+		}
+		for _, scope := range k.Scopes {
+			if scope != ScopeRead && scope != ScopeSubscribe {
+				return fmt.Errorf("API key %d: unknown scope %q, expected %q or %q", i+1, scope, ScopeRead, ScopeSubscribe)
+			}
+		}
+	}
+	return nil
+}
+
+// ErrUnauthorized is returned for requests without a valid API key.
+var ErrUnauthorized = errors.New("missing or invalid API key")
+
+// Authenticator admits requests carrying a known API key in the X-API-Key header with
+// the scope their method needs. Health probes are always admitted.
+type Authenticator struct {
+	keys map[[sha256.Size]byte]APIKey // by key digest, so lookups take the same time for any key
+}
+
+// NewAuthenticator creates an Authenticator accepting keys.
+func NewAuthenticator(keys []APIKey) (*Authenticator, error) {
+	if err := validateAPIKeys(keys); err != nil {
+		return nil, err
+	}
+	a := &Authenticator{keys: make(map[[sha256.Size]byte]APIKey, len(keys))}
+	for _, k := range keys {
+		a.keys[sha256.Sum256([]byte(k.Key))] = k
+	}
+	return a, nil
+}
+
+// authenticate returns an error if r's API key is missing, unknown or lacks the scope
+// r's method requires. WebSocket upgrades need the subscribe scope although they are
+// GETs, since the session's subscribe messages create subscriptions, as with gRPC's
+// NotifyTransactions.
+func (a *Authenticator) authenticate(r *http.Request) error {
+	scope := ScopeSubscribe
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		scope = ScopeRead
+	}
+	return a.check(r.Header.Get(APIKeyHeader), scope)
+}
+
+// known reports whether key is one of the accepted API keys.
+func (a *Authenticator) known(key string) bool {
+	_, ok := a.keys[sha256.Sum256([]byte(key))]
+	return ok
+}
+
+// check returns ErrUnauthorized if key is unknown, or an error if it lacks scope.
+func (a *Authenticator) check(key string, scope Scope) error {
+	k, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return ErrUnauthorized
+	}
+	if !k.allows(scope) {
+		return fmt.Errorf("API key lacks the %s scope", scope)
+	}
+	return nil
+}
+
+// Middleware rejects requests without a valid API key with 401, and requests whose key
+// lacks the required scope with 403.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch err := a.authenticate(r); {
+		case errors.Is(err, ErrUnauthorized):
+			w.Header().Set("WWW-Authenticate", APIKeyHeader)
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, err.Error())
+		case err != nil:
+			apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, err.Error())
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
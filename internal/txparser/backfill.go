@@ -0,0 +1,145 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// JobKindBackfill scans historical blocks for the transactions of one address.
+const JobKindBackfill = "backfill"
+
+// BackfillLogRange is the most blocks covered by one eth_getLogs request of a backfill.
+const BackfillLogRange = 1000
+
+// BackfillParams are the parameters of a backfill job.
+type BackfillParams struct {
+	Address   string `json:"address"`
+	FromBlock int64  `json:"fromBlock"`
+	ToBlock   int64  `json:"toBlock"` // inclusive; live matching covers later blocks
+}
+
+// BackfillStatus is the progress of a backfill job.
+type BackfillStatus struct {
+	Job
+	BackfillParams
+	ScannedBlocks int64 `json:"scannedBlocks"`
+	TotalBlocks   int64 `json:"totalBlocks"`
+	Transactions  int   `json:"transactions"` // stored transactions of the address in the range so far
+}
+
+// AddressLogSource is implemented by sources that can return the ERC-20 Transfer logs
+// sent or received by one address over a block range, sparing a backfill the per-block
+// log requests of live parsing.
+type AddressLogSource interface {
+	GetAddressTransferLogs(ctx context.Context, address string, fromBlock, toBlock int64) ([]RawLog, error)
+}
+
+// RegisterJobs registers the parser's job kinds with m. Call it before m.Start.
+func (p *EthParser) RegisterJobs(m *JobManager) {
+	m.Register(JobKindBackfill, func(ctx context.Context, params json.RawMessage, progress func(float64)) error {
+		var bp BackfillParams
+		if err := json.Unmarshal(params, &bp); err != nil {
+			return fmt.Errorf("invalid backfill params: %w", err)
+		}
+		return p.Backfill(ctx, bp, progress)
+	})
+}
+
+// Backfill stores the transactions of bp.Address found in blocks bp.FromBlock through
+// bp.ToBlock. With token tracking enabled and a source supporting AddressLogSource, the
+// address's ERC-20 transfers are fetched with one eth_getLogs request per direction and
+// BackfillLogRange blocks. Backfilled transactions update statistics and the changefeed
+// like live ones, but are not delivered to webhooks or exporters since they are historical.
+// Transactions already stored for the address, e.g. by live matching after the
+// subscription or by an earlier backfill, are skipped.
+func (p *EthParser) Backfill(ctx context.Context, bp BackfillParams, progress func(float64)) error {
+	if bp.ToBlock < bp.FromBlock {
+		return nil
+	}
+	address := strings.ToLower(bp.Address)
+	total := float64(bp.ToBlock - bp.FromBlock + 1)
+	for start := bp.FromBlock; start <= bp.ToBlock; start += BackfillLogRange {
+		end := min(start+BackfillLogRange-1, bp.ToBlock)
+		transfers, err := p.backfillTokenTransfers(ctx, address, start, end)
+		if err != nil {
+			p.errors.Record("backfill", int(start), err)
+			return fmt.Errorf("failed to fetch token transfer logs of blocks %d-%d: %w", start, end, err)
+		}
+		stored := make(map[string]bool)
+		for _, tx := range p.store.GetTransactionsInRange(address, start, end) {
+			stored[storedTxKey(tx)] = true
+		}
+		record := func(tx Transaction) {
+			if !stored[storedTxKey(tx)] {
+				p.recordTransaction(address, tx)
+			}
+		}
+		for blockNum := start; blockNum <= end; blockNum++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			blockData, err := p.client.GetBlockByNumber(ctx, blockNum)
+			if err != nil {
+				p.errors.Record("backfill", int(blockNum), err)
+				return fmt.Errorf("failed to fetch block %d: %w", blockNum, err)
+			}
+			for i, tx := range parseTransactions(blockData) {
+				if tx.From == address || tx.To == address {
+					raw := blockData.Result.Transactions[i]
+					record(p.applyRules(address, tx, raw))
+				}
+			}
+			if len(transfers[blockNum]) > 0 {
+				source := p.client.(TokenLogSource)
+				timestamp := quantityOrZero(blockData.Result.Timestamp)
+				for _, transfer := range transfers[blockNum] {
+					record(p.tokenTransaction(ctx, source, transfer, int(blockNum), timestamp))
+				}
+			}
+			progress(float64(blockNum-bp.FromBlock+1) / total)
+		}
+	}
+	p.logger.Info("Backfill complete", "address", address, "from", bp.FromBlock, "to", bp.ToBlock)
+	return nil
+}
+
+// storedTxKey identifies a stored transaction of an address. Token transfers share the
+// hash of their transaction, so they are told apart by token, parties and amount.
+func storedTxKey(tx Transaction) string {
+	if tx.MatchType != MatchTypeToken {
+		return tx.Hash
+	}
+	return strings.Join([]string{tx.Hash, tx.Token, tx.From, tx.To, tx.TokenAmount}, "|")
+}
+
+// backfillTokenTransfers returns the ERC-20 transfers of address in blocks fromBlock
+// through toBlock by block, or nil when token tracking is off or the source cannot
+// filter logs by address.
+func (p *EthParser) backfillTokenTransfers(ctx context.Context, address string, fromBlock, toBlock int64) (map[int64][]tokenTransfer, error) {
+	source, ok := p.client.(AddressLogSource)
+	if _, tokens := p.client.(TokenLogSource); !ok || !tokens || !p.trackTokens || !p.features.Enabled(FeatureTokens) {
+		return nil, nil
+	}
+	logs, err := source.GetAddressTransferLogs(ctx, address, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	transfers := make(map[int64][]tokenTransfer)
+	seen := make(map[string]bool) // self-transfers are returned once per direction
+	for _, log := range logs {
+		transfer, ok := decodeTransferLog(log)
+		blockNum, err := hexutil.DecodeInt64(log.BlockNumber)
+		if !ok || err != nil || blockNum < fromBlock || blockNum > toBlock {
+			continue
+		}
+		if key := log.TransactionHash + "/" + log.LogIndex; !seen[key] {
+			seen[key] = true
+			transfers[blockNum] = append(transfers[blockNum], transfer)
+		}
+	}
+	return transfers, nil
+}
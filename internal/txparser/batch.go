@@ -0,0 +1,101 @@
+package txparser
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Per-item error codes reported by batch endpoints.
+const (
+	BatchErrInvalidAddress   = "invalid_address"
+	BatchErrDuplicate        = "duplicate"
+	BatchErrStoreUnavailable = "store_unavailable"
+	BatchErrInternal         = "internal"
+)
+
+// MaxBatchItems bounds the number of items accepted by a single batch request.
+const MaxBatchItems = 10000
+
+// BatchItemResult is the outcome of one item in a batch request.
+type BatchItemResult struct {
+	Input   string `json:"input"`
+	Address string `json:"address,omitempty"` // normalized form of Input
+	Success bool   `json:"success"`
+	Created bool   `json:"created,omitempty"` // false when the item already existed
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchResponse aggregates per-item results of a batch request.
+type BatchResponse struct {
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// add appends a result and updates the counters.
+func (b *BatchResponse) add(result BatchItemResult) {
+	if result.Success {
+		b.Succeeded++
+	} else {
+		b.Failed++
+	}
+	b.Results = append(b.Results, result)
+}
+
+// status is 200 when every item succeeded and 207 Multi-Status otherwise.
+func (b *BatchResponse) status() int {
+	if b.Failed == 0 {
+		return http.StatusOK
+	}
+	return http.StatusMultiStatus
+}
+
+// batchErrorCode maps a domain error to a per-item error code.
+func batchErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidAddress):
+		return BatchErrInvalidAddress
+	case errors.Is(err, ErrStoreUnavailable):
+		return BatchErrStoreUnavailable
+	default:
+		return BatchErrInternal
+	}
+}
+
+// normalizeAddress trims and lowercases a hex address, checking it is 0x followed by 40 hex
+// digits and, if mixed-case, that it carries a valid EIP-55 checksum.
+func normalizeAddress(input string) (string, error) {
+	trimmed := strings.TrimSpace(input)
+	address := strings.ToLower(trimmed)
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return "", fmt.Errorf("address must be 0x followed by 40 hex digits")
+	}
+	for _, c := range address[2:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return "", fmt.Errorf("address contains non-hex character %q", c)
+		}
+	}
+	if !checksumValid(trimmed) {
+		return "", fmt.Errorf("address fails EIP-55 checksum")
+	}
+	return address, nil
+}
+
+// ParseAddresses parses comma-separated addresses into their lowercase form.
+func ParseAddresses(s string) ([]string, error) {
+	var addresses []string
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		address, err := normalizeAddress(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", strings.TrimSpace(entry), err)
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
@@ -0,0 +1,98 @@
+package txparser
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// BlockArchiver persists fetched blocks, e.g. for debugging or later replay via FileBlockSource.
+type BlockArchiver interface {
+	Archive(blockNum int64, block BlockResponse) error
+}
+
+// DirArchiver writes each block's raw JSON to a local directory using the
+// <decimal block number>.json[.gz] layout understood by FileBlockSource.
+type DirArchiver struct {
+	dir      string
+	compress bool
+	maxFiles int64 // 0 keeps every block
+}
+
+// NewDirArchiver creates the archive directory if needed.
+// With compress set, files are gzipped; with maxFiles > 0, only the newest maxFiles blocks are kept.
+func NewDirArchiver(dir string, compress bool, maxFiles int) (*DirArchiver, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating archive directory failed: %w", err)
+	}
+	return &DirArchiver{dir: dir, compress: compress, maxFiles: int64(maxFiles)}, nil
+}
+
+// Archive writes the block and rotates out the block that fell outside the retention window.
+func (a *DirArchiver) Archive(blockNum int64, block BlockResponse) error {
+	data := []byte(block.Raw)
+	if len(data) == 0 {
+		var err error
+		if data, err = json.Marshal(block); err != nil {
+			return fmt.Errorf("block %d marshal failed: %w", blockNum, err)
+		}
+	}
+
+	if err := a.write(blockNum, data); err != nil {
+		return err
+	}
+
+	if a.maxFiles > 0 && blockNum >= a.maxFiles {
+		return a.remove(blockNum - a.maxFiles)
+	}
+	return nil
+}
+
+// write stores data atomically via a temporary file and rename.
+func (a *DirArchiver) write(blockNum int64, data []byte) error {
+	name := strconv.FormatInt(blockNum, 10) + ".json"
+	if a.compress {
+		name += ".gz"
+	}
+	tmp, err := os.CreateTemp(a.dir, name+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating archive file failed: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if a.compress {
+		zw := gzip.NewWriter(tmp)
+		if _, err = zw.Write(data); err == nil {
+			err = zw.Close()
+		}
+	} else {
+		_, err = tmp.Write(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing archive file failed: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(a.dir, name)); err != nil {
+		return fmt.Errorf("renaming archive file failed: %w", err)
+	}
+	return nil
+}
+
+// remove deletes both the plain and compressed file for a block, if present.
+func (a *DirArchiver) remove(blockNum int64) error {
+	base := filepath.Join(a.dir, strconv.FormatInt(blockNum, 10)+".json")
+	for _, path := range []string{base, base + ".gz"} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("rotating archive file failed: %w", err)
+		}
+	}
+	return nil
+}
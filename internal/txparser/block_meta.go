@@ -0,0 +1,38 @@
+package txparser
+
+// BlockMeta describes a recently processed block.
+type BlockMeta struct {
+	Number int    `json:"number"`
+	Hash   string `json:"hash"`
+	// Source is the provider that served the block, e.g. the RPC endpoint host during
+	// failover, so inconsistent data can be traced to a specific provider.
+	Source string `json:"source,omitempty"`
+}
+
+// recordBlockSource remembers the provider of block and forgets blocks older than BlockHashWindow.
+func (p *EthParser) recordBlockSource(block int, source string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blockSources[block] = source
+	delete(p.blockSources, block-BlockHashWindow)
+}
+
+// rollbackBlockSourcesLocked forgets the providers of blocks above block. Callers hold p.mu.
+func (p *EthParser) rollbackBlockSourcesLocked(block int) {
+	for b := range p.blockSources {
+		if b > block {
+			delete(p.blockSources, b)
+		}
+	}
+}
+
+// GetBlockMeta returns the hash and provider of a recently processed block.
+func (p *EthParser) GetBlockMeta(block int) (BlockMeta, bool) {
+	hash, ok := p.store.GetBlockHash(block)
+	if !ok {
+		return BlockMeta{}, false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return BlockMeta{Number: block, Hash: hash, Source: p.blockSources[block]}, true
+}
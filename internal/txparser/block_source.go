@@ -0,0 +1,158 @@
+package txparser
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// BlockSource provides the chain tip and block data consumed by the parser.
+// The JSON-RPC client is the live implementation; FileBlockSource replays captured blocks.
+type BlockSource interface {
+	BlockNumber(ctx context.Context) (string, error)
+	GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error)
+	FinalizedBlockNumber(ctx context.Context) (string, error)
+}
+
+// BlockStreamer is implemented by sources that can decode a block's transactions
+// one at a time without holding the whole block in memory.
+type BlockStreamer interface {
+	StreamBlockTransactions(ctx context.Context, blockNum int64, fn func(RawTx) error) (StreamedBlock, error)
+}
+
+// StreamedBlock is the block-level data returned after streaming a block's transactions.
+type StreamedBlock struct {
+	Hash   string
+	Source string // provider that served the block, see BlockResponse.Source
+}
+
+// BatchBlockSource is implemented by sources that can fetch a range of blocks in one round trip.
+type BatchBlockSource interface {
+	GetBlocksByNumber(ctx context.Context, from, to int64) ([]BlockResponse, error)
+}
+
+// BlockHashSource is implemented by sources that can fetch a block hash without its transactions.
+type BlockHashSource interface {
+	GetBlockHash(ctx context.Context, blockNum int64) (string, error)
+}
+
+// BlockHeader is the part of a block header the parser uses.
+type BlockHeader struct {
+	Hash          string `json:"hash"`
+	Timestamp     string `json:"timestamp"`
+	BaseFeePerGas string `json:"baseFeePerGas"`
+}
+
+// BlockHeaderSource is implemented by sources that can fetch a block header without its transactions.
+type BlockHeaderSource interface {
+	GetBlockHeader(ctx context.Context, blockNum int64) (BlockHeader, error)
+}
+
+// TransactionSource is implemented by sources that can look up a single transaction by hash.
+type TransactionSource interface {
+	GetTransactionByHash(ctx context.Context, hash string) (RawTx, error)
+}
+
+// FileBlockSource serves blocks from exported eth_getBlockByNumber responses on disk.
+// Each block is stored as <dir>/<decimal block number>.json, optionally gzipped as .json.gz.
+type FileBlockSource struct {
+	dir    string
+	latest int64
+}
+
+// NewFileBlockSource creates a FileBlockSource reading block files from dir.
+// The highest block number found in dir is reported as the chain tip.
+func NewFileBlockSource(dir string) (*FileBlockSource, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading block directory failed: %w", err)
+	}
+
+	var latest int64 = -1
+	for _, entry := range entries {
+		blockNum, ok := blockNumberFromFileName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		latest = max(latest, blockNum)
+	}
+	if latest < 0 {
+		return nil, fmt.Errorf("no block files found in %s", dir)
+	}
+
+	return &FileBlockSource{dir: dir, latest: latest}, nil
+}
+
+// BlockNumber returns the highest captured block as a hex string.
+func (f *FileBlockSource) BlockNumber(ctx context.Context) (string, error) {
+	return hexutil.EncodeInt64(f.latest), nil
+}
+
+// FinalizedBlockNumber treats every captured block as final.
+func (f *FileBlockSource) FinalizedBlockNumber(ctx context.Context) (string, error) {
+	return f.BlockNumber(ctx)
+}
+
+// DetectCapabilities reports no optional RPC features.
+func (f *FileBlockSource) DetectCapabilities(ctx context.Context) Capabilities {
+	return Capabilities{}
+}
+
+// GetBlockByNumber reads and decodes the file for the given block.
+func (f *FileBlockSource) GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error) {
+	path := filepath.Join(f.dir, strconv.FormatInt(blockNum, 10)+".json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		path += ".gz"
+		data, err = readGzipFile(path)
+	}
+	if err != nil {
+		return BlockResponse{}, fmt.Errorf("reading block file failed: %w", err)
+	}
+
+	var blockResp BlockResponse
+	if err := json.Unmarshal(data, &blockResp); err != nil {
+		return BlockResponse{}, fmt.Errorf("block file %s unmarshal failed: %w", path, err)
+	}
+	blockResp.Source = path
+	return blockResp, nil
+}
+
+// readGzipFile returns the decompressed contents of a gzip file.
+func readGzipFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// blockNumberFromFileName extracts the block number from a "<n>.json" or "<n>.json.gz" file name.
+func blockNumberFromFileName(name string) (int64, bool) {
+	base, ok := strings.CutSuffix(strings.TrimSuffix(name, ".gz"), ".json")
+	if !ok {
+		return 0, false
+	}
+	blockNum, err := strconv.ParseInt(base, 10, 64)
+	if err != nil || blockNum < 0 {
+		return 0, false
+	}
+	return blockNum, true
+}
@@ -0,0 +1,110 @@
+package txparser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFileBlockSource verifies captured block files can drive the parser.
+func TestFileBlockSource(t *testing.T) {
+	dir := t.TempDir()
+	blocks := map[string]string{
+		"1.json":    `{"jsonrpc":"2.0","id":1,"result":{"number":"0x1","hash":"0xb1","transactions":[{"hash":"0xt1","from":"0x00000000000000000000000000000000000000aa","to":"0x00000000000000000000000000000000000000bb","value":"0x1"}]}}`,
+		"2.json":    `{"jsonrpc":"2.0","id":1,"result":{"number":"0x2","hash":"0xb2","transactions":[]}}`,
+		"notes.txt": "ignored",
+	}
+	for name, body := range blocks {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	source, err := NewFileBlockSource(dir)
+	if err != nil {
+		t.Fatalf("NewFileBlockSource error: %v", err)
+	}
+	if tip, _ := source.BlockNumber(context.Background()); tip != "0x2" {
+		t.Errorf("expected tip 0x2, got %s", tip)
+	}
+
+	parser := NewEthParser(source, NewMemoryStore(), nil)
+	parser.Subscribe("0x00000000000000000000000000000000000000bb")
+	for i := 0; i < 2; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+	if parser.GetCurrentBlock() != 2 {
+		t.Errorf("expected current block 2, got %d", parser.GetCurrentBlock())
+	}
+	if txs := parser.GetTransactions("0x00000000000000000000000000000000000000bb"); len(txs) != 1 || txs[0].Hash != "0xt1" {
+		t.Errorf("expected replayed tx 0xt1, got %+v", txs)
+	}
+
+	if _, err := NewFileBlockSource(t.TempDir()); err == nil {
+		t.Errorf("expected error for empty block directory")
+	}
+}
+
+// TestDirArchiverRoundTrip verifies archived blocks are rotated and readable by FileBlockSource.
+func TestDirArchiverRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	archiver, err := NewDirArchiver(dir, true, 2)
+	if err != nil {
+		t.Fatalf("NewDirArchiver error: %v", err)
+	}
+	for n := int64(1); n <= 3; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0xt%d", n)}}
+		if err := archiver.Archive(n, block); err != nil {
+			t.Fatalf("Archive(%d) error: %v", n, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "1.json.gz")); !os.IsNotExist(err) {
+		t.Errorf("expected block 1 to be rotated out, stat err=%v", err)
+	}
+
+	source, err := NewFileBlockSource(dir)
+	if err != nil {
+		t.Fatalf("NewFileBlockSource error: %v", err)
+	}
+	block, err := source.GetBlockByNumber(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("GetBlockByNumber error: %v", err)
+	}
+	if block.Result.Transactions[0].Hash != "0xt3" {
+		t.Errorf("expected tx 0xt3, got %+v", block.Result.Transactions)
+	}
+}
+
+// TestBlockMetaSource verifies the provider of each processed block is recorded.
+func TestBlockMetaSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "1.json")
+	if err := os.WriteFile(path, []byte(`{"result":{"number":"0x1","hash":"0xb1","transactions":[]}}`), 0o644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	source, err := NewFileBlockSource(dir)
+	if err != nil {
+		t.Fatalf("NewFileBlockSource error: %v", err)
+	}
+	parser := NewEthParser(source, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+
+	meta, ok := parser.GetBlockMeta(1)
+	if !ok || meta.Hash != "0xb1" || meta.Source != path {
+		t.Errorf("unexpected block meta %+v (found=%v)", meta, ok)
+	}
+	if _, ok := parser.GetBlockMeta(2); ok {
+		t.Errorf("expected no meta for unprocessed block")
+	}
+}
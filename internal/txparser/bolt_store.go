@@ -0,0 +1,548 @@
+package txparser
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt buckets. Transactions live in one nested bucket per address, keyed by
+// big-endian block number followed by the transaction's identity (see boltTxKey), so
+// a cursor walks them in block order and storing a transaction twice overwrites it.
+var (
+	boltMetaBucket          = []byte("meta")
+	boltSubscriptionsBucket = []byte("subscriptions")
+	boltTransactionsBucket  = []byte("transactions")
+	boltBlockHashesBucket   = []byte("block_hashes")
+
+	boltSchemaVersionKey = []byte("schema_version")
+	boltCurrentBlockKey  = []byte("current_block")
+)
+
+// boltMigrations upgrade the database schema; entry i moves a database from
+// version i to i+1. Append new migrations, never edit released ones.
+var boltMigrations = []func(tx *bolt.Tx) error{
+	// 0 -> 1: initial layout.
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltMetaBucket, boltSubscriptionsBucket, boltTransactionsBucket, boltBlockHashesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 1 -> 2: transactions keyed by block and identity instead of block and sequence.
+	func(tx *bolt.Tx) error {
+		txs := tx.Bucket(boltTransactionsBucket)
+		var addresses [][]byte
+		if err := txs.ForEachBucket(func(address []byte) error {
+			addresses = append(addresses, bytes.Clone(address))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, address := range addresses {
+			var values [][]byte
+			if err := txs.Bucket(address).ForEach(func(_, v []byte) error {
+				values = append(values, bytes.Clone(v))
+				return nil
+			}); err != nil {
+				return err
+			}
+			if err := txs.DeleteBucket(address); err != nil {
+				return err
+			}
+			bucket, err := txs.CreateBucket(address)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				var t Transaction
+				if err := json.Unmarshal(v, &t); err != nil {
+					return fmt.Errorf("transaction of %s unmarshal failed: %w", address, err)
+				}
+				if err := bucket.Put(boltTxKey(t), v); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// boltInitialMmapSize reserves address space for the database up front. Bolt read
+// transactions are snapshots that never block writers, except that growing the memory
+// map waits for all open reads; reserving 1 GiB keeps long queries from stalling
+// writes during catch-up until the file outgrows it.
+const boltInitialMmapSize = 1 << 30
+
+// boltSubscription is the persisted state of one subscription.
+type boltSubscription struct {
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"` // nil for permanent subscriptions
+	Prefs     NotificationPrefs `json:"prefs"`
+}
+
+// BoltStore is a Store persisted in a BoltDB file, so the current block,
+// subscriptions and transaction history survive restarts. Like MemoryStore,
+// a lapsed subscription keeps its history and preferences when renewed.
+// The Store interface has no error returns; write failures are logged.
+// Reads run in Bolt read transactions, each a consistent snapshot of the database.
+type BoltStore struct {
+	db     *bolt.DB
+	logger *slog.Logger
+	clock  Clock
+	closed atomic.Bool
+}
+
+// OpenBoltStore opens or creates the database at path and migrates it to the current schema.
+func OpenBoltStore(path string, logger *slog.Logger) (*BoltStore, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second, InitialMmapSize: boltInitialMmapSize})
+	if err != nil {
+		return nil, fmt.Errorf("opening bolt store failed: %w", err)
+	}
+	s := &BoltStore{db: db, logger: logger, clock: SystemClock}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate applies pending schema migrations in a single transaction.
+func (s *BoltStore) migrate() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var version uint64
+		if meta := tx.Bucket(boltMetaBucket); meta != nil {
+			if v := meta.Get(boltSchemaVersionKey); v != nil {
+				version = binary.BigEndian.Uint64(v)
+			}
+		}
+		if version > uint64(len(boltMigrations)) {
+			return fmt.Errorf("bolt store schema version %d is newer than supported version %d", version, len(boltMigrations))
+		}
+		for ; version < uint64(len(boltMigrations)); version++ {
+			if err := boltMigrations[version](tx); err != nil {
+				return fmt.Errorf("bolt store migration to version %d failed: %w", version+1, err)
+			}
+			s.logger.Info("Migrated bolt store schema", "version", version+1)
+		}
+		return tx.Bucket(boltMetaBucket).Put(boltSchemaVersionKey, uint64Key(version))
+	})
+}
+
+// Flush syncs the database file to disk. Committed writes are already synced
+// unless the file was opened with NoSync, so this is a safeguard at shutdown.
+func (s *BoltStore) Flush() error {
+	return s.db.Sync()
+}
+
+// Close closes the database file.
+func (s *BoltStore) Close() error {
+	s.closed.Store(true)
+	return s.db.Close()
+}
+
+// Available reports whether the database is still open.
+func (s *BoltStore) Available() bool {
+	return !s.closed.Load()
+}
+
+// SetClock replaces the time source used to expire TTL subscriptions.
+func (s *BoltStore) SetClock(c Clock) {
+	s.clock = c
+}
+
+// CheckHealth verifies the database can be read.
+func (s *BoltStore) CheckHealth(ctx context.Context) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltMetaBucket) == nil {
+			return errors.New("bolt store is not initialized")
+		}
+		return nil
+	})
+}
+
+// update runs fn in a write transaction and logs failures.
+func (s *BoltStore) update(op string, fn func(tx *bolt.Tx) error) {
+	if err := s.db.Update(fn); err != nil {
+		s.logger.Error("Bolt store write failed", "op", op, "err", err)
+	}
+}
+
+// view runs fn in a read transaction and logs failures.
+func (s *BoltStore) view(op string, fn func(tx *bolt.Tx) error) {
+	if err := s.db.View(fn); err != nil {
+		s.logger.Error("Bolt store read failed", "op", op, "err", err)
+	}
+}
+
+// Subscribe adds a permanent subscription. Returns true if subscribed newly.
+func (s *BoltStore) Subscribe(address string) bool {
+	var created bool
+	s.update("subscribe", func(tx *bolt.Tx) error {
+		sub, active, err := s.subscription(tx, address)
+		if err != nil {
+			return err
+		}
+		created = !active
+		sub.ExpiresAt = nil // manual subscriptions are permanent
+		return s.putSubscription(tx, address, sub)
+	})
+	return created
+}
+
+// SubscribeBatch adds permanent subscriptions in a single write transaction. If it
+// fails, no address is subscribed and none is reported as created.
+func (s *BoltStore) SubscribeBatch(addresses []string) map[string]bool {
+	created := make(map[string]bool, len(addresses))
+	s.update("subscribe batch", func(tx *bolt.Tx) error {
+		for _, address := range addresses {
+			sub, active, err := s.subscription(tx, address)
+			if err != nil {
+				clear(created)
+				return err
+			}
+			created[address] = !active
+			sub.ExpiresAt = nil
+			if err := s.putSubscription(tx, address, sub); err != nil {
+				clear(created)
+				return err
+			}
+		}
+		return nil
+	})
+	return created
+}
+
+// SubscribeUntil adds or extends a subscription that expires at expiresAt.
+// It never shortens or replaces a permanent subscription.
+func (s *BoltStore) SubscribeUntil(address string, expiresAt time.Time) bool {
+	var created bool
+	s.update("subscribe until", func(tx *bolt.Tx) error {
+		sub, active, err := s.subscription(tx, address)
+		if err != nil {
+			return err
+		}
+		if active {
+			if sub.ExpiresAt == nil || !expiresAt.After(*sub.ExpiresAt) {
+				return nil
+			}
+		} else {
+			created = true
+		}
+		sub.ExpiresAt = &expiresAt
+		return s.putSubscription(tx, address, sub)
+	})
+	return created
+}
+
+// IsSubscribed checks if an address is subscribed and its subscription has not expired.
+func (s *BoltStore) IsSubscribed(address string) bool {
+	var active bool
+	s.view("is subscribed", func(tx *bolt.Tx) error {
+		var err error
+		_, active, err = s.subscription(tx, address)
+		return err
+	})
+	return active
+}
+
+// SubscriptionCount returns the number of unexpired subscriptions.
+func (s *BoltStore) SubscriptionCount() int {
+	count := 0
+	s.view("count subscriptions", func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSubscriptionsBucket).ForEach(func(k, _ []byte) error {
+			_, active, err := s.subscription(tx, string(k))
+			if active {
+				count++
+			}
+			return err
+		})
+	})
+	return count
+}
+
+// Subscriptions returns every active subscription, sorted by address.
+func (s *BoltStore) Subscriptions() []SubscriptionState {
+	var subs []SubscriptionState
+	s.view("list subscriptions", func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSubscriptionsBucket).ForEach(func(k, _ []byte) error {
+			sub, active, err := s.subscription(tx, string(k))
+			if active {
+				subs = append(subs, SubscriptionState{Address: string(k), ExpiresAt: sub.ExpiresAt, Prefs: sub.Prefs})
+			}
+			return err
+		})
+	})
+	return subs
+}
+
+// subscription loads the stored subscription of address and whether it is active.
+func (s *BoltStore) subscription(tx *bolt.Tx, address string) (boltSubscription, bool, error) {
+	var sub boltSubscription
+	data := tx.Bucket(boltSubscriptionsBucket).Get([]byte(address))
+	if data == nil {
+		return sub, false, nil
+	}
+	if err := json.Unmarshal(data, &sub); err != nil {
+		return sub, false, fmt.Errorf("subscription of %s unmarshal failed: %w", address, err)
+	}
+	active := sub.ExpiresAt == nil || s.clock.Now().Before(*sub.ExpiresAt)
+	return sub, active, nil
+}
+
+func (s *BoltStore) putSubscription(tx *bolt.Tx, address string, sub boltSubscription) error {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(boltSubscriptionsBucket).Put([]byte(address), data)
+}
+
+// AddTransaction stores a transaction in an address's history if subscribed, replacing
+// an earlier copy of the same transaction.
+func (s *BoltStore) AddTransaction(address string, tx Transaction) {
+	s.update("add transaction", func(btx *bolt.Tx) error {
+		if _, active, err := s.subscription(btx, address); err != nil || !active {
+			return err
+		}
+		bucket, err := btx.Bucket(boltTransactionsBucket).CreateBucketIfNotExists([]byte(address))
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(boltTxKey(tx), data)
+	})
+}
+
+// boltTxKey returns the key of tx in an address bucket: the big-endian block, then the
+// lowercase hash, match type and log index. A transaction re-added after a restart or
+// a replayed block lands on its existing key, while a native transfer and the token
+// transfers of one transaction stay separate entries.
+func boltTxKey(tx Transaction) []byte {
+	key := uint64Key(uint64(tx.Block))
+	key = append(key, strings.ToLower(tx.Hash)...)
+	key = append(key, 0)
+	key = append(key, tx.MatchType...)
+	key = append(key, 0)
+	return append(key, uint64Key(uint64(tx.LogIndex))...)
+}
+
+// GetTransactions returns the transactions for a given address.
+func (s *BoltStore) GetTransactions(address string) []Transaction {
+	return s.GetTransactionsInRange(address, 0, math.MaxInt64)
+}
+
+// GetTransactionsInRange returns the transactions of address within [fromBlock, toBlock].
+func (s *BoltStore) GetTransactionsInRange(address string, fromBlock, toBlock int64) []Transaction {
+	txs := []Transaction{}
+	if fromBlock < 0 {
+		fromBlock = 0
+	}
+	s.view("get transactions", func(btx *bolt.Tx) error {
+		bucket := btx.Bucket(boltTransactionsBucket).Bucket([]byte(address))
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Seek(uint64Key(uint64(fromBlock))); k != nil; k, v = c.Next() {
+			if int64(binary.BigEndian.Uint64(k[:8])) > toBlock {
+				break
+			}
+			var tx Transaction
+			if err := json.Unmarshal(v, &tx); err != nil {
+				return fmt.Errorf("transaction of %s unmarshal failed: %w", address, err)
+			}
+			txs = append(txs, tx)
+		}
+		return nil
+	})
+	return txs
+}
+
+// QueryTransactions returns the page of address's transactions selected by q and the total match count.
+func (s *BoltStore) QueryTransactions(address string, q TxQuery) ([]Transaction, int) {
+	pager := newTxPager(q)
+	s.view("query transactions", func(btx *bolt.Tx) error {
+		bucket := btx.Bucket(boltTransactionsBucket).Bucket([]byte(address))
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Seek(uint64Key(uint64(max(q.FromBlock, 0)))); k != nil; k, v = c.Next() {
+			if int64(binary.BigEndian.Uint64(k[:8])) > q.ToBlock {
+				break
+			}
+			var tx Transaction
+			if err := json.Unmarshal(v, &tx); err != nil {
+				return fmt.Errorf("transaction of %s unmarshal failed: %w", address, err)
+			}
+			pager.add(address, tx)
+		}
+		return nil
+	})
+	return pager.page, pager.total
+}
+
+// SetCurrentBlock persists the last processed block.
+func (s *BoltStore) SetCurrentBlock(block int) {
+	s.update("set current block", func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMetaBucket).Put(boltCurrentBlockKey, uint64Key(uint64(block)))
+	})
+}
+
+// GetCurrentBlock returns the last processed block, 0 for a new database.
+func (s *BoltStore) GetCurrentBlock() int {
+	var block int
+	s.view("get current block", func(tx *bolt.Tx) error {
+		if v := tx.Bucket(boltMetaBucket).Get(boltCurrentBlockKey); v != nil {
+			block = int(binary.BigEndian.Uint64(v))
+		}
+		return nil
+	})
+	return block
+}
+
+// SetBlockHash records a block hash and forgets hashes older than BlockHashWindow.
+func (s *BoltStore) SetBlockHash(block int, hash string) {
+	s.update("set block hash", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBlockHashesBucket)
+		if block >= BlockHashWindow {
+			if err := bucket.Delete(uint64Key(uint64(block - BlockHashWindow))); err != nil {
+				return err
+			}
+		}
+		return bucket.Put(uint64Key(uint64(block)), []byte(hash))
+	})
+}
+
+// GetBlockHash returns the recorded hash of a block.
+func (s *BoltStore) GetBlockHash(block int) (string, bool) {
+	var hash string
+	var ok bool
+	s.view("get block hash", func(tx *bolt.Tx) error {
+		if v := tx.Bucket(boltBlockHashesBucket).Get(uint64Key(uint64(block))); v != nil {
+			hash, ok = string(v), true
+		}
+		return nil
+	})
+	return hash, ok
+}
+
+// RollbackTo drops transactions and block hashes above block.
+func (s *BoltStore) RollbackTo(block int) {
+	from := uint64Key(uint64(block) + 1)
+	s.update("rollback", func(tx *bolt.Tx) error {
+		if err := deleteFrom(tx.Bucket(boltBlockHashesBucket), from); err != nil {
+			return err
+		}
+		return tx.Bucket(boltTransactionsBucket).ForEachBucket(func(address []byte) error {
+			return deleteFrom(tx.Bucket(boltTransactionsBucket).Bucket(address), from)
+		})
+	})
+}
+
+// PruneBefore drops transactions below block, returning how many were dropped.
+func (s *BoltStore) PruneBefore(block int64) int {
+	before := uint64Key(uint64(max(block, 0)))
+	var pruned int
+	s.update("prune", func(tx *bolt.Tx) error {
+		pruned = 0
+		return tx.Bucket(boltTransactionsBucket).ForEachBucket(func(address []byte) error {
+			c := tx.Bucket(boltTransactionsBucket).Bucket(address).Cursor()
+			for k, _ := c.First(); k != nil && bytes.Compare(k, before) < 0; k, _ = c.First() {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				pruned++
+			}
+			return nil
+		})
+	})
+	return pruned
+}
+
+// TrimAddresses drops the oldest transactions of every address holding more than
+// limit, returning how many were dropped.
+func (s *BoltStore) TrimAddresses(limit int) int {
+	var trimmed int
+	s.update("trim", func(tx *bolt.Tx) error {
+		trimmed = 0
+		return tx.Bucket(boltTransactionsBucket).ForEachBucket(func(address []byte) error {
+			bucket := tx.Bucket(boltTransactionsBucket).Bucket(address)
+			c := bucket.Cursor()
+			for excess := bucket.Stats().KeyN - limit; excess > 0; excess-- {
+				if k, _ := c.First(); k == nil {
+					break
+				}
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				trimmed++
+			}
+			return nil
+		})
+	})
+	return trimmed
+}
+
+// deleteFrom deletes every key of bucket at or after from.
+func deleteFrom(bucket *bolt.Bucket, from []byte) error {
+	c := bucket.Cursor()
+	for k, _ := c.Seek(from); k != nil; k, _ = c.Seek(from) {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetNotificationPrefs stores notification preferences for a subscribed address.
+func (s *BoltStore) SetNotificationPrefs(address string, prefs NotificationPrefs) bool {
+	var ok bool
+	s.update("set notification prefs", func(tx *bolt.Tx) error {
+		sub, active, err := s.subscription(tx, address)
+		if err != nil || !active {
+			return err
+		}
+		ok = true
+		sub.Prefs = prefs
+		return s.putSubscription(tx, address, sub)
+	})
+	return ok
+}
+
+// GetNotificationPrefs returns the notification preferences of a subscribed address.
+func (s *BoltStore) GetNotificationPrefs(address string) (NotificationPrefs, bool) {
+	var prefs NotificationPrefs
+	var ok bool
+	s.view("get notification prefs", func(tx *bolt.Tx) error {
+		sub, active, err := s.subscription(tx, address)
+		prefs, ok = sub.Prefs, active
+		return err
+	})
+	return prefs, ok
+}
+
+// uint64Key encodes n big-endian so byte order matches numeric order.
+func uint64Key(n uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, n)
+	return key
+}
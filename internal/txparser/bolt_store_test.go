@@ -0,0 +1,145 @@
+package txparser
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// TestBoltStorePersistence verifies state survives reopening the database.
+func TestBoltStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parser.db")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := OpenBoltStore(path, logger)
+	if err != nil {
+		t.Fatalf("OpenBoltStore error: %v", err)
+	}
+
+	if !store.Subscribe("0xa") || store.Subscribe("0xa") {
+		t.Fatalf("expected only the first Subscribe to report a new subscription")
+	}
+	if created := store.SubscribeBatch([]string{"0xa", "0xb"}); len(created) != 2 || created["0xa"] || !created["0xb"] {
+		t.Fatalf("expected SubscribeBatch to create only 0xb, got %v", created)
+	}
+	store.SetNotificationPrefs("0xa", NotificationPrefs{Direction: DirectionIn})
+	for block := int64(1); block <= 300; block++ {
+		store.AddTransaction("0xa", Transaction{Hash: "0xt", Value: "0x1", Block: block})
+		store.SetBlockHash(int(block), "0xb")
+	}
+	store.AddTransaction("0xnot", Transaction{Hash: "0xt", Block: 1})
+	store.SetCurrentBlock(300)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	store, err = OpenBoltStore(path, logger)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer store.Close()
+	if store.GetCurrentBlock() != 300 || !store.IsSubscribed("0xa") || !store.IsSubscribed("0xb") {
+		t.Fatalf("expected current block and subscription to persist")
+	}
+	if prefs, _ := store.GetNotificationPrefs("0xa"); prefs.Direction != DirectionIn {
+		t.Errorf("expected prefs to persist, got %+v", prefs)
+	}
+	if txs := store.GetTransactionsInRange("0xa", 10, 19); len(txs) != 10 || txs[0].Block != 10 || txs[9].Block != 19 {
+		t.Errorf("expected blocks 10..19, got %+v", txs)
+	}
+	if txs, total := store.QueryTransactions("0xa", TxQuery{FromBlock: 10, ToBlock: 19, Offset: 8, Limit: 5}); len(txs) != 2 || txs[0].Block != 18 || total != 10 {
+		t.Errorf("expected blocks 18..19 of 10, got %+v (total %d)", txs, total)
+	}
+	if len(store.GetTransactions("0xnot")) != 0 {
+		t.Errorf("expected no transactions for unsubscribed address")
+	}
+	if _, ok := store.GetBlockHash(300 - BlockHashWindow); ok {
+		t.Errorf("expected hashes outside the window to be pruned")
+	}
+
+	store.RollbackTo(250)
+	if txs := store.GetTransactions("0xa"); len(txs) != 250 {
+		t.Errorf("expected 250 transactions after rollback, got %d", len(txs))
+	}
+	if _, ok := store.GetBlockHash(251); ok {
+		t.Errorf("expected block hash above rollback point to be removed")
+	}
+	if pruned := store.PruneBefore(101); pruned != 100 {
+		t.Errorf("expected 100 transactions pruned, got %d", pruned)
+	}
+	if txs := store.GetTransactions("0xa"); len(txs) != 150 || txs[0].Block != 101 {
+		t.Errorf("expected blocks 101..250 after pruning, got %d", len(txs))
+	}
+	if trimmed := store.TrimAddresses(100); trimmed != 50 {
+		t.Errorf("expected 50 transactions trimmed, got %d", trimmed)
+	}
+	if txs := store.GetTransactions("0xa"); len(txs) != 100 || txs[0].Block != 151 {
+		t.Errorf("expected blocks 151..250 after trimming, got %d", len(txs))
+	}
+
+	clock := NewFakeClock(time.Now())
+	store.SetClock(clock)
+	store.SubscribeUntil("0xttl", clock.Now().Add(time.Hour))
+	clock.Advance(2 * time.Hour)
+	if store.IsSubscribed("0xttl") {
+		t.Errorf("expected TTL subscription to lapse")
+	}
+}
+
+// TestBoltStoreIdempotentWrites verifies storing a transaction twice keeps one copy,
+// including for databases written with the sequence-keyed schema.
+func TestBoltStoreIdempotentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parser.db")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Write a version 1 database holding the same transaction under two sequence keys.
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open error: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := boltMigrations[0](tx); err != nil {
+			return err
+		}
+		if err := tx.Bucket(boltMetaBucket).Put(boltSchemaVersionKey, uint64Key(1)); err != nil {
+			return err
+		}
+		bucket, err := tx.Bucket(boltTransactionsBucket).CreateBucket([]byte("0xa"))
+		if err != nil {
+			return err
+		}
+		data, _ := json.Marshal(Transaction{Hash: "0xt1", Block: 1})
+		for seq := uint64(1); seq <= 2; seq++ {
+			if err := bucket.Put(append(uint64Key(1), uint64Key(seq)...), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("writing version 1 database: %v", err)
+	}
+
+	store, err := OpenBoltStore(path, logger)
+	if err != nil {
+		t.Fatalf("OpenBoltStore error: %v", err)
+	}
+	defer store.Close()
+	store.Subscribe("0xa")
+	if txs := store.GetTransactions("0xa"); len(txs) != 1 {
+		t.Fatalf("expected the migration to merge duplicates, got %+v", txs)
+	}
+
+	store.AddTransaction("0xa", Transaction{Hash: "0xT1", Block: 1})
+	store.AddTransaction("0xa", Transaction{Hash: "0xt1", Block: 1, MatchType: MatchTypeToken, LogIndex: 3})
+	store.AddTransaction("0xa", Transaction{Hash: "0xt1", Block: 1, MatchType: MatchTypeToken, LogIndex: 4})
+	store.AddTransaction("0xa", Transaction{Hash: "0xt1", Block: 1, MatchType: MatchTypeToken, LogIndex: 4})
+	if txs := store.GetTransactions("0xa"); len(txs) != 3 {
+		t.Errorf("expected the native transfer and two token transfers, got %+v", txs)
+	}
+}
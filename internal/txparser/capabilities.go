@@ -0,0 +1,63 @@
+package txparser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Capabilities records which optional RPC features the provider supports.
+type Capabilities struct {
+	Trace         bool `json:"trace"`
+	BlockReceipts bool `json:"blockReceipts"`
+	FeeHistory    bool `json:"feeHistory"`
+	WebSockets    bool `json:"websockets"` // set by ProbeWebSocket for the configured ws-url
+}
+
+// rpcMethodNotFound is the JSON-RPC error code for unsupported methods.
+const rpcMethodNotFound = -32601
+
+// wsProbeTimeout bounds the WebSocket handshake of ProbeWebSocket.
+const wsProbeTimeout = 10 * time.Second
+
+// DetectCapabilities probes the endpoint for optional methods.
+// Probes use genesis or single-block parameters to keep responses small.
+func (r *RPCClient) DetectCapabilities(ctx context.Context) Capabilities {
+	return Capabilities{
+		Trace:         r.supportsMethod(ctx, "trace_block", "0x0"),
+		BlockReceipts: r.supportsMethod(ctx, "eth_getBlockReceipts", "0x0"),
+		FeeHistory:    r.supportsMethod(ctx, "eth_feeHistory", "0x1", "latest", []interface{}{}),
+	}
+}
+
+// supportsMethod reports whether the endpoint recognizes the method.
+// Errors other than "method not found" (e.g. invalid params) still prove support.
+func (r *RPCClient) supportsMethod(ctx context.Context, method string, params ...interface{}) bool {
+	_, err := r.call(ctx, method, params...)
+	if err == nil {
+		return true
+	}
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false // transport failure, treat as unsupported
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	return rpcErr.Code != rpcMethodNotFound &&
+		!strings.Contains(msg, "not found") &&
+		!strings.Contains(msg, "not supported") &&
+		!strings.Contains(msg, "does not exist")
+}
+
+// ProbeWebSocket reports whether a ws:// or wss:// endpoint completes a WebSocket
+// handshake.
+func ProbeWebSocket(ctx context.Context, wsURL string) bool {
+	ctx, cancel := context.WithTimeout(ctx, wsProbeTimeout)
+	defer cancel()
+	conn, err := dialWebSocket(ctx, wsURL)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,57 @@
+package txparser
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// AddChain serves the parser of an additional chain, each with its own client and store,
+// to /subscribe, /transactions and /current-block requests with chain=name.
+func (s *HTTPServer) AddChain(name string, p Parser) {
+	if s.chains == nil {
+		s.chains = make(map[string]Parser)
+	}
+	s.chains[name] = p
+}
+
+// SetChainName names the chain of the server's own parser, so chain=name selects it too.
+func (s *HTTPServer) SetChainName(name string) {
+	s.chain = name
+}
+
+// Chains returns the names of every served chain, sorted.
+func (s *HTTPServer) Chains() []string {
+	names := make([]string, 0, len(s.chains)+1)
+	if s.chain != "" {
+		names = append(names, s.chain)
+	}
+	for name := range s.chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withChain runs h against the parser selected by the chain query parameter. Requests
+// without one use the server's own parser. Other chains have no background jobs, so
+// their subscriptions cannot backfill.
+func (s *HTTPServer) withChain(h func(*HTTPServer, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("chain")
+		if name == "" || name == s.chain {
+			h(s, w, r)
+			return
+		}
+		p, ok := s.chains[name]
+		if !ok {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeUnknownChain, "unknown chain "+name)
+			return
+		}
+		chain := *s
+		chain.parser = p
+		chain.jobs = nil
+		h(&chain, w, r)
+	}
+}
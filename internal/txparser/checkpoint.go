@@ -0,0 +1,185 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// CheckpointVersion is the format version written to new checkpoints.
+const CheckpointVersion = 1
+
+// ErrCheckpointUnsupported is returned when the store cannot list its subscriptions.
+var ErrCheckpointUnsupported = errors.New("store does not support checkpoints")
+
+// ErrCheckpointStale is returned when restoring a checkpoint the store is already past.
+var ErrCheckpointStale = errors.New("store is already at or past the checkpoint block")
+
+// Checkpoint is a compact recovery point: the current block, the recent block hashes
+// used for reorg detection and every active subscription. Restoring one resumes
+// indexing at Block without rescanning. Transaction history is not included; Index
+// summarizes it so operators can tell what a restore leaves out.
+type Checkpoint struct {
+	Version       int                 `json:"version"`
+	CreatedAt     time.Time           `json:"createdAt"`
+	Block         int                 `json:"block"`
+	BlockHashes   map[int]string      `json:"blockHashes"`
+	Subscriptions []SubscriptionState `json:"subscriptions"`
+	Index         []IndexSummary      `json:"index"`
+}
+
+// SubscriptionState is an active subscription with its expiry and preferences.
+type SubscriptionState struct {
+	Address   string            `json:"address"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"` // nil for permanent subscriptions
+	Prefs     NotificationPrefs `json:"prefs"`
+}
+
+// IndexSummary counts the stored transactions of an address at checkpoint time.
+type IndexSummary struct {
+	Address      string `json:"address"`
+	Transactions int    `json:"transactions"`
+	LastBlock    int64  `json:"lastBlock,omitempty"`
+}
+
+// SubscriptionLister is implemented by stores that can enumerate active subscriptions.
+type SubscriptionLister interface {
+	// Subscriptions returns every active subscription, sorted by address.
+	Subscriptions() []SubscriptionState
+}
+
+// Checkpoint captures the parser's state for WriteCheckpoint.
+func (p *EthParser) Checkpoint() (Checkpoint, error) {
+	lister, ok := p.store.(SubscriptionLister)
+	if !ok {
+		return Checkpoint{}, ErrCheckpointUnsupported
+	}
+	if err := p.checkStore(); err != nil {
+		return Checkpoint{}, err
+	}
+	block := p.store.GetCurrentBlock()
+	cp := Checkpoint{
+		Version:       CheckpointVersion,
+		CreatedAt:     p.clock.Now().UTC(),
+		Block:         block,
+		BlockHashes:   make(map[int]string),
+		Subscriptions: lister.Subscriptions(),
+	}
+	for b := max(block-BlockHashWindow+1, 0); b <= block; b++ {
+		if hash, ok := p.store.GetBlockHash(b); ok {
+			cp.BlockHashes[b] = hash
+		}
+	}
+	for _, sub := range cp.Subscriptions {
+		summary := IndexSummary{Address: sub.Address}
+		if _, summary.Transactions = p.store.QueryTransactions(sub.Address, TxQuery{ToBlock: math.MaxInt64, Limit: 1}); summary.Transactions > 0 {
+			last, _ := p.store.QueryTransactions(sub.Address, TxQuery{ToBlock: math.MaxInt64, Offset: summary.Transactions - 1, Limit: 1})
+			if len(last) == 1 {
+				summary.LastBlock = last[0].Block
+			}
+		}
+		cp.Index = append(cp.Index, summary)
+	}
+	return cp, nil
+}
+
+// RestoreCheckpoint rebuilds subscriptions, block hashes and the current block from cp.
+// Subscriptions that have since expired are skipped. It refuses a checkpoint the store
+// is already at or past, so a restart with the same flag never rewinds progress.
+func (p *EthParser) RestoreCheckpoint(cp Checkpoint) error {
+	if err := p.checkStore(); err != nil {
+		return err
+	}
+	if current := p.store.GetCurrentBlock(); current >= cp.Block {
+		return fmt.Errorf("%w: store at %d, checkpoint at %d", ErrCheckpointStale, current, cp.Block)
+	}
+	now := p.clock.Now()
+	addresses := make([]string, 0, len(cp.Subscriptions))
+	for _, sub := range cp.Subscriptions {
+		if sub.ExpiresAt == nil {
+			addresses = append(addresses, sub.Address)
+		}
+	}
+	subscribeBatch(p.store, addresses)
+	for _, sub := range cp.Subscriptions {
+		if sub.ExpiresAt != nil {
+			if !now.Before(*sub.ExpiresAt) {
+				continue
+			}
+			p.store.SubscribeUntil(sub.Address, *sub.ExpiresAt)
+		}
+		p.store.SetNotificationPrefs(sub.Address, sub.Prefs)
+	}
+	blocks := make([]int, 0, len(cp.BlockHashes))
+	for block := range cp.BlockHashes {
+		blocks = append(blocks, block)
+	}
+	sort.Ints(blocks) // oldest first, so the store's hash window keeps the newest
+	for _, block := range blocks {
+		p.store.SetBlockHash(block, cp.BlockHashes[block])
+	}
+	p.mu.Lock()
+	p.store.SetCurrentBlock(cp.Block)
+	p.mu.Unlock()
+	p.reportSubscribers()
+	return nil
+}
+
+// WriteCheckpoint writes cp to path atomically via a temporary file and rename.
+func WriteCheckpoint(path string, cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("checkpoint marshal failed: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing checkpoint failed: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replacing checkpoint failed: %w", err)
+	}
+	return nil
+}
+
+// ReadCheckpoint reads a checkpoint written by WriteCheckpoint.
+func ReadCheckpoint(path string) (Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("reading checkpoint failed: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return Checkpoint{}, fmt.Errorf("checkpoint unmarshal failed: %w", err)
+	}
+	if cp.Version != CheckpointVersion {
+		return Checkpoint{}, fmt.Errorf("checkpoint version %d is not supported, expected %d", cp.Version, CheckpointVersion)
+	}
+	return cp, nil
+}
+
+// StartCheckpoints writes a checkpoint to path every interval until ctx is canceled.
+// Failures are logged and recorded without stopping the loop.
+func (p *EthParser) StartCheckpoints(ctx context.Context, path string, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.clock.After(interval):
+		}
+		cp, err := p.Checkpoint()
+		if err == nil {
+			err = WriteCheckpoint(path, cp)
+		}
+		if err != nil {
+			p.logger.Error("Failed to write checkpoint", "path", path, "err", err)
+			p.errors.Record("checkpoint", cp.Block, err)
+			continue
+		}
+		p.logger.Info("Wrote checkpoint", "path", path, "block", cp.Block, "subscriptions", len(cp.Subscriptions))
+	}
+}
@@ -0,0 +1,85 @@
+package txparser
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the time source used for polling, expiries, rate limiting and timestamps.
+// Tests inject a FakeClock to exercise time-dependent behavior without real sleeps.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel receiving the time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the real wall clock.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// FakeClock is a manually advanced Clock for tests.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock returns a FakeClock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that fires once the clock is advanced past d from now.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d and fires every waiter whose deadline has passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.Slice(c.waiters, func(i, j int) bool { return c.waiters[i].deadline.Before(c.waiters[j].deadline) })
+	fired := 0
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			break
+		}
+		w.ch <- c.now
+		fired++
+	}
+	c.waiters = c.waiters[fired:]
+}
+
+// Waiters returns the number of pending After calls, so tests can wait for a
+// goroutine to block on the clock before advancing it.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
@@ -0,0 +1,108 @@
+package txparser
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// overloadRetryAfter is the Retry-After sent when a concurrency limit rejects a client.
+const overloadRetryAfter = time.Second
+
+// overloadBody explains a connection refused by a connection limit.
+const overloadBody = "too many open connections, retry later\n"
+
+// overloadResponse is written to connections refused by a connection limit.
+var overloadResponse = fmt.Sprintf("HTTP/1.1 503 Service Unavailable\r\n"+
+	"Retry-After: %d\r\n"+
+	"Content-Type: text/plain; charset=utf-8\r\n"+
+	"Content-Length: %d\r\n"+
+	"Connection: close\r\n\r\n%s", int(overloadRetryAfter.Seconds()), len(overloadBody), overloadBody)
+
+// LimitListener returns a listener that keeps at most maxConns accepted connections
+// open. Connections beyond the limit are answered with 503 and Retry-After and closed
+// immediately, so the accept loop never stalls behind slow clients.
+func LimitListener(l net.Listener, maxConns int, logger *slog.Logger) net.Listener {
+	return &limitListener{Listener: l, slots: make(chan struct{}, maxConns), logger: logger}
+}
+
+type limitListener struct {
+	net.Listener
+	slots  chan struct{}
+	logger *slog.Logger
+}
+
+// Accept returns the next connection that fits within the limit.
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case l.slots <- struct{}{}:
+			return &limitConn{Conn: conn, release: func() { <-l.slots }}, nil
+		default:
+			l.logger.Warn("Connection limit reached, rejecting connection", "remote", conn.RemoteAddr().String())
+			go func() {
+				conn.SetWriteDeadline(time.Now().Add(time.Second))
+				conn.Write([]byte(overloadResponse))
+				conn.Close()
+			}()
+		}
+	}
+}
+
+// limitConn frees its listener slot once closed.
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// SetQueryLimit caps how many expensive queries (transaction listings, timelines,
+// sweeps) run at once. A query waits up to queueTimeout for a slot before being
+// rejected with 503 and Retry-After. maxQueries <= 0 removes the limit.
+func (s *HTTPServer) SetQueryLimit(maxQueries int, queueTimeout time.Duration) {
+	if maxQueries <= 0 {
+		s.querySlots = nil
+		return
+	}
+	s.querySlots = make(chan struct{}, maxQueries)
+	s.queryQueueTimeout = queueTimeout
+}
+
+// limitQueries runs next within the expensive query limit, if one is set.
+func (s *HTTPServer) limitQueries(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.querySlots == nil {
+			next(w, r)
+			return
+		}
+		timer := time.NewTimer(s.queryQueueTimeout)
+		defer timer.Stop()
+		select {
+		case s.querySlots <- struct{}{}:
+		case <-timer.C:
+			w.Header().Set("Retry-After", strconv.Itoa(int(overloadRetryAfter.Seconds())))
+			apierror.Write(w, http.StatusServiceUnavailable, apierror.CodeOverloaded, "too many concurrent queries, retry later")
+			return
+		case <-r.Context().Done():
+			return
+		}
+		defer func() { <-s.querySlots }()
+		next(w, r)
+	}
+}
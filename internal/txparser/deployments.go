@@ -0,0 +1,109 @@
+package txparser
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// Deployment is a contract created by a subscribed address.
+type Deployment struct {
+	Deployer  string `json:"deployer"`
+	Contract  string `json:"contract"`
+	Hash      string `json:"hash"` // creating transaction
+	Block     int64  `json:"block"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+}
+
+// contractAddress returns the address of the contract created by deployer with the given
+// transaction nonce: the last 20 bytes of keccak256(rlp([deployer, nonce])). It reports
+// false if either input is malformed.
+func contractAddress(deployer, nonce string) (string, bool) {
+	sender, err := hexutil.Decode(deployer)
+	if err != nil || len(sender) != 20 {
+		return "", false
+	}
+	n, err := hexutil.DecodeUint64(nonce)
+	if err != nil {
+		return "", false
+	}
+
+	// The RLP list payload is at most 21 + 9 bytes, so both prefixes are short forms.
+	payload := append([]byte{0x80 + 20}, sender...)
+	payload = append(payload, rlpUint(new(big.Int).SetUint64(n))...)
+	hash := keccak256(append([]byte{0xc0 + byte(len(payload))}, payload...))
+	return hexutil.Encode(hash[12:]), true
+}
+
+// rlpUint encodes n as an RLP string of its minimal big-endian bytes.
+func rlpUint(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) == 1 && b[0] < 0x80 {
+		return b
+	}
+	return append([]byte{0x80 + byte(len(b))}, b...)
+}
+
+// deploymentLog records the contracts created by each deployer since startup.
+type deploymentLog struct {
+	mu         sync.Mutex
+	byDeployer map[string][]Deployment
+	subscribe  bool // also subscribe every recorded contract
+}
+
+func newDeploymentLog() *deploymentLog {
+	return &deploymentLog{byDeployer: make(map[string][]Deployment)}
+}
+
+// add records d unless its creating transaction was already recorded, e.g. before a reorg.
+func (l *deploymentLog) add(d Deployment) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, existing := range l.byDeployer[d.Deployer] {
+		if existing.Hash == d.Hash {
+			return false
+		}
+	}
+	l.byDeployer[d.Deployer] = append(l.byDeployer[d.Deployer], d)
+	return true
+}
+
+// get returns the deployments of deployer, oldest first.
+func (l *deploymentLog) get(deployer string) []Deployment {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Deployment{}, l.byDeployer[deployer]...)
+}
+
+// recordDeployment records the contract created by tx if its sender is subscribed, and
+// subscribes the contract when enabled with SetDeploymentSubscription.
+func (p *EthParser) recordDeployment(tx Transaction, raw RawTx) {
+	if tx.To != "" || !p.store.IsSubscribed(tx.From) {
+		return
+	}
+	contract, ok := contractAddress(tx.From, raw.Nonce)
+	if !ok {
+		p.logger.Warn("Failed to derive deployed contract address", "deployer", tx.From, "hash", tx.Hash, "nonce", raw.Nonce)
+		return
+	}
+	d := Deployment{Deployer: tx.From, Contract: contract, Hash: tx.Hash, Block: tx.Block, Timestamp: tx.Timestamp}
+	if !p.deployed.add(d) {
+		return
+	}
+	p.logger.Info("Recorded contract deployment", "deployer", d.Deployer, "contract", d.Contract, "block", d.Block)
+	if p.deployed.subscribe && p.store.Subscribe(contract) {
+		p.recordSubscriptionChange(contract, true)
+	}
+}
+
+// SetDeploymentSubscription enables subscribing every contract deployed by a subscribed address.
+func (p *EthParser) SetDeploymentSubscription(enabled bool) {
+	p.deployed.subscribe = enabled
+}
+
+// GetDeployments returns the contracts deployed by address since startup, oldest first.
+func (p *EthParser) GetDeployments(address string) []Deployment {
+	return p.deployed.get(strings.ToLower(address))
+}
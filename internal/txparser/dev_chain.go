@@ -0,0 +1,190 @@
+package txparser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// DevChain is an embedded fake chain for local development. It mines synthetic blocks
+// holding random transfers among a fixed set of seed addresses, so the API can be
+// exercised without an RPC provider. Every mined block is immediately final.
+type DevChain struct {
+	mu        sync.Mutex
+	rng       *rand.Rand
+	clock     Clock
+	addresses []string
+	blocks    []BlockResponse // blocks[n] is block n; block 0 is an empty genesis
+	txs       map[string]RawTx
+	maxTxs    int // upper bound of transfers per mined block
+}
+
+// DevBlockTime is how often a dev chain started with StartMining mines a block on its own.
+const DevBlockTime = 12 * time.Second
+
+// DefaultDevAddressCount is the number of seed addresses generated when none are given.
+const DefaultDevAddressCount = 5
+
+// NewDevChain creates a fake chain transferring between addresses, or between
+// DefaultDevAddressCount generated addresses if none are given. seed makes the
+// generated addresses and transfers reproducible.
+func NewDevChain(addresses []string, seed uint64) *DevChain {
+	c := &DevChain{
+		rng:    rand.New(rand.NewPCG(seed, seed)),
+		clock:  SystemClock,
+		txs:    make(map[string]RawTx),
+		maxTxs: 5,
+	}
+	for _, address := range addresses {
+		c.addresses = append(c.addresses, strings.ToLower(address))
+	}
+	want := DefaultDevAddressCount
+	if len(addresses) > 0 {
+		want = 2 // transfers need a distinct counterparty
+	}
+	for len(c.addresses) < want {
+		c.addresses = append(c.addresses, "0x"+c.randomHex(20))
+	}
+	c.blocks = append(c.blocks, c.newBlock(0, nil))
+	return c
+}
+
+// SetClock replaces the time source used for block timestamps.
+func (c *DevChain) SetClock(clock Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
+
+// SetMaxTransactions sets the upper bound of transfers per mined block; each block holds
+// between 0 and n of them.
+func (c *DevChain) SetMaxTransactions(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxTxs = n
+}
+
+// Addresses returns the seed addresses transfers are generated between.
+func (c *DevChain) Addresses() []string {
+	return append([]string(nil), c.addresses...)
+}
+
+// Mine appends n blocks with random transfers and returns the new tip.
+func (c *DevChain) Mine(n int) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < n; i++ {
+		number := int64(len(c.blocks))
+		var txs []RawTx
+		for j := c.rng.IntN(c.maxTxs + 1); j > 0; j-- {
+			from := c.rng.IntN(len(c.addresses))
+			to := (from + 1 + c.rng.IntN(len(c.addresses)-1)) % len(c.addresses)
+			tx := RawTx{
+				Hash:     "0x" + c.randomHex(32),
+				From:     c.addresses[from],
+				To:       c.addresses[to],
+				Value:    hexutil.EncodeUint64(c.rng.Uint64N(1e18) + 1),
+				GasPrice: hexutil.EncodeUint64(c.rng.Uint64N(100e9) + 1e9),
+				Input:    "0x",
+			}
+			txs = append(txs, tx)
+			c.txs[tx.Hash] = tx
+		}
+		c.blocks = append(c.blocks, c.newBlock(number, txs))
+	}
+	return int64(len(c.blocks) - 1)
+}
+
+// StartMining mines one block every interval until ctx is canceled.
+func (c *DevChain) StartMining(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.clock.After(interval):
+			c.Mine(1)
+		}
+	}
+}
+
+// newBlock builds block number on top of the current tip.
+func (c *DevChain) newBlock(number int64, txs []RawTx) BlockResponse {
+	parent := ""
+	if number > 0 {
+		parent = c.blocks[number-1].Result.Hash
+	}
+	sum := sha256.Sum256([]byte(parent + strconv.FormatInt(number, 10) + c.randomHex(8)))
+
+	var block BlockResponse
+	block.Jsonrpc = "2.0"
+	block.Result.Number = hexutil.EncodeInt64(number)
+	block.Result.Hash = "0x" + hex.EncodeToString(sum[:])
+	block.Result.Timestamp = hexutil.EncodeInt64(c.clock.Now().Unix())
+	block.Result.Transactions = txs
+	block.Source = "dev"
+	return block
+}
+
+// randomHex returns n random bytes, hex encoded.
+func (c *DevChain) randomHex(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(c.rng.UintN(256))
+	}
+	return hex.EncodeToString(b)
+}
+
+// BlockNumber returns the latest mined block.
+func (c *DevChain) BlockNumber(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return hexutil.EncodeInt64(int64(len(c.blocks) - 1)), nil
+}
+
+// FinalizedBlockNumber reports the tip, since the dev chain never reorganizes.
+func (c *DevChain) FinalizedBlockNumber(ctx context.Context) (string, error) {
+	return c.BlockNumber(ctx)
+}
+
+// GetBlockByNumber returns a mined block.
+func (c *DevChain) GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if blockNum < 0 || blockNum >= int64(len(c.blocks)) {
+		return BlockResponse{}, fmt.Errorf("block %d not found", blockNum)
+	}
+	return c.blocks[blockNum], nil
+}
+
+// GetBlockHash returns the hash of a mined block.
+func (c *DevChain) GetBlockHash(ctx context.Context, blockNum int64) (string, error) {
+	block, err := c.GetBlockByNumber(ctx, blockNum)
+	if err != nil {
+		return "", err
+	}
+	return block.Result.Hash, nil
+}
+
+// GetTransactionByHash looks up a mined transaction.
+func (c *DevChain) GetTransactionByHash(ctx context.Context, hash string) (RawTx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tx, ok := c.txs[hash]
+	if !ok {
+		return RawTx{}, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+// DetectCapabilities reports no optional RPC features.
+func (c *DevChain) DetectCapabilities(ctx context.Context) Capabilities {
+	return Capabilities{}
+}
@@ -0,0 +1,108 @@
+package txparser
+
+import (
+	"encoding/hex"
+	"math/bits"
+	"strings"
+)
+
+// keccakRoundConstants are the iota step constants of Keccak-f[1600].
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotations and keccakLanes drive the combined rho and pi steps: lane
+// keccakLanes[i] receives the previous lane rotated left by keccakRotations[i].
+var (
+	keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakLanes     = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// keccakF1600 applies the Keccak-f[1600] permutation to state.
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	for _, rc := range keccakRoundConstants {
+		// theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+		// rho and pi
+		current := a[1]
+		for i, lane := range keccakLanes {
+			current, a[lane] = a[lane], bits.RotateLeft64(current, keccakRotations[i])
+		}
+		// chi
+		for y := 0; y < 25; y += 5 {
+			copy(c[:], a[y:y+5])
+			for x := 0; x < 5; x++ {
+				a[y+x] = c[x] ^ (^c[(x+1)%5] & c[(x+2)%5])
+			}
+		}
+		// iota
+		a[0] ^= rc
+	}
+}
+
+// keccak256 hashes data with the original Keccak padding used by Ethereum,
+// which differs from the final SHA3-256 standard.
+func keccak256(data []byte) [32]byte {
+	const rate = 136
+	var state [25]uint64
+	absorb := func(block []byte) {
+		for i := 0; i < rate/8; i++ {
+			for j := 0; j < 8; j++ {
+				state[i] ^= uint64(block[8*i+j]) << (8 * j)
+			}
+		}
+		keccakF1600(&state)
+	}
+	for len(data) >= rate {
+		absorb(data[:rate])
+		data = data[rate:]
+	}
+	var last [rate]byte
+	copy(last[:], data)
+	last[len(data)] ^= 0x01
+	last[rate-1] ^= 0x80
+	absorb(last[:])
+
+	var sum [32]byte
+	for i := range sum {
+		sum[i] = byte(state[i/8] >> (8 * (i % 8)))
+	}
+	return sum
+}
+
+// checksumAddress returns the EIP-55 mixed-case form of a 0x-prefixed, 40 hex digit address.
+func checksumAddress(address string) string {
+	digits := []byte(strings.ToLower(strings.TrimPrefix(address, "0x")))
+	sum := keccak256(digits)
+	hash := hex.EncodeToString(sum[:])
+	for i, c := range digits {
+		if c >= 'a' && hash[i] >= '8' {
+			digits[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(digits)
+}
+
+// checksumValid reports whether a 0x-prefixed, 40 hex digit address passes EIP-55.
+// All-lowercase and all-uppercase addresses carry no checksum and always pass.
+func checksumValid(address string) bool {
+	digits := strings.TrimPrefix(address, "0x")
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return true
+	}
+	return address == checksumAddress(address)
+}
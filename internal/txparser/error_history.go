@@ -0,0 +1,113 @@
+package txparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// DefaultErrorHistorySize is how many recent errors the parser keeps in memory.
+const DefaultErrorHistorySize = 100
+
+// ErrorRecord is one parser or RPC error with the block being processed at the time.
+type ErrorRecord struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`          // e.g. "parser", "rpc", "archive"
+	Block   int       `json:"block,omitempty"` // block being processed, if known
+	Message string    `json:"message"`
+}
+
+// ErrorHistory is a fixed-size ring buffer of recent errors, optionally mirrored
+// to an append-only JSON lines file.
+type ErrorHistory struct {
+	mu      sync.Mutex
+	records []ErrorRecord
+	next    int // index of the slot overwritten next
+	full    bool
+	file    *os.File
+	clock   Clock
+}
+
+// NewErrorHistory keeps the last size errors. If logPath is set, every error is
+// also appended to that file as a JSON line.
+func NewErrorHistory(size int, logPath string) (*ErrorHistory, error) {
+	h := &ErrorHistory{records: make([]ErrorRecord, max(size, 1)), clock: SystemClock}
+	if logPath != "" {
+		file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("opening error log failed: %w", err)
+		}
+		h.file = file
+	}
+	return h, nil
+}
+
+// Record adds an error to the history.
+func (h *ErrorHistory) Record(source string, block int, err error) {
+	record := ErrorRecord{
+		Time:    h.clock.Now().UTC(),
+		Source:  source,
+		Block:   block,
+		Message: redactURLs(err.Error()),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+	if h.file != nil {
+		if line, err := json.Marshal(record); err == nil {
+			h.file.Write(append(line, '\n')) // best effort; the ring buffer is authoritative
+		}
+	}
+}
+
+// Recent returns the retained errors, newest first.
+func (h *ErrorHistory) Recent() []ErrorRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	count := h.next
+	if h.full {
+		count = len(h.records)
+	}
+	recent := make([]ErrorRecord, 0, count)
+	for i := 1; i <= count; i++ {
+		recent = append(recent, h.records[(h.next-i+len(h.records))%len(h.records)])
+	}
+	return recent
+}
+
+// urlPattern matches URLs embedded in error messages, e.g. by *url.Error.
+var urlPattern = regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^\s"']+`)
+
+// redactURLs reduces every URL in msg to its scheme and host, since paths, query
+// strings and user info of hosted endpoints often embed API keys.
+func redactURLs(msg string) string {
+	return urlPattern.ReplaceAllStringFunc(msg, redactURL)
+}
+
+// redactURL reduces raw to its scheme and host, or "redacted" if it does not parse.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "redacted"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// Close closes the persistent error log, if any.
+func (h *ErrorHistory) Close() error {
+	if h.file == nil {
+		return nil
+	}
+	return h.file.Close()
+}
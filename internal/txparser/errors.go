@@ -0,0 +1,109 @@
+package txparser
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// Domain errors returned by Parser methods. They may be wrapped with details,
+// so match them with errors.Is.
+var (
+	ErrNotSubscribed    = errors.New("address is not subscribed")
+	ErrInvalidAddress   = errors.New("invalid address")
+	ErrStoreUnavailable = errors.New("store unavailable")
+	ErrNotLeader        = errors.New("served by the leader replica only")
+	ErrChainTipUnknown  = errors.New("chain tip not yet known, retry later")
+)
+
+// AvailabilityReporter is implemented by stores that can become unavailable, e.g. once closed.
+type AvailabilityReporter interface {
+	Available() bool
+}
+
+// CurrentBlockReader is implemented by stores whose current block read can fail, e.g.
+// over the network. The parser skips a poll on failure instead of taking the 0
+// GetCurrentBlock reports for a new store and reindexing from the start.
+type CurrentBlockReader interface {
+	CurrentBlock() (int, error)
+}
+
+// ErrorHistorySetter is implemented by stores recording their own failures.
+type ErrorHistorySetter interface {
+	SetErrorHistory(h *ErrorHistory)
+}
+
+// validateAddress checks that address is 0x followed by exactly 40 hex digits.
+func validateAddress(address string) error {
+	digits, ok := strings.CutPrefix(address, "0x")
+	if !ok || len(digits) != 40 {
+		return fmt.Errorf("%w %q: expected 0x followed by 40 hex digits", ErrInvalidAddress, address)
+	}
+	for _, c := range strings.ToLower(digits) {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return fmt.Errorf("%w %q: non-hex character %q", ErrInvalidAddress, address, c)
+		}
+	}
+	return nil
+}
+
+// canonicalAddress validates address and returns its lowercase form, under which
+// subscriptions and transactions are stored. Mixed-case addresses must carry a
+// valid EIP-55 checksum.
+func canonicalAddress(address string) (string, error) {
+	if err := validateAddress(address); err != nil {
+		return "", err
+	}
+	if !checksumValid(address) {
+		return "", fmt.Errorf("%w %q: EIP-55 checksum mismatch", ErrInvalidAddress, address)
+	}
+	return strings.ToLower(address), nil
+}
+
+// checkStore returns ErrStoreUnavailable if the store reports it cannot serve requests.
+func (p *EthParser) checkStore() error {
+	if a, ok := p.store.(AvailabilityReporter); ok && !a.Available() {
+		return ErrStoreUnavailable
+	}
+	return nil
+}
+
+// errorResponses maps domain errors to the HTTP status and API error code they are
+// reported with. The first entry matching with errors.Is wins.
+var errorResponses = []struct {
+	err    error
+	status int
+	code   apierror.Code
+}{
+	{ErrInvalidAddress, http.StatusBadRequest, apierror.CodeInvalidAddress},
+	{ErrInvalidFilter, http.StatusBadRequest, apierror.CodeInvalidFilter},
+	{ErrInvalidTxHash, http.StatusBadRequest, apierror.CodeInvalidRequest},
+	{ErrUnknownFeature, http.StatusBadRequest, apierror.CodeUnknownFeature},
+	{ErrUnauthorized, http.StatusUnauthorized, apierror.CodeUnauthorized},
+	{ErrNotSubscribed, http.StatusNotFound, apierror.CodeNotSubscribed},
+	{ErrJobNotFound, http.StatusNotFound, apierror.CodeJobNotFound},
+	{ErrTransactionNotFound, http.StatusNotFound, apierror.CodeTransactionNotFound},
+	{ErrDeliveryNotFound, http.StatusNotFound, apierror.CodeDeliveryNotFound},
+	{ErrDeliveryNotFailed, http.StatusConflict, apierror.CodeConflict},
+	{ErrCursorExpired, http.StatusGone, apierror.CodeCursorExpired},
+	{ErrTxLookupUnsupported, http.StatusNotImplemented, apierror.CodeNotImplemented},
+	{ErrWebhookQueueFull, http.StatusServiceUnavailable, apierror.CodeOverloaded},
+	{ErrTooManyWatches, http.StatusServiceUnavailable, apierror.CodeOverloaded},
+	{ErrStoreUnavailable, http.StatusServiceUnavailable, apierror.CodeStoreUnavailable},
+	{ErrNotLeader, http.StatusServiceUnavailable, apierror.CodeNotLeader},
+	{ErrChainTipUnknown, http.StatusServiceUnavailable, apierror.CodeNotReady},
+}
+
+// writeError replies with the status and code of a domain error, or 500 INTERNAL.
+func (s *HTTPServer) writeError(w http.ResponseWriter, err error) {
+	for _, resp := range errorResponses {
+		if errors.Is(err, resp.err) {
+			apierror.Write(w, resp.status, resp.code, err.Error())
+			return
+		}
+	}
+	apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+}
@@ -0,0 +1,134 @@
+package txparser
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of store mutation recorded in the changefeed.
+type EventType string
+
+const (
+	EventTxAdded             EventType = "tx_added"
+	EventSubscriptionChanged EventType = "subscription_changed"
+	EventReorg               EventType = "reorg"
+	EventBlockProcessed      EventType = "block_processed"
+	EventAnomaly             EventType = "anomaly"
+	EventTxWatch             EventType = "tx_watch"
+)
+
+// DefaultEventLogSize is how many recent events the changefeed retains.
+const DefaultEventLogSize = 10000
+
+// ErrCursorExpired is returned when a changefeed cursor points at events that were
+// already discarded, or was issued by another process; the consumer must resynchronize
+// from a full read.
+var ErrCursorExpired = errors.New("cursor is older than the retained event log")
+
+// errMalformedCursor is returned by parseEventCursor for input that is not a cursor.
+var errMalformedCursor = errors.New("malformed cursor")
+
+// Event is one entry of the changefeed. Seq is the cursor: strictly increasing, starting at 1.
+type Event struct {
+	Seq         uint64       `json:"seq"`
+	Type        EventType    `json:"type"`
+	Time        time.Time    `json:"time"`
+	Address     string       `json:"address,omitempty"`
+	Block       int          `json:"block,omitempty"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	// Subscribed is set on subscription_changed events.
+	Subscribed *bool `json:"subscribed,omitempty"`
+	// Anomaly is set on anomaly events; Address is empty for the global rate.
+	Anomaly *Anomaly `json:"anomaly,omitempty"`
+	// TxWatch is set on tx_watch events, one per watched transaction state change.
+	TxWatch *TxWatch `json:"txWatch,omitempty"`
+}
+
+// eventLog is a bounded, ordered log of store mutations. Sequence numbers restart with
+// the process, so the cursors handed to clients carry the epoch of the log they index.
+type eventLog struct {
+	mu     sync.Mutex
+	events []Event // ring buffer
+	start  int     // index of the oldest event
+	count  int
+	seq    uint64 // sequence of the newest event
+	epoch  string // random per log, immutable
+	clock  Clock
+}
+
+func newEventLog(size int) *eventLog {
+	return &eventLog{events: make([]Event, max(size, 1)), epoch: newEventEpoch(), clock: SystemClock}
+}
+
+// newEventEpoch returns a random 8-character hex identifier.
+func newEventEpoch() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// formatEventCursor returns the client-facing cursor for seq in the log with epoch.
+func formatEventCursor(epoch string, seq uint64) string {
+	return epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseEventCursor returns the sequence number of a cursor from formatEventCursor. A
+// cursor from another epoch, e.g. issued before a restart, fails with ErrCursorExpired.
+func parseEventCursor(epoch, raw string) (uint64, error) {
+	prefix, digits, ok := strings.Cut(raw, "-")
+	seq, err := strconv.ParseUint(digits, 10, 64)
+	if !ok || err != nil {
+		return 0, fmt.Errorf("%w %q", errMalformedCursor, raw)
+	}
+	if prefix != epoch {
+		return 0, fmt.Errorf("%w: issued by another process", ErrCursorExpired)
+	}
+	return seq, nil
+}
+
+// append assigns the next sequence number to e and records it.
+func (l *eventLog) append(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	e.Seq = l.seq
+	e.Time = l.clock.Now().UTC()
+	if l.count < len(l.events) {
+		l.events[(l.start+l.count)%len(l.events)] = e
+		l.count++
+		return
+	}
+	l.events[l.start] = e
+	l.start = (l.start + 1) % len(l.events)
+}
+
+// since returns up to limit events with Seq > cursor, and the cursor to resume from.
+func (l *eventLog) since(cursor uint64, limit int) ([]Event, uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	oldest := l.seq - uint64(l.count) + 1
+	if l.count > 0 && cursor < oldest-1 {
+		return nil, cursor, ErrCursorExpired
+	}
+	if cursor >= l.seq {
+		return []Event{}, l.seq, nil
+	}
+
+	skip := int(cursor + 1 - oldest)
+	n := min(l.count-skip, limit)
+	events := make([]Event, 0, n)
+	for i := 0; i < n; i++ {
+		events = append(events, l.events[(l.start+skip+i)%len(l.events)])
+	}
+	return events, events[n-1].Seq, nil
+}
@@ -0,0 +1,108 @@
+package txparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Feature names an optional subsystem that can be switched off at runtime.
+type Feature string
+
+// Features gated by FeatureFlags.
+const (
+	FeatureTokens        Feature = "tokens"         // ERC-20 transfer tracking
+	FeatureReceipts      Feature = "receipts"       // receipt enrichment of matched transactions
+	FeatureInputMatching Feature = "input-matching" // matching addresses found in calldata
+	FeatureLogMatching   Feature = "log-matching"   // matching addresses found in event topics
+	FeatureNotifications Feature = "notifications"  // webhook delivery
+	FeatureAnomalies     Feature = "anomalies"      // transaction rate anomaly detection
+)
+
+// knownFeatures lists every Feature.
+var knownFeatures = []Feature{FeatureTokens, FeatureReceipts, FeatureInputMatching, FeatureLogMatching, FeatureNotifications, FeatureAnomalies}
+
+// ErrUnknownFeature is returned for feature names outside the known set.
+var ErrUnknownFeature = errors.New("unknown feature")
+
+// FeatureFlags switches optional subsystems on and off without a restart. A feature
+// only runs while it is both configured, e.g. with SetTokenTracking, and enabled here,
+// so enabling a subsystem that was never configured has no effect. All features are
+// enabled by default, and a nil *FeatureFlags enables everything.
+type FeatureFlags struct {
+	mu       sync.RWMutex
+	disabled map[Feature]bool
+}
+
+// NewFeatureFlags creates flags with every feature enabled except those in disabled.
+func NewFeatureFlags(disabled ...Feature) *FeatureFlags {
+	f := &FeatureFlags{disabled: make(map[Feature]bool)}
+	for _, feature := range disabled {
+		f.disabled[feature] = true
+	}
+	return f
+}
+
+// ParseFeatures parses a comma-separated list of features to disable, e.g.
+// "tokens,notifications". An empty string disables none.
+func ParseFeatures(s string) ([]Feature, error) {
+	var disabled []Feature
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		feature := Feature(name)
+		if !knownFeature(feature) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownFeature, name)
+		}
+		disabled = append(disabled, feature)
+	}
+	return disabled, nil
+}
+
+func knownFeature(feature Feature) bool {
+	for _, known := range knownFeatures {
+		if feature == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether feature may run.
+func (f *FeatureFlags) Enabled(feature Feature) bool {
+	if f == nil {
+		return true
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return !f.disabled[feature]
+}
+
+// Set enables or disables feature.
+func (f *FeatureFlags) Set(feature Feature, enabled bool) error {
+	if !knownFeature(feature) {
+		return fmt.Errorf("%w %q", ErrUnknownFeature, feature)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.disabled[feature] = !enabled
+	return nil
+}
+
+// Snapshot returns the state of every feature.
+func (f *FeatureFlags) Snapshot() map[Feature]bool {
+	states := make(map[Feature]bool, len(knownFeatures))
+	for _, feature := range knownFeatures {
+		states[feature] = f.Enabled(feature)
+	}
+	return states
+}
+
+// SetFeatureFlags gates the parser's optional subsystems with f, which may be shared
+// between parsers. Call it before StartParsing.
+func (p *EthParser) SetFeatureFlags(f *FeatureFlags) {
+	p.features = f
+}
@@ -0,0 +1,257 @@
+package txparser
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/txparser/txparserpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcScopes are the API key scopes required by each TxParser method.
+var grpcScopes = map[string]Scope{
+	txparserpb.TxParser_GetCurrentBlock_FullMethodName:    ScopeRead,
+	txparserpb.TxParser_GetTransactions_FullMethodName:    ScopeRead,
+	txparserpb.TxParser_Subscribe_FullMethodName:          ScopeSubscribe,
+	txparserpb.TxParser_NotifyTransactions_FullMethodName: ScopeSubscribe,
+}
+
+// GRPCServer serves the parser over gRPC for service-to-service consumers, with the
+// same visibility and API keys as the HTTP API.
+type GRPCServer struct {
+	txparserpb.UnimplementedTxParserServer
+
+	parser    Parser
+	logger    *slog.Logger
+	serveOnly Visibility     // visibility of returned transactions
+	auth      *Authenticator // API key checks, nil if the API is open
+
+	srv      *grpc.Server
+	stopping chan struct{} // closed by Shutdown to end notification streams
+}
+
+// NewGRPCServer creates a gRPC server for parser.
+func NewGRPCServer(parser Parser, logger *slog.Logger) *GRPCServer {
+	s := &GRPCServer{parser: parser, logger: logger, stopping: make(chan struct{})}
+	s.srv = grpc.NewServer(grpc.UnaryInterceptor(s.authorizeUnary), grpc.StreamInterceptor(s.authorizeStream))
+	txparserpb.RegisterTxParserServer(s.srv, s)
+	return s
+}
+
+// SetServeOnly sets the visibility of transactions returned by GetTransactions.
+func (s *GRPCServer) SetServeOnly(v Visibility) {
+	s.serveOnly = v
+}
+
+// SetAuthenticator requires an API key with a suitable scope in the x-api-key metadata
+// of every call. It must be called before Serve.
+func (s *GRPCServer) SetAuthenticator(a *Authenticator) {
+	s.auth = a
+}
+
+// Serve accepts connections on listener until Shutdown.
+func (s *GRPCServer) Serve(listener net.Listener) error {
+	return s.srv.Serve(listener)
+}
+
+// Shutdown ends notification streams and waits for other calls to finish, closing
+// them when ctx expires.
+func (s *GRPCServer) Shutdown(ctx context.Context) {
+	close(s.stopping)
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.srv.Stop()
+	}
+}
+
+// GetCurrentBlock returns the last parsed block and the safe block.
+func (s *GRPCServer) GetCurrentBlock(ctx context.Context, _ *txparserpb.GetCurrentBlockRequest) (*txparserpb.GetCurrentBlockResponse, error) {
+	return &txparserpb.GetCurrentBlockResponse{Block: int64(s.parser.GetCurrentBlock()), SafeBlock: int64(s.parser.SafeBlock())}, nil
+}
+
+// Subscribe adds an address to the watch list.
+func (s *GRPCServer) Subscribe(ctx context.Context, req *txparserpb.SubscribeRequest) (*txparserpb.SubscribeResponse, error) {
+	created, err := s.parser.Subscribe(req.GetAddress())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &txparserpb.SubscribeResponse{Created: created}, nil
+}
+
+// GetTransactions returns a page of an address's visible transactions.
+func (s *GRPCServer) GetTransactions(ctx context.Context, req *txparserpb.GetTransactionsRequest) (*txparserpb.GetTransactionsResponse, error) {
+	address, err := canonicalAddress(req.GetAddress())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	q := TxQuery{FromBlock: req.GetFromBlock(), ToBlock: req.GetToBlock(), Offset: int(req.GetOffset()), Limit: int(req.GetLimit())}
+	if q.ToBlock == 0 {
+		q.ToBlock = math.MaxInt64
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTxPageLimit
+	}
+	switch {
+	case q.FromBlock < 0 || q.FromBlock > q.ToBlock:
+		return nil, status.Error(codes.InvalidArgument, "from_block must be between 0 and to_block")
+	case q.Limit < 0 || q.Limit > MaxTxPageLimit || q.Offset < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d and offset not negative", MaxTxPageLimit)
+	}
+	visible, err := s.parser.VisibleBlock(ctx, s.serveOnly)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	q.ToBlock = min(q.ToBlock, int64(visible))
+	page := s.parser.QueryTransactions(address, q)
+	resp := &txparserpb.GetTransactionsResponse{Total: int32(page.Total)}
+	for _, tx := range page.Transactions {
+		resp.Transactions = append(resp.Transactions, transactionProto(tx))
+	}
+	return resp, nil
+}
+
+// NotifyTransactions subscribes the requested addresses and streams their transactions
+// detected from the call on, until the client cancels.
+func (s *GRPCServer) NotifyTransactions(req *txparserpb.NotifyTransactionsRequest, stream grpc.ServerStreamingServer[txparserpb.TransactionNotification]) error {
+	if len(req.GetAddresses()) == 0 {
+		return status.Error(codes.InvalidArgument, "addresses are required")
+	}
+	if isFollower(s.parser) {
+		return grpcError(ErrNotLeader)
+	}
+	// Stream only transactions detected after the call started.
+	_, cursor, _ := s.parser.EventsSince(math.MaxUint64, 0)
+	addresses := make(map[string]bool, len(req.GetAddresses()))
+	for _, address := range req.GetAddresses() {
+		if _, err := s.parser.Subscribe(address); err != nil {
+			return grpcError(err)
+		}
+		addresses[strings.ToLower(address)] = true
+	}
+
+	ticker := time.NewTicker(wsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-ticker.C:
+		}
+		events, next, err := s.parser.EventsSince(cursor, 1000)
+		if errors.Is(err, ErrCursorExpired) {
+			s.logger.Warn("gRPC stream fell behind the changefeed, skipping to its head")
+			_, cursor, _ = s.parser.EventsSince(math.MaxUint64, 0)
+			continue
+		}
+		cursor = next
+		for _, e := range events {
+			if e.Type != EventTxAdded || !addresses[strings.ToLower(e.Address)] {
+				continue
+			}
+			if err := stream.Send(&txparserpb.TransactionNotification{Address: e.Address, Transaction: transactionProto(*e.Transaction)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// authorizeUnary rejects unary calls without a valid API key.
+func (s *GRPCServer) authorizeUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authorizeStream rejects streaming calls without a valid API key.
+func (s *GRPCServer) authorizeStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize checks the call's API key against the scope method requires.
+func (s *GRPCServer) authorize(ctx context.Context, method string) error {
+	if s.auth == nil {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if values := md.Get(APIKeyHeader); len(values) > 0 {
+		key = values[0]
+	}
+	scope, ok := grpcScopes[method]
+	if !ok {
+		scope = ScopeSubscribe
+	}
+	switch err := s.auth.check(key, scope); {
+	case errors.Is(err, ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// grpcError maps domain errors to gRPC status codes, as writeError does for HTTP.
+func grpcError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, ErrInvalidAddress):
+		code = codes.InvalidArgument
+	case errors.Is(err, ErrNotSubscribed):
+		code = codes.NotFound
+	case errors.Is(err, ErrStoreUnavailable), errors.Is(err, ErrNotLeader), errors.Is(err, ErrChainTipUnknown):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+// transactionProto converts tx to its gRPC message.
+func transactionProto(tx Transaction) *txparserpb.Transaction {
+	return &txparserpb.Transaction{
+		Hash:                 tx.Hash,
+		From:                 tx.From,
+		To:                   tx.To,
+		Value:                tx.Value,
+		Block:                tx.Block,
+		Timestamp:            tx.Timestamp,
+		ValueWei:             tx.ValueWei,
+		ValueEther:           tx.ValueEther,
+		GasPriceWei:          tx.GasPriceWei,
+		Status:               tx.Status,
+		GasUsed:              tx.GasUsed,
+		EffectiveGasPriceWei: tx.EffectiveGasPriceWei,
+		FeeWei:               tx.FeeWei,
+		Tags:                 tx.Tags,
+		MatchType:            tx.MatchType,
+		Token:                tx.Token,
+		TokenAmount:          tx.TokenAmount,
+		TokenValue:           tx.TokenValue,
+		RiskScore:            int32(tx.RiskScore),
+		BurnedFeeWei:         tx.BurnedFeeWei,
+		TipFeeWei:            tx.TipFeeWei,
+
+		MaxFeePerGasWei:         tx.MaxFeePerGasWei,
+		MaxPriorityFeePerGasWei: tx.MaxPriorityFeePerGasWei,
+		Nonce:                   tx.Nonce,
+		MethodSelector:          tx.MethodSelector,
+	}
+}
@@ -0,0 +1,90 @@
+package txparser
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/txparser/txparserpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestGRPCServer verifies the gRPC API subscribes, queries and streams new transactions,
+// and enforces API key scopes.
+func TestGRPCServer(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+	keys, err := ParseAPIKeys("reader:read,writer:subscribe")
+	if err != nil {
+		t.Fatalf("ParseAPIKeys error: %v", err)
+	}
+	auth, err := NewAuthenticator(keys)
+	if err != nil {
+		t.Fatalf("NewAuthenticator error: %v", err)
+	}
+	server := NewGRPCServer(parser, logger)
+	server.SetAuthenticator(auth)
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	defer conn.Close()
+	client := txparserpb.NewTxParserClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	withKey := func(key string) context.Context { return metadata.AppendToOutgoingContext(ctx, APIKeyHeader, key) }
+
+	watched := "0x00000000000000000000000000000000000000be"
+	if _, err := client.GetCurrentBlock(ctx, &txparserpb.GetCurrentBlockRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a key, got %v", err)
+	}
+	if _, err := client.Subscribe(withKey("reader"), &txparserpb.SubscribeRequest{Address: watched}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied subscribing with a read key, got %v", err)
+	}
+	if _, err := client.Subscribe(withKey("writer"), &txparserpb.SubscribeRequest{Address: "0xnope"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an invalid address, got %v", err)
+	}
+
+	stream, err := client.NotifyTransactions(withKey("writer"), &txparserpb.NotifyTransactionsRequest{Addresses: []string{watched}})
+	if err != nil {
+		t.Fatalf("NotifyTransactions error: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second) // the handler subscribes before streaming
+	for !parser.store.IsSubscribed(watched) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected NotifyTransactions to subscribe %s", watched)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	raw := RawTx{Hash: "0xt1", From: watched, To: "0xdead", Value: "0x1"}
+	parser.storeTransaction(newTransaction(raw, 0, 0), raw)
+	notification, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv error: %v", err)
+	}
+	if notification.GetAddress() != watched || notification.GetTransaction().GetHash() != "0xt1" {
+		t.Errorf("unexpected notification %+v", notification)
+	}
+
+	resp, err := client.GetTransactions(withKey("reader"), &txparserpb.GetTransactionsRequest{Address: watched})
+	if err != nil {
+		t.Fatalf("GetTransactions error: %v", err)
+	}
+	if resp.GetTotal() != 1 || len(resp.GetTransactions()) != 1 || resp.GetTransactions()[0].GetValue() != "0x1" {
+		t.Errorf("unexpected transactions %+v", resp)
+	}
+}
@@ -2,8 +2,17 @@ package txparser
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
 )
 
 // NewHTTPServer constructs a new HTTP server with the given parser and slog logger.
@@ -12,71 +21,1149 @@ func NewHTTPServer(parser Parser, logger *slog.Logger) *HTTPServer {
 		logger = slog.Default()
 	}
 	return &HTTPServer{
-		parser: parser,
-		logger: logger,
+		parser:           parser,
+		logger:           logger,
+		serveOnly:        VisibilityAll,
+		readinessTimeout: DefaultReadinessTimeout,
+		draining:         new(atomic.Bool),
 	}
 }
 
 // HTTPServer holds the parser and exposes handlers.
 type HTTPServer struct {
-	parser Parser
-	logger *slog.Logger
+	parser    Parser
+	logger    *slog.Logger
+	serveOnly Visibility // default visibility for transaction queries
+
+	capabilities *Capabilities  // detected provider features, nil if not probed
+	jobs         *JobManager    // background jobs, nil if disabled
+	usage        *UsageTracker  // per-key limits and accounting, nil if disabled
+	memory       MemoryReporter // store memory accounting, nil if unavailable
+	leader       *LeaderElector // parsing lease of a replica sharing its store, nil if single
+	devChain     *DevChain      // embedded fake chain in dev mode, nil otherwise
+	shadow       *ShadowStore   // store comparing a candidate backend, nil if not shadowing
+	metrics      Metrics        // request measurements, nil if disabled
+
+	webhooks  *WebhookNotifier  // webhook delivery history, nil if webhooks are disabled
+	features  *FeatureFlags     // runtime subsystem switches, nil if not exposed
+	auth      *Authenticator    // API key checks, nil if the API is open
+	retention *RetentionManager // runtime retention policy, nil if not exposed
+
+	chain  string            // name of parser's chain, empty if unnamed
+	chains map[string]Parser // additional chains selected with the chain parameter
+
+	projects map[string]*HTTPServer // independent watch lists served under /projects/{name}/
+
+	readinessChecks  []readinessCheck
+	readinessTimeout time.Duration // per-dependency limit in /readyz
+	draining         *atomic.Bool  // set by Drain during shutdown, shared by per-chain copies
+
+	querySlots        chan struct{} // running expensive queries, nil if unlimited
+	queryQueueTimeout time.Duration // wait for a query slot before rejecting
+}
+
+// SetLeaderElector reports in /status whether this replica holds the parsing lease.
+func (s *HTTPServer) SetLeaderElector(e *LeaderElector) {
+	s.leader = e
+}
+
+// SetMemoryReporter includes the store's memory usage and headroom in /status.
+func (s *HTTPServer) SetMemoryReporter(memory MemoryReporter) {
+	s.memory = memory
+}
+
+// SetUsageTracker enables per-API-key rate limits, quotas and GET /usage.
+func (s *HTTPServer) SetUsageTracker(usage *UsageTracker) {
+	s.usage = usage
+}
+
+// SetJobManager exposes the given job manager under /jobs.
+func (s *HTTPServer) SetJobManager(jobs *JobManager) {
+	s.jobs = jobs
+}
+
+// SetDevChain exposes the mining endpoints of an embedded dev chain under /dev.
+func (s *HTTPServer) SetDevChain(c *DevChain) {
+	s.devChain = c
+}
+
+// SetShadowStore exposes the shadow store's comparison stats under /admin/shadow.
+func (s *HTTPServer) SetShadowStore(shadow *ShadowStore) {
+	s.shadow = shadow
+}
+
+// SetWebhookNotifier exposes the notifier's delivery history and redelivery under /webhooks.
+func (s *HTTPServer) SetWebhookNotifier(n *WebhookNotifier) {
+	s.webhooks = n
+}
+
+// SetFeatureFlags exposes the given feature flags under /admin/features.
+func (s *HTTPServer) SetFeatureFlags(f *FeatureFlags) {
+	s.features = f
+}
+
+// SetRetentionManager exposes the manager's retention policy under /admin/retention.
+func (s *HTTPServer) SetRetentionManager(r *RetentionManager) {
+	s.retention = r
+}
+
+// SetAuthenticator requires an API key with a suitable scope on every request but
+// health probes.
+func (s *HTTPServer) SetAuthenticator(a *Authenticator) {
+	s.auth = a
+}
+
+// SetCapabilities records the detected provider capabilities for the status endpoint.
+func (s *HTTPServer) SetCapabilities(c Capabilities) {
+	s.capabilities = &c
+}
+
+// SetServeOnly sets the default visibility for transaction queries.
+// Clients can override it per request with the serveOnly query parameter.
+func (s *HTTPServer) SetServeOnly(v Visibility) {
+	s.serveOnly = v
 }
 
 // Router configures our endpoints with net/http’s ServeMux.
 func (s *HTTPServer) Router() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/current-block", s.handleCurrentBlock)
-	mux.HandleFunc("/subscribe", s.handleSubscribe)
-	mux.HandleFunc("/transactions", s.handleGetTransactions)
-	return mux
+	mux.HandleFunc("/current-block", s.withChain((*HTTPServer).handleCurrentBlock))
+	mux.HandleFunc("/subscribe", s.withChain((*HTTPServer).handleSubscribe))
+	mux.HandleFunc("/subscribe/batch", s.handleSubscribeBatch)
+	mux.HandleFunc("/subscribe/from-tx", s.handleSubscribeFromTx)
+	mux.HandleFunc("/subscriptions/{address}", s.handleSubscription)
+	mux.HandleFunc("/transactions", s.limitQueries(s.withChain((*HTTPServer).handleGetTransactions)))
+	mux.HandleFunc("/transactions/stream", s.leaderOnly(s.handleTransactionStream))
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/blocks/{number}", s.handleBlock)
+	mux.HandleFunc("/sweeps", s.limitQueries(s.handleSweeps))
+	mux.HandleFunc("/stats", s.leaderOnly(s.handleStats))
+	mux.HandleFunc("/timeline", s.leaderOnly(s.limitQueries(s.handleTimeline)))
+	mux.HandleFunc("/deployments", s.leaderOnly(s.handleDeployments))
+	mux.HandleFunc("/priority-transactions", s.leaderOnly(s.handlePriorityTransactions))
+	mux.HandleFunc("/events", s.leaderOnly(s.handleEvents))
+	mux.HandleFunc("/ws", s.leaderOnly(s.handleWebSocket))
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/admin/errors", s.handleAdminErrors)
+	mux.HandleFunc("/watch-tx", s.leaderOnly(s.handleWatchTx))
+	mux.HandleFunc("/watch-tx/{hash}", s.leaderOnly(s.handleGetTxWatch))
+	mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/docs", s.handleDocs)
+	s.routeProjects(mux)
+	if s.jobs != nil {
+		mux.HandleFunc("/jobs", s.handleListJobs)
+		mux.HandleFunc("/jobs/{id}", s.handleJob)
+		mux.HandleFunc("/backfill", s.handleBackfill)
+		mux.HandleFunc("/backfill/{id}", s.handleBackfillStatus)
+	}
+	if s.shadow != nil {
+		mux.HandleFunc("/admin/shadow", s.handleAdminShadow)
+	}
+	if s.features != nil {
+		mux.HandleFunc("/admin/features", s.handleAdminFeatures)
+	}
+	if s.retention != nil {
+		mux.HandleFunc("/admin/retention", s.handleAdminRetention)
+	}
+	if s.webhooks != nil {
+		mux.HandleFunc("/webhooks/{address}/deliveries", s.handleWebhookDeliveries)
+		mux.HandleFunc("/webhooks/{address}/deliveries/{id}/redeliver", s.handleWebhookRedeliver)
+	}
+	if s.devChain != nil {
+		mux.HandleFunc("/dev/mine", s.handleDevMine)
+		mux.HandleFunc("/dev/addresses", s.handleDevAddresses)
+	}
+	if handler, ok := s.metrics.(http.Handler); ok {
+		mux.Handle("/metrics", handler)
+	}
+	handler := recordRoute(mux)
+	if s.usage != nil {
+		mux.HandleFunc("/usage", s.handleUsage)
+		handler = s.usage.Middleware(handler)
+	}
+	handler = s.rejectWritesWhileDraining(handler)
+	if s.auth != nil {
+		handler = s.auth.Middleware(handler)
+	}
+	if s.metrics != nil {
+		handler = s.instrument(handler)
+	}
+	return handler
 }
 
-// handleCurrentBlock returns the last parsed block.
+// handleCurrentBlock returns the last parsed block, of another chain with chain=name.
 func (s *HTTPServer) handleCurrentBlock(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
 		return
 	}
-	block := s.parser.GetCurrentBlock()
-	s.writeJSON(w, http.StatusOK, map[string]int{"currentBlock": block})
+	s.writeJSON(w, http.StatusOK, CurrentBlockResponse{CurrentBlock: s.parser.GetCurrentBlock(), SafeBlock: s.parser.SafeBlock()})
 }
 
-// handleSubscribe handles POST /subscribe { "address": "0x1234..." }
-func (s *HTTPServer) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+// handleStatus reports the parser position and detected provider capabilities.
+func (s *HTTPServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	type statusResp struct {
+		CurrentBlock int           `json:"currentBlock"`
+		SafeBlock    int           `json:"safeBlock"`
+		Capabilities *Capabilities `json:"capabilities,omitempty"`
+		Memory       *MemoryUsage  `json:"memory,omitempty"`
+		Chains       []string      `json:"chains,omitempty"`
+		Replica      string        `json:"replica,omitempty"`
+		Leader       *bool         `json:"leader,omitempty"` // parsing lease held, if replicated
+	}
+	resp := statusResp{
+		CurrentBlock: s.parser.GetCurrentBlock(),
+		SafeBlock:    s.parser.SafeBlock(),
+		Capabilities: s.capabilities,
+	}
+	if s.memory != nil {
+		usage := s.memory.MemoryUsage()
+		resp.Memory = &usage
+	}
+	if len(s.chains) > 0 {
+		resp.Chains = s.Chains()
+	}
+	if s.leader != nil {
+		leader := s.leader.IsLeader()
+		resp.Replica, resp.Leader = s.leader.ID(), &leader
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleBlock handles GET /blocks/{number}, returning the hash and provider of a recently processed block.
+func (s *HTTPServer) handleBlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "block number must be a decimal integer")
+		return
+	}
+	meta, ok := s.parser.GetBlockMeta(number)
+	if !ok {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "block not processed or outside the retained window")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, meta)
+}
+
+// handleAdminErrors handles GET /admin/errors, listing recent parser and RPC errors.
+func (s *HTTPServer) handleAdminErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.parser.RecentErrors())
+}
+
+// handleAdminFeatures handles GET /admin/features, listing whether each optional
+// subsystem is enabled, and PATCH /admin/features {"tokens":false,...}, switching the
+// given ones. Unknown features reject the whole update.
+func (s *HTTPServer) handleAdminFeatures(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPatch:
+		var update map[Feature]bool
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			s.logger.Error("Failed to decode JSON in admin features", "err", err)
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body")
+			return
+		}
+		for feature := range update {
+			if !knownFeature(feature) {
+				s.writeError(w, fmt.Errorf("%w %q", ErrUnknownFeature, feature))
+				return
+			}
+		}
+		for feature, enabled := range update {
+			s.features.Set(feature, enabled)
+			s.logger.Info("Changed feature flag", "feature", feature, "enabled", enabled)
+		}
+	default:
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET or PATCH is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.features.Snapshot())
+}
+
+// handleAdminRetention handles GET /admin/retention, reporting the retention policy
+// and what it has pruned, and PUT /admin/retention {"maxBlockAge":...,"memoryBudgetBytes":...},
+// replacing the policy. A new policy is saved and pruned against right away.
+func (s *HTTPServer) handleAdminRetention(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var policy RetentionPolicy
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			s.logger.Error("Failed to decode JSON in admin retention", "err", err)
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body")
+			return
+		}
+		if err := policy.Validate(); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+		if err := s.retention.SetPolicy(policy); err != nil {
+			s.logger.Error("Failed to set retention policy", "err", err)
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "failed to save retention policy")
+			return
+		}
+		s.logger.Info("Changed retention policy", "max_block_age", policy.MaxBlockAge, "memory_budget_bytes", policy.MemoryBudgetBytes)
+	default:
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET or PUT is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.retention.Status())
+}
+
+// handleAdminShadow handles GET /admin/shadow, reporting primary/candidate store mismatches.
+func (s *HTTPServer) handleAdminShadow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.shadow.Stats())
+}
+
+// handleWatchTx handles POST /watch-tx { "hash": "0xabc...", "confirmations": 12 }
+func (s *HTTPServer) handleWatchTx(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	type watchReq struct {
+		Hash          string `json:"hash"`
+		Confirmations int    `json:"confirmations"`
+	}
+	var req watchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("Failed to decode JSON in watch-tx", "err", err)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body")
 		return
 	}
-	type subReq struct {
-		Address string `json:"address"`
+	if req.Hash == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "hash is required")
+		return
+	}
+	if req.Confirmations <= 0 {
+		req.Confirmations = DefaultWatchConfirmations
+	}
+	watch, err := s.parser.WatchTx(req.Hash, req.Confirmations)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, watch)
+}
+
+// handleGetTxWatch handles GET /watch-tx/{hash}
+func (s *HTTPServer) handleGetTxWatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	watch, ok := s.parser.GetTxWatch(r.PathValue("hash"))
+	if !ok {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "transaction is not watched")
+		return
 	}
-	var req subReq
+	s.writeJSON(w, http.StatusOK, watch)
+}
+
+// handleSubscribe handles POST /subscribe { "address": "0x1234...", "webhookUrl": "https://...", "fromBlock": 19000000 },
+// where webhookUrl and fromBlock are optional. With fromBlock, history up to the current
+// block is backfilled by a job and the response is 202 with the job record.
+// chain=name in the query subscribes on another chain, without backfill.
+func (s *HTTPServer) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	var req SubscribeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.logger.Error("Failed to decode JSON in subscribe", "err", err)
-		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body")
 		return
 	}
 	if req.Address == "" {
-		http.Error(w, "address is required", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "address is required")
+		return
+	}
+	if req.FromBlock != nil && (*req.FromBlock < 0 || s.jobs == nil) {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "fromBlock must be non-negative and requires background jobs")
+		return
+	}
+	if req.WebhookURL != "" && req.Notifications == nil {
+		// Keep any existing preferences of a resubscribed address.
+		prefs, _ := s.parser.GetNotificationPrefs(req.Address)
+		req.Notifications = &prefs
+	}
+	if req.WebhookURL != "" {
+		req.Notifications.WebhookURL = req.WebhookURL
+	}
+	if req.Notifications != nil {
+		if err := req.Notifications.Validate(); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+		if err := s.checkWebhookPrefs(*req.Notifications); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+	subscribed, err := s.parser.Subscribe(req.Address)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if req.Notifications != nil {
+		if err := s.parser.SetNotificationPrefs(req.Address, *req.Notifications); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+	// Live matching covers blocks after the current one; older history is scanned
+	// by a backfill job so the request returns immediately.
+	if current := int64(s.parser.GetCurrentBlock()); req.FromBlock != nil && *req.FromBlock <= current {
+		params := BackfillParams{Address: req.Address, FromBlock: *req.FromBlock, ToBlock: current}
+		job, err := s.jobs.SubmitWithPriority(JobKindBackfill, params, s.parser.AddressPriority(req.Address))
+		if err != nil {
+			s.logger.Error("Failed to submit backfill job", "address", req.Address, "err", err)
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "failed to start backfill")
+			return
+		}
+		s.writeJSON(w, http.StatusAccepted, SubscribeResponse{Subscribed: subscribed, Job: &job})
 		return
 	}
-	subscribed := s.parser.Subscribe(req.Address)
-	s.writeJSON(w, http.StatusOK, map[string]bool{"subscribed": subscribed})
+	s.writeJSON(w, http.StatusOK, SubscribeResponse{Subscribed: subscribed})
 }
 
-// handleGetTransactions handles GET /transactions?address=0x1234
+// handleSubscribeBatch handles POST /subscribe/batch ["0xa...", "0xb..."]. Each address is
+// normalized independently, and the valid ones are subscribed together; the response
+// lists per-item results and is 207 Multi-Status if any item failed.
+func (s *HTTPServer) handleSubscribeBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	var inputs []string
+	if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+		s.logger.Error("Failed to decode JSON in subscribe batch", "err", err)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body, expected an array of addresses")
+		return
+	}
+	if len(inputs) > MaxBatchItems {
+		apierror.Write(w, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, fmt.Sprintf("at most %d addresses per batch", MaxBatchItems))
+		return
+	}
+
+	results := make([]BatchItemResult, len(inputs))
+	addresses := make([]string, 0, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for i, input := range inputs {
+		address, err := normalizeAddress(input)
+		switch {
+		case err != nil:
+			results[i] = BatchItemResult{Input: input, Code: BatchErrInvalidAddress, Error: err.Error()}
+		case seen[address]:
+			results[i] = BatchItemResult{Input: input, Address: address, Code: BatchErrDuplicate, Error: "address appears earlier in the batch"}
+		default:
+			seen[address] = true
+			addresses = append(addresses, address)
+			results[i] = BatchItemResult{Input: input, Address: address, Success: true}
+		}
+	}
+	created, err := s.parser.SubscribeBatch(addresses)
+
+	resp := BatchResponse{Results: make([]BatchItemResult, 0, len(inputs))}
+	for _, result := range results {
+		switch {
+		case !result.Success:
+		case err != nil:
+			result = BatchItemResult{Input: result.Input, Address: result.Address, Code: batchErrorCode(err), Error: err.Error()}
+		default:
+			result.Created = created[result.Address]
+		}
+		resp.add(result)
+	}
+	s.writeJSON(w, resp.status(), resp)
+}
+
+// handleSubscribeFromTx handles POST /subscribe/from-tx { "hash": "0xabc...", "tokenRecipients": true }
+// by subscribing the addresses involved in the transaction.
+func (s *HTTPServer) handleSubscribeFromTx(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	var req struct {
+		Hash            string `json:"hash"`
+		TokenRecipients bool   `json:"tokenRecipients"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("Failed to decode JSON in subscribe from tx", "err", err)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body")
+		return
+	}
+	if req.Hash == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "hash is required")
+		return
+	}
+
+	result, err := s.parser.SubscribeFromTx(r.Context(), req.Hash, req.TokenRecipients)
+	switch {
+	case errors.Is(err, ErrTransactionNotFound):
+		apierror.Write(w, http.StatusNotFound, apierror.CodeTransactionNotFound, "transaction not found")
+		return
+	case errors.Is(err, ErrTxLookupUnsupported):
+		s.writeError(w, err)
+		return
+	case err != nil:
+		s.logger.Error("Failed to look up transaction", "hash", req.Hash, "err", err)
+		apierror.Write(w, http.StatusBadGateway, apierror.CodeUpstreamError, "failed to look up transaction")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// handleSubscription handles GET /subscriptions/{address} and PATCH /subscriptions/{address}.
+// PATCH only changes the notification preference fields and priority present in the body.
+func (s *HTTPServer) handleSubscription(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	prefs, err := s.parser.GetNotificationPrefs(address)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPatch:
+		var patch struct {
+			Notifications NotificationPrefs `json:"notifications"`
+			Priority      *string           `json:"priority"`
+		}
+		patch.Notifications = prefs
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			s.logger.Error("Failed to decode JSON in subscription patch", "err", err)
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body")
+			return
+		}
+		if err := patch.Notifications.Validate(); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+		if err := s.checkWebhookPrefs(patch.Notifications); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+		if patch.Priority != nil {
+			priority, err := ParsePriority(*patch.Priority)
+			if err != nil {
+				apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+				return
+			}
+			if err := s.parser.SetAddressPriority(address, priority); err != nil {
+				s.writeError(w, err)
+				return
+			}
+		}
+		prefs = patch.Notifications
+		if err := s.parser.SetNotificationPrefs(address, prefs); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	default:
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET or PATCH is allowed")
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":       address,
+		"notifications": prefs,
+		"priority":      s.parser.AddressPriority(address),
+	})
+}
+
+// handleGetTransactions handles GET /transactions?address=0x1234,
+// GET /transactions?addresses=0xa,0xb and POST /transactions ["0xa", "0xb"].
+// Each form accepts minRisk=N to keep only counterparties scored at least N.
+// The single-address form returns a TransactionPage and also accepts fromBlock, toBlock,
+// direction=in|out, limit (default DefaultTxPageLimit), offset and a filter expression
+// q such as q=value>1eth AND direction=in (see TxQuery.ApplyFilter).
+// Every form accepts chain=name to query another chain.
 func (s *HTTPServer) handleGetTransactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		s.handleGetTransactionsBatch(w, r)
+		return
+	default:
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET or POST is allowed")
+		return
+	}
+	visibility, err := s.visibility(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	minRisk, err := minRiskParam(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	if list := r.URL.Query().Get("addresses"); list != "" {
+		s.writeMultiAddressTransactions(w, r, strings.Split(list, ","), visibility, minRisk)
+		return
+	}
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "address is required")
+		return
+	}
+	address, err = canonicalAddress(address)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	fromBlock, toBlock, err := blockRange(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	direction := Direction(r.URL.Query().Get("direction"))
+	if direction != "" && direction != DirectionIn && direction != DirectionOut {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "direction must be in or out")
+		return
+	}
+	q := TxQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Direction: direction,
+		MinRisk:   minRisk,
+		Offset:    offset,
+		Limit:     limit,
+	}
+	if expr := r.URL.Query().Get("q"); expr != "" {
+		if err := q.ApplyFilter(expr); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidFilter, err.Error())
+			return
+		}
+	}
+	visible, err := s.parser.VisibleBlock(r.Context(), visibility)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	q.ToBlock = min(q.ToBlock, int64(visible))
+	s.writeJSON(w, http.StatusOK, s.parser.QueryTransactions(address, q))
+}
+
+// pageParams parses the optional limit and offset query parameters.
+func pageParams(r *http.Request) (int, int, error) {
+	limit, offset := DefaultTxPageLimit, 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > MaxTxPageLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", MaxTxPageLimit)
+		}
+		limit = parsed
+	}
+	if raw := r.URL.Query().Get("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+		offset = parsed
+	}
+	return limit, offset, nil
+}
+
+// minRiskParam parses the optional minRisk query parameter, defaulting to 0.
+func minRiskParam(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("minRisk")
+	if raw == "" {
+		return 0, nil
+	}
+	minRisk, err := strconv.Atoi(raw)
+	if err != nil || minRisk < 0 || minRisk > MaxRiskScore {
+		return 0, fmt.Errorf("minRisk must be an integer between 0 and %d", MaxRiskScore)
+	}
+	return minRisk, nil
+}
+
+// blockRange parses the optional fromBlock and toBlock query parameters.
+// Missing bounds default to the whole chain.
+func blockRange(r *http.Request) (int64, int64, error) {
+	fromBlock, toBlock := int64(0), int64(math.MaxInt64)
+	for name, dst := range map[string]*int64{"fromBlock": &fromBlock, "toBlock": &toBlock} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("%s must be a non-negative block number", name)
+		}
+		*dst = parsed
+	}
+	if fromBlock > toBlock {
+		return 0, 0, fmt.Errorf("fromBlock must not exceed toBlock")
+	}
+	return fromBlock, toBlock, nil
+}
+
+// handleStats handles GET /stats?address=0x1234, returning transfer value percentiles.
+func (s *HTTPServer) handleStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
 		return
 	}
 	address := r.URL.Query().Get("address")
 	if address == "" {
-		http.Error(w, "address is required", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "address is required")
+		return
+	}
+	stats, ok := s.parser.GetValueStats(address)
+	if !ok {
+		stats = ValueStats{Address: address, Total: "0", Min: "0", Max: "0", P50: "0", P90: "0", P95: "0", P99: "0"}
+	}
+	s.writeJSON(w, http.StatusOK, stats)
+}
+
+// handlePriorityTransactions handles GET /priority-transactions?address=0x1234&limit=50,
+// returning the newest transactions matching the priority rules, of every address
+// without an address. Reads come from the bounded priority inbox, so they bypass the
+// query limit.
+func (s *HTTPServer) handlePriorityTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	query := r.URL.Query()
+	address := query.Get("address")
+	if address != "" {
+		var err error
+		if address, err = canonicalAddress(address); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+	limit := DefaultTxPageLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > MaxTxPageLimit {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", MaxTxPageLimit))
+			return
+		}
+		limit = parsed
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactions": s.parser.GetPriorityTransactions(address, limit),
+	})
+}
+
+// handleDeployments handles GET /deployments?address=0x1234, returning the contracts
+// deployed by a subscribed address.
+func (s *HTTPServer) handleDeployments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	address, err := canonicalAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":     address,
+		"deployments": s.parser.GetDeployments(address),
+	})
+}
+
+// handleTimeline handles GET /timeline?address=0x1234&bucket=1h, returning counts and
+// summed values per block-time bucket. bucket is 1h or 1d and defaults to 1h.
+func (s *HTTPServer) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "address is required")
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = "1h"
+	}
+	width, err := ParseTimelineBucket(bucket)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address": address,
+		"bucket":  bucket,
+		"buckets": s.parser.GetTimeline(address, width),
+	})
+}
+
+// handleEvents handles GET /events?since=<cursor>[&limit=N], returning store mutations
+// after the cursor in order. A 410 response means the cursor expired, or was issued
+// before a restart, and the client must resync.
+func (s *HTTPServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	query := r.URL.Query()
+	var cursor uint64
+	if raw := query.Get("since"); raw != "" {
+		parsed, err := parseEventCursor(s.parser.EventEpoch(), raw)
+		if errors.Is(err, ErrCursorExpired) {
+			s.writeError(w, err)
+			return
+		}
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "since must be a cursor returned by a previous call")
+			return
+		}
+		cursor = parsed
+	}
+	limit := 1000
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(parsed, limit)
+	}
+
+	events, next, err := s.parser.EventsSince(cursor, limit)
+	if errors.Is(err, ErrCursorExpired) {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events":     events,
+		"nextCursor": formatEventCursor(s.parser.EventEpoch(), next),
+	})
+}
+
+// handleSweeps handles GET /sweeps?address=0x1234[&window=10][&maxFee=<wei>],
+// linking deposits to the address with the outbound transfers that swept them.
+func (s *HTTPServer) handleSweeps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	query := r.URL.Query()
+	address := query.Get("address")
+	if address == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "address is required")
+		return
+	}
+
+	window := int64(DefaultSweepWindow)
+	if raw := query.Get("window"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "window must be a non-negative integer")
+			return
+		}
+		window = parsed
+	}
+	maxFee, _ := parseWei(DefaultSweepMaxFee)
+	if raw := query.Get("maxFee"); raw != "" {
+		parsed, ok := parseWei(raw)
+		if !ok || parsed.Sign() < 0 {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "maxFee must be a wei amount")
+			return
+		}
+		maxFee = parsed
+	}
+
+	links := CorrelateSweeps(address, s.parser.GetTransactions(address), window, maxFee)
+	s.writeJSON(w, http.StatusOK, links)
+}
+
+// handleListJobs handles GET /jobs, newest first.
+func (s *HTTPServer) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.jobs.List())
+}
+
+// handleJob handles GET /jobs/{id} and DELETE /jobs/{id} (cancel).
+func (s *HTTPServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		if err := s.jobs.Cancel(id); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	default:
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET or DELETE is allowed")
+		return
+	}
+	job, ok := s.jobs.Get(id)
+	if !ok {
+		s.writeError(w, ErrJobNotFound)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, job)
+}
+
+// handleBackfill handles POST /backfill {"address":"0x...","fromBlock":n,"toBlock":m},
+// starting a job that scans the history of a subscribed address. toBlock defaults to
+// the current block, and the response is 202 with the job record.
+func (s *HTTPServer) handleBackfill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	type backfillReq struct {
+		Address   string `json:"address"`
+		FromBlock int64  `json:"fromBlock"`
+		ToBlock   *int64 `json:"toBlock,omitempty"`
+	}
+	var req backfillReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("Failed to decode JSON in backfill", "err", err)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body")
+		return
+	}
+	if req.Address == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "address is required")
+		return
+	}
+	address, err := canonicalAddress(req.Address)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	current := int64(s.parser.GetCurrentBlock())
+	params := BackfillParams{Address: address, FromBlock: req.FromBlock, ToBlock: current}
+	if req.ToBlock != nil {
+		params.ToBlock = *req.ToBlock
+	}
+	if params.FromBlock < 0 || params.ToBlock < params.FromBlock || params.ToBlock > current {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "blocks must satisfy 0 <= fromBlock <= toBlock <= current block")
+		return
+	}
+	// Only subscribed addresses are backfilled, so their history stays consistent with live matches.
+	if _, err := s.parser.GetNotificationPrefs(params.Address); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	job, err := s.jobs.SubmitWithPriority(JobKindBackfill, params, s.parser.AddressPriority(params.Address))
+	if err != nil {
+		s.logger.Error("Failed to submit backfill job", "address", params.Address, "err", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "failed to start backfill")
+		return
+	}
+	s.writeJSON(w, http.StatusAccepted, job)
+}
+
+// handleBackfillStatus handles GET /backfill/{id}, reporting the blocks scanned and the
+// transactions stored so far by a backfill job.
+func (s *HTTPServer) handleBackfillStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok || job.Kind != JobKindBackfill {
+		s.writeError(w, ErrJobNotFound)
+		return
+	}
+	status := BackfillStatus{Job: job}
+	if err := json.Unmarshal(job.Params, &status.BackfillParams); err != nil {
+		s.logger.Error("Failed to decode backfill params", "job", job.ID, "err", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "invalid backfill job")
+		return
+	}
+	if status.ToBlock >= status.FromBlock {
+		status.TotalBlocks = status.ToBlock - status.FromBlock + 1
+		status.ScannedBlocks = int64(job.Progress * float64(status.TotalBlocks))
+		if job.State == JobDone {
+			status.ScannedBlocks = status.TotalBlocks
+		}
+		q := TxQuery{FromBlock: status.FromBlock, ToBlock: status.ToBlock, Limit: 1}
+		status.Transactions = s.parser.QueryTransactions(status.Address, q).Total
+	}
+	s.writeJSON(w, http.StatusOK, status)
+}
+
+// handleWebhookDeliveries handles GET /webhooks/{address}/deliveries, listing the recent
+// webhook deliveries of a subscription, newest first, with payload snippets.
+func (s *HTTPServer) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	address, err := canonicalAddress(r.PathValue("address"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.webhooks.Deliveries(address))
+}
+
+// handleWebhookRedeliver handles POST /webhooks/{address}/deliveries/{id}/redeliver,
+// queueing a failed delivery again.
+func (s *HTTPServer) handleWebhookRedeliver(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "delivery id must be a decimal integer")
+		return
+	}
+	address, err := canonicalAddress(r.PathValue("address"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	rec, err := s.webhooks.Redeliver(address, id)
+	switch {
+	case err == nil:
+		s.writeJSON(w, http.StatusAccepted, rec)
+	case errors.Is(err, ErrDeliveryNotFound), errors.Is(err, ErrDeliveryNotFailed):
+		s.writeError(w, err)
+	case errors.Is(err, ErrWebhookQueueFull):
+		w.Header().Set("Retry-After", strconv.Itoa(int(overloadRetryAfter.Seconds())))
+		s.writeError(w, err)
+	default:
+		s.logger.Error("Failed to redeliver webhook", "address", address, "delivery", id, "err", err)
+		s.writeError(w, err)
+	}
+}
+
+// maxDevMineBlocks bounds the blocks mined by a single POST /dev/mine.
+const maxDevMineBlocks = 1000
+
+// handleDevMine handles POST /dev/mine?blocks=3, mining blocks on the dev chain (default 1).
+func (s *HTTPServer) handleDevMine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	blocks := 1
+	if raw := r.URL.Query().Get("blocks"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxDevMineBlocks {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("blocks must be between 1 and %d", maxDevMineBlocks))
+			return
+		}
+		blocks = parsed
+	}
+	tip := s.devChain.Mine(blocks)
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"mined": blocks,
+		"tip":   tip,
+	})
+}
+
+// handleDevAddresses handles GET /dev/addresses, listing the dev chain's seed addresses.
+func (s *HTTPServer) handleDevAddresses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.devChain.Addresses())
+}
+
+// handleUsage handles GET /usage, reporting the caller's own API key usage.
+func (s *HTTPServer) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.usage.Usage(s.usage.accountKey(r)))
+}
+
+// visibility returns the serveOnly query override, or the server default when absent.
+func (s *HTTPServer) visibility(r *http.Request) (Visibility, error) {
+	raw := r.URL.Query().Get("serveOnly")
+	if raw == "" {
+		return s.serveOnly, nil
+	}
+	return ParseVisibility(raw)
+}
+
+// filterVisible drops transactions from blocks above maxBlock.
+func filterVisible[T interface{ blockNumber() int64 }](txs []T, maxBlock int) []T {
+	visible := make([]T, 0, len(txs))
+	for _, tx := range txs {
+		if tx.blockNumber() <= int64(maxBlock) {
+			visible = append(visible, tx)
+		}
+	}
+	return visible
+}
+
+// handleGetTransactionsBatch handles POST /transactions with a JSON array of addresses.
+func (s *HTTPServer) handleGetTransactionsBatch(w http.ResponseWriter, r *http.Request) {
+	visibility, err := s.visibility(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	minRisk, err := minRiskParam(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+	var addresses []string
+	if err := json.NewDecoder(r.Body).Decode(&addresses); err != nil {
+		s.logger.Error("Failed to decode JSON in transactions batch", "err", err)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid JSON body, expected an array of addresses")
+		return
+	}
+	s.writeMultiAddressTransactions(w, r, addresses, visibility, minRisk)
+}
+
+// writeMultiAddressTransactions writes the merged transactions of the given addresses
+// whose counterparty scored at least minRisk.
+func (s *HTTPServer) writeMultiAddressTransactions(w http.ResponseWriter, r *http.Request, addresses []string, visibility Visibility, minRisk int) {
+	cleaned := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if address = strings.TrimSpace(address); address != "" {
+			cleaned = append(cleaned, address)
+		}
+	}
+	if len(cleaned) == 0 {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "at least one address is required")
+		return
+	}
+	visible, err := s.parser.VisibleBlock(r.Context(), visibility)
+	if err != nil {
+		s.writeError(w, err)
 		return
 	}
-	txs := s.parser.GetTransactions(address)
+	txs := filterVisible(s.parser.GetTransactionsForAddresses(cleaned), visible)
+	txs = filterMinRisk(txs, minRisk)
 	s.writeJSON(w, http.StatusOK, txs)
 }
 
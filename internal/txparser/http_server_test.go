@@ -0,0 +1,1122 @@
+package txparser
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// newTestServer returns an HTTPServer over a fresh parser and memory store.
+func newTestServer() (*HTTPServer, *EthParser) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+	return NewHTTPServer(parser, logger), parser
+}
+
+// TestUsageLimits verifies per-key rate limiting and usage reporting, and that keys
+// the Authenticator does not accept are accounted as anonymous.
+func TestUsageLimits(t *testing.T) {
+	server, _ := newTestServer()
+	auth, err := NewAuthenticator([]APIKey{{Key: "team-a", Scopes: []Scope{ScopeRead}}, {Key: "team-b", Scopes: []Scope{ScopeRead}}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator error: %v", err)
+	}
+	usage := NewUsageTracker(UsageLimits{RatePerSecond: 0.001, Burst: 2})
+	usage.SetAuthenticator(auth)
+	server.SetAuthenticator(auth)
+	server.SetUsageTracker(usage)
+	handler := server.Router()
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/current-block", nil)
+		req.Header.Set(APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := do("team-a"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	rec := do("team-a")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := do("team-b"); rec.Code != http.StatusOK {
+		t.Errorf("expected other key to be unaffected, got %d", rec.Code)
+	}
+
+	if got := usage.Usage("team-a"); got.Requests != 2 || got.Bytes == 0 {
+		t.Errorf("unexpected usage %+v", got)
+	}
+
+	var reported KeyUsage
+	req := httptest.NewRequest(http.MethodGet, "/usage", nil)
+	req.Header.Set(APIKeyHeader, "team-b")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if err := json.NewDecoder(rec.Body).Decode(&reported); err != nil {
+		t.Fatalf("decoding usage: %v", err)
+	}
+	if reported.Key != "team-b" || reported.Requests != 2 {
+		t.Errorf("unexpected reported usage %+v", reported)
+	}
+
+	// Without an Authenticator, rotating keys shares the anonymous limit.
+	server, _ = newTestServer()
+	server.SetUsageTracker(NewUsageTracker(UsageLimits{RatePerSecond: 0.001, Burst: 2}))
+	handler = server.Router()
+	for i, key := range []string{"k1", "k2", "k3"} {
+		if rec := do(key); (rec.Code == http.StatusOK) != (i < 2) {
+			t.Errorf("request with key %s: unexpected status %d", key, rec.Code)
+		}
+	}
+	if got := server.usage.Usage(anonymousKey); got.Requests != 2 || len(server.usage.keys) != 1 {
+		t.Errorf("expected made-up keys to be accounted as anonymous, got %+v over %d keys", got, len(server.usage.keys))
+	}
+}
+
+// failingChecker is a HealthChecker that always reports err.
+type failingChecker struct{ err error }
+
+func (f failingChecker) CheckHealth(ctx context.Context) error { return f.err }
+
+// TestReadyz verifies per-dependency readiness reporting.
+func TestReadyz(t *testing.T) {
+	server, _ := newTestServer()
+	server.AddReadinessCheck("store", NewMemoryStore().(*MemoryStore))
+	server.AddReadinessCheck("rpc", failingChecker{errors.New("connection refused")})
+
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+
+	var body struct {
+		Status       string             `json:"status"`
+		Dependencies []DependencyStatus `json:"dependencies"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding readyz: %v", err)
+	}
+	if body.Status != "unavailable" || len(body.Dependencies) != 2 {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	if body.Dependencies[0].Status != "ok" || body.Dependencies[1].Error != "connection refused" {
+		t.Errorf("unexpected dependency statuses %+v", body.Dependencies)
+	}
+}
+
+// TestSubscriptionPrefsPatch verifies PATCH merges notification preferences and sets priority.
+func TestSubscriptionPrefsPatch(t *testing.T) {
+	server, parser := newTestServer()
+	handler := server.Router()
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	parser.SetNotificationPrefs("0x000000000000000000000000000000000000000a", NotificationPrefs{Direction: DirectionIn, MinValue: "100"})
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/subscriptions/0x000000000000000000000000000000000000000a", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := patch(`{"notifications": {"channels": ["webhook"]}}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	prefs, _ := parser.GetNotificationPrefs("0x000000000000000000000000000000000000000a")
+	if prefs.Direction != DirectionIn || prefs.MinValue != "100" || len(prefs.Channels) != 1 {
+		t.Errorf("expected merged prefs, got %+v", prefs)
+	}
+	if rec := patch(`{"notifications": {"channels": ["pager"]}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown channel, got %d", rec.Code)
+	}
+	if rec := patch(`{"priority": "high"}`); rec.Code != http.StatusOK || parser.AddressPriority("0x000000000000000000000000000000000000000a") != PriorityHigh {
+		t.Errorf("expected priority to be set, got %d and %s", rec.Code, parser.AddressPriority("0x000000000000000000000000000000000000000a"))
+	}
+	if rec := patch(`{"priority": "urgent"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown priority, got %d", rec.Code)
+	}
+
+	if !prefs.Wants(ChannelWebhook, "0x000000000000000000000000000000000000000a", Transaction{From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x64"}, RawTx{}) {
+		t.Errorf("expected inbound transfer of 100 wei to notify")
+	}
+	if prefs.Wants(ChannelWebhook, "0x000000000000000000000000000000000000000a", Transaction{From: "0x000000000000000000000000000000000000000a", To: "0x000000000000000000000000000000000000000b", Value: "0x64"}, RawTx{}) {
+		t.Errorf("expected outbound transfer not to notify")
+	}
+
+	if rec := patch(`{"notifications": {"tags": ["denylist"]}}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	prefs, _ = parser.GetNotificationPrefs("0x000000000000000000000000000000000000000a")
+	if prefs.Wants(ChannelWebhook, "0x000000000000000000000000000000000000000a", Transaction{From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x64"}, RawTx{}) {
+		t.Errorf("expected an untagged transfer not to notify once tags are required")
+	}
+	if !prefs.Wants(ChannelWebhook, "0x000000000000000000000000000000000000000a", Transaction{From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x64", Tags: []string{"large", "denylist"}}, RawTx{}) {
+		t.Errorf("expected a transfer tagged denylist to notify")
+	}
+}
+
+// TestChainParameter verifies the chain parameter routes requests to another chain's parser.
+func TestChainParameter(t *testing.T) {
+	server, mainnet := newTestServer()
+	polygon := NewEthParser(&mockClient{}, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	polygon.store.SetCurrentBlock(42)
+	server.SetChainName("mainnet")
+	server.AddChain("polygon", polygon)
+	handler := server.Router()
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/subscribe?chain=polygon", `{"address": "0x000000000000000000000000000000000000000a"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if !polygon.store.IsSubscribed("0x000000000000000000000000000000000000000a") || mainnet.store.IsSubscribed("0x000000000000000000000000000000000000000a") {
+		t.Errorf("expected the subscription on polygon only")
+	}
+	polygon.store.AddTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0x1", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 40})
+	if rec := do(http.MethodGet, "/transactions?chain=polygon&address=0x000000000000000000000000000000000000000a", ""); !strings.Contains(rec.Body.String(), `"hash":"0x1"`) {
+		t.Errorf("expected the polygon transaction, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodGet, "/transactions?chain=mainnet&address=0x000000000000000000000000000000000000000a", ""); strings.Contains(rec.Body.String(), `"hash":"0x1"`) {
+		t.Errorf("expected no mainnet transactions, got %s", rec.Body)
+	}
+	if rec := do(http.MethodGet, "/current-block?chain=polygon", ""); !strings.Contains(rec.Body.String(), `"currentBlock":42`) {
+		t.Errorf("expected polygon's current block, got %s", rec.Body)
+	}
+	if rec := do(http.MethodGet, "/current-block?chain=goerli", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown chain, got %d", rec.Code)
+	}
+	if got := server.Chains(); !slices.Equal(got, []string{"mainnet", "polygon"}) {
+		t.Errorf("unexpected chains %v", got)
+	}
+}
+
+// TestPriorityTransactions verifies rule-matched transactions are served newest first from
+// the bounded priority inbox, and dropped when their blocks are rolled back.
+func TestPriorityTransactions(t *testing.T) {
+	server, parser := newTestServer()
+	rules, err := ParseRules([]byte(`[{"name": "whale", "when": {"minValue": "1000"}}]`))
+	if err != nil {
+		t.Fatalf("ParseRules error: %v", err)
+	}
+	parser.SetPriorityRules(rules, 2)
+	watched, other := "0x"+strings.Repeat("a", 40), "0x"+strings.Repeat("b", 40)
+	parser.Subscribe(watched)
+	for block, value := range []string{"0x3e8", "0x1", "0x7d0", "0xbb8"} {
+		parser.storeTransaction(Transaction{Hash: fmt.Sprintf("0x%d", block), From: other, To: watched, Value: value, Block: int64(block)}, RawTx{})
+	}
+	handler := server.Router()
+	get := func(target string) []PriorityTransaction {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var body struct{ Transactions []PriorityTransaction }
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); rec.Code != http.StatusOK || err != nil {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		return body.Transactions
+	}
+
+	got := get("/priority-transactions?address=0x" + strings.ToUpper(watched[2:]))
+	if len(got) != 2 || got[0].Hash != "0x3" || got[1].Hash != "0x2" || got[0].Rules[0] != "whale" {
+		t.Errorf("expected the two newest priority transactions, got %+v", got)
+	}
+	if got := get("/priority-transactions?limit=1"); len(got) != 1 || got[0].Address != watched {
+		t.Errorf("expected one transaction of any address, got %+v", got)
+	}
+	parser.inbox.rollback(2)
+	if got := get("/priority-transactions"); len(got) != 1 || got[0].Hash != "0x2" {
+		t.Errorf("expected rolled back transactions to be dropped, got %+v", got)
+	}
+}
+
+// TestTransactionsBlockRange verifies block range, direction and pagination on GET /transactions.
+func TestTransactionsBlockRange(t *testing.T) {
+	server, parser := newTestServer()
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	for block := int64(1); block <= 5; block++ {
+		from := "0x000000000000000000000000000000000000000b"
+		if block%2 == 0 {
+			from = "0x000000000000000000000000000000000000000a"
+		}
+		parser.store.AddTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: fmt.Sprintf("0x%d", block), From: from, Block: block})
+	}
+	parser.store.SetCurrentBlock(5)
+
+	get := func(query string) (int, TransactionPage) {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=0x000000000000000000000000000000000000000a&"+query, nil))
+		var page TransactionPage
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+				t.Fatalf("decoding transactions: %v", err)
+			}
+		}
+		return rec.Code, page
+	}
+
+	_, page := get("fromBlock=2&toBlock=4")
+	if txs := page.Transactions; len(txs) != 3 || txs[0].Block != 2 || txs[2].Block != 4 || page.NextOffset != nil {
+		t.Errorf("expected blocks 2..4 on one page, got %+v", page)
+	}
+	_, page = get("limit=2&offset=1")
+	if txs := page.Transactions; len(txs) != 2 || txs[0].Block != 2 || page.Total != 5 || page.NextOffset == nil || *page.NextOffset != 3 {
+		t.Errorf("expected blocks 2..3 of 5 with next offset 3, got %+v", page)
+	}
+	_, page = get("direction=out")
+	if txs := page.Transactions; len(txs) != 2 || txs[0].Block != 2 || txs[1].Block != 4 || page.Total != 2 {
+		t.Errorf("expected outgoing blocks 2 and 4, got %+v", page)
+	}
+
+	for _, query := range []string{"fromBlock=4&toBlock=2", "limit=0", "offset=-1", "direction=sideways"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, code)
+		}
+	}
+}
+
+// TestTransactionFilter verifies q filter expressions on GET /transactions.
+func TestTransactionFilter(t *testing.T) {
+	server, parser := newTestServer()
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	values := []string{"0x0", "0xde0b6b3a7640000", "0x1bc16d674ec80000", "0x3b9aca00"} // 0, 1 eth, 2 eth, 1 gwei
+	for i, value := range values {
+		tx := Transaction{Hash: fmt.Sprintf("0x%d", i), From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: value, Block: int64(100 + i)}
+		if i == 2 {
+			tx.From, tx.To, tx.Tags = "0x000000000000000000000000000000000000000a", "0x000000000000000000000000000000000000000b", []string{"large"}
+		}
+		parser.store.AddTransaction("0x000000000000000000000000000000000000000a", tx)
+	}
+	parser.store.SetCurrentBlock(200)
+
+	get := func(expr string) (int, []Transaction) {
+		rec := httptest.NewRecorder()
+		target := "/transactions?address=0x000000000000000000000000000000000000000a&q=" + url.QueryEscape(expr)
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var page TransactionPage
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+				t.Fatalf("decoding transactions: %v", err)
+			}
+		}
+		return rec.Code, page.Transactions
+	}
+
+	for expr, want := range map[string][]int64{
+		"value>=1eth":                               {101, 102},
+		"value>1eth and direction=in":               {},
+		"value<=1gwei AND block>100":                {103},
+		"value=1000000000wei":                       {103},
+		"value>0.5eth AND value<1.5eth":             {101},
+		"tag=large":                                 {102},
+		"direction=in AND block>=101 AND block<103": {101},
+	} {
+		code, txs := get(expr)
+		var got []int64
+		for _, tx := range txs {
+			got = append(got, tx.Block)
+		}
+		if code != http.StatusOK || !slices.Equal(got, want) {
+			t.Errorf("%s: expected blocks %v, got %d %v", expr, want, code, got)
+		}
+	}
+
+	for _, expr := range []string{"value>1btc", "value>0.1wei", "size>1", "risk<5", "direction=in AND direction=out", "value>1eth OR block>1"} {
+		if code, _ := get(expr); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", expr, code)
+		}
+	}
+}
+
+// TestSubscribeFromTx verifies the sender, recipient and token recipient of a transaction are subscribed.
+func TestSubscribeFromTx(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	recipient := "0x00000000000000000000000000000000000000aa"
+	parser := NewEthParser(&mockClient{txs: map[string]RawTx{
+		"0xtx1": {
+			Hash:  "0xtx1",
+			From:  "0x000000000000000000000000000000000000005e",
+			To:    "0x0000000000000000000000000000000000000070",
+			Input: "0xa9059cbb" + strings.Repeat("0", 24) + recipient[2:] + strings.Repeat("0", 63) + "1",
+		},
+	}}, NewMemoryStore(), logger)
+	parser.Subscribe("0x000000000000000000000000000000000000005e")
+	handler := NewHTTPServer(parser, logger).Router()
+
+	req := httptest.NewRequest(http.MethodPost, "/subscribe/from-tx", strings.NewReader(`{"hash":"0xtx1","tokenRecipients":true}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result TxSubscription
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Subscribed, []string{"0x0000000000000000000000000000000000000070", recipient}) {
+		t.Errorf("unexpected subscribed addresses %v", result.Subscribed)
+	}
+	if !reflect.DeepEqual(result.AlreadySubscribed, []string{"0x000000000000000000000000000000000000005e"}) {
+		t.Errorf("unexpected already subscribed addresses %v", result.AlreadySubscribed)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/subscribe/from-tx", strings.NewReader(`{"hash":"0xmissing"}`))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown transaction, got %d", rec.Code)
+	}
+}
+
+// TestSubscribeBatch verifies per-item results and the multi-status response.
+func TestSubscribeBatch(t *testing.T) {
+	server, parser := newTestServer()
+	parser.Subscribe("0x00000000000000000000000000000000000000bb")
+	handler := server.Router()
+
+	body := `["0x00000000000000000000000000000000000000AA", "0x00000000000000000000000000000000000000bb", "nope", "0x00000000000000000000000000000000000000aa"]`
+	req := httptest.NewRequest(http.MethodPost, "/subscribe/batch", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp BatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if resp.Succeeded != 2 || resp.Failed != 2 {
+		t.Errorf("expected 2 succeeded and 2 failed, got %+v", resp)
+	}
+	want := []BatchItemResult{
+		{Input: "0x00000000000000000000000000000000000000AA", Address: "0x00000000000000000000000000000000000000aa", Success: true, Created: true},
+		{Input: "0x00000000000000000000000000000000000000bb", Address: "0x00000000000000000000000000000000000000bb", Success: true},
+		{Input: "nope", Code: BatchErrInvalidAddress, Error: "address must be 0x followed by 40 hex digits"},
+		{Input: "0x00000000000000000000000000000000000000aa", Address: "0x00000000000000000000000000000000000000aa", Code: BatchErrDuplicate, Error: "address appears earlier in the batch"},
+	}
+	if !reflect.DeepEqual(resp.Results, want) {
+		t.Errorf("unexpected results\n got: %+v\nwant: %+v", resp.Results, want)
+	}
+	if !parser.store.IsSubscribed("0x00000000000000000000000000000000000000aa") {
+		t.Errorf("expected normalized address to be subscribed")
+	}
+}
+
+// TestTimeline verifies transactions are bucketed by block time.
+func TestTimeline(t *testing.T) {
+	server, parser := newTestServer()
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	for i, ts := range []int64{3600, 3700, 7200, 90000} {
+		parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: fmt.Sprintf("0x%d", i), Value: "0x64", Block: int64(i + 1), Timestamp: ts}, RawTx{})
+	}
+
+	get := func(query string) (*httptest.ResponseRecorder, []TimelineBucket) {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/timeline?"+query, nil))
+		var body struct {
+			Buckets []TimelineBucket `json:"buckets"`
+		}
+		json.NewDecoder(rec.Body).Decode(&body)
+		return rec, body.Buckets
+	}
+
+	_, hourly := get("address=0x000000000000000000000000000000000000000a&bucket=1h")
+	want := []TimelineBucket{{Start: 3600, Count: 2, Total: "200"}, {Start: 7200, Count: 1, Total: "100"}, {Start: 90000, Count: 1, Total: "100"}}
+	if !reflect.DeepEqual(hourly, want) {
+		t.Errorf("unexpected hourly buckets %+v", hourly)
+	}
+	_, daily := get("address=0x000000000000000000000000000000000000000a&bucket=1d")
+	want = []TimelineBucket{{Start: 0, Count: 3, Total: "300"}, {Start: 86400, Count: 1, Total: "100"}}
+	if !reflect.DeepEqual(daily, want) {
+		t.Errorf("unexpected daily buckets %+v", daily)
+	}
+	if rec, _ := get("address=0x000000000000000000000000000000000000000a&bucket=1w"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown bucket, got %d", rec.Code)
+	}
+}
+
+// TestDevChainMining verifies on-demand mining feeds synthetic transfers to the parser.
+func TestDevChainMining(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	chain := NewDevChain(nil, 1)
+	parser := NewEthParser(chain, NewMemoryStore(), logger)
+	addresses := chain.Addresses()
+	if len(addresses) != DefaultDevAddressCount {
+		t.Fatalf("expected %d seed addresses, got %v", DefaultDevAddressCount, addresses)
+	}
+	for _, address := range addresses {
+		parser.Subscribe(address)
+	}
+	server := NewHTTPServer(parser, logger)
+	server.SetDevChain(chain)
+
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dev/mine?blocks=20", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for i := 0; i < 20; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+	if parser.GetCurrentBlock() != 20 {
+		t.Errorf("expected current block 20, got %d", parser.GetCurrentBlock())
+	}
+
+	var total int
+	for _, address := range addresses {
+		for _, tx := range parser.GetTransactions(address) {
+			total++
+			if tx.From == tx.To || tx.Timestamp == 0 {
+				t.Errorf("unexpected synthetic transaction %+v", tx)
+			}
+			if _, err := chain.GetTransactionByHash(context.Background(), tx.Hash); err != nil {
+				t.Errorf("expected %s to be retrievable: %v", tx.Hash, err)
+			}
+		}
+	}
+	if total == 0 {
+		t.Errorf("expected synthetic transfers between seed addresses")
+	}
+
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dev/mine?blocks=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for zero blocks, got %d", rec.Code)
+	}
+}
+
+// TestRiskScoring verifies counterparties are scored once per TTL and filtered by minRisk.
+func TestRiskScoring(t *testing.T) {
+	var requests int
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		score := 10
+		if r.URL.Query().Get("address") == "0xbad" {
+			score = 90
+		}
+		fmt.Fprintf(w, `{"score":%d}`, score)
+	}))
+	defer provider.Close()
+
+	server, parser := newTestServer()
+	parser.SetRiskScorer(NewHTTPRiskScorer(provider.URL, time.Hour))
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	for i, from := range []string{"0xbad", "0xok", "0xbad"} {
+		parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: fmt.Sprintf("0x%d", i), From: from, To: "0x000000000000000000000000000000000000000a", Block: int64(i + 1)}, RawTx{})
+	}
+	parser.store.SetCurrentBlock(3)
+	if requests != 2 {
+		t.Errorf("expected cached scores to save a request, got %d requests", requests)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=0x000000000000000000000000000000000000000a&minRisk=50", nil))
+	var page TransactionPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decoding transactions: %v", err)
+	}
+	if txs := page.Transactions; len(txs) != 2 || txs[0].RiskScore != 90 || txs[1].Hash != "0x2" {
+		t.Errorf("expected the two risky transactions, got %+v", txs)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=0x000000000000000000000000000000000000000a&minRisk=101", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for out-of-range minRisk, got %d", rec.Code)
+	}
+}
+
+// TestWebSocketStream verifies the /ws handshake and that only transactions of
+// in-session subscriptions detected after connecting are streamed.
+func TestWebSocketStream(t *testing.T) {
+	server, parser := newTestServer()
+	ts := httptest.NewServer(server.Router())
+	defer ts.Close()
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xold", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 1}, RawTx{})
+
+	netConn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer netConn.Close()
+	netConn.SetDeadline(time.Now().Add(5 * time.Second))
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(netConn, "GET /ws HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: %s\r\n\r\n", key)
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected handshake response %d %v", resp.StatusCode, resp.Header)
+	}
+	ws := &wsConn{conn: netConn, br: br, client: true}
+	read := func() WSMessage {
+		_, data, err := ws.readMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	ws.writeJSON(WSRequest{Action: "subscribe", Address: "0xzz"})
+	if msg := read(); msg.Type != "error" {
+		t.Errorf("expected an error for an invalid address, got %+v", msg)
+	}
+	ws.writeJSON(WSRequest{Action: "subscribe", Address: "0x000000000000000000000000000000000000000a"})
+	if msg := read(); msg.Type != "subscribed" || msg.Address != "0x000000000000000000000000000000000000000a" {
+		t.Fatalf("unexpected subscribe reply %+v", msg)
+	}
+
+	parser.addTransaction("0x000000000000000000000000000000000000000c", Transaction{Hash: "0xother", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000c", Value: "0x1", Block: 2}, RawTx{})
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xnew", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 2}, RawTx{})
+	if msg := read(); msg.Type != "transaction" || msg.Address != "0x000000000000000000000000000000000000000a" || msg.Transaction == nil || msg.Transaction.Hash != "0xnew" {
+		t.Errorf("expected the new transaction of 0x000000000000000000000000000000000000000a, got %+v", msg)
+	}
+	ws.writeMessage(wsOpClose, nil)
+	if _, _, err := ws.readMessage(); !errors.Is(err, errWSClosed) {
+		t.Errorf("expected the close frame to be echoed, got %v", err)
+	}
+}
+
+// TestTransactionStream verifies /transactions/stream pushes new transactions of the
+// address as Server-Sent Events with heartbeats, and resumes from Last-Event-ID.
+func TestTransactionStream(t *testing.T) {
+	defer func(interval time.Duration) { sseHeartbeatInterval = interval }(sseHeartbeatInterval)
+	sseHeartbeatInterval = 50 * time.Millisecond
+	server, parser := newTestServer()
+	ts := httptest.NewServer(server.Router())
+	defer ts.Close()
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+
+	if resp, err := http.Get(ts.URL + "/transactions/stream?address=0x000000000000000000000000000000000000000c"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unsubscribed address, got %v %v", resp, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	open := func(lastEventID string) (*bufio.Reader, func()) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/transactions/stream?address=0x000000000000000000000000000000000000000A", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("unexpected response %d %v", resp.StatusCode, resp.Header)
+		}
+		return bufio.NewReader(resp.Body), func() { resp.Body.Close() }
+	}
+	// next returns the next event with data, skipping heartbeats.
+	next := func(br *bufio.Reader) (id, data string, heartbeats int) {
+		for {
+			var fields []string
+			for {
+				line, err := br.ReadString('\n')
+				if err != nil {
+					t.Fatalf("reading stream: %v", err)
+				}
+				if line == "\n" {
+					break
+				}
+				fields = append(fields, strings.TrimSuffix(line, "\n"))
+			}
+			if fields[0] == ": heartbeat" {
+				heartbeats++
+				continue
+			}
+			if len(fields) != 3 || fields[1] != "event: transaction" {
+				t.Fatalf("unexpected event %q", fields)
+			}
+			return strings.TrimPrefix(fields[0], "id: "), strings.TrimPrefix(fields[2], "data: "), heartbeats
+		}
+	}
+
+	br, closeStream := open("")
+	time.Sleep(120 * time.Millisecond) // let a heartbeat pass
+	parser.addTransaction("0x000000000000000000000000000000000000000c", Transaction{Hash: "0xother", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000c", Value: "0x1", Block: 2}, RawTx{})
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xt1", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 2}, RawTx{})
+	id, data, heartbeats := next(br)
+	if !strings.Contains(data, `"hash":"0xt1"`) || heartbeats == 0 {
+		t.Errorf("expected 0xt1 after a heartbeat, got %s after %d heartbeats", data, heartbeats)
+	}
+	closeStream()
+
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xt2", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 3}, RawTx{})
+	br, closeStream = open(id)
+	defer closeStream()
+	if _, data, _ := next(br); !strings.Contains(data, `"hash":"0xt2"`) {
+		t.Errorf("expected the missed 0xt2 on resume, got %s", data)
+	}
+
+	short, shortParser := newTestServer()
+	shortParser.events = newEventLog(1)
+	shortParser.Subscribe("0x000000000000000000000000000000000000000a")
+	for i := 0; i < 3; i++ {
+		shortParser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xt", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 4}, RawTx{})
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/transactions/stream?address=0x000000000000000000000000000000000000000a", nil)
+	req.Header.Set("Last-Event-ID", formatEventCursor(shortParser.EventEpoch(), 1))
+	short.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusGone {
+		t.Errorf("expected 410 for an expired Last-Event-ID, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/transactions/stream?address=0x000000000000000000000000000000000000000a", nil)
+	req.Header.Set("Last-Event-ID", id) // issued by the first server's changefeed
+	short.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusGone {
+		t.Errorf("expected 410 for a Last-Event-ID from another process, got %d", rec.Code)
+	}
+}
+
+// TestQueryLimit verifies that expensive queries beyond the limit are rejected with
+// 503 and Retry-After once the queue timeout passes.
+func TestQueryLimit(t *testing.T) {
+	server, _ := newTestServer()
+	server.SetQueryLimit(1, 10*time.Millisecond)
+	release := make(chan struct{})
+	blocking := server.limitQueries(func(w http.ResponseWriter, r *http.Request) { <-release })
+
+	done := make(chan struct{})
+	go func() {
+		blocking(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/transactions", nil))
+		close(done)
+	}()
+	for len(server.querySlots) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=0x000000000000000000000000000000000000000a", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 503 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/current-block", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected cheap endpoints to be unaffected, got %d", rec.Code)
+	}
+
+	close(release)
+	<-done
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=0x000000000000000000000000000000000000000a", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 once the slot is free, got %d", rec.Code)
+	}
+}
+
+// TestPrometheusMetrics verifies that parser, RPC client and HTTP measurements are
+// exposed on /metrics, along with the store's memory gauges.
+func TestPrometheusMetrics(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, req.ID)
+	}))
+	defer node.Close()
+	metrics := NewPrometheusMetrics()
+	client := NewJSONRPCClient(node.URL).(*RPCClient)
+	client.SetMetrics(metrics)
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	server, parser := newTestServer()
+	parser.SetMetrics(metrics)
+	server.SetMetrics(metrics)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0x1", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 7}, RawTx{})
+	parser.commitBlock(7, 1, "test")
+
+	store := NewMemoryStore().(*MemoryStore)
+	store.SetMemoryBudget(1<<20, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	store.Subscribe("0x000000000000000000000000000000000000000a")
+	store.AddTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0x1", Value: "0x1", Block: 7})
+	metrics.SetMemoryReporter(store)
+	usage := store.MemoryUsage()
+
+	handler := server.Router()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/subscriptions/0x000000000000000000000000000000000000000a", nil))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		"txparser_blocks_parsed_total 1",
+		"txparser_current_block 7",
+		"txparser_transactions_stored_total 1",
+		"txparser_subscribers 1",
+		`txparser_rpc_request_duration_seconds_count{method="eth_blockNumber"} 1`,
+		`txparser_http_requests_total{route="/subscriptions/{address}",code="200"} 1`,
+		"txparser_store_memory_used_bytes " + formatFloat(float64(usage.UsedBytes)),
+		"txparser_store_memory_budget_bytes 1.048576e+06",
+		"txparser_store_memory_headroom_bytes " + formatFloat(float64(usage.HeadroomBytes)),
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected %q in metrics output:\n%s", line, body)
+		}
+	}
+}
+
+// TestAdminFeatures verifies /admin/features lists and switches feature flags, and that
+// a disabled subsystem stops running without reconfiguring the parser.
+func TestAdminFeatures(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+	parser.SetInputMatching(true)
+	features := NewFeatureFlags(FeatureTokens)
+	parser.SetFeatureFlags(features)
+	server := NewHTTPServer(parser, logger)
+	server.SetFeatureFlags(features)
+	watched := "0x00000000000000000000000000000000000000be"
+	parser.Subscribe(watched)
+	do := func(method, body string) (int, map[Feature]bool) {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(method, "/admin/features", strings.NewReader(body)))
+		var states map[Feature]bool
+		json.Unmarshal(rec.Body.Bytes(), &states)
+		return rec.Code, states
+	}
+
+	if code, states := do(http.MethodGet, ""); code != http.StatusOK || states[FeatureTokens] || !states[FeatureInputMatching] || len(states) != len(knownFeatures) {
+		t.Fatalf("unexpected initial features %d %v", code, states)
+	}
+	if code, _ := do(http.MethodPatch, `{"input-matching":false,"mempool":true}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown feature, got %d", code)
+	}
+	if !features.Enabled(FeatureInputMatching) {
+		t.Errorf("expected a rejected update to change nothing")
+	}
+	if code, states := do(http.MethodPatch, `{"input-matching":false,"tokens":true}`); code != http.StatusOK || states[FeatureInputMatching] || !states[FeatureTokens] {
+		t.Fatalf("unexpected features after update %d %v", code, states)
+	}
+
+	word := "000000000000000000000000" + watched[2:]
+	raw := RawTx{Hash: "0xt1", From: "0xhot", To: "0xbatcher", Input: "0xa9059cbb" + word}
+	parser.storeTransaction(newTransaction(raw, 1, 0), raw)
+	if txs := parser.GetTransactions(watched); len(txs) != 0 {
+		t.Errorf("expected no input matches while input-matching is disabled, got %+v", txs)
+	}
+}
+
+// TestAdminRetention verifies a policy set at /admin/retention is saved, prunes old
+// transactions without waiting for the next interval, and rejects negative limits.
+func TestAdminRetention(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := NewMemoryStore()
+	watched := "0x00000000000000000000000000000000000000be"
+	store.Subscribe(watched)
+	for block := int64(1); block <= 10; block++ {
+		store.AddTransaction(watched, Transaction{Hash: fmt.Sprintf("0x%x", block), Block: block})
+	}
+	store.SetCurrentBlock(10)
+	path := filepath.Join(t.TempDir(), "retention.json")
+	retention, err := NewRetentionManager(store, RetentionPolicy{}, path, logger)
+	if err != nil {
+		t.Fatalf("NewRetentionManager error: %v", err)
+	}
+	retention.SetClock(NewFakeClock(time.Unix(0, 0))) // only policy changes trigger pruning
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	retention.Start(ctx, time.Hour)
+	server := NewHTTPServer(NewEthParser(&mockClient{}, store, logger), logger)
+	server.SetRetentionManager(retention)
+	do := func(method, body string) (int, RetentionStatus) {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(method, "/admin/retention", strings.NewReader(body)))
+		var status RetentionStatus
+		json.Unmarshal(rec.Body.Bytes(), &status)
+		return rec.Code, status
+	}
+
+	if code, _ := do(http.MethodPut, `{"maxBlockAge":-1}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative block age, got %d", code)
+	}
+	if code, status := do(http.MethodPut, `{"maxBlockAge":3}`); code != http.StatusOK || status.MaxBlockAge != 3 {
+		t.Fatalf("unexpected status after update %d %+v", code, status)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for retention.Status().PrunedTransactions != 6 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 6 pruned transactions, got %+v", retention.Status())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if txs := store.GetTransactions(watched); len(txs) != 4 || txs[0].Block != 7 {
+		t.Errorf("expected blocks 7 to 10 kept, got %+v", txs)
+	}
+	if code, status := do(http.MethodGet, ""); code != http.StatusOK || status.PrunedTransactions != 6 || status.LastPruneAt == nil {
+		t.Errorf("unexpected status %d %+v", code, status)
+	}
+	if code, _ := do(http.MethodPut, `{"maxBlockAge":3,"maxTransactionsPerAddress":2}`); code != http.StatusOK {
+		t.Fatalf("unexpected status after per-address update %d", code)
+	}
+	for retention.Status().PrunedTransactions != 8 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 8 pruned transactions, got %+v", retention.Status())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := retention.Status(); status.Evicted[EvictionBlockAge] != 6 || status.Evicted[EvictionPerAddress] != 2 {
+		t.Errorf("unexpected evictions by reason %+v", status.Evicted)
+	}
+	if txs := store.GetTransactions(watched); len(txs) != 2 || txs[0].Block != 9 {
+		t.Errorf("expected blocks 9 and 10 kept, got %+v", txs)
+	}
+
+	reloaded, err := NewRetentionManager(NewMemoryStore(), RetentionPolicy{MaxBlockAge: 100}, path, logger)
+	if err != nil {
+		t.Fatalf("NewRetentionManager reload error: %v", err)
+	}
+	if policy := reloaded.Status().RetentionPolicy; policy.MaxBlockAge != 3 {
+		t.Errorf("expected the saved policy to override defaults, got %+v", policy)
+	}
+}
+
+// TestAPIKeyAuth verifies requests need a known API key, writes need the subscribe scope,
+// and health probes stay open.
+func TestAPIKeyAuth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	keys, err := ParseAPIKeys("reader:read, writer:subscribe")
+	if err != nil {
+		t.Fatalf("ParseAPIKeys error: %v", err)
+	}
+	auth, err := NewAuthenticator(keys)
+	if err != nil {
+		t.Fatalf("NewAuthenticator error: %v", err)
+	}
+	server := NewHTTPServer(NewEthParser(&mockClient{}, NewMemoryStore(), logger), logger)
+	server.SetAuthenticator(auth)
+	handler := server.Router()
+
+	for _, tt := range []struct {
+		key, method, path string
+		want              int
+	}{
+		{"", http.MethodGet, "/current-block", http.StatusUnauthorized},
+		{"unknown", http.MethodGet, "/current-block", http.StatusUnauthorized},
+		{"reader", http.MethodGet, "/current-block", http.StatusOK},
+		{"reader", http.MethodPost, "/subscribe", http.StatusForbidden},
+		{"writer", http.MethodPost, "/subscribe", http.StatusOK},
+		{"writer", http.MethodGet, "/transactions?address=0x000000000000000000000000000000000000000a", http.StatusOK},
+		{"", http.MethodGet, "/healthz", http.StatusOK},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"address":"0x000000000000000000000000000000000000000a"}`))
+		if tt.key != "" {
+			req.Header.Set(APIKeyHeader, tt.key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s with key %q: expected %d, got %d", tt.method, tt.path, tt.key, tt.want, rec.Code)
+		}
+	}
+
+	// WebSocket sessions can subscribe addresses, so read-only keys cannot open them.
+	// An admitted upgrade fails later only because the recorder cannot be hijacked.
+	for key, want := range map[string]int{"reader": http.StatusForbidden, "writer": http.StatusInternalServerError} {
+		req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		req.Header.Set(APIKeyHeader, key)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("websocket upgrade with key %q: expected %d, got %d", key, want, rec.Code)
+		}
+	}
+
+	for _, bad := range []string{"k1", "k1:admin", "k1:read,k1:subscribe", ":read"} {
+		if _, err := ParseAPIKeys(bad); err == nil {
+			t.Errorf("expected ParseAPIKeys(%q) to fail", bad)
+		}
+	}
+}
+
+// TestProjects verifies each project serves its own API under /projects/{name}/ with
+// subscriptions isolated from the primary watch list and other projects.
+func TestProjects(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := &mockClient{}
+	primary := NewEthParser(client, NewMemoryStore(), logger)
+	staging := NewEthParser(client, NewMemoryStore(), logger)
+	server := NewHTTPServer(primary, logger)
+	server.AddProject("staging", NewHTTPServer(staging, logger))
+	server.AddProject("production", NewHTTPServer(NewEthParser(client, NewMemoryStore(), logger), logger))
+	metrics := NewPrometheusMetrics()
+	server.SetMetrics(metrics)
+	handler := server.Router()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/projects/staging/subscribe", `{"address":"0x000000000000000000000000000000000000000a"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	raw := RawTx{Hash: "0xt1", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1"}
+	for _, p := range []*EthParser{primary, staging} {
+		p.storeTransaction(newTransaction(raw, 0, 0), raw)
+	}
+	for path, want := range map[string]int{
+		"/transactions?address=0x000000000000000000000000000000000000000a":                     0,
+		"/projects/staging/transactions?address=0x000000000000000000000000000000000000000a":    1,
+		"/projects/production/transactions?address=0x000000000000000000000000000000000000000a": 0,
+	} {
+		rec := do(http.MethodGet, path, "")
+		var page TransactionPage
+		json.Unmarshal(rec.Body.Bytes(), &page)
+		if rec.Code != http.StatusOK || page.Total != want {
+			t.Errorf("GET %s: expected %d transactions, got %d: %s", path, want, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := do(http.MethodGet, "/projects", "")
+	if !strings.Contains(rec.Body.String(), `[{"name":"production","currentBlock":0},{"name":"staging","currentBlock":0}]`) {
+		t.Errorf("unexpected project list %s", rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/projects/unknown/current-block", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown project, got %d", rec.Code)
+	}
+
+	body := do(http.MethodGet, "/metrics", "").Body.String()
+	for _, line := range []string{
+		`txparser_http_requests_total{route="/transactions",code="200"} 1`,
+		`txparser_http_requests_total{route="/projects/{name}/transactions",code="200"} 2`,
+		`txparser_http_requests_total{route="/projects/{name}/subscribe",code="200"} 1`,
+		`txparser_http_requests_total{route="/projects",code="200"} 1`,
+		`txparser_http_requests_total{route="unmatched",code="404"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected %q in metrics output:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "staging") {
+		t.Errorf("expected project names to stay out of route labels:\n%s", body)
+	}
+}
+
+// TestOpenAPI verifies /openapi.json describes the documented routes with schemas
+// derived from their types, that each documented route is served, and that /docs
+// renders the document.
+func TestOpenAPI(t *testing.T) {
+	server, _ := newTestServer()
+	handler := server.Router()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("expected OpenAPI 3.0.3, got %q", doc.OpenAPI)
+	}
+	for _, route := range apiRoutes {
+		if _, ok := doc.Paths[route.Pattern][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("expected %s %s in the document", route.Method, route.Pattern)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(route.Method, route.Pattern, strings.NewReader("{}")))
+		if rec.Code == http.StatusNotFound || rec.Code == http.StatusMethodNotAllowed {
+			t.Errorf("documented %s %s is not served: %d", route.Method, route.Pattern, rec.Code)
+		}
+	}
+	if !strings.Contains(string(doc.Paths["/transactions"]["get"]), `"in":"query","name":"serveOnly"`) {
+		t.Errorf("expected the query parameters of /transactions, got %s", doc.Paths["/transactions"]["get"])
+	}
+	subscribe := doc.Components.Schemas["SubscribeRequest"]
+	if !reflect.DeepEqual(subscribe.Required, []string{"address"}) || subscribe.Properties["fromBlock"] == nil {
+		t.Errorf("unexpected SubscribeRequest schema %+v", subscribe)
+	}
+	if tx := doc.Components.Schemas["Transaction"]; tx.Properties["hash"] == nil || tx.Properties["tipFeeWei"] == nil {
+		t.Errorf("expected the Transaction schema through TransactionPage, got %+v", tx)
+	}
+	if apiErr := doc.Components.Schemas["Error"]; !reflect.DeepEqual(apiErr.Required, []string{"code", "message"}) {
+		t.Errorf("expected the error envelope schema, got %+v", apiErr)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `url: "openapi.json"`) {
+		t.Errorf("expected the Swagger UI page, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestErrorResponses verifies that handlers and middleware report errors as JSON
+// envelopes carrying the code of the error.
+func TestErrorResponses(t *testing.T) {
+	server, _ := newTestServer()
+	handler := server.Router()
+	for _, tc := range []struct {
+		method, target string
+		status         int
+		code           apierror.Code
+	}{
+		{http.MethodGet, "/transactions?address=0xzz", http.StatusBadRequest, apierror.CodeInvalidAddress},
+		{http.MethodGet, "/transactions?address=0xabc", http.StatusBadRequest, apierror.CodeInvalidAddress},
+		{http.MethodGet, "/transactions/stream?address=0x0000000000000000000000000000000000000abc", http.StatusNotFound, apierror.CodeNotSubscribed},
+		{http.MethodGet, "/transactions?address=0x0000000000000000000000000000000000000abc&q=value", http.StatusBadRequest, apierror.CodeInvalidFilter},
+		{http.MethodPost, "/current-block", http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed},
+		{http.MethodGet, "/current-block?chain=nope", http.StatusBadRequest, apierror.CodeUnknownChain},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+		if rec.Code != tc.status || rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s: expected %d with a JSON body, got %d %q", tc.method, tc.target, tc.status, rec.Code, rec.Header().Get("Content-Type"))
+			continue
+		}
+		got, err := apierror.Decode(rec.Body)
+		if err != nil || got.Code != tc.code || got.Message == "" {
+			t.Errorf("%s %s: expected code %s, got %+v, %v", tc.method, tc.target, tc.code, got, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package txparser
+
+import "strings"
+
+// MatchTypeInput marks transactions stored because a subscribed address appeared in their calldata.
+const MatchTypeInput = "input"
+
+// calldataAddresses returns the distinct addresses ABI-encoded as 32-byte words in
+// a transaction's input, after its 4-byte method selector. A word is an address
+// when its first 12 bytes are zero and the remaining 20 are not.
+func calldataAddresses(input string) []string {
+	data := strings.ToLower(strings.TrimPrefix(input, "0x"))
+	if len(data) < 8+64 {
+		return nil
+	}
+	data = data[8:]
+
+	var addresses []string
+	for offset := 0; offset+64 <= len(data); offset += 64 {
+		word := data[offset : offset+64]
+		if strings.Trim(word[:24], "0") != "" || strings.Trim(word[24:], "0") == "" {
+			continue
+		}
+		address := "0x" + word[24:]
+		if !containsString(addresses, address) {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// tokenTransferRecipient returns the recipient of an ERC-20 transfer or transferFrom
+// call, or "" when input is neither.
+func tokenTransferRecipient(input string) string {
+	if len(input) < 10 {
+		return ""
+	}
+	word := 0
+	switch strings.ToLower(input[:10]) {
+	case "0xa9059cbb": // transfer(to, amount)
+	case "0x23b872dd": // transferFrom(from, to, amount)
+		word = 1
+	default:
+		return ""
+	}
+	data := strings.ToLower(input[10:])
+	if len(data) < (word+1)*64 {
+		return ""
+	}
+	return "0x" + data[word*64+24:(word+1)*64]
+}
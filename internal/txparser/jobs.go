@@ -0,0 +1,439 @@
+package txparser
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JobState is the lifecycle state of a background job.
+type JobState string
+
+const (
+	JobQueued   JobState = "queued"
+	JobRunning  JobState = "running"
+	JobDone     JobState = "done"
+	JobFailed   JobState = "failed"
+	JobCanceled JobState = "canceled"
+)
+
+// Priority orders queued jobs. Higher priorities are scheduled more often but
+// never starve lower ones, see priorityWeights.
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// priorityOrder lists priorities from highest to lowest.
+var priorityOrder = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// priorityWeights is how many jobs of each priority start per scheduling round
+// while jobs of several priorities are waiting.
+var priorityWeights = map[Priority]int{PriorityHigh: 4, PriorityNormal: 2, PriorityLow: 1}
+
+// ParsePriority parses a priority name; empty means PriorityNormal.
+func ParsePriority(s string) (Priority, error) {
+	if s == "" {
+		return PriorityNormal, nil
+	}
+	p := Priority(s)
+	if _, ok := priorityWeights[p]; !ok {
+		return "", fmt.Errorf("priority must be high, normal or low")
+	}
+	return p, nil
+}
+
+// orDefault maps the empty priority of older job records to PriorityNormal.
+func (p Priority) orDefault() Priority {
+	if p == "" {
+		return PriorityNormal
+	}
+	return p
+}
+
+// ErrJobNotFound is returned for unknown job IDs.
+var ErrJobNotFound = errors.New("job not found")
+
+// Job is the persisted record of a long-running operation such as a backfill or export.
+type Job struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	State     JobState        `json:"state"`
+	Priority  Priority        `json:"priority,omitempty"`
+	Progress  float64         `json:"progress"` // fraction complete, 0..1
+	Error     string          `json:"error,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// finished reports whether the job reached a terminal state.
+func (j *Job) finished() bool {
+	return j.State == JobDone || j.State == JobFailed || j.State == JobCanceled
+}
+
+// JobFunc executes a job of one kind. It should report progress and return promptly
+// once ctx is canceled.
+type JobFunc func(ctx context.Context, params json.RawMessage, progress func(float64)) error
+
+// JobManager runs registered job kinds on a worker pool and persists job records.
+type JobManager struct {
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	cancels  map[string]context.CancelFunc
+	canceled map[string]bool // running jobs canceled by a user rather than by shutdown
+	handlers map[string]JobFunc
+	pending  map[Priority][]string // queued job IDs per priority, in submission order
+	credits  map[Priority]int      // jobs each priority may still start this round
+	wake     chan struct{}
+	path     string // persistence file; empty keeps jobs in memory only
+	workers  int
+	logger   *slog.Logger
+	clock    Clock
+}
+
+// NewJobManager creates a JobManager running up to workers jobs at a time.
+// If path is set, job records are loaded from and saved to that file; jobs that
+// were queued or running when the process stopped are queued again.
+func NewJobManager(path string, workers int, logger *slog.Logger) (*JobManager, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	m := &JobManager{
+		jobs:     make(map[string]*Job),
+		cancels:  make(map[string]context.CancelFunc),
+		canceled: make(map[string]bool),
+		handlers: make(map[string]JobFunc),
+		pending:  make(map[Priority][]string),
+		credits:  make(map[Priority]int),
+		wake:     make(chan struct{}, 1),
+		path:     path,
+		workers:  max(workers, 1),
+		logger:   logger,
+		clock:    SystemClock,
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetClock replaces the time source used for job timestamps. Call it before Start.
+func (m *JobManager) SetClock(c Clock) {
+	m.clock = c
+}
+
+// Register sets the function executing jobs of the given kind.
+func (m *JobManager) Register(kind string, fn JobFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[kind] = fn
+}
+
+// Submit queues a new job of a registered kind at PriorityNormal.
+func (m *JobManager) Submit(kind string, params interface{}) (Job, error) {
+	return m.SubmitWithPriority(kind, params, PriorityNormal)
+}
+
+// SubmitWithPriority queues a new job of a registered kind at the given priority.
+func (m *JobManager) SubmitWithPriority(kind string, params interface{}, priority Priority) (Job, error) {
+	priority = priority.orDefault()
+	if _, ok := priorityWeights[priority]; !ok {
+		return Job{}, fmt.Errorf("unknown job priority %q", priority)
+	}
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return Job{}, fmt.Errorf("job params marshal failed: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.handlers[kind]; !ok {
+		return Job{}, fmt.Errorf("unknown job kind %q", kind)
+	}
+	now := m.clock.Now()
+	job := &Job{
+		ID:        newJobID(),
+		Kind:      kind,
+		State:     JobQueued,
+		Priority:  priority,
+		Params:    rawParams,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.jobs[job.ID] = job
+	m.pending[priority] = append(m.pending[priority], job.ID)
+	m.saveLocked()
+	m.signal()
+	return *job, nil
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (m *JobManager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns snapshots of all jobs, newest first.
+func (m *JobManager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// Cancel stops a queued or running job. Canceling a finished job is a no-op.
+func (m *JobManager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	switch job.State {
+	case JobQueued:
+		m.setStateLocked(job, JobCanceled, "")
+	case JobRunning:
+		m.canceled[id] = true
+		m.cancels[id]() // the worker records the canceled state
+	}
+	return nil
+}
+
+// Start launches the worker pool; workers stop when ctx is canceled.
+func (m *JobManager) Start(ctx context.Context) {
+	for i := 0; i < m.workers; i++ {
+		go m.worker(ctx)
+	}
+}
+
+// worker runs queued jobs until ctx is canceled.
+func (m *JobManager) worker(ctx context.Context) {
+	for {
+		job, fn, jobCtx := m.next(ctx)
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.wake:
+				continue
+			}
+		}
+		m.run(jobCtx, job, fn)
+	}
+}
+
+// next claims the oldest queued job of the priority chosen by the weighted scheduler, if any.
+func (m *JobManager) next(ctx context.Context) (*Job, JobFunc, context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		priority, ok := m.nextPriorityLocked()
+		if !ok {
+			return nil, nil, nil
+		}
+		id := m.pending[priority][0]
+		m.pending[priority] = m.pending[priority][1:]
+		job := m.jobs[id]
+		if job == nil || job.State != JobQueued {
+			continue // canceled while queued
+		}
+		if m.handlers[job.Kind] == nil { // restored from a build that registered the kind
+			m.setStateLocked(job, JobFailed, fmt.Sprintf("unknown job kind %q", job.Kind))
+			m.logger.Error("Job failed", "id", job.ID, "kind", job.Kind, "err", job.Error)
+			continue
+		}
+		m.credits[priority]--
+		jobCtx, cancel := context.WithCancel(ctx)
+		m.cancels[id] = cancel
+		m.setStateLocked(job, JobRunning, "")
+		if m.queuedLocked() > 0 {
+			m.signal() // let another idle worker pick up the rest
+		}
+		return job, m.handlers[job.Kind], jobCtx
+	}
+}
+
+// nextPriorityLocked returns the highest priority with queued jobs and credit left
+// in the current round, starting a new round once every waiting priority used its
+// credit. Callers hold m.mu.
+func (m *JobManager) nextPriorityLocked() (Priority, bool) {
+	for round := 0; round < 2; round++ {
+		waiting := false
+		for _, p := range priorityOrder {
+			if len(m.pending[p]) == 0 {
+				continue
+			}
+			waiting = true
+			if m.credits[p] > 0 {
+				return p, true
+			}
+		}
+		if !waiting {
+			return "", false
+		}
+		for p, weight := range priorityWeights {
+			m.credits[p] = weight
+		}
+	}
+	return "", false
+}
+
+// queuedLocked counts pending job IDs across priorities. Callers hold m.mu.
+func (m *JobManager) queuedLocked() int {
+	n := 0
+	for _, ids := range m.pending {
+		n += len(ids)
+	}
+	return n
+}
+
+// run executes a claimed job and records its outcome.
+func (m *JobManager) run(ctx context.Context, job *Job, fn JobFunc) {
+	m.logger.Info("Job started", "id", job.ID, "kind", job.Kind)
+	err := fn(ctx, job.Params, func(progress float64) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// Persist only meaningful progress changes to keep file writes cheap.
+		if progress-job.Progress >= 0.01 || progress >= 1 {
+			job.Progress = min(progress, 1)
+			job.UpdatedAt = m.clock.Now()
+			m.saveLocked()
+		}
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	canceled := m.canceled[job.ID]
+	interrupted := ctx.Err() != nil && !canceled
+	m.cancels[job.ID]()
+	delete(m.cancels, job.ID)
+	delete(m.canceled, job.ID)
+
+	switch {
+	case canceled:
+		m.setStateLocked(job, JobCanceled, "")
+		m.logger.Info("Job canceled", "id", job.ID, "kind", job.Kind)
+	case interrupted:
+		// The workers are stopping; the job resumes when the records are loaded again.
+		m.setStateLocked(job, JobQueued, "")
+		m.logger.Info("Job interrupted by shutdown", "id", job.ID, "kind", job.Kind)
+	case err != nil:
+		m.setStateLocked(job, JobFailed, err.Error())
+		m.logger.Error("Job failed", "id", job.ID, "kind", job.Kind, "err", err)
+	default:
+		job.Progress = 1
+		m.setStateLocked(job, JobDone, "")
+		m.logger.Info("Job finished", "id", job.ID, "kind", job.Kind)
+	}
+}
+
+// setStateLocked updates a job's state and persists it. Callers hold m.mu.
+func (m *JobManager) setStateLocked(job *Job, state JobState, errMsg string) {
+	job.State = state
+	job.Error = errMsg
+	job.UpdatedAt = m.clock.Now()
+	m.saveLocked()
+}
+
+// signal wakes one idle worker without blocking.
+func (m *JobManager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// saveLocked writes all job records to the persistence file. Callers hold m.mu.
+// Failures are logged rather than returned so job execution is never blocked on disk.
+func (m *JobManager) saveLocked() {
+	if m.path == "" {
+		return
+	}
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		m.logger.Error("Failed to marshal job records", "err", err)
+		return
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		m.logger.Error("Failed to write job records", "err", err)
+		return
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		m.logger.Error("Failed to replace job records", "err", err)
+	}
+}
+
+// load restores job records from the persistence file, re-queuing unfinished jobs.
+func (m *JobManager) load() error {
+	if m.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("creating job directory failed: %w", err)
+	}
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading job records failed: %w", err)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("job records unmarshal failed: %w", err)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	for _, job := range jobs {
+		if !job.finished() {
+			job.State = JobQueued
+			job.Priority = job.Priority.orDefault()
+			m.pending[job.Priority] = append(m.pending[job.Priority], job.ID)
+		}
+		m.jobs[job.ID] = job
+	}
+	return nil
+}
+
+// newJobID returns a random 16-character hex identifier.
+func newJobID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,364 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForJobState polls until the job reaches state or the deadline passes.
+func waitForJobState(t *testing.T, m *JobManager, id string, state JobState) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := m.Get(id); job.State == state {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := m.Get(id)
+	t.Fatalf("job %s: expected state %s, got %s", id, state, job.State)
+	return job
+}
+
+// TestJobManager verifies jobs run, can be canceled, and survive a restart.
+func TestJobManager(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	m, err := NewJobManager(path, 1, logger)
+	if err != nil {
+		t.Fatalf("NewJobManager error: %v", err)
+	}
+	m.Register("count", func(ctx context.Context, params json.RawMessage, progress func(float64)) error {
+		var n int
+		if err := json.Unmarshal(params, &n); err != nil {
+			return err
+		}
+		for i := 1; i <= n; i++ {
+			progress(float64(i) / float64(n))
+		}
+		return nil
+	})
+	m.Register("block", func(ctx context.Context, params json.RawMessage, progress func(float64)) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// Queue a job before starting the workers so it is persisted as queued.
+	queued, err := m.Submit("count", 4)
+	if err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	restored, err := NewJobManager(path, 1, logger)
+	if err != nil {
+		t.Fatalf("reloading jobs error: %v", err)
+	}
+	if job, ok := restored.Get(queued.ID); !ok || job.State != JobQueued {
+		t.Errorf("expected restored job to be queued, got %+v", job)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Start(ctx)
+
+	if job := waitForJobState(t, m, queued.ID, JobDone); job.Progress != 1 {
+		t.Errorf("expected progress 1, got %v", job.Progress)
+	}
+
+	blocking, err := m.Submit("block", nil)
+	if err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	waitForJobState(t, m, blocking.ID, JobRunning)
+	if err := m.Cancel(blocking.ID); err != nil {
+		t.Fatalf("Cancel error: %v", err)
+	}
+	waitForJobState(t, m, blocking.ID, JobCanceled)
+
+	if _, err := m.Submit("unknown", nil); err == nil {
+		t.Errorf("expected error for unknown job kind")
+	}
+}
+
+// TestJobManagerShutdown verifies a job interrupted by stopping the workers stays queued
+// and runs again once the records are reloaded.
+func TestJobManagerShutdown(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	m, err := NewJobManager(path, 1, logger)
+	if err != nil {
+		t.Fatalf("NewJobManager error: %v", err)
+	}
+	m.Register("block", func(ctx context.Context, params json.RawMessage, progress func(float64)) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, stop := context.WithCancel(context.Background())
+	m.Start(ctx)
+	job, err := m.Submit("block", nil)
+	if err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	waitForJobState(t, m, job.ID, JobRunning)
+	stop()
+	waitForJobState(t, m, job.ID, JobQueued)
+
+	restored, err := NewJobManager(path, 1, logger)
+	if err != nil {
+		t.Fatalf("reloading jobs error: %v", err)
+	}
+	restored.Register("block", func(ctx context.Context, params json.RawMessage, progress func(float64)) error {
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	restored.Start(ctx)
+	waitForJobState(t, restored, job.ID, JobDone)
+}
+
+// TestJobManagerUnknownKind verifies a restored job of a kind no longer registered
+// fails instead of running.
+func TestJobManagerUnknownKind(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	m, err := NewJobManager(path, 1, logger)
+	if err != nil {
+		t.Fatalf("NewJobManager error: %v", err)
+	}
+	m.Register("removed", func(ctx context.Context, params json.RawMessage, progress func(float64)) error {
+		return nil
+	})
+	job, err := m.Submit("removed", nil)
+	if err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+
+	restored, err := NewJobManager(path, 1, logger)
+	if err != nil {
+		t.Fatalf("reloading jobs error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	restored.Start(ctx)
+	if got := waitForJobState(t, restored, job.ID, JobFailed); got.Error != `unknown job kind "removed"` {
+		t.Errorf("unexpected error %q", got.Error)
+	}
+}
+
+// TestJobPriorityScheduling verifies the weighted scheduler favors high priority
+// jobs without starving lower priorities.
+func TestJobPriorityScheduling(t *testing.T) {
+	m, err := NewJobManager("", 1, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewJobManager error: %v", err)
+	}
+	m.Register("noop", func(ctx context.Context, params json.RawMessage, progress func(float64)) error { return nil })
+	for i := 0; i < 6; i++ {
+		m.SubmitWithPriority("noop", i, PriorityLow)
+		m.SubmitWithPriority("noop", i, PriorityHigh)
+		m.Submit("noop", i)
+	}
+
+	var order []Priority
+	for {
+		job, _, _ := m.next(context.Background())
+		if job == nil {
+			break
+		}
+		order = append(order, job.Priority)
+	}
+	want := []Priority{
+		PriorityHigh, PriorityHigh, PriorityHigh, PriorityHigh, PriorityNormal, PriorityNormal, PriorityLow,
+		PriorityHigh, PriorityHigh, PriorityNormal, PriorityNormal, PriorityLow,
+		PriorityNormal, PriorityNormal, PriorityLow,
+		PriorityLow, PriorityLow, PriorityLow,
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("unexpected scheduling order\n got: %v\nwant: %v", order, want)
+	}
+}
+
+// TestSubscribeBackfill verifies that POST /subscribe with fromBlock returns a job that
+// backfills history in block order alongside live matches.
+func TestSubscribeBackfill(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mc := &mockClient{latestBlock: "0x4", blocks: map[int64]BlockResponse{}}
+	for n := int64(1); n <= 3; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Transactions = []RawTx{
+			{Hash: fmt.Sprintf("0xin%d", n), From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1"},
+			{Hash: fmt.Sprintf("0xother%d", n), From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000c", Value: "0x1"},
+		}
+		mc.blocks[n] = block
+	}
+	store := NewMemoryStore()
+	store.SetCurrentBlock(3)
+	parser := NewEthParser(mc, store, logger)
+	jobs, err := NewJobManager("", 1, logger)
+	if err != nil {
+		t.Fatalf("NewJobManager error: %v", err)
+	}
+	parser.RegisterJobs(jobs)
+	server := NewHTTPServer(parser, logger)
+	server.SetJobManager(jobs)
+
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(`{"address":"0x000000000000000000000000000000000000000a","fromBlock":2}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Subscribed bool `json:"subscribed"`
+		Job        Job  `json:"job"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if !resp.Subscribed || resp.Job.Kind != JobKindBackfill || resp.Job.State != JobQueued {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	// A live match lands before the job runs; backfilled history is inserted before it.
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xlive", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 4}, RawTx{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs.Start(ctx)
+	waitForJobState(t, jobs, resp.Job.ID, JobDone)
+
+	var hashes []string
+	for _, tx := range parser.GetTransactions("0x000000000000000000000000000000000000000a") {
+		hashes = append(hashes, tx.Hash)
+	}
+	if want := []string{"0xin2", "0xin3", "0xlive"}; !reflect.DeepEqual(hashes, want) {
+		t.Errorf("expected %v, got %v", want, hashes)
+	}
+}
+
+// rangeLogClient is a tokenClient that also serves address-filtered Transfer logs over
+// block ranges, recording each requested range.
+type rangeLogClient struct {
+	tokenClient
+	ranges [][2]int64
+}
+
+func (c *rangeLogClient) GetAddressTransferLogs(ctx context.Context, address string, fromBlock, toBlock int64) ([]RawLog, error) {
+	c.ranges = append(c.ranges, [2]int64{fromBlock, toBlock})
+	var logs []RawLog
+	for n := fromBlock; n <= toBlock; n++ {
+		logs = append(logs, c.logs[n]...)
+	}
+	return logs, nil
+}
+
+// TestBackfillEndpoint verifies that POST /backfill scans native and token history of a
+// subscribed address in block order, and that GET /backfill/{id} reports its progress.
+func TestBackfillEndpoint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	watched := "0x00000000000000000000000000000000000000aa"
+	topic := "0x" + strings.Repeat("0", 24) + watched[2:]
+	client := &rangeLogClient{tokenClient: tokenClient{
+		mockClient: mockClient{latestBlock: "0x3", blocks: map[int64]BlockResponse{}},
+		logs: map[int64][]RawLog{
+			2: {{Address: "0xusdc", Topics: []string{TransferEventTopic, topic, topic}, Data: "0x" + strings.Repeat("0", 63) + "5",
+				TransactionHash: "0xtoken2", BlockNumber: "0x2", LogIndex: "0x0"}}, // self-transfer, returned per direction
+		},
+		decimals: map[string]int{},
+	}}
+	for n := int64(1); n <= 3; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0xin%d", n), From: "0x000000000000000000000000000000000000000b", To: watched, Value: "0x1"}}
+		client.blocks[n] = block
+	}
+	store := NewMemoryStore()
+	store.SetCurrentBlock(3)
+	parser := NewEthParser(client, store, logger)
+	parser.SetTokenTracking(true)
+	jobs, err := NewJobManager("", 1, logger)
+	if err != nil {
+		t.Fatalf("NewJobManager error: %v", err)
+	}
+	parser.RegisterJobs(jobs)
+	server := NewHTTPServer(parser, logger)
+	server.SetJobManager(jobs)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/backfill", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(`{"address":"` + watched + `","fromBlock":1}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unsubscribed address, got %d", rec.Code)
+	}
+	parser.Subscribe(watched)
+	if rec := post(`{"address":"` + watched + `","fromBlock":1,"toBlock":4}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a range past the current block, got %d", rec.Code)
+	}
+	rec := post(`{"address":"` + watched + `","fromBlock":1,"toBlock":2}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job Job
+	json.Unmarshal(rec.Body.Bytes(), &job)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs.Start(ctx)
+	waitForJobState(t, jobs, job.ID, JobDone)
+
+	var hashes []string
+	for _, tx := range parser.GetTransactions(watched) {
+		hashes = append(hashes, tx.Hash)
+	}
+	if want := []string{"0xin1", "0xin2", "0xtoken2"}; !reflect.DeepEqual(hashes, want) {
+		t.Errorf("expected %v, got %v", want, hashes)
+	}
+	if want := [][2]int64{{1, 2}}; !reflect.DeepEqual(client.ranges, want) {
+		t.Errorf("expected log ranges %v, got %v", want, client.ranges)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/backfill/"+job.ID, nil))
+	var status BackfillStatus
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if rec.Code != http.StatusOK || status.State != JobDone || status.Address != watched ||
+		status.ScannedBlocks != 2 || status.TotalBlocks != 2 || status.Transactions != 3 {
+		t.Errorf("unexpected status %d %+v", rec.Code, status)
+	}
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/backfill/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown job, got %d", rec.Code)
+	}
+
+	// Backfilling an overlapping range, here up to the current block, skips what is stored.
+	rec = post(`{"address":"` + checksumAddress(watched) + `","fromBlock":1}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for a checksummed address, got %d: %s", rec.Code, rec.Body.String())
+	}
+	json.Unmarshal(rec.Body.Bytes(), &job)
+	waitForJobState(t, jobs, job.ID, JobDone)
+	hashes = nil
+	for _, tx := range parser.GetTransactions(watched) {
+		hashes = append(hashes, tx.Hash)
+	}
+	if want := []string{"0xin1", "0xin2", "0xtoken2", "0xin3"}; !reflect.DeepEqual(hashes, want) {
+		t.Errorf("expected %v without duplicates, got %v", want, hashes)
+	}
+
+	bad := "0x" + strings.Repeat("0", 38) + "aA"
+	if checksumValid(bad) {
+		t.Fatalf("expected %s to fail its checksum", bad)
+	}
+	if rec := post(`{"address":"` + bad + `","fromBlock":1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad checksum, got %d", rec.Code)
+	}
+}
@@ -2,40 +2,121 @@ package txparser
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
 )
 
-// JSONRPCClient is a minimal interface for Ethereum JSON-RPC calls
+// JSONRPCClient is a minimal interface for Ethereum JSON-RPC calls.
+// Cancelling ctx aborts the call in flight.
 type JSONRPCClient interface {
-	BlockNumber() (string, error)
-	GetBlockByNumber(blockNum int64) (BlockResponse, error)
+	BlockNumber(ctx context.Context) (string, error)
+	GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error)
+	FinalizedBlockNumber(ctx context.Context) (string, error)
+	DetectCapabilities(ctx context.Context) Capabilities
 }
 
 // RPCClient is a simple implementation of JSONRPCClient
 type RPCClient struct {
 	endpoint string
 	client   *http.Client
+	nextID   atomic.Uint64
+	lenient  bool
+	metrics  Metrics
+	retry    RetryPolicy
+	logger   *slog.Logger
+	limiter  *RateLimiter // caps requests per second, nil if unlimited
+	clock    Clock        // paces retry backoff
 }
 
-// NewJSONRPCClient creates a new RPCClient
-func NewJSONRPCClient(endpoint string) JSONRPCClient {
+// NewJSONRPCClient creates a new RPCClient, or a MultiClient failing over between
+// endpoint and the fallbacks when any are given.
+func NewJSONRPCClient(endpoint string, fallbacks ...string) JSONRPCClient {
+	if len(fallbacks) > 0 {
+		m, _ := NewMultiClient(append([]string{endpoint}, fallbacks...)) // cannot fail with endpoints
+		return m
+	}
 	return &RPCClient{
 		endpoint: endpoint,
 		client: &http.Client{
 			Timeout: 15 * time.Second,
 		},
+		metrics: NoopMetrics{},
+		retry:   DefaultRetryPolicy,
+		logger:  slog.Default(),
+		clock:   SystemClock,
 	}
 }
 
+// SetStrictValidation controls whether responses must carry jsonrpc "2.0" and the
+// ID of the request they answer. It is on by default; turn it off only for nodes
+// or proxies known to rewrite IDs.
+func (r *RPCClient) SetStrictValidation(strict bool) {
+	r.lenient = !strict
+}
+
+// ErrInvalidResponse is returned when a response fails strict JSON-RPC validation.
+var ErrInvalidResponse = errors.New("invalid JSON-RPC response")
+
 // rpcRequest is used to form the body of a JSON-RPC request
 type rpcRequest struct {
 	JSONRPC string        `json:"jsonrpc"`
 	Method  string        `json:"method"`
 	Params  []interface{} `json:"params"`
-	ID      int           `json:"id"`
+	ID      uint64        `json:"id"`
+}
+
+// newRequest builds a JSON-RPC request with a unique, incrementing ID.
+func (r *RPCClient) newRequest(method string, params ...interface{}) rpcRequest {
+	if params == nil {
+		params = []interface{}{}
+	}
+	return rpcRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      r.nextID.Add(1),
+	}
+}
+
+// validateResponse checks a response envelope against the request it answers.
+// A JSON array is rejected since a single request must get exactly one response.
+func (r *RPCClient) validateResponse(req rpcRequest, body []byte) error {
+	if r.lenient {
+		return nil
+	}
+	var envelope struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		var batch []json.RawMessage
+		if json.Unmarshal(body, &batch) == nil {
+			return fmt.Errorf("%w: got %d responses to %s", ErrInvalidResponse, len(batch), req.Method)
+		}
+		return fmt.Errorf("%s unmarshal failed: %w", req.Method, err)
+	}
+	return checkEnvelope(req, envelope.JSONRPC, envelope.ID)
+}
+
+// checkEnvelope verifies the jsonrpc version and that id echoes the request ID.
+func checkEnvelope(req rpcRequest, version string, id json.RawMessage) error {
+	if version != "2.0" {
+		return fmt.Errorf("%w: jsonrpc version %q in response to %s", ErrInvalidResponse, version, req.Method)
+	}
+	if string(id) != strconv.FormatUint(req.ID, 10) {
+		return fmt.Errorf("%w: response id %s does not match request id %d (%s)", ErrInvalidResponse, id, req.ID, req.Method)
+	}
+	return nil
 }
 
 type rpcResponseBlockNumber struct {
@@ -48,15 +129,10 @@ type rpcResponseBlockNumber struct {
 	} `json:"error,omitempty"`
 }
 
-func (r *RPCClient) BlockNumber() (string, error) {
-	reqBody := rpcRequest{
-		JSONRPC: "2.0",
-		Method:  "eth_blockNumber",
-		Params:  []interface{}{},
-		ID:      1,
-	}
+func (r *RPCClient) BlockNumber(ctx context.Context) (string, error) {
+	reqBody := r.newRequest("eth_blockNumber")
 
-	respBody, err := r.doRequest(reqBody)
+	respBody, err := r.doRequest(ctx, reqBody)
 	if err != nil {
 		return "", err
 	}
@@ -77,30 +153,43 @@ type BlockResponse struct {
 	Jsonrpc string `json:"jsonrpc"`
 	ID      int    `json:"id"`
 	Result  struct {
-		Number       string  `json:"number"`
-		Hash         string  `json:"hash"`
-		Transactions []RawTx `json:"transactions"`
+		Number        string  `json:"number"`
+		Hash          string  `json:"hash"`
+		Timestamp     string  `json:"timestamp"`
+		BaseFeePerGas string  `json:"baseFeePerGas"` // absent before London
+		Transactions  []RawTx `json:"transactions"`
 	} `json:"result"`
+
+	// Raw holds the undecoded JSON-RPC response when fetched from a live endpoint.
+	Raw json.RawMessage `json:"-"`
+	// Source identifies the provider that served the block: the endpoint host, or a file path.
+	Source string `json:"-"`
 }
 
 type RawTx struct {
-	Hash  string `json:"hash"`
-	From  string `json:"from"`
-	To    string `json:"to"`
-	Value string `json:"value"`
-	// Potentially blockNumber, input, gas, etc. For brevity, only keep needed fields
+	Hash     string `json:"hash"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Value    string `json:"value"`
+	GasPrice string `json:"gasPrice"`
+	Input    string `json:"input"`
+	Nonce    string `json:"nonce"`
+	// MaxFeePerGas and MaxPriorityFeePerGas are set for EIP-1559 transactions.
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+	// Potentially blockNumber, gas, etc. For brevity, only keep needed fields
+
+	// blockTimestamp and blockBaseFee are set by the streaming decoder when the block
+	// timestamp and base fee precede the transactions array in the response.
+	blockTimestamp string
+	blockBaseFee   string
 }
 
 // GetBlockByNumber retrieves a specific block's data (and transactions).
-func (r *RPCClient) GetBlockByNumber(blockNum int64) (BlockResponse, error) {
-	hexBlockNum := fmt.Sprintf("0x%x", blockNum)
-	reqBody := rpcRequest{
-		JSONRPC: "2.0",
-		Method:  "eth_getBlockByNumber",
-		Params:  []interface{}{hexBlockNum, true},
-		ID:      1,
-	}
-	respBody, err := r.doRequest(reqBody)
+func (r *RPCClient) GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error) {
+	hexBlockNum := hexutil.EncodeInt64(blockNum)
+	reqBody := r.newRequest("eth_getBlockByNumber", hexBlockNum, true)
+	respBody, err := r.doRequest(ctx, reqBody)
 	if err != nil {
 		return BlockResponse{}, fmt.Errorf("GetBlockByNumber request failed: %w", err)
 	}
@@ -109,35 +198,412 @@ func (r *RPCClient) GetBlockByNumber(blockNum int64) (BlockResponse, error) {
 	if err := json.Unmarshal(respBody, &blockResp); err != nil {
 		return BlockResponse{}, fmt.Errorf("GetBlockByNumber unmarshal failed: %w", err)
 	}
+	blockResp.Raw = respBody
+	blockResp.Source = r.Provider()
 	return blockResp, nil
 }
 
-// doRequest performs the JSON-RPC HTTP call and returns raw bytes of the response.
-func (r *RPCClient) doRequest(data interface{}) ([]byte, error) {
-	payload, err := json.Marshal(data)
+// GetBlocksByNumber fetches blocks from through to (inclusive) in a single JSON-RPC
+// batch request. Responses may arrive in any order; they are matched to requests by ID.
+func (r *RPCClient) GetBlocksByNumber(ctx context.Context, from, to int64) ([]BlockResponse, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	reqs := make([]rpcRequest, 0, to-from+1)
+	for blockNum := from; blockNum <= to; blockNum++ {
+		reqs = append(reqs, r.newRequest("eth_getBlockByNumber", hexutil.EncodeInt64(blockNum), true))
+	}
+	entries, err := r.postBatch(ctx, "GetBlocksByNumber", reqs)
 	if err != nil {
-		return nil, fmt.Errorf("json marshal failed: %w", err)
+		return nil, err
+	}
+
+	blocks := make([]BlockResponse, 0, len(reqs))
+	for i, entry := range entries {
+		if entry == nil {
+			return nil, fmt.Errorf("%w: no response for block %d in batch", ErrInvalidResponse, from+int64(i))
+		}
+		var result struct {
+			Result json.RawMessage `json:"result"`
+			Error  *RPCError       `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal(entry, &result); err != nil {
+			return nil, fmt.Errorf("GetBlocksByNumber unmarshal failed: %w", err)
+		}
+		if result.Error != nil {
+			return nil, fmt.Errorf("block %d: %w", from+int64(i), result.Error)
+		}
+		if len(result.Result) == 0 || string(result.Result) == "null" {
+			return nil, fmt.Errorf("block %d not found", from+int64(i))
+		}
+		var blockResp BlockResponse
+		if err := json.Unmarshal(entry, &blockResp); err != nil {
+			return nil, fmt.Errorf("GetBlocksByNumber unmarshal failed: %w", err)
+		}
+		blockResp.Raw = entry
+		blockResp.Source = r.Provider()
+		blocks = append(blocks, blockResp)
 	}
+	return blocks, nil
+}
 
-	req, err := http.NewRequest("POST", r.endpoint, bytes.NewBuffer(payload))
+// postBatch sends reqs as a single JSON-RPC batch request and returns the validated
+// responses in request order, matched by ID since they may arrive in any order. A
+// request without a response gets a nil entry. name prefixes transport errors.
+func (r *RPCClient) postBatch(ctx context.Context, name string, reqs []rpcRequest) ([]json.RawMessage, error) {
+	resp, err := r.post(ctx, reqs)
 	if err != nil {
-		return nil, fmt.Errorf("http.NewRequest error: %w", err)
+		return nil, fmt.Errorf("%s request failed: %w", name, err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	defer resp.Body.Close()
+
+	var entries []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%s unmarshal failed: %w", name, err)
+	}
+	if len(entries) != len(reqs) {
+		return nil, fmt.Errorf("%w: got %d responses to a batch of %d", ErrInvalidResponse, len(entries), len(reqs))
+	}
+
+	byID := make(map[string]json.RawMessage, len(entries))
+	for _, entry := range entries {
+		var envelope struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(entry, &envelope); err != nil {
+			return nil, fmt.Errorf("%s unmarshal failed: %w", name, err)
+		}
+		byID[string(envelope.ID)] = entry
+	}
+
+	ordered := make([]json.RawMessage, len(reqs))
+	for i, req := range reqs {
+		entry, ok := byID[strconv.FormatUint(req.ID, 10)]
+		if !ok {
+			continue
+		}
+		if err := r.validateResponse(req, entry); err != nil {
+			return nil, err
+		}
+		ordered[i] = entry
+	}
+	return ordered, nil
+}
 
-	resp, err := r.client.Do(req)
+// Provider names the endpoint for block metadata and logs. Only the host is used,
+// since paths and query strings of hosted endpoints often embed API keys.
+func (r *RPCClient) Provider() string {
+	u, err := url.Parse(r.endpoint)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
+
+// GetBlockHash returns the hash of a block without fetching its transactions.
+func (r *RPCClient) GetBlockHash(ctx context.Context, blockNum int64) (string, error) {
+	result, err := r.call(ctx, "eth_getBlockByNumber", hexutil.EncodeInt64(blockNum), false)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request error: %w", err)
+		return "", fmt.Errorf("GetBlockHash request failed: %w", err)
+	}
+	var header *struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(result, &header); err != nil {
+		return "", fmt.Errorf("GetBlockHash unmarshal failed: %w", err)
+	}
+	if header == nil {
+		return "", fmt.Errorf("block %d not found", blockNum)
+	}
+	return header.Hash, nil
+}
+
+// GetBlockHeader returns the hash, timestamp and base fee of a block without fetching
+// its transactions.
+func (r *RPCClient) GetBlockHeader(ctx context.Context, blockNum int64) (BlockHeader, error) {
+	result, err := r.call(ctx, "eth_getBlockByNumber", hexutil.EncodeInt64(blockNum), false)
+	if err != nil {
+		return BlockHeader{}, fmt.Errorf("GetBlockHeader request failed: %w", err)
+	}
+	var header *BlockHeader
+	if err := json.Unmarshal(result, &header); err != nil {
+		return BlockHeader{}, fmt.Errorf("GetBlockHeader unmarshal failed: %w", err)
+	}
+	if header == nil {
+		return BlockHeader{}, fmt.Errorf("block %d not found", blockNum)
+	}
+	return *header, nil
+}
+
+// ErrTransactionNotFound is returned when the node does not know a transaction hash.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// GetTransactionByHash fetches a single transaction by its hash.
+func (r *RPCClient) GetTransactionByHash(ctx context.Context, hash string) (RawTx, error) {
+	result, err := r.call(ctx, "eth_getTransactionByHash", hash)
+	if err != nil {
+		return RawTx{}, fmt.Errorf("GetTransactionByHash request failed: %w", err)
+	}
+	var tx *RawTx
+	if err := json.Unmarshal(result, &tx); err != nil {
+		return RawTx{}, fmt.Errorf("GetTransactionByHash unmarshal failed: %w", err)
+	}
+	if tx == nil {
+		return RawTx{}, ErrTransactionNotFound
+	}
+	return *tx, nil
+}
+
+// FinalizedBlockNumber returns the hex number of the latest finalized block.
+func (r *RPCClient) FinalizedBlockNumber(ctx context.Context) (string, error) {
+	reqBody := r.newRequest("eth_getBlockByNumber", "finalized", false)
+	respBody, err := r.doRequest(ctx, reqBody)
+	if err != nil {
+		return "", fmt.Errorf("FinalizedBlockNumber request failed: %w", err)
+	}
+
+	var blockResp struct {
+		Result *struct {
+			Number string `json:"number"`
+		} `json:"result"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(respBody, &blockResp); err != nil {
+		return "", fmt.Errorf("FinalizedBlockNumber unmarshal failed: %w", err)
+	}
+	if blockResp.Error != nil {
+		return "", fmt.Errorf("rpc error: %s", blockResp.Error.Message)
+	}
+	if blockResp.Result == nil {
+		return "", fmt.Errorf("finalized block not available")
+	}
+	return blockResp.Result.Number, nil
+}
+
+// RPCError is an error object returned by the JSON-RPC endpoint.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// call performs a single JSON-RPC request and returns the raw result.
+func (r *RPCClient) call(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	respBody, err := r.doRequest(ctx, r.newRequest(method, params...))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("%s unmarshal failed: %w", method, err)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+// StreamBlockTransactions fetches a block and calls fn for each transaction as it is decoded
+// from the response body, so the transactions array is never materialized in full.
+func (r *RPCClient) StreamBlockTransactions(ctx context.Context, blockNum int64, fn func(RawTx) error) (StreamedBlock, error) {
+	req := r.newRequest("eth_getBlockByNumber", hexutil.EncodeInt64(blockNum), true)
+	resp, err := r.post(ctx, req)
+	if err != nil {
+		return StreamedBlock{}, fmt.Errorf("StreamBlockTransactions request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	dec := json.NewDecoder(resp.Body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return StreamedBlock{}, err
+	}
+	var hash, version string
+	var id json.RawMessage
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return StreamedBlock{}, fmt.Errorf("reading response key failed: %w", err)
+		}
+		switch key {
+		case "result":
+			if hash, err = streamBlockResult(dec, fn); err != nil {
+				return StreamedBlock{}, err
+			}
+		case "error":
+			var rpcErr RPCError
+			if err := dec.Decode(&rpcErr); err != nil {
+				return StreamedBlock{}, fmt.Errorf("decoding rpc error failed: %w", err)
+			}
+			return StreamedBlock{}, &rpcErr
+		case "jsonrpc":
+			if err := dec.Decode(&version); err != nil {
+				return StreamedBlock{}, fmt.Errorf("decoding jsonrpc version failed: %w", err)
+			}
+		case "id":
+			if err := dec.Decode(&id); err != nil {
+				return StreamedBlock{}, fmt.Errorf("decoding response id failed: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return StreamedBlock{}, fmt.Errorf("skipping response field failed: %w", err)
+			}
+		}
+	}
+	if !r.lenient {
+		if err := checkEnvelope(req, version, id); err != nil {
+			return StreamedBlock{}, err
+		}
 	}
+	return StreamedBlock{Hash: hash, Source: r.Provider()}, nil
+}
+
+// streamBlockResult walks a block object, decoding entries of its transactions array one by one.
+// It returns the block hash.
+func streamBlockResult(dec *json.Decoder, fn func(RawTx) error) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", fmt.Errorf("reading block result failed: %w", err)
+	}
+	if tok == nil {
+		return "", fmt.Errorf("block not found")
+	}
+	if tok != json.Delim('{') {
+		return "", fmt.Errorf("unexpected block result token %v", tok)
+	}
+	var hash, timestamp, baseFee string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("reading block key failed: %w", err)
+		}
+		switch key {
+		case "hash":
+			if err := dec.Decode(&hash); err != nil {
+				return "", fmt.Errorf("decoding block hash failed: %w", err)
+			}
+			continue
+		case "timestamp":
+			if err := dec.Decode(&timestamp); err != nil {
+				return "", fmt.Errorf("decoding block timestamp failed: %w", err)
+			}
+			continue
+		case "baseFeePerGas":
+			if err := dec.Decode(&baseFee); err != nil {
+				return "", fmt.Errorf("decoding block base fee failed: %w", err)
+			}
+			continue
+		case "transactions":
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", fmt.Errorf("skipping block field failed: %w", err)
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return "", err
+		}
+		for dec.More() {
+			var tx RawTx
+			if err := dec.Decode(&tx); err != nil {
+				return "", fmt.Errorf("decoding transaction failed: %w", err)
+			}
+			tx.blockTimestamp = timestamp
+			tx.blockBaseFee = baseFee
+			if err := fn(tx); err != nil {
+				return "", err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return "", err
+		}
+	}
+	return hash, expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("reading JSON token failed: %w", err)
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %q, got %v", delim, tok)
+	}
+	return nil
+}
+
+// doRequest performs the JSON-RPC HTTP call and returns raw bytes of the validated response.
+func (r *RPCClient) doRequest(ctx context.Context, req rpcRequest) ([]byte, error) {
+	resp, err := r.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
 	buf := new(bytes.Buffer)
 	if _, err := buf.ReadFrom(resp.Body); err != nil {
 		return nil, fmt.Errorf("reading response body failed: %w", err)
 	}
+	if err := r.validateResponse(req, buf.Bytes()); err != nil {
+		return nil, err
+	}
 	return buf.Bytes(), nil
 }
+
+// requestMethod names the method of a request for metrics and logs, "batch" for batches.
+func requestMethod(data interface{}) string {
+	if req, ok := data.(rpcRequest); ok {
+		return req.Method
+	}
+	return "batch"
+}
+
+// postOnce makes a single attempt of post.
+func (r *RPCClient) postOnce(ctx context.Context, data interface{}) (resp *http.Response, err error) {
+	if r.limiter != nil {
+		if err := r.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("waiting for rate limit failed: %w", err)
+		}
+	}
+	method := requestMethod(data)
+	start := time.Now()
+	defer func() { r.metrics.RPCCall(method, time.Since(start), err) }()
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.endpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err = r.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(urlErr.URL) // the endpoint path may embed an API key
+		}
+		return nil, fmt.Errorf("HTTP request error: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, newStatusError(resp)
+	}
+	return resp, nil
+}
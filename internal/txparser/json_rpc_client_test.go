@@ -0,0 +1,669 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDetectCapabilities verifies method-not-found errors disable a capability
+// while other RPC errors still count as support.
+func TestDetectCapabilities(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch req.Method {
+		case "trace_block":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method trace_block does not exist/is not available"}}`, req.ID)
+		case "eth_getBlockReceipts":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":[]}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32602,"message":"invalid params"}}`, req.ID)
+		}
+	}))
+	defer srv.Close()
+
+	caps := NewJSONRPCClient(srv.URL).DetectCapabilities(context.Background())
+	want := Capabilities{Trace: false, BlockReceipts: true, FeeHistory: true, WebSockets: false}
+	if caps != want {
+		t.Errorf("expected %+v, got %+v", want, caps)
+	}
+}
+
+// TestStreamBlockTransactions verifies transactions are decoded regardless of field order.
+func TestStreamBlockTransactions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":{"timestamp":"0x64","transactions":[`+
+			`{"hash":"0xt1","from":"0x000000000000000000000000000000000000000a","to":"0x000000000000000000000000000000000000000b","value":"0x1"},`+
+			`{"hash":"0xt2","from":"0x000000000000000000000000000000000000000b","to":"0x000000000000000000000000000000000000000c","value":"0x2"}],`+
+			`"number":"0x7","hash":"0xb7","uncles":[]},"id":%d}`, req.ID)
+	}))
+	defer srv.Close()
+
+	client := NewJSONRPCClient(srv.URL).(*RPCClient)
+	var hashes []string
+	block, err := client.StreamBlockTransactions(context.Background(), 7, func(tx RawTx) error {
+		hashes = append(hashes, tx.Hash)
+		if tx.blockTimestamp != "0x64" {
+			t.Errorf("expected block timestamp 0x64 on %s, got %q", tx.Hash, tx.blockTimestamp)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamBlockTransactions error: %v", err)
+	}
+	if len(hashes) != 2 || hashes[0] != "0xt1" || hashes[1] != "0xt2" {
+		t.Errorf("unexpected streamed hashes %v", hashes)
+	}
+	if block.Hash != "0xb7" {
+		t.Errorf("expected block hash 0xb7, got %s", block.Hash)
+	}
+	if want := strings.TrimPrefix(srv.URL, "http://"); block.Source != want {
+		t.Errorf("expected source %s, got %s", want, block.Source)
+	}
+}
+
+// TestStreamedBlockRetry verifies that a streamed block failing midway stores nothing,
+// so its retry stores each transaction once.
+func TestStreamedBlockRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"number":"0x7","hash":"0xb7","timestamp":"0x64","transactions":[`+
+			`{"hash":"0xt1","from":"0x000000000000000000000000000000000000000a","to":"0x000000000000000000000000000000000000000b","value":"0x1"},`+
+			`{"hash":"0xt2","from":"0x000000000000000000000000000000000000000b","to":"0x000000000000000000000000000000000000000a","value":"0x2"}]}}`, req.ID)
+		if calls.Add(1) == 1 {
+			body = body[:strings.Index(body, `{"hash":"0xt2"`)] // the connection drops after the first transaction
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	client := NewJSONRPCClient(srv.URL).(*RPCClient)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	parser := NewEthParser(client, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetLowMemory(true)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	if _, _, err := parser.processBlock(context.Background(), 7); err == nil {
+		t.Fatalf("expected the truncated stream to fail")
+	}
+	if txs := parser.GetTransactions("0x000000000000000000000000000000000000000a"); len(txs) != 0 {
+		t.Fatalf("expected nothing stored from the failed stream, got %+v", txs)
+	}
+	if _, _, err := parser.processBlock(context.Background(), 7); err != nil {
+		t.Fatalf("processBlock retry error: %v", err)
+	}
+	if txs := parser.GetTransactions("0x000000000000000000000000000000000000000a"); len(txs) != 2 || txs[0].Hash != "0xt1" || txs[1].Hash != "0xt2" {
+		t.Errorf("expected 0xt1 and 0xt2 once each, got %+v", txs)
+	}
+}
+
+// TestTransactionMetadata verifies nonce, fee caps and method selector are parsed, and
+// that a streamed block whose timestamp follows its transactions gets it from the header.
+func TestTransactionMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case req.Method == "eth_blockNumber":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+		case req.Method == "eth_getBlockByNumber" && req.Params[1] == false:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"number":"0x1","hash":"0xb1","timestamp":"0x64","baseFeePerGas":"0x1"}}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"number":"0x1","hash":"0xb1","transactions":[`+
+				`{"hash":"0xt1","from":"0x000000000000000000000000000000000000000a","to":"0x000000000000000000000000000000000000000b","value":"0x1","nonce":"0x0","gasPrice":"0x3","input":"0x"},`+
+				`{"hash":"0xt2","from":"0x000000000000000000000000000000000000000a","to":"0x000000000000000000000000000000000000000c","value":"0x0","nonce":"0x1","gasPrice":"0x3","maxFeePerGas":"0x4","maxPriorityFeePerGas":"0x2",`+
+				`"input":"0xA9059CBB000000000000000000000000000000000000000000000000000000000000000b"}],`+
+				`"timestamp":"0x64","baseFeePerGas":"0x1"}}`, req.ID)
+		}
+	}))
+	defer srv.Close()
+
+	parser := NewEthParser(NewJSONRPCClient(srv.URL), NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetLowMemory(true)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+	txs := parser.GetTransactions("0x000000000000000000000000000000000000000a")
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %+v", txs)
+	}
+	if tx := txs[0]; tx.Nonce != "0" || tx.MethodSelector != "" || tx.MaxFeePerGasWei != "" || tx.Timestamp != 100 {
+		t.Errorf("unexpected plain transfer metadata %+v", tx)
+	}
+	if tx := txs[1]; tx.Nonce != "1" || tx.MethodSelector != "0xa9059cbb" || tx.MaxFeePerGasWei != "4" ||
+		tx.MaxPriorityFeePerGasWei != "2" || tx.GasPriceWei != "3" || tx.Timestamp != 100 {
+		t.Errorf("unexpected contract call metadata %+v", tx)
+	}
+}
+
+// TestMultiClientPrefersFastest verifies tip polling moves to the fastest endpoint
+// while block fetches go to the others.
+func TestMultiClientPrefersFastest(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string][]string{}
+	newEndpoint := func(name string, delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req rpcRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			hits[name] = append(hits[name], req.Method)
+			mu.Unlock()
+			time.Sleep(delay)
+			if req.Method == "eth_blockNumber" {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, req.ID)
+				return
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"number":"0x1","transactions":[]}}`, req.ID)
+		}))
+	}
+	slow := newEndpoint("slow", 30*time.Millisecond)
+	defer slow.Close()
+	fast := newEndpoint("fast", 0)
+	defer fast.Close()
+
+	client, err := NewMultiClient([]string{slow.URL, fast.URL})
+	if err != nil {
+		t.Fatalf("NewMultiClient error: %v", err)
+	}
+	if err := client.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("CheckHealth error: %v", err)
+	}
+	// CheckHealth stops at the first healthy endpoint; seed the fast one directly.
+	client.observe(client.endpoints[1], time.Millisecond, nil)
+
+	mu.Lock()
+	hits = map[string][]string{}
+	mu.Unlock()
+	for i := 0; i < 3; i++ {
+		client.BlockNumber(context.Background())
+		client.GetBlockByNumber(context.Background(), 1)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, method := range hits["fast"] {
+		if method != "eth_blockNumber" {
+			t.Errorf("expected only tip polling on fast endpoint, got %s", method)
+		}
+	}
+	for _, method := range hits["slow"] {
+		if method != "eth_getBlockByNumber" {
+			t.Errorf("expected only block fetches on slow endpoint, got %s", method)
+		}
+	}
+}
+
+// TestStrictResponseValidation verifies mismatched IDs, wrong versions and batched
+// replies are rejected unless strict validation is disabled.
+func TestStrictResponseValidation(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	client := NewJSONRPCClient(srv.URL).(*RPCClient)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"stale id", `{"jsonrpc":"2.0","id":0,"result":"0x1"}`},
+		{"string id", `{"jsonrpc":"2.0","id":"%d","result":"0x1"}`},
+		{"missing version", `{"id":%d,"result":"0x1"}`},
+		{"duplicated", `[{"jsonrpc":"2.0","id":%d,"result":"0x1"},{"jsonrpc":"2.0","id":%d,"result":"0x1"}]`},
+	}
+	for _, tt := range tests {
+		next := client.nextID.Load() + 1
+		body = strings.ReplaceAll(tt.body, "%d", strconv.FormatUint(next, 10))
+		if _, err := client.BlockNumber(context.Background()); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("%s: expected ErrInvalidResponse, got %v", tt.name, err)
+		}
+	}
+
+	body = `{"jsonrpc":"2.0","id":1,"result":"0x5"}`
+	if _, err := client.BlockNumber(context.Background()); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("expected reused id to be rejected, got %v", err)
+	}
+	client.SetStrictValidation(false)
+	if got, err := client.BlockNumber(context.Background()); err != nil || got != "0x5" {
+		t.Errorf("expected lenient client to accept response, got %q, %v", got, err)
+	}
+}
+
+// TestCatchUpBatches verifies a lagging parser fetches several blocks per batch request
+// and that batched responses are matched to requests by ID.
+func TestCatchUpBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			var req rpcRequest
+			json.Unmarshal(body, &req)
+			result := `"0x5"`
+			if req.Method != "eth_blockNumber" {
+				result = `{"number":"0x5"}`
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+			return
+		}
+		mu.Lock()
+		batches = append(batches, len(reqs))
+		mu.Unlock()
+		var resps []string
+		for i := len(reqs) - 1; i >= 0; i-- { // answer in reverse order
+			num := reqs[i].Params[0].(string)
+			resps = append(resps, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"number":%q,"hash":"0xh%s",`+
+				`"transactions":[{"hash":"0xt%s","from":"0x00000000000000000000000000000000000000aa","to":"0x00000000000000000000000000000000000000bb","value":"0x1"}]}}`, reqs[i].ID, num, num, num))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(resps, ","))
+	}))
+	defer srv.Close()
+
+	parser := NewEthParser(NewJSONRPCClient(srv.URL), NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetCatchUp(3)
+	parser.Subscribe("0x00000000000000000000000000000000000000bb")
+	for i := 0; i < 2; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+	if parser.GetCurrentBlock() != 5 || parser.behindTip() {
+		t.Errorf("expected to reach tip 5, at %d", parser.GetCurrentBlock())
+	}
+	if len(batches) != 2 || batches[0] != 3 || batches[1] != 2 {
+		t.Errorf("expected batches of 3 and 2 blocks, got %v", batches)
+	}
+	txs := parser.GetTransactions("0x00000000000000000000000000000000000000bb")
+	if len(txs) != 5 || txs[0].Hash != "0xt0x1" || txs[4].Hash != "0xt0x5" {
+		t.Errorf("expected one tx per block in order, got %+v", txs)
+	}
+	if hash, _ := parser.store.GetBlockHash(2); hash != "0xh0x2" {
+		t.Errorf("expected block 2 hash 0xh0x2, got %s", hash)
+	}
+}
+
+// TestReceiptEnrichment verifies receipts of a block's matched transactions are fetched
+// in one batch, or one eth_getBlockReceipts call, and add status, gas used and fee.
+func TestReceiptEnrichment(t *testing.T) {
+	var batches, blockReceipts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			var req rpcRequest
+			json.Unmarshal(body, &req)
+			result := `"0x1"`
+			if req.Method == "eth_getBlockByNumber" {
+				result = `{"number":"0x1","hash":"0xh1","baseFeePerGas":"0x3b9ac9f6","transactions":[` +
+					`{"hash":"0xok","from":"0x00000000000000000000000000000000000000aa","to":"0x00000000000000000000000000000000000000bb","value":"0x1","gasPrice":"0x9"},` +
+					`{"hash":"0xother","from":"0x00000000000000000000000000000000000000aa","to":"0x00000000000000000000000000000000000000cc","value":"0x1","gasPrice":"0x9"},` +
+					`{"hash":"0xreverted","from":"0x00000000000000000000000000000000000000bb","to":"0x00000000000000000000000000000000000000aa","value":"0x5","gasPrice":"0x2"}]}`
+			}
+			if req.Method == "eth_getBlockReceipts" {
+				blockReceipts.Add(1)
+				result = `[{"transactionHash":"0xok","status":"0x1","gasUsed":"0x5208","effectiveGasPrice":"0x3b9aca00"},` +
+					`{"transactionHash":"0xother","status":"0x1","gasUsed":"0x5208"},` +
+					`{"transactionHash":"0xreverted","status":"0x0","gasUsed":"0x5208"}]`
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+			return
+		}
+		batches.Add(1)
+		receipts := map[string]string{
+			"0xok":       `{"transactionHash":"0xok","status":"0x1","gasUsed":"0x5208","effectiveGasPrice":"0x3b9aca00"}`,
+			"0xreverted": `{"transactionHash":"0xreverted","status":"0x0","gasUsed":"0x5208"}`,
+		}
+		var resps []string
+		for i := len(reqs) - 1; i >= 0; i-- { // answer in reverse order
+			resps = append(resps, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, reqs[i].ID, receipts[reqs[i].Params[0].(string)]))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(resps, ","))
+	}))
+	defer srv.Close()
+
+	parser := NewEthParser(NewJSONRPCClient(srv.URL), NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetReceiptEnrichment(true)
+	parser.Subscribe("0x00000000000000000000000000000000000000bb")
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+	if n := batches.Load(); n != 1 {
+		t.Errorf("expected one receipt batch, got %d", n)
+	}
+	txs := parser.GetTransactions("0x00000000000000000000000000000000000000bb")
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %+v", txs)
+	}
+	if tx := txs[0]; tx.Status != ReceiptSuccess || tx.GasUsed != "21000" || tx.EffectiveGasPriceWei != "1000000000" || tx.FeeWei != "21000000000000" ||
+		tx.BurnedFeeWei != "20999999790000" || tx.TipFeeWei != "210000" {
+		t.Errorf("unexpected successful transaction %+v", tx)
+	}
+	if tx := txs[1]; tx.Status != ReceiptFailed || tx.FeeWei != "42000" {
+		t.Errorf("unexpected reverted transaction %+v", tx)
+	}
+	stats, _ := parser.GetValueStats("0x00000000000000000000000000000000000000bb")
+	if stats.Count != 1 {
+		t.Errorf("expected the reverted transfer to be left out of value stats, got %+v", stats)
+	}
+	if fees := stats.Fees; fees == nil || fees.Count != 1 || fees.TotalWei != "42000" || fees.SplitCount != 1 || fees.TipWei != "0" {
+		t.Errorf("expected the fee paid by 0xbb in stats, got %+v", fees)
+	}
+
+	parser = NewEthParser(NewJSONRPCClient(srv.URL), NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetReceiptEnrichment(true)
+	parser.SetBlockReceipts(true)
+	parser.Subscribe("0x00000000000000000000000000000000000000bb")
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+	if batches.Load() != 1 || blockReceipts.Load() != 1 {
+		t.Errorf("expected one eth_getBlockReceipts call and no further batch, got %d calls and %d batches", blockReceipts.Load(), batches.Load())
+	}
+	if txs := parser.GetTransactions("0x00000000000000000000000000000000000000bb"); len(txs) != 2 || txs[0].FeeWei != "21000000000000" || txs[1].Status != ReceiptFailed {
+		t.Errorf("expected block receipts to enrich both transactions, got %+v", txs)
+	}
+}
+
+// TestProbeWebSocket verifies only an endpoint completing the handshake counts as
+// supporting WebSockets.
+func TestProbeWebSocket(t *testing.T) {
+	ws := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgradeWebSocket(w, r); err == nil {
+			conn.Close()
+		}
+	}))
+	defer ws.Close()
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+
+	if !ProbeWebSocket(context.Background(), "ws"+strings.TrimPrefix(ws.URL, "http")) {
+		t.Errorf("expected the WebSocket endpoint to pass the probe")
+	}
+	if ProbeWebSocket(context.Background(), "ws"+strings.TrimPrefix(plain.URL, "http")) {
+		t.Errorf("expected a plain HTTP endpoint to fail the probe")
+	}
+}
+
+// TestRPCFailover verifies that failed calls fail over to another endpoint, that an
+// endpoint is taken out of rotation after consecutive failures, and that it returns
+// after the cooldown once it answers again.
+func TestRPCFailover(t *testing.T) {
+	var mu sync.Mutex
+	primaryDown := true
+	hits := map[string]int{}
+	newEndpoint := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			hits[name]++
+			if name == "primary" && primaryDown {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			var req rpcRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, req.ID)
+		}))
+	}
+	primary := newEndpoint("primary")
+	defer primary.Close()
+	backup := newEndpoint("backup")
+	defer backup.Close()
+
+	client, ok := NewJSONRPCClient(primary.URL, backup.URL).(*MultiClient)
+	if !ok {
+		t.Fatal("expected a MultiClient for several endpoints")
+	}
+	clock := NewFakeClock(time.Unix(0, 0))
+	client.SetClock(clock)
+	// Make the primary look fastest so it is tried first.
+	client.observe(client.endpoints[0], time.Millisecond, nil)
+	client.observe(client.endpoints[1], time.Second, nil)
+
+	for i := 0; i < FailoverThreshold; i++ {
+		if tip, err := client.BlockNumber(context.Background()); err != nil || tip != "0x10" {
+			t.Fatalf("call %d: expected failover to the backup, got %q, %v", i, tip, err)
+		}
+	}
+	if stats := client.Stats(); stats[0].Healthy || stats[0].Failures != FailoverThreshold {
+		t.Fatalf("expected the primary out of rotation, got %+v", stats[0])
+	}
+
+	mu.Lock()
+	hits = map[string]int{}
+	primaryDown = false
+	mu.Unlock()
+	client.BlockNumber(context.Background())
+	mu.Lock()
+	if hits["primary"] != 0 {
+		t.Errorf("expected the primary to be skipped during the cooldown, got %d calls", hits["primary"])
+	}
+	mu.Unlock()
+
+	clock.Advance(FailoverCooldown)
+	client.BlockNumber(context.Background())
+	if stats := client.Stats(); !stats[0].Healthy || stats[0].Failures != 0 {
+		t.Errorf("expected the primary back in rotation, got %+v", stats[0])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if hits["primary"] == 0 {
+		t.Error("expected the primary to be retried after the cooldown")
+	}
+}
+
+// TestRPCCancellation verifies cancelling the context aborts a call in flight without
+// failing over or counting against the endpoint.
+func TestRPCCancellation(t *testing.T) {
+	var calls atomic.Int32
+	hang := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.Copy(io.Discard, r.Body) // the server notices the client going away only once the body is read
+		<-r.Context().Done()
+	})
+	primary := httptest.NewServer(hang)
+	defer primary.Close()
+	backup := httptest.NewServer(hang)
+	defer backup.Close()
+
+	client := NewJSONRPCClient(primary.URL, backup.URL).(*MultiClient)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := client.BlockNumber(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the call to return on cancellation, took %v", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected no failover after cancellation, got %d calls", n)
+	}
+	for _, stats := range client.Stats() {
+		if stats.Samples != 0 || stats.Failures != 0 {
+			t.Errorf("expected cancelled calls not to be recorded, got %+v", stats)
+		}
+	}
+}
+
+// TestRPCRetry verifies transient HTTP failures are retried with backoff paced by the
+// client's clock, other failures are not, and a per-call policy overrides the client's.
+func TestRPCRetry(t *testing.T) {
+	var calls atomic.Int32
+	var status atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, req.ID)
+	}))
+	defer srv.Close()
+	client := NewJSONRPCClient(srv.URL).(*RPCClient)
+	client.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	clock := NewFakeClock(time.Unix(0, 0))
+	client.SetClock(clock)
+
+	status.Store(http.StatusTooManyRequests)
+	type result struct {
+		tip string
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		tip, err := client.BlockNumber(context.Background())
+		done <- result{tip, err}
+	}()
+	for _, delay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		for clock.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(delay)
+	}
+	if got := <-done; got.err != nil || got.tip != "0x10" || calls.Load() != 3 {
+		t.Fatalf("expected success on the third attempt, got %q, %v after %d calls", got.tip, got.err, calls.Load())
+	}
+
+	calls.Store(0)
+	if _, err := client.BlockNumber(WithRetryPolicy(context.Background(), NoRetries)); err == nil || calls.Load() != 1 {
+		t.Errorf("expected a single attempt with NoRetries, got %v after %d calls", err, calls.Load())
+	}
+
+	calls.Store(0)
+	status.Store(http.StatusBadRequest)
+	if _, err := client.BlockNumber(context.Background()); err == nil || calls.Load() != 1 {
+		t.Errorf("expected a 400 not to be retried, got %v after %d calls", err, calls.Load())
+	}
+
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	if d := policy.delay(4, 0); d != 5*time.Second {
+		t.Errorf("expected the backoff capped at MaxDelay, got %v", d)
+	}
+	if d := policy.delay(1, 3*time.Second); d != 3*time.Second {
+		t.Errorf("expected Retry-After to extend the backoff, got %v", d)
+	}
+}
+
+// TestRPCRateLimit verifies requests beyond the burst wait for the token bucket to
+// refill, and that a canceled wait fails without sending.
+func TestRPCRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, req.ID)
+	}))
+	defer srv.Close()
+	client := NewJSONRPCClient(srv.URL).(*RPCClient)
+	client.SetRateLimit(10, 2)
+	clock := NewFakeClock(time.Unix(0, 0))
+	client.limiter.SetClock(clock)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.BlockNumber(context.Background()); err != nil {
+			t.Fatalf("BlockNumber error within burst: %v", err)
+		}
+	}
+	done := make(chan error)
+	go func() {
+		_, err := client.BlockNumber(context.Background())
+		done <- err
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the third request to wait, got %d calls", calls.Load())
+	}
+	clock.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil || calls.Load() != 3 {
+		t.Fatalf("expected the third request after a refill, got %v after %d calls", err, calls.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.BlockNumber(ctx); err == nil || calls.Load() != 3 {
+		t.Errorf("expected a canceled wait to fail without sending, got %v after %d calls", err, calls.Load())
+	}
+}
+
+// TestHeadSubscriberReconnect verifies the newHeads subscriber wakes the parser on heads,
+// reconnects after the WebSocket drops, and measures the blocks mined meanwhile.
+func TestHeadSubscriberReconnect(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	parser := NewEthParser(&mockClient{latestBlock: "0x9"}, NewMemoryStore(), logger)
+	metrics := NewPrometheusMetrics()
+	parser.SetMetrics(metrics)
+
+	var connections atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, data, err := conn.readMessage(); err != nil || !strings.Contains(string(data), `"eth_subscribe"`) {
+			t.Errorf("expected eth_subscribe, got %s (%v)", data, err)
+			return
+		}
+		conn.writeMessage(wsOpText, []byte(`{"jsonrpc":"2.0","id":1,"result":"0xsub"}`))
+		if connections.Add(1) == 1 {
+			conn.writeMessage(wsOpText, []byte(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xsub","result":{"number":"0x5"}}}`))
+			return // drop the first connection after one head
+		}
+		conn.readMessage() // hold the second one open
+	}))
+	defer srv.Close()
+
+	heads := NewHeadSubscriber("ws"+strings.TrimPrefix(srv.URL, "http"), parser, logger)
+	heads.SetBackoff(RetryPolicy{BaseDelay: time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go heads.Run(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var b strings.Builder
+		metrics.WriteTo(&b)
+		if strings.Contains(b.String(), "txparser_ws_reconnects_total 1\n") {
+			if !strings.Contains(b.String(), "txparser_ws_last_gap_blocks 4\n") {
+				t.Errorf("expected a gap of blocks 6 to 9, got\n%s", b.String())
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a reconnect, got %d connections", connections.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case <-parser.wake:
+	default:
+		t.Errorf("expected the parser to be woken to backfill the gap")
+	}
+}
@@ -0,0 +1,124 @@
+package txparser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLeaderLease is how long a replica's parsing lease lasts without renewal.
+const DefaultLeaderLease = 15 * time.Second
+
+// LeaseStore is implemented by stores shared between replicas that can grant a named,
+// expiring lease to one holder at a time.
+type LeaseStore interface {
+	// AcquireLease grants the lease name to holder for ttl, or renews it if holder
+	// already has it, reporting whether holder has it now.
+	AcquireLease(name, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives up the lease name if holder has it.
+	ReleaseLease(name, holder string) error
+}
+
+// LeaderElector keeps one replica at a time the leader through a lease in a shared
+// store, renewing it every third of its TTL. A replica that fails to renew steps down
+// at once, so it stops parsing before the lease can pass to another; the block being
+// processed when leadership changes may still be written twice.
+type LeaderElector struct {
+	leases LeaseStore
+	name   string
+	id     string
+	ttl    time.Duration
+	logger *slog.Logger
+	clock  Clock
+
+	leader atomic.Bool
+}
+
+// NewLeaderElector creates an elector competing for the lease name in leases as id.
+func NewLeaderElector(leases LeaseStore, name, id string, ttl time.Duration, logger *slog.Logger) *LeaderElector {
+	return &LeaderElector{leases: leases, name: name, id: id, ttl: ttl, logger: logger, clock: SystemClock}
+}
+
+// ReplicaID returns an identifier for this process, unique among replicas: the host
+// name and process ID.
+func ReplicaID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// SetClock replaces the time source spacing lease renewals.
+func (e *LeaderElector) SetClock(c Clock) {
+	e.clock = c
+}
+
+// IsLeader reports whether this replica holds the lease.
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// ID returns the identity this replica holds the lease under.
+func (e *LeaderElector) ID() string {
+	return e.id
+}
+
+// Run competes for the lease until ctx is canceled, then releases it if held.
+func (e *LeaderElector) Run(ctx context.Context) {
+	for {
+		e.renew()
+		select {
+		case <-ctx.Done():
+			if e.leader.Swap(false) {
+				if err := e.leases.ReleaseLease(e.name, e.id); err != nil {
+					e.logger.Warn("Failed to release leader lease", "lease", e.name, "err", err)
+				}
+			}
+			return
+		case <-e.clock.After(e.ttl / 3):
+		}
+	}
+}
+
+// renew acquires or extends the lease, logging leadership changes.
+func (e *LeaderElector) renew() {
+	held, err := e.leases.AcquireLease(e.name, e.id, e.ttl)
+	if err != nil {
+		e.logger.Warn("Failed to renew leader lease", "lease", e.name, "err", err)
+		held = false // without a renewal another replica may take over
+	}
+	switch was := e.leader.Swap(held); {
+	case held && !was:
+		e.logger.Info("Became leader, parsing blocks", "lease", e.name, "id", e.id)
+	case !held && was:
+		e.logger.Warn("Lost leadership, serving the API only", "lease", e.name, "id", e.id)
+	}
+}
+
+// FollowerReporter is implemented by parsers that can run as a follower replica.
+type FollowerReporter interface {
+	IsFollower() bool
+}
+
+// isFollower reports whether p runs as a follower of another replica.
+func isFollower(p Parser) bool {
+	f, ok := p.(FollowerReporter)
+	return ok && f.IsFollower()
+}
+
+// leaderOnly answers 503 NOT_LEADER on followers for routes reading state the parser
+// derives in-process, such as events and stats, which only the leader keeps current.
+func (s *HTTPServer) leaderOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isFollower(s.parser) {
+			s.writeError(w, ErrNotLeader)
+			return
+		}
+		next(w, r)
+	}
+}
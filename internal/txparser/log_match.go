@@ -0,0 +1,100 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// MatchTypeLog marks transactions stored because a subscribed address appeared as an
+// indexed topic of an event they emitted, e.g. a deposit or claim for that address.
+const MatchTypeLog = "log"
+
+// BlockLogSource is implemented by sources that can return every event log of a block.
+type BlockLogSource interface {
+	GetBlockLogs(ctx context.Context, blockNum int64) ([]RawLog, error)
+}
+
+// GetBlockLogs returns all event logs emitted in a block.
+func (r *RPCClient) GetBlockLogs(ctx context.Context, blockNum int64) ([]RawLog, error) {
+	hexBlockNum := hexutil.EncodeInt64(blockNum)
+	result, err := r.call(ctx, "eth_getLogs", map[string]interface{}{
+		"fromBlock": hexBlockNum,
+		"toBlock":   hexBlockNum,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockLogs request failed: %w", err)
+	}
+	var logs []RawLog
+	if err := json.Unmarshal(result, &logs); err != nil {
+		return nil, fmt.Errorf("GetBlockLogs unmarshal failed: %w", err)
+	}
+	return logs, nil
+}
+
+// topicAddresses returns the distinct non-zero addresses among the indexed topics of
+// log, skipping the event signature in the first topic.
+func topicAddresses(log RawLog) []string {
+	if log.Removed || len(log.Topics) < 2 {
+		return nil
+	}
+	var addresses []string
+	for _, topic := range log.Topics[1:] {
+		address, ok := topicAddress(topic)
+		if !ok || strings.Trim(address[2:], "0") == "" || containsString(addresses, address) {
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// fetchLogMatches fetches the logs of a block and returns, by transaction hash, the
+// subscribed addresses found in the indexed topics of its events. Failures are
+// recorded and yield no matches, since the block's transfers are stored regardless.
+func (p *EthParser) fetchLogMatches(ctx context.Context, blockNum int) map[string][]string {
+	source, ok := p.client.(BlockLogSource)
+	if !ok || !p.matchLogs || !p.features.Enabled(FeatureLogMatching) {
+		return nil
+	}
+	logs, err := source.GetBlockLogs(ctx, int64(blockNum))
+	if err != nil {
+		p.logger.Warn("Failed to fetch block logs", "block", blockNum, "err", err)
+		p.errors.Record("logs", blockNum, err)
+		return nil
+	}
+	matches := make(map[string][]string)
+	for _, log := range logs {
+		for _, address := range topicAddresses(log) {
+			if p.store.IsSubscribed(address) && !containsString(matches[log.TransactionHash], address) {
+				matches[log.TransactionHash] = append(matches[log.TransactionHash], address)
+			}
+		}
+	}
+	return matches
+}
+
+// storeLogMatches stores each of txs under the subscribed addresses its events named
+// in matches, unless the address was already matched as its sender, recipient or,
+// with input matching, in its calldata. raws holds the source RawTx for each entry of txs.
+func (p *EthParser) storeLogMatches(matches map[string][]string, txs []Transaction, raws []RawTx) {
+	if len(matches) == 0 {
+		return
+	}
+	for i, tx := range txs {
+		for _, address := range matches[tx.Hash] {
+			if strings.EqualFold(address, tx.From) || strings.EqualFold(address, tx.To) {
+				continue
+			}
+			if p.matchInput && p.features.Enabled(FeatureInputMatching) && containsString(calldataAddresses(raws[i].Input), address) {
+				continue
+			}
+			matched := p.applyRules(address, tx, raws[i])
+			matched.MatchType = MatchTypeLog
+			p.addTransaction(address, matched, raws[i])
+		}
+	}
+}
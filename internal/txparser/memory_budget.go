@@ -0,0 +1,153 @@
+package txparser
+
+import (
+	"container/heap"
+	"log/slog"
+)
+
+// Memory budget thresholds, as fractions of the configured budget.
+const (
+	memoryWarnRatio  = 0.8 // warn when usage crosses this ratio
+	memoryEvictRatio = 0.9 // eviction frees memory down to this ratio
+)
+
+// txOverheadBytes approximates the fixed per-transaction cost: the struct itself,
+// string and slice headers, and the slot in its address's slice.
+const txOverheadBytes = 160
+
+// MemoryUsage reports the estimated size of a store against its budget.
+type MemoryUsage struct {
+	UsedBytes           int64 `json:"usedBytes"`
+	BudgetBytes         int64 `json:"budgetBytes,omitempty"`
+	HeadroomBytes       int64 `json:"headroomBytes,omitempty"`
+	EvictedTransactions int64 `json:"evictedTransactions"`
+}
+
+// MemoryReporter is implemented by stores that account their memory use.
+type MemoryReporter interface {
+	MemoryUsage() MemoryUsage
+}
+
+// estimateTxBytes approximates the memory held by one stored transaction.
+func estimateTxBytes(tx Transaction) int64 {
+	size := txOverheadBytes + len(tx.Hash) + len(tx.From) + len(tx.To) + len(tx.Value) + len(tx.MatchType) +
+		len(tx.ValueWei) + len(tx.ValueEther) + len(tx.GasPriceWei) + len(tx.Token) + len(tx.TokenAmount) + len(tx.TokenValue) +
+		len(tx.Status) + len(tx.GasUsed) + len(tx.EffectiveGasPriceWei) + len(tx.FeeWei) +
+		len(tx.BurnedFeeWei) + len(tx.TipFeeWei) + len(tx.MaxFeePerGasWei) + len(tx.MaxPriorityFeePerGasWei) +
+		len(tx.Nonce) + len(tx.MethodSelector)
+	for _, tag := range tx.Tags {
+		size += 16 + len(tag)
+	}
+	return int64(size)
+}
+
+// SetMemoryBudget caps the estimated memory used by stored transactions.
+// Crossing 80% of the budget logs a warning; exceeding it evicts the oldest
+// transactions across all addresses until usage is back under 90%. Zero disables the cap.
+func (m *MemoryStore) SetMemoryBudget(budgetBytes int64, logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budgetBytes = budgetBytes
+	m.budgetLogger = logger
+	m.enforceBudgetLocked()
+}
+
+// MemoryUsage returns the store's estimated memory use and remaining headroom.
+func (m *MemoryStore) MemoryUsage() MemoryUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	usage := MemoryUsage{
+		UsedBytes:           m.usedBytes,
+		BudgetBytes:         m.budgetBytes,
+		EvictedTransactions: m.evictedTxs,
+	}
+	if m.budgetBytes > 0 {
+		usage.HeadroomBytes = max(m.budgetBytes-m.usedBytes, 0)
+	}
+	return usage
+}
+
+// enforceBudgetLocked warns near the budget and evicts once it is exceeded. Callers hold m.mu.
+func (m *MemoryStore) enforceBudgetLocked() {
+	if m.budgetBytes <= 0 {
+		return
+	}
+
+	warnAt := int64(float64(m.budgetBytes) * memoryWarnRatio)
+	switch {
+	case m.usedBytes >= warnAt && !m.budgetWarned:
+		m.budgetWarned = true
+		m.budgetLogger.Warn("Memory store approaching budget",
+			"used_bytes", m.usedBytes,
+			"budget_bytes", m.budgetBytes,
+		)
+	case m.usedBytes < warnAt:
+		m.budgetWarned = false
+	}
+
+	if m.usedBytes <= m.budgetBytes {
+		return
+	}
+	target := int64(float64(m.budgetBytes) * memoryEvictRatio)
+	evicted := m.evictOldestLocked(target)
+	m.evictedTxs += int64(evicted)
+	m.budgetLogger.Warn("Memory store over budget, evicted oldest transactions",
+		"evicted", evicted,
+		"used_bytes", m.usedBytes,
+		"budget_bytes", m.budgetBytes,
+	)
+}
+
+// evictOldestLocked drops the transactions with the lowest blocks across all addresses
+// until usage is at most target, returning how many were dropped. Addresses are kept in
+// a heap by their oldest transaction, so each eviction costs O(log addresses) instead
+// of a scan under the write lock. Callers hold m.mu.
+func (m *MemoryStore) evictOldestLocked(target int64) int {
+	oldest := make(oldestHeap, 0, len(m.transactions))
+	for address, txs := range m.transactions {
+		if len(txs) > 0 {
+			oldest = append(oldest, oldestEntry{address: address, block: txs[0].Block})
+		}
+	}
+	heap.Init(&oldest)
+
+	evicted := 0
+	for m.usedBytes > target && len(oldest) > 0 {
+		entry := &oldest[0]
+		txs := m.transactions[entry.address]
+		m.usedBytes -= estimateTxBytes(txs[0])
+		txs = txs[1:]
+		m.transactions[entry.address] = txs
+		evicted++
+		if len(txs) == 0 {
+			heap.Pop(&oldest)
+		} else {
+			entry.block = txs[0].Block
+			heap.Fix(&oldest, 0)
+		}
+	}
+	return evicted
+}
+
+// oldestEntry is an address with the block of its oldest stored transaction.
+type oldestEntry struct {
+	address string
+	block   int64
+}
+
+// oldestHeap is a min-heap of addresses by the block of their oldest transaction.
+type oldestHeap []oldestEntry
+
+func (h oldestHeap) Len() int            { return len(h) }
+func (h oldestHeap) Less(i, j int) bool  { return h[i].block < h[j].block }
+func (h oldestHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *oldestHeap) Push(x interface{}) { *h = append(*h, x.(oldestEntry)) }
+func (h *oldestHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -1,22 +1,93 @@
 package txparser
 
-import "sync"
+import (
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
 
 type Store interface {
 	Subscribe(address string) bool
+	// SubscribeUntil adds a subscription that lapses at expiresAt.
+	// It never shortens or replaces a permanent subscription.
+	SubscribeUntil(address string, expiresAt time.Time) bool
 	IsSubscribed(address string) bool
 	AddTransaction(address string, tx Transaction)
 	GetTransactions(address string) []Transaction
+	// GetTransactionsInRange returns an address's transactions with fromBlock <= block <= toBlock.
+	GetTransactionsInRange(address string, fromBlock, toBlock int64) []Transaction
+	// QueryTransactions returns the page of an address's transactions selected by q,
+	// and the number of transactions matching q across all pages.
+	QueryTransactions(address string, q TxQuery) ([]Transaction, int)
 	SetCurrentBlock(block int)
 	GetCurrentBlock() int
+
+	// SetBlockHash records the hash of a processed block for reorg detection.
+	SetBlockHash(block int, hash string)
+	// GetBlockHash returns the recorded hash of a block, if still retained.
+	GetBlockHash(block int) (string, bool)
+	// RollbackTo discards transactions and block hashes above block.
+	RollbackTo(block int)
+
+	// SetNotificationPrefs stores preferences for a subscribed address.
+	// Returns false if the address is not subscribed.
+	SetNotificationPrefs(address string, prefs NotificationPrefs) bool
+	// GetNotificationPrefs returns the preferences of a subscribed address.
+	GetNotificationPrefs(address string) (NotificationPrefs, bool)
+}
+
+// BatchSubscriber is implemented by stores that can add many permanent subscriptions
+// at once, e.g. in a single transaction.
+type BatchSubscriber interface {
+	// SubscribeBatch subscribes every address, reporting for each whether it was
+	// subscribed newly.
+	SubscribeBatch(addresses []string) map[string]bool
+}
+
+// subscribeBatch subscribes addresses in one call when store supports it, or one by one.
+func subscribeBatch(store Store, addresses []string) map[string]bool {
+	if batcher, ok := store.(BatchSubscriber); ok {
+		return batcher.SubscribeBatch(addresses)
+	}
+	created := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		created[address] = store.Subscribe(address)
+	}
+	return created
 }
 
+// Flusher is implemented by stores that can force their writes to durable storage.
+type Flusher interface {
+	Flush() error
+}
+
+// BlockHashWindow is how many recent block hashes the MemoryStore retains.
+const BlockHashWindow = 256
+
 // MemoryStore holds subscriptions and transactions in memory.
+//
+// Transaction lists are copy-on-write: writers never modify the elements within the
+// length of a list already handed out, so readers take the lock only to grab the
+// current list and scan it unlocked. Large queries therefore don't stall the parser's
+// writes during catch-up bursts.
 type MemoryStore struct {
 	mu           sync.RWMutex
 	CurrentBlock int
 	subscribed   map[string]bool
+	expiries     map[string]time.Time // only for subscriptions with a TTL
+	prefs        map[string]NotificationPrefs
 	transactions map[string][]Transaction
+	blockHashes  map[int]string
+	clock        Clock // decides when TTL subscriptions lapse
+
+	// Memory accounting, see memory_budget.go.
+	usedBytes    int64
+	budgetBytes  int64
+	evictedTxs   int64
+	budgetWarned bool
+	budgetLogger *slog.Logger
 }
 
 func (m *MemoryStore) GetCurrentBlock() int {
@@ -31,47 +102,161 @@ func (m *MemoryStore) SetCurrentBlock(block int) {
 func NewMemoryStore() Store {
 	return &MemoryStore{
 		subscribed:   make(map[string]bool),
+		expiries:     make(map[string]time.Time),
+		prefs:        make(map[string]NotificationPrefs),
 		transactions: make(map[string][]Transaction),
+		blockHashes:  make(map[int]string),
+		clock:        SystemClock,
 	}
 }
 
+// SetClock replaces the time source used to expire TTL subscriptions.
+func (m *MemoryStore) SetClock(c Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = c
+}
+
 // Subscribe adds an address to the subscription set.
 // Returns true if subscribed newly, false if already subscribed.
 func (m *MemoryStore) Subscribe(address string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if m.subscribed[address] {
+	wasActive := m.isActive(address)
+	delete(m.expiries, address) // manual subscriptions are permanent
+	if wasActive {
 		return false
 	}
-	m.subscribed[address] = true
-	m.transactions[address] = []Transaction{}
+	m.activate(address)
+	return true
+}
+
+// SubscribeBatch adds permanent subscriptions under a single lock.
+func (m *MemoryStore) SubscribeBatch(addresses []string) map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	created := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		wasActive := m.isActive(address)
+		delete(m.expiries, address)
+		if !wasActive {
+			m.activate(address)
+		}
+		created[address] = !wasActive
+	}
+	return created
+}
+
+// SubscribeUntil adds or extends a subscription that expires at expiresAt.
+// Returns true if the address was not already subscribed.
+func (m *MemoryStore) SubscribeUntil(address string, expiresAt time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wasActive := m.isActive(address)
+	if wasActive {
+		if current, ok := m.expiries[address]; ok && expiresAt.After(current) {
+			m.expiries[address] = expiresAt
+		}
+		return false
+	}
+	m.activate(address)
+	m.expiries[address] = expiresAt
 	return true
 }
 
-// IsSubscribed checks if an address is subscribed.
+// IsSubscribed checks if an address is subscribed and its subscription has not expired.
 func (m *MemoryStore) IsSubscribed(address string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.subscribed[address]
+	return m.isActive(address)
+}
+
+// SubscriptionCount returns the number of unexpired subscriptions.
+func (m *MemoryStore) SubscriptionCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	count := 0
+	for address := range m.subscribed {
+		if m.isActive(address) {
+			count++
+		}
+	}
+	return count
 }
 
-// AddTransaction appends a transaction to an address’s list if subscribed.
+// Subscriptions returns every active subscription, sorted by address.
+func (m *MemoryStore) Subscriptions() []SubscriptionState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := make([]SubscriptionState, 0, len(m.subscribed))
+	for address := range m.subscribed {
+		if !m.isActive(address) {
+			continue
+		}
+		sub := SubscriptionState{Address: address, Prefs: m.prefs[address]}
+		if expiresAt, ok := m.expiries[address]; ok {
+			sub.ExpiresAt = &expiresAt
+		}
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Address < subs[j].Address })
+	return subs
+}
+
+// isActive reports whether address has an unexpired subscription. Callers hold m.mu.
+func (m *MemoryStore) isActive(address string) bool {
+	if !m.subscribed[address] {
+		return false
+	}
+	expiresAt, ok := m.expiries[address]
+	return !ok || m.clock.Now().Before(expiresAt)
+}
+
+// activate marks address as subscribed, keeping any history from a lapsed subscription.
+func (m *MemoryStore) activate(address string) {
+	m.subscribed[address] = true
+	if _, ok := m.transactions[address]; !ok {
+		m.transactions[address] = []Transaction{}
+	}
+}
+
+// AddTransaction adds a transaction to an address’s list if subscribed, keeping the
+// list in block order when older history is backfilled.
 func (m *MemoryStore) AddTransaction(address string, tx Transaction) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if m.subscribed[address] {
-		m.transactions[address] = append(m.transactions[address], tx)
+	if m.isActive(address) {
+		txs := m.transactions[address]
+		i := sort.Search(len(txs), func(i int) bool { return txs[i].Block > tx.Block })
+		if i == len(txs) {
+			// Appending only writes past the length of every snapshot.
+			m.transactions[address] = append(txs, tx)
+		} else {
+			// Inserting would shift elements snapshots still see, so copy instead.
+			m.transactions[address] = slices.Concat(txs[:i], []Transaction{tx}, txs[i:])
+		}
+		m.usedBytes += estimateTxBytes(tx)
+		m.enforceBudgetLocked()
 	}
 }
 
-// GetTransactions returns the transactions for a given address.
-func (m *MemoryStore) GetTransactions(address string) []Transaction {
+// snapshot returns the current transaction list of address. It must only be read:
+// writers leave its elements untouched, so no lock is needed to scan it.
+func (m *MemoryStore) snapshot(address string) ([]Transaction, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-
 	txs, ok := m.transactions[address]
+	return txs, ok
+}
+
+// GetTransactions returns the transactions for a given address.
+func (m *MemoryStore) GetTransactions(address string) []Transaction {
+	txs, ok := m.snapshot(address)
 	if !ok {
 		return []Transaction{}
 	}
@@ -81,3 +266,169 @@ func (m *MemoryStore) GetTransactions(address string) []Transaction {
 	copy(cp, txs)
 	return cp
 }
+
+// GetTransactionsInRange returns the transactions of address within [fromBlock, toBlock].
+// Each address's list is kept in block order, so the bounds are found by binary search
+// instead of scanning the whole history.
+func (m *MemoryStore) GetTransactionsInRange(address string, fromBlock, toBlock int64) []Transaction {
+	txs, _ := m.snapshot(address)
+	start := sort.Search(len(txs), func(i int) bool { return txs[i].Block >= fromBlock })
+	end := sort.Search(len(txs), func(i int) bool { return txs[i].Block > toBlock })
+	if start >= end {
+		return []Transaction{}
+	}
+
+	// Return a copy to avoid external mutation
+	cp := make([]Transaction, end-start)
+	copy(cp, txs[start:end])
+	return cp
+}
+
+// QueryTransactions returns the page of address's transactions selected by q and the total match count.
+// It scans a snapshot, so writes made meanwhile are not reflected.
+func (m *MemoryStore) QueryTransactions(address string, q TxQuery) ([]Transaction, int) {
+	txs, _ := m.snapshot(address)
+	start := sort.Search(len(txs), func(i int) bool { return txs[i].Block >= q.FromBlock })
+	pager := newTxPager(q)
+	for _, tx := range txs[start:] {
+		if tx.Block > q.ToBlock {
+			break
+		}
+		pager.add(address, tx)
+	}
+	return pager.page, pager.total
+}
+
+// SetBlockHash records a block hash and forgets hashes older than BlockHashWindow.
+func (m *MemoryStore) SetBlockHash(block int, hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blockHashes[block] = hash
+	delete(m.blockHashes, block-BlockHashWindow)
+}
+
+// GetBlockHash returns the recorded hash of a block.
+func (m *MemoryStore) GetBlockHash(block int) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hash, ok := m.blockHashes[block]
+	return hash, ok
+}
+
+// RollbackTo drops transactions and block hashes above block.
+// Transactions are kept in block order, so each list is truncated in place. Truncated
+// lists are clipped so later appends reallocate rather than overwrite snapshots.
+func (m *MemoryStore) RollbackTo(block int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for address, txs := range m.transactions {
+		keep := len(txs)
+		for keep > 0 && txs[keep-1].Block > int64(block) {
+			keep--
+			m.usedBytes -= estimateTxBytes(txs[keep])
+		}
+		if keep < len(txs) {
+			m.transactions[address] = slices.Clip(txs[:keep])
+		}
+	}
+	for b := range m.blockHashes {
+		if b > block {
+			delete(m.blockHashes, b)
+		}
+	}
+}
+
+// PruneBefore drops transactions below block, returning how many were dropped.
+func (m *MemoryStore) PruneBefore(block int64) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pruned := 0
+	for address, txs := range m.transactions {
+		drop := 0
+		for drop < len(txs) && txs[drop].Block < block {
+			m.usedBytes -= estimateTxBytes(txs[drop])
+			drop++
+		}
+		if drop > 0 {
+			// Reslicing keeps lists already handed to readers intact.
+			m.transactions[address] = txs[drop:]
+			pruned += drop
+		}
+	}
+	return pruned
+}
+
+// TrimAddresses drops the oldest transactions of every address holding more than
+// limit, returning how many were dropped.
+func (m *MemoryStore) TrimAddresses(limit int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trimmed := 0
+	for address, txs := range m.transactions {
+		excess := len(txs) - limit
+		if excess <= 0 {
+			continue
+		}
+		for _, tx := range txs[:excess] {
+			m.usedBytes -= estimateTxBytes(tx)
+		}
+		m.transactions[address] = txs[excess:]
+		trimmed += excess
+	}
+	return trimmed
+}
+
+// PurgeExpiredSubscriptions forgets subscriptions whose TTL has passed, with their
+// notification preferences, returning how many were purged. Transaction history is
+// kept, subject to retention, in case the address is subscribed again.
+func (m *MemoryStore) PurgeExpiredSubscriptions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	purged := 0
+	for address, expiresAt := range m.expiries {
+		if now.Before(expiresAt) {
+			continue
+		}
+		delete(m.subscribed, address)
+		delete(m.expiries, address)
+		delete(m.prefs, address)
+		if len(m.transactions[address]) == 0 {
+			delete(m.transactions, address)
+		}
+		purged++
+	}
+	return purged
+}
+
+// SetNotificationPrefs stores notification preferences for a subscribed address.
+func (m *MemoryStore) SetNotificationPrefs(address string, prefs NotificationPrefs) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.isActive(address) {
+		return false
+	}
+	m.prefs[address] = prefs
+	return true
+}
+
+// GetNotificationPrefs returns the notification preferences of a subscribed address.
+func (m *MemoryStore) GetNotificationPrefs(address string) (NotificationPrefs, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.isActive(address) {
+		return NotificationPrefs{}, false
+	}
+	// Copy the channel list to avoid external mutation
+	prefs := m.prefs[address]
+	prefs.Channels = slices.Clone(prefs.Channels)
+	return prefs, true
+}
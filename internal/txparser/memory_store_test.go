@@ -0,0 +1,61 @@
+package txparser
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+// TestMemoryStoreSnapshots verifies lists handed to readers are never modified by later
+// appends, backfilled inserts, rollbacks or evictions, and that queries run alongside writes.
+func TestMemoryStoreSnapshots(t *testing.T) {
+	store := NewMemoryStore().(*MemoryStore)
+	store.Subscribe("0xa")
+	for block := int64(2); block <= 10; block += 2 {
+		store.AddTransaction("0xa", Transaction{Hash: fmt.Sprintf("0x%d", block), Block: block})
+	}
+
+	snapshot, _ := store.snapshot("0xa")
+	want := fmt.Sprint(snapshot)
+	store.AddTransaction("0xa", Transaction{Hash: "0x5", Block: 5}) // backfilled insert
+	store.RollbackTo(6)
+	store.AddTransaction("0xa", Transaction{Hash: "0x7", Block: 7}) // append after truncation
+	store.evictOldestLocked(store.usedBytes - 1)
+	if got := fmt.Sprint(snapshot); got != want {
+		t.Fatalf("expected snapshot to stay %s, got %s", want, got)
+	}
+	var blocks []int64
+	for _, tx := range store.GetTransactions("0xa") {
+		blocks = append(blocks, tx.Block)
+	}
+	if fmt.Sprint(blocks) != "[4 5 6 7]" {
+		t.Errorf("expected blocks [4 5 6 7], got %v", blocks)
+	}
+
+	// Run with -race: queries scan unlocked while the parser writes.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for block := int64(100); block < 2000; block++ {
+			store.AddTransaction("0xa", Transaction{Block: block})
+			if block%100 == 0 {
+				store.AddTransaction("0xa", Transaction{Block: block - 50})
+				store.RollbackTo(int(block - 10))
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		txs, total := store.QueryTransactions("0xa", TxQuery{ToBlock: math.MaxInt64})
+		if len(txs) != total {
+			t.Fatalf("expected an unpaged query to return all %d matches, got %d", total, len(txs))
+		}
+		for j := 1; j < len(txs); j++ {
+			if txs[j].Block < txs[j-1].Block {
+				t.Fatalf("expected a block-ordered snapshot, got %d after %d", txs[j].Block, txs[j-1].Block)
+			}
+		}
+	}
+	wg.Wait()
+}
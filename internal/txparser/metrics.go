@@ -0,0 +1,429 @@
+package txparser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// Metrics receives operational measurements from EthParser, RPCClient, HTTPServer and
+// WebhookNotifier.
+// Implementations must be safe for concurrent use. PrometheusMetrics serves them on
+// /metrics; other collectors can be plugged in by implementing this interface.
+type Metrics interface {
+	// BlockParsed is called once a block's transactions are stored and it becomes current.
+	BlockParsed(block int)
+	// TransactionStored is called for every transaction stored for a subscribed address.
+	TransactionStored()
+	// ChainLag reports how many blocks the parser is behind the chain tip.
+	ChainLag(blocks int)
+	// Subscribers reports the number of active subscriptions.
+	Subscribers(n int)
+	// RPCCall records one JSON-RPC request ("batch" for batch requests) and its outcome.
+	RPCCall(method string, duration time.Duration, err error)
+	// HTTPRequest records one served API request by route pattern.
+	HTTPRequest(route string, status int, duration time.Duration)
+	// WebhookAttempt records one webhook POST to target, the receiving host.
+	WebhookAttempt(target string, duration time.Duration, err error)
+	// WebhookDelivery records the outcome of a webhook delivery after attempts tries.
+	WebhookDelivery(target string, attempts int, delivered bool)
+	// HeadReconnect records a restored newHeads subscription and the blocks mined while
+	// it was down.
+	HeadReconnect(gap int)
+	// TransactionsEvicted records n stored transactions dropped by retention for reason.
+	TransactionsEvicted(reason string, n int)
+}
+
+// NoopMetrics discards all measurements. It is the default.
+type NoopMetrics struct{}
+
+func (NoopMetrics) BlockParsed(int)                        {}
+func (NoopMetrics) TransactionStored()                     {}
+func (NoopMetrics) ChainLag(int)                           {}
+func (NoopMetrics) Subscribers(int)                        {}
+func (NoopMetrics) RPCCall(string, time.Duration, error)   {}
+func (NoopMetrics) HTTPRequest(string, int, time.Duration) {}
+
+func (NoopMetrics) WebhookAttempt(string, time.Duration, error) {}
+func (NoopMetrics) WebhookDelivery(string, int, bool)           {}
+func (NoopMetrics) HeadReconnect(int)                           {}
+func (NoopMetrics) TransactionsEvicted(string, int)             {}
+
+// WithoutChainGauges wraps m for a parser other than the primary one, e.g. of an
+// additional chain or a project, so it reports RPC, storage and eviction metrics into
+// the same collector while the block, chain lag and subscriber metrics stay the
+// primary parser's.
+func WithoutChainGauges(m Metrics) Metrics {
+	return secondaryMetrics{m}
+}
+
+type secondaryMetrics struct{ Metrics }
+
+func (secondaryMetrics) BlockParsed(int) {}
+func (secondaryMetrics) ChainLag(int)    {}
+func (secondaryMetrics) Subscribers(int) {}
+
+// SubscriptionCounter is implemented by stores that can count active subscriptions.
+type SubscriptionCounter interface {
+	SubscriptionCount() int
+}
+
+// latencyBuckets are the histogram upper bounds in seconds, matching Prometheus client defaults.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram is a cumulative latency histogram in the Prometheus model.
+type histogram struct {
+	counts []uint64 // per bucket of latencyBuckets, non-cumulative
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets))
+	}
+	seconds := d.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// PrometheusMetrics collects Metrics in memory and serves them in the Prometheus text
+// exposition format. It needs no client library.
+type PrometheusMetrics struct {
+	mu           sync.Mutex
+	blocksParsed uint64
+	currentBlock int
+	txsStored    uint64
+	chainLag     int
+	subscribers  int
+	rpcLatency   map[string]*histogram // by method
+	rpcErrors    map[string]uint64     // by method
+	httpLatency  map[string]*histogram // by route
+	httpRequests map[[2]string]uint64  // by route and status code
+
+	webhookLatency    map[string]*histogram // by target
+	webhookDeliveries map[[2]string]uint64  // by target and outcome
+	webhookRetryDepth map[string]int        // retries of the target's last finished delivery
+
+	headReconnects uint64
+	headGapBlocks  uint64 // blocks missed across all reconnects
+	lastHeadGap    int
+
+	evicted map[string]uint64 // by reason
+
+	memory MemoryReporter // store memory accounting read on each scrape, nil if unavailable
+}
+
+// NewPrometheusMetrics creates an empty collector.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		rpcLatency:   make(map[string]*histogram),
+		rpcErrors:    make(map[string]uint64),
+		httpLatency:  make(map[string]*histogram),
+		httpRequests: make(map[[2]string]uint64),
+
+		webhookLatency:    make(map[string]*histogram),
+		webhookDeliveries: make(map[[2]string]uint64),
+		webhookRetryDepth: make(map[string]int),
+
+		evicted: make(map[string]uint64),
+	}
+}
+
+func (m *PrometheusMetrics) BlockParsed(block int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocksParsed++
+	m.currentBlock = block
+}
+
+func (m *PrometheusMetrics) TransactionStored() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txsStored++
+}
+
+func (m *PrometheusMetrics) ChainLag(blocks int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chainLag = blocks
+}
+
+func (m *PrometheusMetrics) Subscribers(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = n
+}
+
+func (m *PrometheusMetrics) RPCCall(method string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	observe(m.rpcLatency, method, duration)
+	if err != nil {
+		m.rpcErrors[method]++
+	}
+}
+
+func (m *PrometheusMetrics) HTTPRequest(route string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	observe(m.httpLatency, route, duration)
+	m.httpRequests[[2]string{route, strconv.Itoa(status)}]++
+}
+
+func (m *PrometheusMetrics) WebhookAttempt(target string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	observe(m.webhookLatency, target, duration)
+}
+
+func (m *PrometheusMetrics) WebhookDelivery(target string, attempts int, delivered bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcome := "failed"
+	if delivered {
+		outcome = "delivered"
+	}
+	m.webhookDeliveries[[2]string{target, outcome}]++
+	m.webhookRetryDepth[target] = max(attempts-1, 0)
+}
+
+func (m *PrometheusMetrics) HeadReconnect(gap int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.headReconnects++
+	m.headGapBlocks += uint64(gap)
+	m.lastHeadGap = gap
+}
+
+func (m *PrometheusMetrics) TransactionsEvicted(reason string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evicted[reason] += uint64(n)
+}
+
+// SetMemoryReporter exports the store's estimated memory use, budget and headroom as
+// gauges, read on each scrape.
+func (m *PrometheusMetrics) SetMemoryReporter(memory MemoryReporter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memory = memory
+}
+
+// observe adds d to the histogram of key, creating it on first use.
+func observe(histograms map[string]*histogram, key string, d time.Duration) {
+	h, ok := histograms[key]
+	if !ok {
+		h = &histogram{}
+		histograms[key] = h
+	}
+	h.observe(d)
+}
+
+// ServeHTTP handles GET /metrics.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	writeMetric(&b, "txparser_blocks_parsed_total", "counter", "Blocks parsed since startup.", float64(m.blocksParsed))
+	writeMetric(&b, "txparser_current_block", "gauge", "Last parsed block number.", float64(m.currentBlock))
+	writeMetric(&b, "txparser_transactions_stored_total", "counter", "Transactions stored for subscribed addresses.", float64(m.txsStored))
+	writeMetric(&b, "txparser_chain_lag_blocks", "gauge", "Blocks between the last parsed block and the chain tip.", float64(m.chainLag))
+	writeMetric(&b, "txparser_subscribers", "gauge", "Active address subscriptions.", float64(m.subscribers))
+	writeHistograms(&b, "txparser_rpc_request_duration_seconds", "JSON-RPC request latency by method.", "method", m.rpcLatency)
+
+	b.WriteString("# HELP txparser_rpc_errors_total Failed JSON-RPC requests by method.\n")
+	b.WriteString("# TYPE txparser_rpc_errors_total counter\n")
+	for _, method := range sortedKeys(m.rpcErrors) {
+		fmt.Fprintf(&b, "txparser_rpc_errors_total{method=%q} %d\n", method, m.rpcErrors[method])
+	}
+
+	b.WriteString("# HELP txparser_http_requests_total Served API requests by route and status code.\n")
+	b.WriteString("# TYPE txparser_http_requests_total counter\n")
+	for _, key := range sortedPairs(m.httpRequests) {
+		fmt.Fprintf(&b, "txparser_http_requests_total{route=%q,code=%q} %d\n", key[0], key[1], m.httpRequests[key])
+	}
+	writeHistograms(&b, "txparser_http_request_duration_seconds", "API request latency by route.", "route", m.httpLatency)
+
+	b.WriteString("# HELP txparser_webhook_deliveries_total Finished webhook deliveries by target host and outcome.\n")
+	b.WriteString("# TYPE txparser_webhook_deliveries_total counter\n")
+	for _, key := range sortedPairs(m.webhookDeliveries) {
+		fmt.Fprintf(&b, "txparser_webhook_deliveries_total{target=%q,outcome=%q} %d\n", key[0], key[1], m.webhookDeliveries[key])
+	}
+	b.WriteString("# HELP txparser_webhook_retry_depth Retries needed by the last finished webhook delivery to each target host.\n")
+	b.WriteString("# TYPE txparser_webhook_retry_depth gauge\n")
+	for _, target := range sortedKeys(m.webhookRetryDepth) {
+		fmt.Fprintf(&b, "txparser_webhook_retry_depth{target=%q} %d\n", target, m.webhookRetryDepth[target])
+	}
+	writeHistograms(&b, "txparser_webhook_attempt_duration_seconds", "Webhook POST latency by target host.", "target", m.webhookLatency)
+	writeMetric(&b, "txparser_ws_reconnects_total", "counter", "Reconnects of the newHeads WebSocket subscription.", float64(m.headReconnects))
+	writeMetric(&b, "txparser_ws_gap_blocks_total", "counter", "Blocks mined while the newHeads subscription was down, backfilled over HTTP.", float64(m.headGapBlocks))
+	b.WriteString("# HELP txparser_transactions_evicted_total Stored transactions dropped by retention, by reason.\n")
+	b.WriteString("# TYPE txparser_transactions_evicted_total counter\n")
+	for _, reason := range sortedKeys(m.evicted) {
+		fmt.Fprintf(&b, "txparser_transactions_evicted_total{reason=%q} %d\n", reason, m.evicted[reason])
+	}
+	writeMetric(&b, "txparser_ws_last_gap_blocks", "gauge", "Blocks missed during the last newHeads reconnect.", float64(m.lastHeadGap))
+	if m.memory != nil {
+		usage := m.memory.MemoryUsage()
+		writeMetric(&b, "txparser_store_memory_used_bytes", "gauge", "Estimated memory held by stored transactions.", float64(usage.UsedBytes))
+		writeMetric(&b, "txparser_store_memory_budget_bytes", "gauge", "Configured store memory budget, 0 if unlimited.", float64(usage.BudgetBytes))
+		if usage.BudgetBytes > 0 {
+			writeMetric(&b, "txparser_store_memory_headroom_bytes", "gauge", "Store memory left before the budget is reached.", float64(usage.HeadroomBytes))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeMetric(b *strings.Builder, name, kind, help string, value float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, formatFloat(value))
+}
+
+// writeHistograms writes one histogram per label value with cumulative buckets.
+func writeHistograms(b *strings.Builder, name, help, label string, histograms map[string]*histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, key := range sortedKeys(histograms) {
+		h := histograms[key]
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "%s_bucket{%s=%q,le=%q} %d\n", name, label, key, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", name, label, key, h.count)
+		fmt.Fprintf(b, "%s_sum{%s=%q} %s\n", name, label, key, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s=%q} %d\n", name, label, key, h.count)
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPairs[V any](m map[[2]string]V) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// SetMetrics replaces the default NoopMetrics.
+func (p *EthParser) SetMetrics(m Metrics) {
+	p.metrics = m
+}
+
+// reportSubscribers updates the subscriber gauge when the store can count subscriptions.
+func (p *EthParser) reportSubscribers() {
+	if counter, ok := p.store.(SubscriptionCounter); ok {
+		p.metrics.Subscribers(counter.SubscriptionCount())
+	}
+}
+
+// SetMetrics records the latency and errors of every request.
+func (r *RPCClient) SetMetrics(m Metrics) {
+	r.metrics = m
+}
+
+// SetMetrics records the latency, retries and outcome of every delivery.
+func (n *WebhookNotifier) SetMetrics(m Metrics) {
+	n.metrics = m
+}
+
+// SetMetrics records every API request and serves m on /metrics if it is an http.Handler.
+func (s *HTTPServer) SetMetrics(m Metrics) {
+	s.metrics = m
+}
+
+// routeKey is the request context key under which instrument collects the route
+// template of the request being served.
+type routeKey struct{}
+
+// instrument records the route, status and latency of requests served by next.
+// The route is the template of the matched ServeMux pattern, so path parameters and
+// project names don't inflate cardinality.
+func (s *HTTPServer) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		route := new(string)
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+		if *route == "" {
+			*route = "unmatched"
+		}
+		s.metrics.HTTPRequest(*route, sw.status, time.Since(start))
+	})
+}
+
+// recordRoute notes the pattern mux matched for a request for instrument, unless a
+// router mounted below mux already noted a more specific one. Middlewares between
+// instrument and mux may copy the request, so its Pattern isn't visible to instrument.
+func recordRoute(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok && *route == "" {
+			*route = r.Pattern
+		}
+	})
+}
+
+// statusResponseWriter remembers the status code written through it.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusResponseWriter) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,374 @@
+package txparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ewmaWeight is the weight of the newest sample in the latency and error-rate averages.
+const ewmaWeight = 0.2
+
+// unhealthyErrorRate is the error-rate average above which an endpoint is avoided.
+const unhealthyErrorRate = 0.5
+
+// Failover settings: after FailoverThreshold consecutive failures an endpoint is taken
+// out of rotation for FailoverCooldown, then tried again by the next call or probe.
+const (
+	FailoverThreshold = 3
+	FailoverCooldown  = 30 * time.Second
+)
+
+// endpointState tracks the observed performance of one RPC endpoint.
+type endpointState struct {
+	client    *RPCClient
+	samples   int64
+	latency   float64 // EWMA in seconds
+	errorRate float64 // EWMA of failures, 0..1
+
+	failures  int       // consecutive failures
+	downUntil time.Time // out of rotation until then
+}
+
+// available reports whether e is in rotation at now.
+func (e *endpointState) available(now time.Time) bool {
+	return !now.Before(e.downUntil)
+}
+
+// endpointFailure reports whether err reflects on the endpoint rather than the request:
+// a missing transaction or a token without decimals is a valid answer.
+func endpointFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrTransactionNotFound) && !errors.Is(err, ErrNoDecimals)
+}
+
+// score ranks endpoints for time-sensitive calls; lower is better.
+// Unmeasured endpoints score zero so they are tried early.
+func (e *endpointState) score() float64 {
+	if e.samples == 0 {
+		return 0
+	}
+	return e.latency * (1 + 4*e.errorRate)
+}
+
+// EndpointStats is a snapshot of an endpoint's measured latency and error rate.
+type EndpointStats struct {
+	Endpoint  string  `json:"endpoint"`
+	LatencyMs float64 `json:"latencyMs"`
+	ErrorRate float64 `json:"errorRate"`
+	Samples   int64   `json:"samples"`
+	Healthy   bool    `json:"healthy"`
+	Failures  int     `json:"consecutiveFailures"`
+}
+
+// MultiClient spreads JSON-RPC calls across several endpoints. Time-sensitive calls
+// (tip polling, hashes) go to the fastest healthy endpoint, while bulk block fetches
+// are spread round-robin across the remaining ones. A failed call fails over to the
+// other endpoints in rotation, and endpoints failing repeatedly are skipped for a while.
+type MultiClient struct {
+	mu        sync.Mutex
+	endpoints []*endpointState
+	nextBulk  int
+	clock     Clock
+}
+
+// NewMultiClient creates a MultiClient over the given endpoint URLs. Failed calls fail
+// over to the next endpoint at once rather than being retried, see SetRetryPolicy.
+func NewMultiClient(endpoints []string) (*MultiClient, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("at least one RPC endpoint is required")
+	}
+	m := &MultiClient{clock: SystemClock}
+	for _, endpoint := range endpoints {
+		client := NewJSONRPCClient(endpoint).(*RPCClient)
+		client.SetRetryPolicy(NoRetries)
+		m.endpoints = append(m.endpoints, &endpointState{client: client})
+	}
+	return m, nil
+}
+
+// SetStrictValidation controls response validation on every endpoint.
+func (m *MultiClient) SetStrictValidation(strict bool) {
+	for _, e := range m.endpoints {
+		e.client.SetStrictValidation(strict)
+	}
+}
+
+// SetClock replaces the time source used for failover cooldowns and retry backoff.
+func (m *MultiClient) SetClock(c Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = c
+	for _, e := range m.endpoints {
+		e.client.SetClock(c)
+	}
+}
+
+// SetMetrics records the latency and errors of requests to every endpoint.
+func (m *MultiClient) SetMetrics(metrics Metrics) {
+	for _, e := range m.endpoints {
+		e.client.SetMetrics(metrics)
+	}
+}
+
+// fastest returns the healthy endpoint with the best score, or the best overall if none is healthy.
+func (m *MultiClient) fastest() *endpointState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.fastestLocked()
+}
+
+func (m *MultiClient) fastestLocked() *endpointState {
+	now := m.clock.Now()
+	var best, bestUp, bestAny *endpointState
+	for _, e := range m.endpoints {
+		if bestAny == nil || e.score() < bestAny.score() {
+			bestAny = e
+		}
+		if !e.available(now) {
+			continue
+		}
+		if bestUp == nil || e.score() < bestUp.score() {
+			bestUp = e
+		}
+		if e.errorRate < unhealthyErrorRate && (best == nil || e.score() < best.score()) {
+			best = e
+		}
+	}
+	switch {
+	case best != nil:
+		return best
+	case bestUp != nil:
+		return bestUp
+	default:
+		return bestAny
+	}
+}
+
+// bulk returns the next healthy endpoint other than the fastest, round-robin.
+// With a single usable endpoint it returns that one.
+func (m *MultiClient) bulk() *endpointState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fastest := m.fastestLocked()
+	now := m.clock.Now()
+	for i := 0; i < len(m.endpoints); i++ {
+		e := m.endpoints[(m.nextBulk+i)%len(m.endpoints)]
+		if e != fastest && e.available(now) && e.errorRate < unhealthyErrorRate {
+			m.nextBulk = (m.nextBulk + i + 1) % len(m.endpoints)
+			return e
+		}
+	}
+	return fastest
+}
+
+// observe records the outcome of a call against e.
+func (m *MultiClient) observe(e *endpointState, elapsed time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failed := 0.0
+	if endpointFailure(err) {
+		failed = 1
+		e.failures++
+		if e.failures >= FailoverThreshold {
+			e.downUntil = m.clock.Now().Add(FailoverCooldown)
+		}
+	} else {
+		e.failures = 0
+		e.downUntil = time.Time{}
+	}
+	if e.samples == 0 {
+		e.latency, e.errorRate = elapsed.Seconds(), failed
+	} else {
+		e.latency = ewmaWeight*elapsed.Seconds() + (1-ewmaWeight)*e.latency
+		e.errorRate = ewmaWeight*failed + (1-ewmaWeight)*e.errorRate
+	}
+	e.samples++
+}
+
+// timed runs fn against e and records its latency and outcome. Calls cut short by
+// ctx are not recorded, since they say nothing about the endpoint.
+func timed[T any](ctx context.Context, m *MultiClient, e *endpointState, fn func(*RPCClient) (T, error)) (T, error) {
+	start := time.Now()
+	result, err := fn(e.client)
+	if ctx.Err() == nil {
+		m.observe(e, time.Since(start), err)
+	}
+	return result, err
+}
+
+// failover runs fn against first and, if the endpoint fails, against every other
+// endpoint in rotation from fastest to slowest until one answers or ctx is done.
+func failover[T any](ctx context.Context, m *MultiClient, first *endpointState, fn func(*RPCClient) (T, error)) (T, error) {
+	result, err := timed(ctx, m, first, fn)
+	if !endpointFailure(err) {
+		return result, err
+	}
+	for _, e := range m.fallbacks(first) {
+		if ctx.Err() != nil {
+			return result, err
+		}
+		if result, err = timed(ctx, m, e, fn); !endpointFailure(err) {
+			return result, err
+		}
+	}
+	return result, err
+}
+
+// fallbacks returns the endpoints in rotation other than tried, best score first.
+func (m *MultiClient) fallbacks(tried *endpointState) []*endpointState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	var others []*endpointState
+	for _, e := range m.endpoints {
+		if e != tried && e.available(now) {
+			others = append(others, e)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool { return others[i].score() < others[j].score() })
+	return others
+}
+
+// Stats returns the measured performance of every endpoint.
+func (m *MultiClient) Stats() []EndpointStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]EndpointStats, 0, len(m.endpoints))
+	now := m.clock.Now()
+	for _, e := range m.endpoints {
+		stats = append(stats, EndpointStats{
+			Endpoint:  e.client.endpoint,
+			LatencyMs: math.Round(e.latency*1e6) / 1e3,
+			ErrorRate: e.errorRate,
+			Samples:   e.samples,
+			Healthy:   e.available(now) && e.errorRate < unhealthyErrorRate,
+			Failures:  e.failures,
+		})
+	}
+	return stats
+}
+
+// StartProbing measures every endpoint with eth_blockNumber each interval, so idle
+// endpoints keep fresh latency figures and endpoints out of rotation return as soon
+// as they answer again. It returns when ctx is canceled.
+func (m *MultiClient) StartProbing(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, e := range m.endpoints {
+				timed(ctx, m, e, func(c *RPCClient) (string, error) { return c.BlockNumber(ctx) })
+			}
+		}
+	}
+}
+
+// BlockNumber polls the chain tip on the fastest endpoint.
+func (m *MultiClient) BlockNumber(ctx context.Context) (string, error) {
+	return failover(ctx, m, m.fastest(), func(c *RPCClient) (string, error) {
+		return c.BlockNumber(ctx)
+	})
+}
+
+// FinalizedBlockNumber queries the fastest endpoint.
+func (m *MultiClient) FinalizedBlockNumber(ctx context.Context) (string, error) {
+	return failover(ctx, m, m.fastest(), func(c *RPCClient) (string, error) {
+		return c.FinalizedBlockNumber(ctx)
+	})
+}
+
+// GetBlockHeader queries the fastest endpoint.
+func (m *MultiClient) GetBlockHeader(ctx context.Context, blockNum int64) (BlockHeader, error) {
+	return failover(ctx, m, m.fastest(), func(c *RPCClient) (BlockHeader, error) {
+		return c.GetBlockHeader(ctx, blockNum)
+	})
+}
+
+// GetBlockHash queries the fastest endpoint.
+func (m *MultiClient) GetBlockHash(ctx context.Context, blockNum int64) (string, error) {
+	return failover(ctx, m, m.fastest(), func(c *RPCClient) (string, error) {
+		return c.GetBlockHash(ctx, blockNum)
+	})
+}
+
+// GetTransactionByHash queries the fastest endpoint.
+func (m *MultiClient) GetTransactionByHash(ctx context.Context, hash string) (RawTx, error) {
+	return failover(ctx, m, m.fastest(), func(c *RPCClient) (RawTx, error) {
+		return c.GetTransactionByHash(ctx, hash)
+	})
+}
+
+// GetBlockByNumber fetches a full block from a bulk endpoint.
+func (m *MultiClient) GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error) {
+	return failover(ctx, m, m.bulk(), func(c *RPCClient) (BlockResponse, error) {
+		return c.GetBlockByNumber(ctx, blockNum)
+	})
+}
+
+// GetBlocksByNumber fetches a batch of blocks from a bulk endpoint.
+func (m *MultiClient) GetBlocksByNumber(ctx context.Context, from, to int64) ([]BlockResponse, error) {
+	return failover(ctx, m, m.bulk(), func(c *RPCClient) ([]BlockResponse, error) {
+		return c.GetBlocksByNumber(ctx, from, to)
+	})
+}
+
+// GetTransferLogs fetches a block's token transfer logs from a bulk endpoint.
+func (m *MultiClient) GetTransferLogs(ctx context.Context, blockNum int64) ([]RawLog, error) {
+	return failover(ctx, m, m.bulk(), func(c *RPCClient) ([]RawLog, error) {
+		return c.GetTransferLogs(ctx, blockNum)
+	})
+}
+
+// GetBlockLogs fetches all of a block's logs from a bulk endpoint.
+func (m *MultiClient) GetBlockLogs(ctx context.Context, blockNum int64) ([]RawLog, error) {
+	return failover(ctx, m, m.bulk(), func(c *RPCClient) ([]RawLog, error) {
+		return c.GetBlockLogs(ctx, blockNum)
+	})
+}
+
+// GetAddressTransferLogs fetches an address's token transfer logs from a bulk endpoint.
+func (m *MultiClient) GetAddressTransferLogs(ctx context.Context, address string, fromBlock, toBlock int64) ([]RawLog, error) {
+	return failover(ctx, m, m.bulk(), func(c *RPCClient) ([]RawLog, error) {
+		return c.GetAddressTransferLogs(ctx, address, fromBlock, toBlock)
+	})
+}
+
+// TokenDecimals queries the fastest endpoint.
+func (m *MultiClient) TokenDecimals(ctx context.Context, contract string) (int, error) {
+	return failover(ctx, m, m.fastest(), func(c *RPCClient) (int, error) {
+		return c.TokenDecimals(ctx, contract)
+	})
+}
+
+// StreamBlockTransactions streams a block from a bulk endpoint. It does not fail over,
+// since fn may already have seen part of the block.
+func (m *MultiClient) StreamBlockTransactions(ctx context.Context, blockNum int64, fn func(RawTx) error) (StreamedBlock, error) {
+	return timed(ctx, m, m.bulk(), func(c *RPCClient) (StreamedBlock, error) {
+		return c.StreamBlockTransactions(ctx, blockNum, fn)
+	})
+}
+
+// DetectCapabilities probes the fastest endpoint.
+func (m *MultiClient) DetectCapabilities(ctx context.Context) Capabilities {
+	return m.fastest().client.DetectCapabilities(ctx)
+}
+
+// CheckHealth succeeds if any endpoint answers eth_blockNumber.
+func (m *MultiClient) CheckHealth(ctx context.Context) error {
+	var lastErr error
+	for _, e := range m.endpoints {
+		if _, lastErr = timed(ctx, m, e, func(c *RPCClient) (string, error) { return c.BlockNumber(ctx) }); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,74 @@
+package txparser
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Notification channels a subscription can opt into.
+const (
+	ChannelWebhook   = "webhook"
+	ChannelWebSocket = "websocket"
+	ChannelSSE       = "sse"
+)
+
+// tokenTransferSelectors are the ERC-20 transfer and transferFrom method selectors.
+var tokenTransferSelectors = []string{"0xa9059cbb", "0x23b872dd"}
+
+// NotificationPrefs selects which matched transactions notify a subscriber, and how.
+// The zero value notifies on every transaction over every channel.
+type NotificationPrefs struct {
+	Direction          Direction `json:"direction,omitempty"` // in, out, or empty for both
+	MinValue           string    `json:"minValue,omitempty"`  // wei, decimal or 0x hex
+	TokenTransfersOnly bool      `json:"tokenTransfersOnly,omitempty"`
+	Tags               []string  `json:"tags,omitempty"`       // rule tags of which one is required; empty means any
+	Channels           []string  `json:"channels,omitempty"`   // empty means all channels
+	WebhookURL         string    `json:"webhookUrl,omitempty"` // http(s) URL for the webhook channel
+}
+
+// Validate checks the preferences for unknown directions, channels or malformed amounts.
+func (n NotificationPrefs) Validate() error {
+	if n.Direction != "" && n.Direction != DirectionIn && n.Direction != DirectionOut {
+		return fmt.Errorf("invalid direction %q", n.Direction)
+	}
+	if _, err := parseOptionalWei(n.MinValue); err != nil {
+		return fmt.Errorf("minValue: %w", err)
+	}
+	if n.WebhookURL != "" {
+		if u, err := url.Parse(n.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhookUrl %q must be an http(s) URL", n.WebhookURL)
+		}
+	}
+	for _, channel := range n.Channels {
+		switch channel {
+		case ChannelWebhook, ChannelWebSocket, ChannelSSE:
+		default:
+			return fmt.Errorf("unknown channel %q", channel)
+		}
+	}
+	return nil
+}
+
+// Wants reports whether tx, matched for address, should notify over channel.
+func (n NotificationPrefs) Wants(channel, address string, tx Transaction, raw RawTx) bool {
+	if len(n.Channels) > 0 && !containsString(n.Channels, channel) {
+		return false
+	}
+	in := newRuleInput(address, tx, raw)
+	if n.Direction != "" && n.Direction != in.direction() {
+		return false
+	}
+	if len(n.Tags) > 0 && !containsAny(n.Tags, tx.Tags) {
+		return false
+	}
+	if n.TokenTransfersOnly && tx.MatchType != MatchTypeToken && !containsString(tokenTransferSelectors, in.selector) {
+		return false
+	}
+	if minValue, _ := parseOptionalWei(n.MinValue); minValue != nil {
+		value, ok := tx.WeiValue()
+		if !ok || value.Cmp(minValue) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package txparser
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// apiRoute documents one operation of the HTTP API in the OpenAPI document. Request,
+// response and parameter schemas are derived from the types in types.go, so a route
+// is documented by adding an entry to apiRoutes. Every operation documents the
+// apierror envelope as its default response.
+type apiRoute struct {
+	Pattern  string      // ServeMux path, e.g. /subscriptions/{address}
+	Method   string      // HTTP method
+	Summary  string      // one-line description
+	Params   interface{} // struct whose query tags name the query parameters, or nil
+	Request  interface{} // JSON request body, or nil
+	Response interface{} // JSON response body
+	Status   int         // status of a successful response; 0 means 200
+}
+
+// apiRoutes lists the documented operations.
+var apiRoutes = []apiRoute{
+	{Pattern: "/current-block", Method: http.MethodGet, Summary: "Last parsed block", Response: CurrentBlockResponse{}},
+	{Pattern: "/subscribe", Method: http.MethodPost, Summary: "Watch an address", Request: SubscribeRequest{}, Response: SubscribeResponse{}},
+	{Pattern: "/transactions", Method: http.MethodGet, Summary: "Transactions of a watched address", Params: TransactionsParams{}, Response: TransactionPage{}},
+}
+
+// openAPIDocsPage is the Swagger UI page served at /docs, rendering /openapi.json.
+// It loads the Swagger UI scripts and styles from a CDN.
+//
+//go:embed openapi_docs.html
+var openAPIDocsPage []byte
+
+// openAPIDocument builds an OpenAPI 3 document describing routes.
+func openAPIDocument(routes []apiRoute) map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]map[string]interface{})
+	for _, route := range routes {
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		op := map[string]interface{}{
+			"summary": route.Summary,
+			"responses": map[string]interface{}{
+				strconv.Itoa(status): map[string]interface{}{
+					"description": http.StatusText(status),
+					"content":     jsonContent(apiSchema(reflect.TypeOf(route.Response), schemas)),
+				},
+				"default": map[string]interface{}{
+					"description": "Error",
+					"content":     jsonContent(apiSchema(reflect.TypeOf(apierror.Envelope{}), schemas)),
+				},
+			},
+		}
+		if params := apiParams(route.Pattern, route.Params, schemas); len(params) > 0 {
+			op["parameters"] = params
+		}
+		if route.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(apiSchema(reflect.TypeOf(route.Request), schemas)),
+			}
+		}
+		if paths[route.Pattern] == nil {
+			paths[route.Pattern] = make(map[string]interface{})
+		}
+		paths[route.Pattern][strings.ToLower(route.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi":    "3.0.3",
+		"info":       map[string]interface{}{"title": "tx-parser-svc", "version": "1"},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// apiParams describes the path parameters of pattern and the query parameters
+// tagged on the params struct.
+func apiParams(pattern string, params interface{}, schemas map[string]interface{}) []interface{} {
+	var out []interface{}
+	for _, segment := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+			out = append(out, map[string]interface{}{
+				"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	if params == nil {
+		return out
+	}
+	t := reflect.TypeOf(params)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+		param := map[string]interface{}{"name": name, "in": "query", "schema": apiSchema(field.Type, schemas)}
+		if doc := field.Tag.Get("doc"); doc != "" {
+			param["description"] = doc
+		}
+		out = append(out, param)
+	}
+	return out
+}
+
+// jsonContent wraps schema as an application/json media type.
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// apiSchema returns the schema of values of t as encoded by encoding/json. Named
+// structs are added to schemas once and referenced, so recursive types terminate.
+func apiSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": apiSchema(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": apiSchema(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = nil // placeholder while the fields are described
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return ref
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes the JSON object encoding t, flattening embedded structs the
+// way encoding/json does. Fields without omitempty are required.
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema := apiSchema(field.Type, schemas)
+			if doc := field.Tag.Get("doc"); doc != "" {
+				if _, isRef := schema["$ref"]; isRef {
+					schema = map[string]interface{}{"allOf": []interface{}{schema}}
+				}
+				schema["description"] = doc
+			}
+			properties[name] = schema
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	collect(t)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// handleOpenAPI handles GET /openapi.json, serving the OpenAPI document of the API.
+func (s *HTTPServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, openAPIDocument(apiRoutes))
+}
+
+// handleDocs handles GET /docs, serving Swagger UI for /openapi.json.
+func (s *HTTPServer) handleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(openAPIDocsPage)
+}
@@ -2,11 +2,17 @@ package txparser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
 )
 
 // Parser is the interface exposing the key methods needed by external users.
@@ -14,12 +20,81 @@ type Parser interface {
 	// GetCurrentBlock returns the last parsed block number (as an int).
 	GetCurrentBlock() int
 
-	// Subscribe adds an address to the watch list.
-	Subscribe(address string) bool
+	// Subscribe adds an address to the watch list, reporting whether it was newly added.
+	Subscribe(address string) (bool, error)
 
 	// GetTransactions returns transactions (inbound/outbound) for an address.
 	GetTransactions(address string) []Transaction
 
+	// SetNotificationPrefs replaces the notification preferences of a subscribed address.
+	SetNotificationPrefs(address string, prefs NotificationPrefs) error
+
+	// GetNotificationPrefs returns the notification preferences of a subscribed address.
+	GetNotificationPrefs(address string) (NotificationPrefs, error)
+
+	// GetTransactionsInRange returns an address's transactions between two blocks, inclusive.
+	GetTransactionsInRange(address string, fromBlock, toBlock int64) []Transaction
+
+	// QueryTransactions returns a filtered page of an address's transactions.
+	QueryTransactions(address string, q TxQuery) TransactionPage
+
+	// GetTransactionsForAddresses returns the transactions of several addresses
+	// merged into a single block-ordered list, each tagged with its address.
+	GetTransactionsForAddresses(addresses []string) []AddressTransaction
+
+	// WatchTx starts tracking a transaction hash until it reaches the given confirmations.
+	// It returns ErrInvalidTxHash or ErrTooManyWatches if the hash cannot be watched.
+	WatchTx(hash string, confirmations int) (TxWatch, error)
+
+	// GetTxWatch returns the tracking state of a watched transaction hash.
+	GetTxWatch(hash string) (TxWatch, bool)
+
+	// SubscribeBatch subscribes many valid addresses at once, reporting for each
+	// whether it was subscribed newly.
+	SubscribeBatch(addresses []string) (map[string]bool, error)
+
+	// SubscribeFromTx looks up a transaction and subscribes its sender and recipient,
+	// plus the token transfer recipient when includeTokenRecipient is set.
+	SubscribeFromTx(ctx context.Context, hash string, includeTokenRecipient bool) (TxSubscription, error)
+
+	// SetAddressPriority sets the catch-up priority of a subscribed address.
+	SetAddressPriority(address string, priority Priority) error
+
+	// AddressPriority returns the catch-up priority of an address, PriorityNormal by default.
+	AddressPriority(address string) Priority
+
+	// GetBlockMeta returns the hash and provider of a recently processed block.
+	GetBlockMeta(block int) (BlockMeta, bool)
+
+	// GetValueStats returns count, total and percentile transfer values for an address.
+	GetValueStats(address string) (ValueStats, bool)
+
+	// GetTimeline returns per-bucket transaction counts and summed values for an address.
+	GetTimeline(address string, width time.Duration) []TimelineBucket
+
+	// GetDeployments returns the contracts deployed by an address, oldest first.
+	GetDeployments(address string) []Deployment
+
+	// GetPriorityTransactions returns up to limit transactions matching the priority
+	// rules, newest first, of an address or of every address when it is empty.
+	GetPriorityTransactions(address string, limit int) []PriorityTransaction
+
+	// EventsSince returns up to limit changefeed events after cursor, and the next cursor.
+	EventsSince(cursor uint64, limit int) ([]Event, uint64, error)
+
+	// EventEpoch identifies this process's changefeed; cursors given to clients carry it.
+	EventEpoch() string
+
+	// RecentErrors returns recent parser and RPC errors, newest first.
+	RecentErrors() []ErrorRecord
+
+	// VisibleBlock returns the highest block whose transactions may be served under v,
+	// or ErrChainTipUnknown if that is not known yet.
+	VisibleBlock(ctx context.Context, v Visibility) (int, error)
+
+	// SafeBlock returns the highest block deep enough below the chain tip to be indexed.
+	SafeBlock() int
+
 	// StartParsing starts a background loop that fetches new blocks,
 	// parses transactions, and updates the store until the context is canceled.
 	StartParsing(ctx context.Context, pollInterval time.Duration)
@@ -27,27 +102,195 @@ type Parser interface {
 
 // EthParser is a concrete implementation of Parser interface.
 type EthParser struct {
-	client JSONRPCClient // for calling Ethereum JSON-RPC
-	store  Store         // in-memory store
-	logger *slog.Logger
+	client        BlockSource     // live JSON-RPC client or a replay source
+	store         Store           // in-memory store
+	archiver      BlockArchiver   // optional raw block export
+	flagged       FlaggedExporter // optional sink for rule-tagged transactions
+	risk          RiskScorer      // rates counterparties of matched transactions
+	rules         []Rule          // tagging rules evaluated per matched transaction
+	lowMemory     bool            // stream block transactions instead of decoding whole blocks
+	catchUp       int             // blocks fetched per batch while behind the tip, 0 to disable
+	trackTokens   bool            // also store ERC-20 transfers found with eth_getLogs
+	matchInput    bool            // also match subscribed addresses found in calldata
+	matchLogs     bool            // also match subscribed addresses found in event topics
+	receipts      bool            // add receipt status, gas used and fee to matched transactions
+	blockReceipts bool            // fetch receipts with eth_getBlockReceipts
+	logger        *slog.Logger
+	clock         Clock // time source for polling, TTLs and timestamps
+
+	prefetcher *blockPrefetcher // optional look-ahead block cache used while catching up
+	errors     *ErrorHistory    // recent errors exposed to operators
+	webhooks   *WebhookNotifier // optional delivery to subscriber webhook URLs
+	metrics    Metrics          // operational measurements, NoopMetrics by default
+	watches    *txWatcher       // individually watched transaction hashes
+	stats      *valueStats      // per-address value histograms
+	timeline   *timeline        // per-address activity buckets by block time
+	anomalies  *anomalyDetector // optional transaction rate anomaly detection
+	deployed   *deploymentLog   // contracts created by subscribed addresses
+	inbox      *priorityInbox   // optional newest transactions matching priority rules
+	events     *eventLog        // changefeed of store mutations
+	features   *FeatureFlags    // runtime switches for optional subsystems, nil enables all
+
+	// decimals caches token contract decimals, -1 when unknown, guarded by tokenMu.
+	decimals map[string]int
+	tokenMu  sync.Mutex
+
+	// blockSources records the provider of each block within BlockHashWindow, guarded by mu.
+	blockSources map[int]string
+
+	// priorities holds subscriptions marked other than PriorityNormal; their backfill
+	// and enrichment jobs are submitted at that priority.
+	priorities map[string]Priority
+
+	// discoveryContracts trigger auto-subscription of their counterparties for discoveryTTL.
+	discoveryContracts map[string]bool
+	discoveryTTL       time.Duration
+
+	// startBlock is applied on the first poll when the store has no progress yet.
+	startBlock *StartBlock
+
+	mu             sync.RWMutex // for synchronizing currentBlock
+	parseRunning   bool
+	latestBlock    int // chain tip seen on the last poll
+	finalizedBlock int // finalized block seen on the last poll
+	confirmations  int // depth required for VisibilityConfirmed
+	indexDepth     int // blocks behind the tip a block must be to be processed
+
+	trackFinalized atomic.Bool // fetch finalizedBlock on every poll, see SetFinalizedTracking
+
+	audit        bool // verify store invariants after every block, see audit.go
+	auditedBlock int  // last block verified by the audit
+
+	// done is closed when the parsing loop exits, and abort cancels the RPC calls of the
+	// block in progress. Both are set by StartParsing, guarded by mu.
+	done  chan struct{}
+	abort context.CancelFunc
 
-	mu           sync.RWMutex // for synchronizing currentBlock
-	parseRunning bool
+	wake   chan struct{}  // signaled by Wake to poll without waiting for the interval
+	leader *LeaderElector // optional; the loop only parses while it holds the lease
 }
 
-// NewEthParser returns a new EthParser with the given JSONRPCClient and MemoryStore.
-func NewEthParser(client JSONRPCClient, store Store, logger *slog.Logger) *EthParser {
+// DefaultConfirmations is the block depth used for VisibilityConfirmed unless overridden.
+const DefaultConfirmations = 12
+
+// NewEthParser returns a new EthParser with the given BlockSource and Store.
+func NewEthParser(client BlockSource, store Store, logger *slog.Logger) *EthParser {
 	if logger == nil {
 		logger = slog.Default()
 	}
-	return &EthParser{
-		client: client,
-		store:  store,
-		logger: logger,
+	errHistory, _ := NewErrorHistory(DefaultErrorHistorySize, "") // cannot fail without a log file
+	if setter, ok := store.(ErrorHistorySetter); ok {
+		setter.SetErrorHistory(errHistory)
+	}
+	p := &EthParser{
+		client:        client,
+		store:         store,
+		logger:        logger,
+		errors:        errHistory,
+		risk:          NoopRiskScorer{},
+		metrics:       NoopMetrics{},
+		stats:         newValueStats(),
+		timeline:      newTimeline(),
+		deployed:      newDeploymentLog(),
+		events:        newEventLog(DefaultEventLogSize),
+		priorities:    make(map[string]Priority),
+		blockSources:  make(map[int]string),
+		decimals:      make(map[string]int),
+		confirmations: DefaultConfirmations,
+		clock:         SystemClock,
+		wake:          make(chan struct{}, 1),
 	}
+	p.watches = newTxWatcher(p.txWatchChanged)
+	return p
+}
+
+// txWatchChanged logs a watched transaction state change and records it in the changefeed.
+func (p *EthParser) txWatchChanged(watch TxWatch) {
+	p.logger.Info("Watched transaction changed state",
+		"hash", watch.Hash,
+		"state", watch.State,
+		"block", watch.Block,
+	)
+	p.events.append(Event{Type: EventTxWatch, Block: int(watch.Block), TxWatch: &watch})
+}
+
+// WatchTx starts tracking a transaction hash until it reaches the given confirmations.
+// It returns ErrInvalidTxHash or ErrTooManyWatches if the hash cannot be watched.
+func (p *EthParser) WatchTx(hash string, confirmations int) (TxWatch, error) {
+	return p.watches.add(hash, confirmations, p.GetCurrentBlock())
+}
+
+// GetTxWatch returns the tracking state of a watched transaction hash.
+func (p *EthParser) GetTxWatch(hash string) (TxWatch, bool) {
+	return p.watches.get(hash)
+}
+
+// SetErrorHistory replaces the default in-memory error history, e.g. with a persistent one.
+func (p *EthParser) SetErrorHistory(h *ErrorHistory) {
+	p.errors = h
+	if setter, ok := p.store.(ErrorHistorySetter); ok {
+		setter.SetErrorHistory(h)
+	}
+}
+
+// GetValueStats returns count, total and percentile transfer values for an address.
+// Statistics are rebuilt from the stored transactions when parsing starts and then
+// cover every transaction matched, including ones later evicted. Percentiles are
+// approximate, within about 10% of the exact value; see ValueStats.
+func (p *EthParser) GetValueStats(address string) (ValueStats, bool) {
+	address = strings.ToLower(address)
+	return p.stats.get(address)
+}
+
+// GetTimeline returns the non-empty activity buckets of width for address, oldest first.
+// Like value statistics, the timeline is rebuilt from the store when parsing starts.
+func (p *EthParser) GetTimeline(address string, width time.Duration) []TimelineBucket {
+	address = strings.ToLower(address)
+	return p.timeline.get(address, width)
+}
+
+// EventsSince returns up to limit changefeed events after cursor, and the next cursor.
+// It returns ErrCursorExpired if events after cursor were already discarded.
+func (p *EthParser) EventsSince(cursor uint64, limit int) ([]Event, uint64, error) {
+	return p.events.since(cursor, limit)
+}
+
+// EventEpoch identifies this process's changefeed; cursors given to clients carry it.
+func (p *EthParser) EventEpoch() string {
+	return p.events.epoch
+}
+
+// RecentErrors returns recent parser and RPC errors, newest first.
+func (p *EthParser) RecentErrors() []ErrorRecord {
+	return p.errors.Recent()
+}
+
+// SetConfirmations sets how many blocks deep a block must be for VisibilityConfirmed.
+func (p *EthParser) SetConfirmations(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.confirmations = n
+}
+
+// SetFinalizedTracking makes every poll fetch the chain's finalized block for
+// VisibilityFinalized. The first finalized query enables it too, so the extra RPC call
+// is only made once finalized transactions are served.
+func (p *EthParser) SetFinalizedTracking(enabled bool) {
+	p.trackFinalized.Store(enabled)
+}
+
+// SetIndexConfirmations makes the parser only process blocks at least n blocks behind
+// the chain tip, so transactions a reorg may remove are never stored. Zero processes
+// blocks as soon as they appear.
+func (p *EthParser) SetIndexConfirmations(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.indexDepth = max(n, 0)
 }
 
 // StartParsing runs a background loop that continuously processes the next block.
+// Cancelling ctx stops the loop once the block in progress is stored; Stop waits for
+// that and aborts the block's RPC calls if its own context ends first.
 func (p *EthParser) StartParsing(ctx context.Context, pollInterval time.Duration) {
 	p.mu.Lock()
 	if p.parseRunning {
@@ -56,105 +299,920 @@ func (p *EthParser) StartParsing(ctx context.Context, pollInterval time.Duration
 		return
 	}
 	p.parseRunning = true
+	work, abort := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	p.done, p.abort = done, abort
 	p.mu.Unlock()
+	defer close(done)
+	defer abort()
 
+	p.rebuildStats()
 	p.logger.Info("Background parser loop started", "interval", pollInterval.String())
 
+	checked := false // consistency verified since parsing (re)started
 	for {
 		select {
 		case <-ctx.Done():
 			p.logger.Info("Context canceled, stopping parser loop.")
 			return
 		default:
-			err := p.processNextBlock()
+			if p.IsFollower() {
+				checked = false // another replica advances the store meanwhile
+				// Keep the tip current so confirmed and finalized visibility work here too.
+				if _, err := p.refreshTip(work); err != nil {
+					p.logger.Warn("Failed to refresh chain tip as follower", "err", err)
+				}
+				select {
+				case <-ctx.Done():
+				case <-p.clock.After(pollInterval):
+				}
+				continue
+			}
+			if !checked {
+				// Parsing waits for the check, so a reorg during downtime is never
+				// built upon; a failed check is retried after the poll interval.
+				if err := p.checkConsistency(work); err != nil {
+					p.logger.Error("Startup consistency check failed", "err", err)
+					p.errors.Record("parser", p.GetCurrentBlock(), err)
+					select {
+					case <-ctx.Done():
+					case <-p.clock.After(pollInterval):
+					}
+					continue
+				}
+				checked = true
+			}
+			err := p.processNextBlock(work)
 			if err != nil {
 				p.logger.Error("Error processing next block", "err", err)
+				p.errors.Record("parser", p.GetCurrentBlock()+1, err)
+			}
+			if err == nil && p.catchUp > 0 && p.behindTip() {
+				continue // keep going until the tip is reached
+			}
+			select {
+			case <-ctx.Done():
+			case <-p.clock.After(pollInterval):
+			case <-p.wake:
 			}
-			time.Sleep(pollInterval)
 		}
 	}
 }
 
-// processNextBlock fetches the next block from the chain, parses it, and stores relevant txs.
-func (p *EthParser) processNextBlock() error {
-	currentBlock := p.GetCurrentBlock()
+// SetLeaderElector makes the parsing loop process blocks only while e holds the
+// leader lease, so replicas sharing a store don't process blocks twice. The elector
+// must be run separately. It must be called before StartParsing.
+func (p *EthParser) SetLeaderElector(e *LeaderElector) {
+	p.leader = e
+}
+
+// IsFollower reports whether another replica holds the leader lease. A follower serves
+// the shared store but not the state derived in-process while parsing, such as events,
+// stats and watches.
+func (p *EthParser) IsFollower() bool {
+	return p.leader != nil && !p.leader.IsLeader()
+}
+
+// Wake makes the parsing loop poll for new blocks now instead of at the end of the
+// current interval, e.g. when a new head is announced.
+func (p *EthParser) Wake() {
+	select {
+	case p.wake <- struct{}{}:
+	default: // a poll is already pending
+	}
+}
 
-	// Retrieve latest on-chain block
-	latestBlockHex, err := p.client.BlockNumber()
+// checkConsistency verifies the stored current block still matches the chain.
+// If a reorg happened while the service was down, it rolls back to the last
+// block whose recorded hash matches the chain before parsing resumes.
+func (p *EthParser) checkConsistency(ctx context.Context) error {
+	currentBlock, err := p.storedCurrentBlock()
 	if err != nil {
-		return fmt.Errorf("failed to get block number: %w", err)
+		return fmt.Errorf("failed to read current block: %w", err)
+	}
+	if _, ok := p.store.GetBlockHash(currentBlock); !ok {
+		return nil // nothing recorded to verify against
+	}
+
+	ancestor := currentBlock
+	verified := false
+	for ; ancestor > 0; ancestor-- {
+		storedHash, ok := p.store.GetBlockHash(ancestor)
+		if !ok {
+			break // beyond the retained hash window
+		}
+		chainHash, err := p.chainBlockHash(ctx, ancestor)
+		if err != nil {
+			return fmt.Errorf("failed to fetch hash of block %d: %w", ancestor, err)
+		}
+		if chainHash == storedHash {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		p.logger.Warn("Reorg during downtime reaches past the retained block hashes, rollback target is unverified",
+			"from", currentBlock,
+			"to", ancestor,
+			"window", BlockHashWindow,
+		)
 	}
 
-	latestBlockDecimal, err := hexToInt64(latestBlockHex)
+	if depth := currentBlock - ancestor; depth > 0 {
+		p.mu.Lock()
+		p.store.RollbackTo(ancestor)
+		p.store.SetCurrentBlock(ancestor)
+		p.rollbackBlockSourcesLocked(ancestor)
+		p.mu.Unlock()
+		if p.prefetcher != nil {
+			p.prefetcher.reset() // cached blocks may belong to the abandoned fork
+		}
+		p.watches.rollback(ancestor)
+		if p.inbox != nil {
+			p.inbox.rollback(ancestor)
+		}
+		p.events.append(Event{Type: EventReorg, Block: ancestor})
+		p.auditBlock(ancestor, true)
+		p.logger.Warn("Rolled back stored state after reorg during downtime",
+			"from", currentBlock,
+			"to", ancestor,
+			"depth", depth,
+		)
+	}
+	return nil
+}
+
+// chainBlockHash returns the canonical hash of a block, preferring a header-only fetch.
+func (p *EthParser) chainBlockHash(ctx context.Context, blockNum int) (string, error) {
+	if hashSource, ok := p.client.(BlockHashSource); ok {
+		return hashSource.GetBlockHash(ctx, int64(blockNum))
+	}
+	block, err := p.client.GetBlockByNumber(ctx, int64(blockNum))
 	if err != nil {
-		return fmt.Errorf("failed converting block hex to int64: %w", err)
+		return "", err
 	}
+	return block.Result.Hash, nil
+}
 
-	if int64(currentBlock) >= latestBlockDecimal {
-		p.logger.Debug("Already at or past the chain tip",
+// refreshTip records the latest on-chain block, and the finalized one while it is
+// tracked, returning the latest block.
+func (p *EthParser) refreshTip(ctx context.Context) (int64, error) {
+	latestBlockHex, err := p.client.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+	latestBlock, err := hexutil.DecodeInt64(latestBlockHex)
+	if err != nil {
+		return 0, fmt.Errorf("failed converting block hex to int64: %w", err)
+	}
+
+	p.mu.Lock()
+	p.latestBlock = int(latestBlock)
+	p.mu.Unlock()
+	if p.trackFinalized.Load() {
+		p.refreshFinalizedBlock(ctx)
+	}
+	return latestBlock, nil
+}
+
+// processNextBlock fetches the next block from the chain, parses it, and stores relevant txs.
+func (p *EthParser) processNextBlock(ctx context.Context) error {
+	currentBlock, err := p.storedCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("failed to read current block: %w", err)
+	}
+
+	latestBlockDecimal, err := p.refreshTip(ctx)
+	if err != nil {
+		return err
+	}
+	p.mu.RLock()
+	safeBlock := latestBlockDecimal - int64(p.indexDepth)
+	p.mu.RUnlock()
+
+	if currentBlock == 0 && p.startBlock != nil {
+		currentBlock = p.applyStartBlock(max(safeBlock, 0))
+	}
+	p.metrics.ChainLag(max(int(latestBlockDecimal)-currentBlock, 0))
+	if int64(currentBlock) >= safeBlock {
+		p.logger.Debug("Already at or past the safe block",
 			"latest", latestBlockDecimal,
+			"safe", safeBlock,
 			"current", currentBlock,
 		)
 		return nil
 	}
 
 	nextBlock := currentBlock + 1
-	blockData, err := p.client.GetBlockByNumber(int64(nextBlock))
+	if batcher, ok := p.client.(BatchBlockSource); ok && p.catchUp > 1 && !p.lowMemory && safeBlock > int64(nextBlock) {
+		return p.processBlockBatch(ctx, batcher, nextBlock, min(nextBlock+p.catchUp-1, int(safeBlock)))
+	}
+	if p.prefetcher != nil && !p.lowMemory && safeBlock > int64(nextBlock) {
+		// Blocks after nextBlock download while nextBlock is being matched.
+		p.prefetcher.prefetch(ctx, nextBlock, int(safeBlock))
+	}
+	txCount, source, err := p.processBlock(ctx, nextBlock)
+	if err != nil {
+		return err
+	}
+	p.commitBlock(nextBlock, txCount, source)
+	return nil
+}
+
+// applyStartBlock moves a parser without stored progress to just before the configured
+// start block, returning the new current block.
+func (p *EthParser) applyStartBlock(latest int64) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	start := p.startBlock.resolve(latest)
+	p.logger.Info("Starting from configured block", "start_block", p.startBlock.String(), "block", start)
+	p.startBlock = nil
+	p.store.SetCurrentBlock(int(start - 1))
+	return int(start - 1)
+}
+
+// processBlockBatch fetches blocks from through to in one request and processes them in order.
+func (p *EthParser) processBlockBatch(ctx context.Context, batcher BatchBlockSource, from, to int) error {
+	blocks, err := batcher.GetBlocksByNumber(ctx, int64(from), int64(to))
 	if err != nil {
-		return fmt.Errorf("failed to fetch block data for block %d: %w", nextBlock, err)
+		return fmt.Errorf("failed to fetch blocks %d-%d: %w", from, to, err)
+	}
+	for i, blockData := range blocks {
+		blockNum := from + i
+		txCount := p.storeBlock(ctx, blockNum, blockData)
+		p.commitBlock(blockNum, txCount, blockData.Source)
 	}
+	return nil
+}
 
-	transactions := parseTransactions(blockData)
-	p.storeTransactions(transactions)
+// commitBlock advances the current block to blockNum once its transactions are stored.
+func (p *EthParser) commitBlock(blockNum, txCount int, source string) {
+	p.recordBlockSource(blockNum, source)
 
 	p.mu.Lock()
-	p.store.SetCurrentBlock(nextBlock)
+	p.store.SetCurrentBlock(blockNum)
+	lag := max(p.latestBlock-blockNum, 0)
 	p.mu.Unlock()
+	p.metrics.BlockParsed(blockNum)
+	p.metrics.ChainLag(lag)
+	p.reportSubscribers()
+	p.watches.advance(blockNum)
+	p.events.append(Event{Type: EventBlockProcessed, Block: blockNum})
+	p.reportAnomalies(blockNum)
+	p.auditBlock(blockNum, false)
 
 	p.logger.Info("Parsed block",
-		"block", nextBlock,
-		"tx_count", len(transactions),
+		"block", blockNum,
+		"tx_count", txCount,
+		"source", source,
 	)
+}
+
+// reportAnomalies logs and appends an anomaly event for every rate anomaly in the
+// window closed by blockNum.
+func (p *EthParser) reportAnomalies(blockNum int) {
+	if p.anomalies == nil || !p.features.Enabled(FeatureAnomalies) {
+		return
+	}
+	for _, a := range p.anomalies.advance(blockNum) {
+		p.logger.Warn("Detected transaction rate anomaly",
+			"kind", a.Kind,
+			"address", a.Address,
+			"from_block", a.FromBlock,
+			"to_block", a.ToBlock,
+			"count", a.Count,
+			"mean", a.Mean,
+		)
+		p.events.append(Event{Type: EventAnomaly, Address: a.Address, Block: blockNum, Anomaly: &a})
+	}
+}
+
+// Stop waits for the parsing loop to exit after the context passed to StartParsing is
+// cancelled, then flushes the store. If ctx ends first, the RPC calls of the block in
+// progress are aborted and ctx's error is returned. It returns at once if the loop was
+// never started.
+func (p *EthParser) Stop(ctx context.Context) error {
+	p.mu.RLock()
+	done, abort := p.done, p.abort
+	p.mu.RUnlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		abort()
+		return fmt.Errorf("parser loop did not stop in time: %w", ctx.Err())
+	}
+	if f, ok := p.store.(Flusher); ok {
+		if err := f.Flush(); err != nil {
+			return fmt.Errorf("failed to flush store: %w", err)
+		}
+	}
+	p.logger.Info("Parser stopped", "block", p.GetCurrentBlock())
 	return nil
 }
 
+// behindTip reports whether the safe block of the last poll is ahead of the current block.
+func (p *EthParser) behindTip() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.store.GetCurrentBlock() < p.latestBlock-p.indexDepth
+}
+
+// processBlock fetches a block and stores its relevant transactions, returning the tx count
+// and the provider that served the block.
+// In low-memory mode, sources that support it are decoded one transaction at a time and
+// only matching transactions are held until the whole block has been read, so a stream
+// failing midway stores nothing and its retry stores each transaction once.
+func (p *EthParser) processBlock(ctx context.Context, blockNum int) (int, string, error) {
+	if streamer, ok := p.client.(BlockStreamer); ok && p.lowMemory {
+		txCount := 0
+		var timestamp, baseFee string
+		var header *BlockHeader
+		var matched []Transaction
+		var matchedRaws []RawTx
+		logMatches := p.fetchLogMatches(ctx, blockNum)
+		block, err := streamer.StreamBlockTransactions(ctx, int64(blockNum), func(raw RawTx) error {
+			txCount++
+			if raw.blockTimestamp == "" {
+				// The header fields followed the transactions in the response.
+				header = p.streamedBlockHeader(ctx, blockNum, header)
+				raw.blockTimestamp, raw.blockBaseFee = header.Timestamp, header.BaseFeePerGas
+			}
+			timestamp, baseFee = raw.blockTimestamp, raw.blockBaseFee
+			tx := newTransaction(raw, int64(blockNum), quantityOrZero(raw.blockTimestamp))
+			if p.streamedMatch(tx, raw, logMatches) {
+				matched = append(matched, tx)
+				matchedRaws = append(matchedRaws, raw)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to stream block data for block %d: %w", blockNum, err)
+		}
+		p.enrichReceipts(ctx, blockNum, baseFee, matched)
+		p.storeTransactions(matched, matchedRaws)
+		p.storeLogMatches(logMatches, matched, matchedRaws)
+		p.storeTokenTransfers(ctx, blockNum, quantityOrZero(timestamp))
+		p.store.SetBlockHash(blockNum, block.Hash)
+		return txCount, block.Source, nil
+	}
+
+	blockData, err := p.fetchBlock(ctx, blockNum)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to fetch block data for block %d: %w", blockNum, err)
+	}
+	return p.storeBlock(ctx, blockNum, blockData), blockData.Source, nil
+}
+
+// streamedMatch reports whether storeTransaction or storeLogMatches would act on a
+// streamed transaction: it involves a subscribed address, a watched hash or a discovery
+// contract, or emitted an event naming a subscribed address. Addresses discovered or
+// deployed within a streamed block are matched from the next block on.
+func (p *EthParser) streamedMatch(tx Transaction, raw RawTx, logMatches map[string][]string) bool {
+	if _, watched := p.watches.get(tx.Hash); watched || len(logMatches[tx.Hash]) > 0 {
+		return true
+	}
+	if p.discoveryContracts[strings.ToLower(tx.To)] || p.discoveryContracts[strings.ToLower(tx.From)] {
+		return true
+	}
+	if p.store.IsSubscribed(tx.From) || p.store.IsSubscribed(tx.To) {
+		return true
+	}
+	if p.matchInput && p.features.Enabled(FeatureInputMatching) {
+		return slices.ContainsFunc(calldataAddresses(raw.Input), p.store.IsSubscribed)
+	}
+	return false
+}
+
+// streamedBlockHeader returns header, fetching the header of a streamed block the first
+// time it is needed. A failed fetch yields an empty header, leaving the timestamp and
+// fee split of the block's transactions unset rather than failing the block.
+func (p *EthParser) streamedBlockHeader(ctx context.Context, blockNum int, header *BlockHeader) *BlockHeader {
+	if header != nil {
+		return header
+	}
+	header = &BlockHeader{}
+	source, ok := p.client.(BlockHeaderSource)
+	if !ok {
+		return header
+	}
+	fetched, err := source.GetBlockHeader(ctx, int64(blockNum))
+	if err != nil {
+		p.logger.Warn("Failed to fetch block header", "block", blockNum, "err", err)
+		p.errors.Record("header", blockNum, err)
+		return header
+	}
+	*header = fetched
+	return header
+}
+
+// storeBlock archives a fetched block and stores its relevant transactions, returning the tx count.
+func (p *EthParser) storeBlock(ctx context.Context, blockNum int, blockData BlockResponse) int {
+	if p.archiver != nil {
+		if err := p.archiver.Archive(int64(blockNum), blockData); err != nil {
+			p.logger.Warn("Failed to archive block", "block", blockNum, "err", err)
+			p.errors.Record("archive", blockNum, err)
+		}
+	}
+
+	transactions := parseTransactions(blockData)
+	p.enrichReceipts(ctx, blockNum, blockData.Result.BaseFeePerGas, transactions)
+	p.storeTransactions(transactions, blockData.Result.Transactions)
+	p.storeLogMatches(p.fetchLogMatches(ctx, blockNum), transactions, blockData.Result.Transactions)
+	p.storeTokenTransfers(ctx, blockNum, quantityOrZero(blockData.Result.Timestamp))
+	p.store.SetBlockHash(blockNum, blockData.Result.Hash)
+	return len(transactions)
+}
+
+// SetRules replaces the rules evaluated against each matched transaction.
+// It must be called before StartParsing.
+func (p *EthParser) SetRules(rules []Rule) {
+	p.rules = rules
+}
+
+// SetLowMemory enables streaming block processing when the BlockSource supports it.
+// Streamed blocks are never fully materialized, so they are not archived.
+func (p *EthParser) SetLowMemory(enabled bool) {
+	p.lowMemory = enabled
+}
+
+// SetTokenTracking enables storing ERC-20 Transfer events of subscribed addresses as
+// MatchTypeToken transactions, when the BlockSource supports eth_getLogs.
+func (p *EthParser) SetTokenTracking(enabled bool) {
+	p.trackTokens = enabled
+}
+
+// SetInputMatching enables matching subscribed addresses that appear as
+// word-aligned arguments in transaction input data (e.g. batched payouts).
+func (p *EthParser) SetInputMatching(enabled bool) {
+	p.matchInput = enabled
+}
+
+// SetLogMatching enables matching subscribed addresses that appear, padded to 32 bytes,
+// as indexed topics of the events a transaction emitted, when the BlockSource supports
+// eth_getLogs. Every log of each block is fetched, so it costs one more request per block.
+func (p *EthParser) SetLogMatching(enabled bool) {
+	p.matchLogs = enabled
+}
+
+// SetAutoDiscovery enables auto-subscribing every address that transacts with one of
+// the given contracts. Discovered subscriptions lapse after ttl unless renewed by new activity.
+func (p *EthParser) SetAutoDiscovery(contracts []string, ttl time.Duration) {
+	p.discoveryContracts = make(map[string]bool, len(contracts))
+	for _, contract := range contracts {
+		p.discoveryContracts[strings.ToLower(contract)] = true
+	}
+	p.discoveryTTL = ttl
+}
+
+// SetPrefetchDepth enables fetching up to depth blocks ahead concurrently while
+// the parser lags behind the chain tip. Zero disables prefetching.
+// Prefetching is skipped in low-memory mode, since it holds whole blocks.
+func (p *EthParser) SetPrefetchDepth(depth int) {
+	if depth <= 0 {
+		p.prefetcher = nil
+		return
+	}
+	p.prefetcher = newBlockPrefetcher(p.client, depth)
+}
+
+// SetStartBlock selects where indexing begins when the store has no progress yet,
+// instead of genesis. Stored progress always takes precedence. Call it before StartParsing.
+func (p *EthParser) SetStartBlock(s StartBlock) {
+	p.startBlock = &s
+}
+
+// SetCatchUp enables catch-up mode: while behind the chain tip, the parser fetches up
+// to batchSize blocks per JSON-RPC batch request, when the BlockSource supports it, and
+// polls again without waiting until the tip is reached. Zero disables catch-up mode.
+// Batches are skipped in low-memory mode, since they hold whole blocks.
+func (p *EthParser) SetCatchUp(batchSize int) {
+	p.catchUp = max(batchSize, 0)
+}
+
+// SetAnomalyDetection enables flagging windows of DefaultAnomalyWindow blocks whose matched
+// transaction count, per address or overall, lies at least sensitivity standard deviations
+// from the rolling mean, such as a dormant wallet being drained. Anomalies are logged and
+// appended to the changefeed. Zero disables detection. Call it before StartParsing.
+func (p *EthParser) SetAnomalyDetection(sensitivity float64) {
+	if sensitivity <= 0 {
+		p.anomalies = nil
+		return
+	}
+	p.anomalies = newAnomalyDetector(sensitivity, DefaultAnomalyWindow)
+}
+
+// SetArchiver enables exporting every fetched block through a.
+func (p *EthParser) SetArchiver(a BlockArchiver) {
+	p.archiver = a
+}
+
+// SetClock replaces the time source used by the polling loop, discovery TTLs, event,
+// error and watch timestamps, and the store's subscription expiries when it supports it.
+// Call it before StartParsing.
+func (p *EthParser) SetClock(c Clock) {
+	p.clock = c
+	p.events.clock = c
+	p.watches.clock = c
+	p.errors.clock = c
+	if store, ok := p.store.(interface{ SetClock(Clock) }); ok {
+		store.SetClock(c)
+	}
+}
+
+// SetWebhookNotifier enables POSTing matched transactions to the webhook URL in each
+// subscription's notification preferences.
+func (p *EthParser) SetWebhookNotifier(n *WebhookNotifier) {
+	p.webhooks = n
+}
+
+// SetRiskScorer replaces the default NoopRiskScorer. Scoring failures are recorded and
+// leave the transaction with a score of 0.
+func (p *EthParser) SetRiskScorer(r RiskScorer) {
+	p.risk = r
+}
+
+// SetFlaggedExporter forwards every rule-tagged matched transaction to e.
+func (p *EthParser) SetFlaggedExporter(e FlaggedExporter) {
+	p.flagged = e
+}
+
+// refreshFinalizedBlock updates the finalized block; failures only degrade VisibilityFinalized.
+func (p *EthParser) refreshFinalizedBlock(ctx context.Context) {
+	finalizedHex, err := p.client.FinalizedBlockNumber(ctx)
+	if err != nil {
+		p.logger.Warn("Failed to get finalized block number", "err", err)
+		p.errors.Record("rpc", 0, err)
+		return
+	}
+	finalized, err := hexutil.DecodeInt64(finalizedHex)
+	if err != nil {
+		p.logger.Warn("Failed converting finalized block hex to int64", "err", err)
+		return
+	}
+	p.mu.Lock()
+	p.finalizedBlock = int(finalized)
+	p.mu.Unlock()
+}
+
+// fetchBlock returns a block from the prefetch cache, falling back to the BlockSource.
+func (p *EthParser) fetchBlock(ctx context.Context, blockNum int) (BlockResponse, error) {
+	if p.prefetcher != nil {
+		if block, ok := p.prefetcher.take(blockNum); ok {
+			return block, nil
+		}
+	}
+	return p.client.GetBlockByNumber(ctx, int64(blockNum))
+}
+
 // parseTransactions transforms JSON-RPC block result into our Transaction type.
 func parseTransactions(block BlockResponse) []Transaction {
 	var txs []Transaction
+	blockNum := quantityOrZero(block.Result.Number)
+	timestamp := quantityOrZero(block.Result.Timestamp)
 	for _, tx := range block.Result.Transactions {
-		txs = append(txs, Transaction{
-			Hash:  tx.Hash,
-			From:  tx.From,
-			To:    tx.To,
-			Value: tx.Value,
-			Block: hexToInt64OrZero(block.Result.Number),
-		})
+		txs = append(txs, newTransaction(tx, blockNum, timestamp))
 	}
 	return txs
 }
 
+// newTransaction converts a single RawTx included in the given block.
+func newTransaction(raw RawTx, blockNum, timestamp int64) Transaction {
+	return Transaction{
+		Hash:        raw.Hash,
+		From:        strings.ToLower(raw.From),
+		To:          strings.ToLower(raw.To),
+		Value:       raw.Value,
+		Block:       blockNum,
+		Timestamp:   timestamp,
+		ValueWei:    weiDecimal(raw.Value),
+		ValueEther:  etherDecimal(raw.Value),
+		GasPriceWei: weiDecimal(raw.GasPrice),
+
+		MaxFeePerGasWei:         weiDecimal(raw.MaxFeePerGas),
+		MaxPriorityFeePerGasWei: weiDecimal(raw.MaxPriorityFeePerGas),
+		Nonce:                   quantityDecimal(raw.Nonce),
+		MethodSelector:          methodSelector(raw.Input),
+	}
+}
+
+// methodSelector returns the lowercase 4-byte selector of a call's input, or "" when the
+// input is too short to hold one.
+func methodSelector(input string) string {
+	if len(input) < 10 || !strings.HasPrefix(input, "0x") {
+		return ""
+	}
+	return strings.ToLower(input[:10])
+}
+
 // storeTransactions stores transactions if from/to addresses are subscribed.
-func (p *EthParser) storeTransactions(txs []Transaction) {
-	for _, tx := range txs {
-		if p.store.IsSubscribed(tx.From) {
-			p.store.AddTransaction(tx.From, tx)
+// raws holds the source RawTx for each entry of txs.
+func (p *EthParser) storeTransactions(txs []Transaction, raws []RawTx) {
+	for i, tx := range txs {
+		p.storeTransaction(tx, raws[i])
+	}
+}
+
+// storeTransaction stores tx under its from/to addresses if they are subscribed.
+func (p *EthParser) storeTransaction(tx Transaction, raw RawTx) {
+	p.watches.observe(tx.Hash, tx.Block)
+	p.discoverCounterparty(tx)
+	p.recordDeployment(tx, raw)
+	if p.store.IsSubscribed(tx.From) {
+		p.addTransaction(tx.From, p.applyRules(tx.From, tx, raw), raw)
+	}
+	if tx.To != tx.From && p.store.IsSubscribed(tx.To) { // store self-transfers once
+		p.addTransaction(tx.To, p.applyRules(tx.To, tx, raw), raw)
+	}
+	if p.matchInput && p.features.Enabled(FeatureInputMatching) {
+		p.storeInputMatches(tx, raw)
+	}
+}
+
+// addTransaction stores a matched transaction for address and delivers it to webhooks and exporters.
+func (p *EthParser) addTransaction(address string, tx Transaction, raw RawTx) {
+	tx = p.recordTransaction(address, tx)
+	p.recordPriority(address, tx, raw)
+	p.notifyWebhook(address, tx, raw)
+	if p.flagged != nil && len(tx.Tags) > 0 {
+		if err := p.flagged.ExportFlagged(address, tx); err != nil {
+			p.logger.Warn("Failed to export flagged transaction", "hash", tx.Hash, "err", err)
+			p.errors.Record("siem", int(tx.Block), err)
+		}
+	}
+}
+
+// recordTransaction scores and stores a matched transaction for address, updates
+// derived state, and returns the scored transaction.
+func (p *EthParser) recordTransaction(address string, tx Transaction) Transaction {
+	score, err := p.risk.Score(counterparty(address, tx))
+	if err != nil {
+		p.logger.Warn("Failed to score counterparty", "hash", tx.Hash, "err", err)
+		p.errors.Record("risk", int(tx.Block), err)
+	}
+	tx.RiskScore = score
+	p.store.AddTransaction(address, tx)
+	p.metrics.TransactionStored()
+	p.observeStats(address, tx)
+	if p.anomalies != nil && p.features.Enabled(FeatureAnomalies) {
+		p.anomalies.observe(address)
+	}
+	p.events.append(Event{Type: EventTxAdded, Address: address, Block: int(tx.Block), Transaction: &tx})
+	return tx
+}
+
+// storeInputMatches stores tx for subscribed addresses found in its calldata
+// that are not already its sender or recipient.
+func (p *EthParser) storeInputMatches(tx Transaction, raw RawTx) {
+	for _, address := range calldataAddresses(raw.Input) {
+		if strings.EqualFold(address, tx.From) || strings.EqualFold(address, tx.To) {
+			continue
+		}
+		if p.store.IsSubscribed(address) {
+			matched := p.applyRules(address, tx, raw)
+			matched.MatchType = MatchTypeInput
+			p.addTransaction(address, matched, raw)
+		}
+	}
+}
+
+// discoverCounterparty auto-subscribes the other side of a transaction with a discovery contract.
+func (p *EthParser) discoverCounterparty(tx Transaction) {
+	if len(p.discoveryContracts) == 0 {
+		return
+	}
+	var counterparty string
+	switch {
+	case p.discoveryContracts[strings.ToLower(tx.To)]:
+		counterparty = tx.From
+	case p.discoveryContracts[strings.ToLower(tx.From)]:
+		counterparty = tx.To
+	default:
+		return
+	}
+	if counterparty == "" {
+		return
+	}
+	if p.store.SubscribeUntil(counterparty, p.clock.Now().Add(p.discoveryTTL)) {
+		p.recordSubscriptionChange(counterparty, true)
+		p.logger.Info("Auto-subscribed counterparty of discovery contract",
+			"address", counterparty,
+			"hash", tx.Hash,
+			"ttl", p.discoveryTTL.String(),
+		)
+	}
+}
+
+// applyRules returns tx tagged by every rule matching it from address's point of view.
+func (p *EthParser) applyRules(address string, tx Transaction, raw RawTx) Transaction {
+	if len(p.rules) == 0 {
+		return tx
+	}
+	tags, matched := evaluateRules(p.rules, newRuleInput(address, tx, raw))
+	if len(matched) > 0 {
+		p.logger.Info("Rules matched transaction",
+			"address", address,
+			"hash", tx.Hash,
+			"rules", matched,
+		)
+	}
+	tx.Tags = tags
+	return tx
+}
+
+// Subscribe adds an address to the subscription set, reporting whether it was newly added.
+// The address is stored lowercase. It returns ErrInvalidAddress for malformed addresses,
+// including mixed-case ones with a bad EIP-55 checksum.
+func (p *EthParser) Subscribe(address string) (bool, error) {
+	address, err := canonicalAddress(address)
+	if err != nil {
+		return false, err
+	}
+	if err := p.checkStore(); err != nil {
+		return false, err
+	}
+	subscribed := p.store.Subscribe(address)
+	if subscribed {
+		p.recordSubscriptionChange(address, true)
+	}
+	return subscribed, nil
+}
+
+// SubscribeBatch subscribes many addresses at once, in a single store transaction when
+// the store supports it, reporting for each whether it was subscribed newly. Addresses
+// must be valid; duplicates are subscribed once.
+func (p *EthParser) SubscribeBatch(addresses []string) (map[string]bool, error) {
+	if err := p.checkStore(); err != nil {
+		return nil, err
+	}
+	normalized := make([]string, len(addresses))
+	for i, address := range addresses {
+		normalized[i] = strings.ToLower(address)
+	}
+	created := subscribeBatch(p.store, normalized)
+	for _, address := range normalized {
+		if created[address] {
+			subscribed := true
+			p.events.append(Event{Type: EventSubscriptionChanged, Address: address, Subscribed: &subscribed})
+		}
+	}
+	p.reportSubscribers()
+	return created, nil
+}
+
+// ErrTxLookupUnsupported is returned when the block source cannot look up transactions by hash.
+var ErrTxLookupUnsupported = errors.New("block source does not support transaction lookup")
+
+// TxSubscription reports the addresses subscribed from a transaction.
+type TxSubscription struct {
+	Hash              string   `json:"hash"`
+	Subscribed        []string `json:"subscribed"`
+	AlreadySubscribed []string `json:"alreadySubscribed"`
+}
+
+// SubscribeFromTx looks up a transaction and subscribes its sender and recipient,
+// plus the token transfer recipient when includeTokenRecipient is set.
+func (p *EthParser) SubscribeFromTx(ctx context.Context, hash string, includeTokenRecipient bool) (TxSubscription, error) {
+	source, ok := p.client.(TransactionSource)
+	if !ok {
+		return TxSubscription{}, ErrTxLookupUnsupported
+	}
+	raw, err := source.GetTransactionByHash(ctx, hash)
+	if err != nil {
+		return TxSubscription{}, err
+	}
+
+	addresses := []string{raw.From, raw.To}
+	if includeTokenRecipient {
+		addresses = append(addresses, tokenTransferRecipient(raw.Input))
+	}
+	result := TxSubscription{Hash: raw.Hash, Subscribed: []string{}, AlreadySubscribed: []string{}}
+	for _, address := range addresses {
+		if address == "" || containsString(result.Subscribed, address) || containsString(result.AlreadySubscribed, address) {
+			continue
+		}
+		created, err := p.Subscribe(address)
+		if err != nil {
+			return TxSubscription{}, err
 		}
-		if p.store.IsSubscribed(tx.To) {
-			p.store.AddTransaction(tx.To, tx)
+		if created {
+			result.Subscribed = append(result.Subscribed, address)
+		} else {
+			result.AlreadySubscribed = append(result.AlreadySubscribed, address)
 		}
 	}
+	return result, nil
+}
+
+// recordSubscriptionChange appends a subscription_changed event to the changefeed.
+func (p *EthParser) recordSubscriptionChange(address string, subscribed bool) {
+	p.events.append(Event{Type: EventSubscriptionChanged, Address: address, Subscribed: &subscribed})
+	p.reportSubscribers()
+}
+
+// SetNotificationPrefs replaces the notification preferences of a subscribed address.
+func (p *EthParser) SetNotificationPrefs(address string, prefs NotificationPrefs) error {
+	address = strings.ToLower(address)
+	if err := p.checkStore(); err != nil {
+		return err
+	}
+	if !p.store.SetNotificationPrefs(address, prefs) {
+		return ErrNotSubscribed
+	}
+	p.recordSubscriptionChange(address, true)
+	return nil
 }
 
-// Subscribe adds an address to the subscription set.
-func (p *EthParser) Subscribe(address string) bool {
-	return p.store.Subscribe(address)
+// SetAddressPriority sets the catch-up priority of a subscribed address.
+func (p *EthParser) SetAddressPriority(address string, priority Priority) error {
+	address = strings.ToLower(address)
+	if err := p.checkStore(); err != nil {
+		return err
+	}
+	if !p.store.IsSubscribed(address) {
+		return ErrNotSubscribed
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if priority == PriorityNormal {
+		delete(p.priorities, address)
+	} else {
+		p.priorities[address] = priority
+	}
+	return nil
+}
+
+// AddressPriority returns the catch-up priority of an address, PriorityNormal by default.
+func (p *EthParser) AddressPriority(address string) Priority {
+	address = strings.ToLower(address)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if priority, ok := p.priorities[address]; ok {
+		return priority
+	}
+	return PriorityNormal
+}
+
+// GetNotificationPrefs returns the notification preferences of a subscribed address.
+func (p *EthParser) GetNotificationPrefs(address string) (NotificationPrefs, error) {
+	address = strings.ToLower(address)
+	if err := p.checkStore(); err != nil {
+		return NotificationPrefs{}, err
+	}
+	prefs, ok := p.store.GetNotificationPrefs(address)
+	if !ok {
+		return NotificationPrefs{}, ErrNotSubscribed
+	}
+	return prefs, nil
 }
 
 // GetTransactions returns all transactions for a given address.
 func (p *EthParser) GetTransactions(address string) []Transaction {
+	address = strings.ToLower(address)
 	return p.store.GetTransactions(address)
 }
 
+// GetTransactionsInRange returns an address's transactions between two blocks, inclusive.
+func (p *EthParser) GetTransactionsInRange(address string, fromBlock, toBlock int64) []Transaction {
+	address = strings.ToLower(address)
+	return p.store.GetTransactionsInRange(address, fromBlock, toBlock)
+}
+
+// QueryTransactions returns a filtered page of an address's transactions, oldest first.
+func (p *EthParser) QueryTransactions(address string, q TxQuery) TransactionPage {
+	address = strings.ToLower(address)
+	txs, total := p.store.QueryTransactions(address, q)
+	page := TransactionPage{Transactions: txs, Total: total, Offset: q.Offset, Limit: q.Limit}
+	if next := q.Offset + len(txs); next < total {
+		page.NextOffset = &next
+	}
+	return page
+}
+
+// GetTransactionsForAddresses merges the transactions of all given addresses, ordered by block.
+// Duplicate addresses are only queried once.
+func (p *EthParser) GetTransactionsForAddresses(addresses []string) []AddressTransaction {
+	seen := make(map[string]bool, len(addresses))
+	merged := []AddressTransaction{}
+	for _, address := range addresses {
+		address = strings.ToLower(address)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		for _, tx := range p.store.GetTransactions(address) {
+			merged = append(merged, AddressTransaction{Address: address, Transaction: tx})
+		}
+	}
+	// Stable sort keeps per-address insertion order for transactions in the same block.
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Block < merged[j].Block
+	})
+	return merged
+}
+
 // GetCurrentBlock returns the in-memory current block number.
 func (p *EthParser) GetCurrentBlock() int {
 	p.mu.RLock()
@@ -162,20 +1220,62 @@ func (p *EthParser) GetCurrentBlock() int {
 	return p.store.GetCurrentBlock()
 }
 
-// hexToInt64 converts a "0x..." hex string to int64.
-func hexToInt64(h string) (int64, error) {
-	if len(h) > 2 && h[:2] == "0x" {
-		h = h[2:]
+// storedCurrentBlock returns the current block, failing where GetCurrentBlock would
+// report 0 because the store could not be read.
+func (p *EthParser) storedCurrentBlock() (int, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if reader, ok := p.store.(CurrentBlockReader); ok {
+		return reader.CurrentBlock()
 	}
-	val, err := strconv.ParseInt(h, 16, 64)
-	if err != nil {
-		return 0, err
+	return p.store.GetCurrentBlock(), nil
+}
+
+// SafeBlock returns the chain tip seen on the last poll less the index confirmations:
+// the highest block the parser will process.
+func (p *EthParser) SafeBlock() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return max(p.latestBlock-p.indexDepth, 0)
+}
+
+// VisibleBlock returns the highest block that may be served under the given visibility.
+// The first finalized query fetches the finalized block before answering and enables
+// tracking it on every poll. It returns ErrChainTipUnknown if the chain tip or finalized
+// block the visibility depends on has not been fetched yet.
+func (p *EthParser) VisibleBlock(ctx context.Context, v Visibility) (int, error) {
+	current := p.store.GetCurrentBlock()
+	switch v {
+	case VisibilityConfirmed:
+		p.mu.RLock()
+		latest, confirmations := p.latestBlock, p.confirmations
+		p.mu.RUnlock()
+		if latest == 0 {
+			return 0, ErrChainTipUnknown
+		}
+		return min(current, max(latest-confirmations, 0)), nil
+	case VisibilityFinalized:
+		p.trackFinalized.Store(true)
+		p.mu.RLock()
+		finalized := p.finalizedBlock
+		p.mu.RUnlock()
+		if finalized == 0 {
+			p.refreshFinalizedBlock(ctx)
+			p.mu.RLock()
+			finalized = p.finalizedBlock
+			p.mu.RUnlock()
+		}
+		if finalized == 0 {
+			return 0, ErrChainTipUnknown
+		}
+		return min(current, finalized), nil
+	default:
+		return current, nil
 	}
-	return val, nil
 }
 
-// hexToInt64OrZero returns 0 if hex parsing fails.
-func hexToInt64OrZero(h string) int64 {
-	v, _ := hexToInt64(h)
+// quantityOrZero decodes a block number or timestamp quantity, returning 0 if it is malformed.
+func quantityOrZero(h string) int64 {
+	v, _ := hexutil.DecodeInt64(h)
 	return v
 }
@@ -2,6 +2,16 @@ package txparser
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,18 +47,60 @@ func TestMemoryStore(t *testing.T) {
 	}
 }
 
+// TestMemoryBudget verifies the oldest transactions are evicted once the budget is exceeded.
+func TestMemoryBudget(t *testing.T) {
+	store := NewMemoryStore().(*MemoryStore)
+	store.Subscribe("0x000000000000000000000000000000000000000a")
+	store.Subscribe("0x000000000000000000000000000000000000000b")
+
+	tx := Transaction{Hash: "0x1", From: "0x000000000000000000000000000000000000000a", To: "0x000000000000000000000000000000000000000b", Value: "0x1"}
+	perTx := estimateTxBytes(tx)
+	store.SetMemoryBudget(perTx*4, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for block := int64(1); block <= 5; block++ {
+		tx.Block = block
+		address := "0x000000000000000000000000000000000000000a"
+		if block%2 == 0 {
+			address = "0x000000000000000000000000000000000000000b"
+		}
+		store.AddTransaction(address, tx)
+	}
+
+	usage := store.MemoryUsage()
+	if usage.UsedBytes > usage.BudgetBytes || usage.EvictedTransactions != 2 {
+		t.Fatalf("unexpected usage after eviction %+v", usage)
+	}
+	if txs := store.GetTransactions("0x000000000000000000000000000000000000000a"); len(txs) != 2 || txs[0].Block != 3 {
+		t.Errorf("expected blocks 1 evicted from 0x000000000000000000000000000000000000000a, got %+v", txs)
+	}
+	if txs := store.GetTransactions("0x000000000000000000000000000000000000000b"); len(txs) != 1 || txs[0].Block != 4 {
+		t.Errorf("expected block 2 evicted from 0x000000000000000000000000000000000000000b, got %+v", txs)
+	}
+}
+
 // mockClient is a stub JSONRPCClient for testing parser logic.
 type mockClient struct {
 	latestBlock string
 	blocks      map[int64]BlockResponse
+	txs         map[string]RawTx
 }
 
-func (m *mockClient) BlockNumber() (string, error) {
+func (m *mockClient) BlockNumber(ctx context.Context) (string, error) {
 	return m.latestBlock, nil
 }
-func (m *mockClient) GetBlockByNumber(blockNum int64) (BlockResponse, error) {
+func (m *mockClient) GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error) {
 	return m.blocks[blockNum], nil
 }
+func (m *mockClient) FinalizedBlockNumber(ctx context.Context) (string, error) {
+	return m.latestBlock, nil
+}
+func (m *mockClient) GetTransactionByHash(ctx context.Context, hash string) (RawTx, error) {
+	tx, ok := m.txs[hash]
+	if !ok {
+		return RawTx{}, ErrTransactionNotFound
+	}
+	return tx, nil
+}
 
 // TestParser verifies the parser processes blocks and stores transactions for subscribed addresses.
 func TestParser(t *testing.T) {
@@ -57,36 +109,42 @@ func TestParser(t *testing.T) {
 		blocks: map[int64]BlockResponse{
 			1: {
 				Result: struct {
-					Number       string  `json:"number"`
-					Hash         string  `json:"hash"`
-					Transactions []RawTx `json:"transactions"`
+					Number        string  `json:"number"`
+					Hash          string  `json:"hash"`
+					Timestamp     string  `json:"timestamp"`
+					BaseFeePerGas string  `json:"baseFeePerGas"`
+					Transactions  []RawTx `json:"transactions"`
 				}{
 					Number: "0x1",
 					Hash:   "0xblock1",
 					Transactions: []RawTx{
-						{Hash: "0xtx1", From: "0xABCDEF", To: "0x123", Value: "0x10"},
-						{Hash: "0xtx2", From: "0x555", To: "0x666", Value: "0x20"},
+						{Hash: "0xtx1", From: "0x0000000000000000000000000000000000ABCDEF", To: "0x0000000000000000000000000000000000000123", Value: "0x10"},
+						{Hash: "0xtx2", From: "0x0000000000000000000000000000000000000555", To: "0x666", Value: "0x20"},
 					},
 				},
 			},
 			2: {
 				Result: struct {
-					Number       string  `json:"number"`
-					Hash         string  `json:"hash"`
-					Transactions []RawTx `json:"transactions"`
+					Number        string  `json:"number"`
+					Hash          string  `json:"hash"`
+					Timestamp     string  `json:"timestamp"`
+					BaseFeePerGas string  `json:"baseFeePerGas"`
+					Transactions  []RawTx `json:"transactions"`
 				}{
 					Number: "0x2",
 					Hash:   "0xblock2",
 					Transactions: []RawTx{
-						{Hash: "0xtx3", From: "0x123", To: "0xABCDEF", Value: "0x15"},
+						{Hash: "0xtx3", From: "0x0000000000000000000000000000000000000123", To: "0x0000000000000000000000000000000000ABCDEF", Value: "0x15"},
 					},
 				},
 			},
 			3: {
 				Result: struct {
-					Number       string  `json:"number"`
-					Hash         string  `json:"hash"`
-					Transactions []RawTx `json:"transactions"`
+					Number        string  `json:"number"`
+					Hash          string  `json:"hash"`
+					Timestamp     string  `json:"timestamp"`
+					BaseFeePerGas string  `json:"baseFeePerGas"`
+					Transactions  []RawTx `json:"transactions"`
 				}{
 					Number:       "0x3",
 					Hash:         "0xblock3",
@@ -97,20 +155,20 @@ func TestParser(t *testing.T) {
 	}
 
 	store := NewMemoryStore()
-	logger := slog.New(slog.NewTextHandler(nil, nil)) // minimal logger to pass in
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil)) // minimal logger to pass in
 	parser := NewEthParser(mc, store, logger)
 
-	// Subscribe to address "0x123" so we only track those.
-	parser.Subscribe("0x123")
+	// Subscribe to address "0x0000000000000000000000000000000000000123" so we only track those.
+	parser.Subscribe("0x0000000000000000000000000000000000000123")
 
 	// Manually process next blocks
-	if err := parser.processNextBlock(); err != nil {
+	if err := parser.processNextBlock(context.Background()); err != nil {
 		t.Fatalf("processNextBlock block1 error: %v", err)
 	}
-	if err := parser.processNextBlock(); err != nil {
+	if err := parser.processNextBlock(context.Background()); err != nil {
 		t.Fatalf("processNextBlock block2 error: %v", err)
 	}
-	if err := parser.processNextBlock(); err != nil {
+	if err := parser.processNextBlock(context.Background()); err != nil {
 		t.Fatalf("processNextBlock block3 error: %v", err)
 	}
 
@@ -119,8 +177,8 @@ func TestParser(t *testing.T) {
 		t.Errorf("expected current block=3, got %d", parser.GetCurrentBlock())
 	}
 
-	// We subscribed to "0x123", so let's see which txs we got
-	txs := parser.GetTransactions("0x123")
+	// We subscribed to "0x0000000000000000000000000000000000000123", so let's see which txs we got
+	txs := parser.GetTransactions("0x0000000000000000000000000000000000000123")
 	if len(txs) != 2 {
 		t.Errorf("expected 2 transactions for 0x123, got %d", len(txs))
 	}
@@ -131,3 +189,884 @@ func TestParser(t *testing.T) {
 	time.Sleep(30 * time.Millisecond)
 	cancel() // ensure no panic or hang
 }
+
+// TestGetTransactionsForAddresses verifies multi-address results are merged in block order.
+func TestGetTransactionsForAddresses(t *testing.T) {
+	store := NewMemoryStore()
+	parser := NewEthParser(&mockClient{}, store, nil)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	parser.Subscribe("0x000000000000000000000000000000000000000b")
+
+	store.AddTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0x1", Block: 5})
+	store.AddTransaction("0x000000000000000000000000000000000000000b", Transaction{Hash: "0x2", Block: 3})
+	store.AddTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0x3", Block: 7})
+
+	txs := parser.GetTransactionsForAddresses([]string{"0x000000000000000000000000000000000000000a", "0x000000000000000000000000000000000000000b", "0x000000000000000000000000000000000000000a"})
+	if len(txs) != 3 {
+		t.Fatalf("expected 3 merged txs, got %d", len(txs))
+	}
+	wantOrder := []string{"0x2", "0x1", "0x3"}
+	for i, hash := range wantOrder {
+		if txs[i].Hash != hash {
+			t.Errorf("position %d: expected hash %s, got %s", i, hash, txs[i].Hash)
+		}
+	}
+	if txs[0].Address != "0x000000000000000000000000000000000000000b" {
+		t.Errorf("expected first tx attributed to 0x000000000000000000000000000000000000000b, got %s", txs[0].Address)
+	}
+}
+
+// TestVisibleBlock verifies confirmation and finality limits on served blocks.
+func TestVisibleBlock(t *testing.T) {
+	mc := &mockClient{latestBlock: "0x5", blocks: map[int64]BlockResponse{}}
+	store := NewMemoryStore()
+	store.SetCurrentBlock(4)
+	parser := NewEthParser(mc, store, nil)
+	parser.SetConfirmations(2)
+	ctx := context.Background()
+
+	if _, err := parser.VisibleBlock(ctx, VisibilityConfirmed); !errors.Is(err, ErrChainTipUnknown) {
+		t.Errorf("expected confirmed visibility to be unknown before the first poll, got %v", err)
+	}
+	if err := parser.processNextBlock(ctx); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+	if got, _ := parser.VisibleBlock(ctx, VisibilityAll); got != 5 {
+		t.Errorf("expected all visibility up to 5, got %d", got)
+	}
+	if got, _ := parser.VisibleBlock(ctx, VisibilityConfirmed); got != 3 {
+		t.Errorf("expected confirmed visibility up to 3, got %d", got)
+	}
+	if got, err := parser.VisibleBlock(ctx, VisibilityFinalized); got != 5 || err != nil {
+		t.Errorf("expected the first finalized query to fetch the finalized block, got %d %v", got, err)
+	}
+	parser.SetConfirmations(10)
+	if got, _ := parser.VisibleBlock(ctx, VisibilityConfirmed); got != 0 {
+		t.Errorf("expected confirmed visibility clamped to 0, got %d", got)
+	}
+}
+
+// TestCheckConsistency verifies a reorg during downtime rolls back to the last matching ancestor.
+func TestCheckConsistency(t *testing.T) {
+	mc := &mockClient{latestBlock: "0x3", blocks: map[int64]BlockResponse{}}
+	for n := int64(1); n <= 3; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Hash = fmt.Sprintf("0xb%d", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0xt%d", n), From: "0x000000000000000000000000000000000000000a", To: "0x000000000000000000000000000000000000000b"}}
+		mc.blocks[n] = block
+	}
+
+	parser := NewEthParser(mc, NewMemoryStore(), nil)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	for i := 0; i < 3; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+
+	// Reorg blocks 2 and 3 away while "offline".
+	for _, n := range []int64{2, 3} {
+		block := mc.blocks[n]
+		block.Result.Hash = fmt.Sprintf("0xreorg%d", n)
+		mc.blocks[n] = block
+	}
+
+	if err := parser.checkConsistency(context.Background()); err != nil {
+		t.Fatalf("checkConsistency error: %v", err)
+	}
+	if parser.GetCurrentBlock() != 1 {
+		t.Errorf("expected rollback to block 1, got %d", parser.GetCurrentBlock())
+	}
+	if txs := parser.GetTransactions("0x000000000000000000000000000000000000000a"); len(txs) != 1 || txs[0].Hash != "0xt1" {
+		t.Errorf("expected only tx from block 1 to remain, got %+v", txs)
+	}
+}
+
+// flakyBlockClient fails the next failures block fetches.
+type flakyBlockClient struct {
+	*mockClient
+	failures int
+}
+
+func (c *flakyBlockClient) GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error) {
+	if c.failures > 0 {
+		c.failures--
+		return BlockResponse{}, errors.New("connection refused")
+	}
+	return c.mockClient.GetBlockByNumber(ctx, blockNum)
+}
+
+// TestStartupConsistencyRetry verifies a failed startup check holds parsing back
+// until it is retried successfully.
+func TestStartupConsistencyRetry(t *testing.T) {
+	mc := &mockClient{latestBlock: "0x3", blocks: map[int64]BlockResponse{}}
+	for n := int64(1); n <= 3; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Hash = fmt.Sprintf("0xb%d", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0xt%d", n), From: "0x000000000000000000000000000000000000000a", To: "0x000000000000000000000000000000000000000b"}}
+		mc.blocks[n] = block
+	}
+	client := &flakyBlockClient{mockClient: mc}
+	store := NewMemoryStore()
+	parser := NewEthParser(client, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	for i := 0; i < 3; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+	block := mc.blocks[3]
+	block.Result.Hash = "0xreorg3"
+	mc.blocks[3] = block
+	client.failures = 1
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	parser.SetClock(clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go parser.StartParsing(ctx, time.Minute)
+	for clock.Waiters() == 0 {
+		runtime.Gosched()
+	}
+	if hash, _ := store.GetBlockHash(3); hash != "0xb3" || len(parser.GetTransactions("0x000000000000000000000000000000000000000a")) != 3 {
+		t.Fatalf("expected no block processed while the check fails, block 3 hash %q", hash)
+	}
+
+	clock.Advance(time.Minute)
+	for clock.Waiters() == 0 {
+		runtime.Gosched()
+	}
+	if hash, _ := store.GetBlockHash(3); hash != "0xreorg3" || parser.GetCurrentBlock() != 3 {
+		t.Errorf("expected the retried check to roll back and reparse block 3, got hash %q at block %d", hash, parser.GetCurrentBlock())
+	}
+}
+
+// TestAutoDiscovery verifies counterparties of a discovery contract are subscribed with a TTL.
+func TestAutoDiscovery(t *testing.T) {
+	store := NewMemoryStore()
+	parser := NewEthParser(&mockClient{}, store, nil)
+	parser.SetAutoDiscovery([]string{"0xDAPP"}, time.Hour)
+
+	tx := Transaction{Hash: "0xt1", From: "0xuser", To: "0xdapp", Block: 1}
+	parser.storeTransaction(tx, RawTx{})
+
+	if txs := parser.GetTransactions("0xuser"); len(txs) != 1 {
+		t.Fatalf("expected discovered address to record the tx, got %d", len(txs))
+	}
+
+	if !store.SubscribeUntil("0xexpired", time.Now().Add(-time.Second)) {
+		t.Fatalf("expected new TTL subscription to return true")
+	}
+	if store.IsSubscribed("0xexpired") {
+		t.Errorf("expected expired subscription to be inactive")
+	}
+	if n := store.(SubscriptionPurger).PurgeExpiredSubscriptions(); n != 1 || !store.IsSubscribed("0xuser") {
+		t.Errorf("expected only the expired subscription to be purged, got %d", n)
+	}
+	if n := store.(SubscriptionPurger).PurgeExpiredSubscriptions(); n != 0 {
+		t.Errorf("expected nothing left to purge, got %d", n)
+	}
+	if !store.Subscribe("0xexpired") {
+		t.Errorf("expected re-subscribing a lapsed address to return true")
+	}
+}
+
+// TestPrefetch verifies catching up with prefetching yields the same result as sequential fetches.
+func TestPrefetch(t *testing.T) {
+	mc := &mockClient{latestBlock: "0x5", blocks: map[int64]BlockResponse{}}
+	for n := int64(1); n <= 5; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0xt%d", n), To: "0x000000000000000000000000000000000000000a"}}
+		mc.blocks[n] = block
+	}
+
+	parser := NewEthParser(mc, NewMemoryStore(), nil)
+	parser.SetPrefetchDepth(3)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	for i := 0; i < 5; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+
+	txs := parser.GetTransactions("0x000000000000000000000000000000000000000a")
+	if len(txs) != 5 {
+		t.Fatalf("expected 5 txs, got %d", len(txs))
+	}
+	for i, tx := range txs {
+		if tx.Block != int64(i+1) {
+			t.Errorf("tx %d: expected block %d, got %d", i, i+1, tx.Block)
+		}
+	}
+
+	parser.prefetcher.prefetch(context.Background(), 2, 5)
+	parser.prefetcher.reset()
+	if _, ok := parser.prefetcher.take(3); ok {
+		t.Errorf("expected a reset to drop prefetched blocks")
+	}
+}
+
+// TestInputMatching verifies subscribed addresses in calldata are matched with a marker.
+func TestInputMatching(t *testing.T) {
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), nil)
+	parser.SetInputMatching(true)
+	parser.Subscribe("0x00000000000000000000000000000000000000be")
+
+	word := "000000000000000000000000" + "00000000000000000000000000000000000000be"
+	amount := "00000000000000000000000000000000000000000000000000000000000003e8"
+	raw := RawTx{Hash: "0xt1", From: "0xhot", To: "0xbatcher", Input: "0xa9059cbb" + word + amount}
+	parser.storeTransaction(newTransaction(raw, 1, 0), raw)
+
+	txs := parser.GetTransactions("0x00000000000000000000000000000000000000be")
+	if len(txs) != 1 || txs[0].MatchType != MatchTypeInput {
+		t.Fatalf("expected one input match, got %+v", txs)
+	}
+}
+
+// TestErrorHistory verifies the ring buffer keeps the newest errors first, redacts
+// endpoint URLs and mirrors errors to the log file.
+func TestErrorHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	history, err := NewErrorHistory(2, path)
+	if err != nil {
+		t.Fatalf("NewErrorHistory error: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		history.Record("rpc", i, fmt.Errorf("failure %d", i))
+	}
+	recent := history.Recent()
+	if len(recent) != 2 || recent[0].Block != 3 || recent[1].Block != 2 {
+		t.Errorf("expected blocks [3 2], got %+v", recent)
+	}
+
+	transport := &url.Error{Op: "Post", URL: "https://mainnet.example.io/v3/secret-key?token=abc", Err: io.ErrUnexpectedEOF}
+	history.Record("parser", 4, fmt.Errorf("failed to get block number: %w", transport))
+	if msg := history.Recent()[0].Message; strings.Contains(msg, "secret") || !strings.Contains(msg, "https://mainnet.example.io") {
+		t.Errorf("expected the endpoint to be reduced to its host, got %q", msg)
+	}
+	if err := history.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.Count(string(data), "\n") != 4 || strings.Contains(string(data), "secret") {
+		t.Errorf("unexpected error log %q, %v", data, err)
+	}
+}
+
+// TestWatchTx verifies a watched hash moves from pending to mined to confirmed, that
+// each change reaches the changefeed, and that finished watches expire.
+func TestWatchTx(t *testing.T) {
+	mc := &mockClient{latestBlock: "0x3", blocks: map[int64]BlockResponse{}}
+	for n := int64(1); n <= 3; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0x%064X", n)}}
+		mc.blocks[n] = block
+	}
+	parser := NewEthParser(mc, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	parser.watches.clock = clock
+	hash := fmt.Sprintf("0x%064x", 2)
+
+	if _, err := parser.WatchTx("0xt2", 2); !errors.Is(err, ErrInvalidTxHash) {
+		t.Errorf("expected an invalid hash error, got %v", err)
+	}
+	if watch, err := parser.WatchTx(hash, 2); err != nil || watch.State != TxPending {
+		t.Fatalf("expected pending, got %s %v", watch.State, err)
+	}
+	wantStates := []TxWatchState{TxPending, TxMined, TxConfirmed}
+	for i, want := range wantStates {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+		if watch, _ := parser.GetTxWatch(strings.ToUpper(hash)); watch.State != want {
+			t.Errorf("after block %d: expected %s, got %s", i+1, want, watch.State)
+		}
+	}
+
+	events, _, _ := parser.EventsSince(0, 100)
+	var changes []TxWatchState
+	for _, e := range events {
+		if e.Type == EventTxWatch {
+			changes = append(changes, e.TxWatch.State)
+		}
+	}
+	if len(changes) != 2 || changes[0] != TxMined || changes[1] != TxConfirmed {
+		t.Errorf("expected mined and confirmed changefeed events, got %v", changes)
+	}
+
+	parser.watches.limit = 1
+	other := fmt.Sprintf("0x%064x", 9)
+	if _, err := parser.WatchTx(other, 2); !errors.Is(err, ErrTooManyWatches) {
+		t.Errorf("expected the watch limit to apply, got %v", err)
+	}
+	clock.Advance(DefaultWatchRetention + time.Second)
+	if _, err := parser.WatchTx(other, 2); err != nil {
+		t.Errorf("expected the confirmed watch to expire and free a slot, got %v", err)
+	}
+	if _, ok := parser.GetTxWatch(hash); ok {
+		t.Error("expected the confirmed watch to be forgotten after the retention")
+	}
+}
+
+// TestEventLog verifies changefeed ordering, paging and cursor expiry.
+func TestEventLog(t *testing.T) {
+	log := newEventLog(3)
+	for block := 1; block <= 4; block++ {
+		log.append(Event{Type: EventBlockProcessed, Block: block})
+	}
+
+	if _, _, err := log.since(0, 10); err != ErrCursorExpired {
+		t.Errorf("expected expired cursor, got %v", err)
+	}
+	events, next, err := log.since(1, 2)
+	if err != nil {
+		t.Fatalf("since error: %v", err)
+	}
+	if len(events) != 2 || events[0].Block != 2 || next != 3 {
+		t.Errorf("unexpected page %+v next=%d", events, next)
+	}
+	events, next, _ = log.since(next, 10)
+	if len(events) != 1 || events[0].Seq != 4 || next != 4 {
+		t.Errorf("unexpected final page %+v next=%d", events, next)
+	}
+	if events, _, _ := log.since(4, 10); len(events) != 0 {
+		t.Errorf("expected no events after the head, got %+v", events)
+	}
+	if seq, err := parseEventCursor(log.epoch, formatEventCursor(log.epoch, 4)); seq != 4 || err != nil {
+		t.Errorf("expected the cursor to round-trip, got %d %v", seq, err)
+	}
+	if _, err := parseEventCursor(newEventEpoch(), formatEventCursor(log.epoch, 4)); !errors.Is(err, ErrCursorExpired) {
+		t.Errorf("expected a cursor of another epoch to be expired, got %v", err)
+	}
+	if _, err := parseEventCursor(log.epoch, "4"); errors.Is(err, ErrCursorExpired) || err == nil {
+		t.Errorf("expected a malformed cursor error, got %v", err)
+	}
+}
+
+// TestAuditMode verifies store invariants hold while parsing, including self-transfers,
+// and that a corrupted store fails loudly.
+func TestAuditMode(t *testing.T) {
+	mc := &mockClient{latestBlock: "0x3", blocks: map[int64]BlockResponse{}}
+	for n := int64(1); n <= 3; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Hash = fmt.Sprintf("0xb%d", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0xt%d", n), From: "0x000000000000000000000000000000000000000a", To: "0x000000000000000000000000000000000000000a", Value: "0x1"}}
+		mc.blocks[n] = block
+	}
+	store := NewMemoryStore()
+	parser := NewEthParser(mc, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetAuditMode(true)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+
+	for i := 0; i < 2; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+	if txs := parser.GetTransactions("0x000000000000000000000000000000000000000a"); len(txs) != 2 {
+		t.Fatalf("expected self-transfers stored once, got %d transactions", len(txs))
+	}
+
+	store.AddTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xt2", Block: 2})
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "duplicate transaction 0xt2") {
+			t.Errorf("expected duplicate transaction to panic, got %v", r)
+		}
+	}()
+	parser.processNextBlock(context.Background())
+}
+
+// TestFakeClock verifies polling and subscription expiry follow an injected clock.
+func TestFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemoryStore()
+	parser := NewEthParser(&mockClient{latestBlock: "0x5", blocks: map[int64]BlockResponse{}}, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetClock(clock)
+
+	store.SubscribeUntil("0x000000000000000000000000000000000000000a", clock.Now().Add(time.Hour))
+	clock.Advance(59 * time.Minute)
+	if !parser.store.IsSubscribed("0x000000000000000000000000000000000000000a") {
+		t.Fatalf("expected subscription to be active before its TTL")
+	}
+	clock.Advance(time.Minute)
+	if parser.store.IsSubscribed("0x000000000000000000000000000000000000000a") {
+		t.Fatalf("expected subscription to lapse at its TTL")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go parser.StartParsing(ctx, time.Minute)
+	for block := 1; block <= 3; block++ {
+		for clock.Waiters() == 0 {
+			runtime.Gosched()
+		}
+		if got := parser.GetCurrentBlock(); got != block {
+			t.Fatalf("expected block %d before the poll interval elapsed, got %d", block, got)
+		}
+		clock.Advance(time.Minute)
+	}
+}
+
+// TestNewTransactionDecimalValues verifies hex quantities are restated exactly in decimal,
+// including values that overflow 64 bits.
+func TestNewTransactionDecimalValues(t *testing.T) {
+	tx := newTransaction(RawTx{Hash: "0xt", Value: "0x1bc16d674ec80000ffff", GasPrice: "0x3b9aca00"}, 1, 0)
+	if tx.ValueWei != "131072000000000000065535" || tx.ValueEther != "131072.000000000000065535" {
+		t.Errorf("unexpected valueWei %q or valueEther %q", tx.ValueWei, tx.ValueEther)
+	}
+	if value, ok := tx.WeiValue(); !ok || value.String() != tx.ValueWei {
+		t.Errorf("expected WeiValue to match valueWei, got %v", value)
+	}
+	for value, want := range map[string]string{"0x0": "0", "0xde0b6b3a7640000": "1", "0x14d1120d7b160000": "1.5", "0x1": "0.000000000000000001"} {
+		if got := newTransaction(RawTx{Value: value}, 1, 0).ValueEther; got != want {
+			t.Errorf("%s: expected valueEther %q, got %q", value, want, got)
+		}
+	}
+	if tx.GasPriceWei != "1000000000" {
+		t.Errorf("unexpected gasPriceWei %q", tx.GasPriceWei)
+	}
+	if tx := newTransaction(RawTx{Value: "0xzz"}, 1, 0); tx.ValueWei != "" || tx.ValueEther != "" || tx.GasPriceWei != "" {
+		t.Errorf("expected invalid and missing quantities to be omitted, got %+v", tx)
+	}
+}
+
+// TestDomainErrors verifies Parser methods report typed errors.
+func TestDomainErrors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+
+	if _, err := parser.Subscribe("not-an-address"); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("expected ErrInvalidAddress, got %v", err)
+	}
+	if _, err := parser.GetNotificationPrefs("0x000000000000000000000000000000000000000a"); !errors.Is(err, ErrNotSubscribed) {
+		t.Errorf("expected ErrNotSubscribed, got %v", err)
+	}
+	if err := parser.SetAddressPriority("0x000000000000000000000000000000000000000a", PriorityHigh); !errors.Is(err, ErrNotSubscribed) {
+		t.Errorf("expected ErrNotSubscribed, got %v", err)
+	}
+
+	store, err := OpenBoltStore(filepath.Join(t.TempDir(), "parser.db"), logger)
+	if err != nil {
+		t.Fatalf("OpenBoltStore error: %v", err)
+	}
+	parser = NewEthParser(&mockClient{}, store, logger)
+	store.Close()
+	if _, err := parser.Subscribe("0x000000000000000000000000000000000000000a"); !errors.Is(err, ErrStoreUnavailable) {
+		t.Errorf("expected ErrStoreUnavailable, got %v", err)
+	}
+}
+
+// TestAddressNormalization verifies addresses are checked against EIP-55 and matched
+// regardless of case.
+func TestAddressNormalization(t *testing.T) {
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	lower := strings.ToLower(checksummed)
+	if got := checksumAddress(lower); got != checksummed {
+		t.Errorf("checksumAddress = %s, want %s", got, checksummed)
+	}
+
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := parser.Subscribe("0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed"); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("expected ErrInvalidAddress for a bad checksum, got %v", err)
+	}
+	if created, err := parser.Subscribe(checksummed); err != nil || !created {
+		t.Fatalf("Subscribe(%s) = %v, %v", checksummed, created, err)
+	}
+	if created, err := parser.Subscribe("0x" + strings.ToUpper(lower[2:])); err != nil || created {
+		t.Errorf("expected the uppercase form to match the existing subscription, got %v, %v", created, err)
+	}
+
+	raw := RawTx{Hash: "0xtx1", From: "0x1111111111111111111111111111111111111111", To: checksummed, Value: "0x1"}
+	parser.storeTransactions([]Transaction{newTransaction(raw, 1, 0)}, []RawTx{raw})
+	for _, address := range []string{checksummed, lower} {
+		txs := parser.GetTransactions(address)
+		if len(txs) != 1 || txs[0].To != lower {
+			t.Errorf("GetTransactions(%s) = %+v, want one transaction to %s", address, txs, lower)
+		}
+	}
+}
+
+// tokenClient is a mockClient that also serves Transfer logs and token decimals.
+type tokenClient struct {
+	mockClient
+	logs     map[int64][]RawLog
+	decimals map[string]int
+	calls    int
+}
+
+func (c *tokenClient) GetTransferLogs(ctx context.Context, blockNum int64) ([]RawLog, error) {
+	return c.logs[blockNum], nil
+}
+
+func (c *tokenClient) TokenDecimals(ctx context.Context, contract string) (int, error) {
+	c.calls++
+	decimals, ok := c.decimals[contract]
+	if !ok {
+		return 0, ErrNoDecimals
+	}
+	return decimals, nil
+}
+
+// TestTokenTransfers verifies ERC-20 Transfer logs are stored as token transactions
+// with decimals-adjusted values, and that other logs are ignored.
+func TestTokenTransfers(t *testing.T) {
+	word := func(address string) string { return "0x" + strings.Repeat("0", 24) + address[2:] }
+	amount := func(digits string) string { return "0x" + strings.Repeat("0", 64-len(digits)) + digits }
+	sender := "0x00000000000000000000000000000000000000aa"
+	recipient := "0x00000000000000000000000000000000000000bb"
+	transfer := func(contract, hash, data string, extraTopics ...string) RawLog {
+		return RawLog{
+			Address:         contract,
+			Topics:          append([]string{TransferEventTopic, word(sender), word(recipient)}, extraTopics...),
+			Data:            data,
+			TransactionHash: hash,
+		}
+	}
+	client := &tokenClient{
+		mockClient: mockClient{latestBlock: "0x2", blocks: map[int64]BlockResponse{}},
+		logs: map[int64][]RawLog{
+			1: {
+				transfer("0xUSDC", "0xt1", amount("16e360")), // 1500000
+				transfer("0xNFT", "0xt2", "0x", word(sender)),
+			},
+			2: {
+				transfer("0xusdc", "0xt3", amount("1")),
+				transfer("0xweird", "0xt4", amount("7")),
+				transfer("0xusdc", "0xt5", "0x16e360"), // not a 32-byte word
+			},
+		},
+		decimals: map[string]int{"0xusdc": 6},
+	}
+	client.blocks[1] = BlockResponse{}
+	client.blocks[2] = BlockResponse{}
+	parser := NewEthParser(client, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetTokenTracking(true)
+	parser.Subscribe(recipient)
+	for i := 0; i < 2; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+
+	txs := parser.GetTransactions(recipient)
+	if len(txs) != 3 {
+		t.Fatalf("expected 3 token transfers, got %+v", txs)
+	}
+	want := []struct{ hash, token, amount, value string }{
+		{"0xt1", "0xusdc", "1500000", "1.5"},
+		{"0xt3", "0xusdc", "1", "0.000001"},
+		{"0xt4", "0xweird", "7", ""},
+	}
+	for i, w := range want {
+		tx := txs[i]
+		if tx.Hash != w.hash || tx.Token != w.token || tx.TokenAmount != w.amount || tx.TokenValue != w.value ||
+			tx.MatchType != MatchTypeToken || tx.From != sender {
+			t.Errorf("tx %d: expected %+v, got %+v", i, w, tx)
+		}
+	}
+	if client.calls != 2 {
+		t.Errorf("expected decimals to be fetched once per contract, got %d calls", client.calls)
+	}
+	if _, ok := parser.GetValueStats(recipient); ok {
+		t.Errorf("expected token transfers not to feed native value stats")
+	}
+}
+
+type logClient struct {
+	mockClient
+	logs map[int64][]RawLog
+}
+
+func (c *logClient) GetBlockLogs(ctx context.Context, blockNum int64) ([]RawLog, error) {
+	return c.logs[blockNum], nil
+}
+
+// TestLogMatching verifies transactions whose events carry a subscribed address as an
+// indexed topic are stored as log matches, once per address and not for its own transfers.
+func TestLogMatching(t *testing.T) {
+	word := func(address string) string { return "0x" + strings.Repeat("0", 24) + address[2:] }
+	watched := "0x00000000000000000000000000000000000000be"
+	depositTopic := "0xe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c"
+	client := &logClient{
+		mockClient: mockClient{latestBlock: "0x1", blocks: map[int64]BlockResponse{}},
+		logs: map[int64][]RawLog{1: {
+			{Address: "0xvault", Topics: []string{depositTopic, word(watched)}, TransactionHash: "0xt1"},
+			{Address: "0xvault", Topics: []string{depositTopic, word(watched), word(watched)}, TransactionHash: "0xt1"},
+			{Address: "0xvault", Topics: []string{depositTopic, word(watched)}, TransactionHash: "0xt2"},
+			{Address: "0xvault", Topics: []string{depositTopic, word(watched)}, TransactionHash: "0xt3", Removed: true},
+			{Address: "0xvault", Topics: []string{word(watched)}, TransactionHash: "0xt3"}, // signature only
+		}},
+	}
+	var block BlockResponse
+	block.Result.Number = "0x1"
+	block.Result.Transactions = []RawTx{
+		{Hash: "0xt1", From: "0xrelayer", To: "0xvault", Value: "0x0"},
+		{Hash: "0xt2", From: watched, To: "0xvault", Value: "0x5"},
+		{Hash: "0xt3", From: "0xother", To: "0xvault", Value: "0x0"},
+	}
+	client.blocks[1] = block
+	parser := NewEthParser(client, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetLogMatching(true)
+	parser.Subscribe(watched)
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+
+	txs := parser.GetTransactions(watched)
+	if len(txs) != 2 {
+		t.Fatalf("expected a log match and a direct transfer, got %+v", txs)
+	}
+	for _, tx := range txs {
+		switch tx.Hash {
+		case "0xt1":
+			if tx.MatchType != MatchTypeLog || tx.From != "0xrelayer" {
+				t.Errorf("expected 0xt1 as a log match, got %+v", tx)
+			}
+		case "0xt2":
+			if tx.MatchType != "" {
+				t.Errorf("expected the sender's own transaction to match by from, got %+v", tx)
+			}
+		default:
+			t.Errorf("unexpected match %+v", tx)
+		}
+	}
+}
+
+// TestIndexConfirmations verifies blocks closer to the tip than the configured
+// confirmations are not processed until the chain grows past them.
+func TestIndexConfirmations(t *testing.T) {
+	mc := &mockClient{latestBlock: "0x5", blocks: map[int64]BlockResponse{}}
+	for n := int64(1); n <= 6; n++ {
+		var block BlockResponse
+		block.Result.Number = fmt.Sprintf("0x%x", n)
+		block.Result.Transactions = []RawTx{{Hash: fmt.Sprintf("0xt%d", n), From: "0x000000000000000000000000000000000000000a", To: "0x000000000000000000000000000000000000000b", Value: "0x1"}}
+		mc.blocks[n] = block
+	}
+	parser := NewEthParser(mc, NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetIndexConfirmations(2)
+	parser.Subscribe("0x000000000000000000000000000000000000000a")
+	for i := 0; i < 5; i++ {
+		if err := parser.processNextBlock(context.Background()); err != nil {
+			t.Fatalf("processNextBlock error: %v", err)
+		}
+	}
+	if current, safe := parser.GetCurrentBlock(), parser.SafeBlock(); current != 3 || safe != 3 {
+		t.Fatalf("expected current and safe block 3, got %d and %d", current, safe)
+	}
+	if txs := parser.GetTransactions("0x000000000000000000000000000000000000000a"); len(txs) != 3 {
+		t.Errorf("expected transactions of blocks 1-3 only, got %+v", txs)
+	}
+	rec := httptest.NewRecorder()
+	NewHTTPServer(parser, nil).Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/current-block", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `{"currentBlock":3,"safeBlock":3}`) {
+		t.Errorf("expected current and safe block in the response, got %s", body)
+	}
+
+	mc.latestBlock = "0x6"
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+	if current := parser.GetCurrentBlock(); current != 4 {
+		t.Errorf("expected block 4 once the tip moved, got %d", current)
+	}
+}
+
+// TestStartBlock verifies parsing begins at the configured block only without stored progress.
+func TestStartBlock(t *testing.T) {
+	for _, tt := range []struct {
+		spec string
+		want int64
+	}{{"latest", 100}, {"latest-10", 90}, {"latest-500", 1}, {"42", 42}} {
+		start, err := ParseStartBlock(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseStartBlock(%q) error: %v", tt.spec, err)
+		}
+		if got := start.resolve(100); got != tt.want || start.String() != tt.spec {
+			t.Errorf("%s: expected %d, got %d (%s)", tt.spec, tt.want, got, start)
+		}
+	}
+	for _, spec := range []string{"", "latest+1", "latest-", "-5", "earliest"} {
+		if _, err := ParseStartBlock(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start, _ := ParseStartBlock("latest-10")
+	parser := NewEthParser(&mockClient{latestBlock: "0x64"}, NewMemoryStore(), logger)
+	parser.SetStartBlock(start)
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+	if parser.GetCurrentBlock() != 90 {
+		t.Errorf("expected to start at block 90, got %d", parser.GetCurrentBlock())
+	}
+
+	store := NewMemoryStore()
+	store.SetCurrentBlock(50)
+	parser = NewEthParser(&mockClient{latestBlock: "0x64"}, store, logger)
+	parser.SetStartBlock(start)
+	if err := parser.processNextBlock(context.Background()); err != nil {
+		t.Fatalf("processNextBlock error: %v", err)
+	}
+	if parser.GetCurrentBlock() != 51 {
+		t.Errorf("expected stored progress to win, got %d", parser.GetCurrentBlock())
+	}
+}
+
+// TestDeployments verifies contracts created by subscribed addresses are recorded and subscribed.
+func TestDeployments(t *testing.T) {
+	const deployer = "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0"
+	for nonce, want := range map[string]string{
+		"0x0": "0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d",
+		"0x1": "0x343c43a37d37dff08ae8c4a11544c718abb4fcf8",
+	} {
+		if got, ok := contractAddress(deployer, nonce); !ok || got != want {
+			t.Errorf("contractAddress(nonce %s) = %s, %v, want %s", nonce, got, ok, want)
+		}
+	}
+
+	store := NewMemoryStore()
+	parser := NewEthParser(&mockClient{}, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	parser.SetDeploymentSubscription(true)
+	parser.Subscribe(deployer)
+	raws := []RawTx{
+		{Hash: "0xdeploy", From: deployer, Value: "0x0", Nonce: "0x1"},
+		{Hash: "0xother", From: "0x1111111111111111111111111111111111111111", Value: "0x0", Nonce: "0x0"},
+	}
+	txs := []Transaction{newTransaction(raws[0], 7, 0), newTransaction(raws[1], 7, 0)}
+	parser.storeTransactions(txs, raws)
+	parser.storeTransactions(txs, raws) // reprocessed after a reorg
+
+	deployments := parser.GetDeployments(deployer)
+	if len(deployments) != 1 || deployments[0].Contract != "0x343c43a37d37dff08ae8c4a11544c718abb4fcf8" || deployments[0].Block != 7 {
+		t.Fatalf("unexpected deployments %+v", deployments)
+	}
+	if !store.IsSubscribed(deployments[0].Contract) {
+		t.Errorf("expected deployed contract to be subscribed")
+	}
+}
+
+// slowBlockClient blocks GetBlockByNumber until release is closed.
+type slowBlockClient struct {
+	mockClient
+	fetching chan struct{}
+	release  chan struct{}
+}
+
+func (c *slowBlockClient) GetBlockByNumber(ctx context.Context, blockNum int64) (BlockResponse, error) {
+	close(c.fetching)
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return BlockResponse{}, ctx.Err()
+	}
+	return c.mockClient.GetBlockByNumber(ctx, blockNum)
+}
+
+// TestParserStop verifies Stop lets the block in progress finish after cancellation,
+// and aborts it once its own context ends.
+func TestParserStop(t *testing.T) {
+	var block BlockResponse
+	block.Result.Number, block.Result.Hash = "0x1", "0xh1"
+	block.Result.Transactions = []RawTx{{Hash: "0xtx1", From: "0x000000000000000000000000000000000000000a", To: "0x000000000000000000000000000000000000000b", Value: "0x1"}}
+	newClient := func() *slowBlockClient {
+		return &slowBlockClient{
+			mockClient: mockClient{latestBlock: "0x1", blocks: map[int64]BlockResponse{1: block}},
+			fetching:   make(chan struct{}),
+			release:    make(chan struct{}),
+		}
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	client := newClient()
+	parser := NewEthParser(client, NewMemoryStore(), logger)
+	parser.Subscribe("0x000000000000000000000000000000000000000b")
+	ctx, cancel := context.WithCancel(context.Background())
+	go parser.StartParsing(ctx, time.Hour)
+	<-client.fetching
+	cancel()
+	close(client.release)
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stopCancel()
+	if err := parser.Stop(stopCtx); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+	if parser.GetCurrentBlock() != 1 || len(parser.GetTransactions("0x000000000000000000000000000000000000000b")) != 1 {
+		t.Errorf("expected the in-flight block to be stored, at block %d", parser.GetCurrentBlock())
+	}
+
+	client = newClient()
+	parser = NewEthParser(client, NewMemoryStore(), logger)
+	ctx, cancel = context.WithCancel(context.Background())
+	go parser.StartParsing(ctx, time.Hour)
+	<-client.fetching
+	cancel()
+	expired, expiredCancel := context.WithCancel(context.Background())
+	expiredCancel()
+	if err := parser.Stop(expired); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Stop to time out, got %v", err)
+	}
+	if parser.GetCurrentBlock() != 0 {
+		t.Errorf("expected the aborted block not to be stored, at block %d", parser.GetCurrentBlock())
+	}
+}
+
+// TestCheckpoint verifies a written checkpoint restores subscriptions, block hashes and
+// progress into an empty store, and is refused once the store has caught up to it.
+func TestCheckpoint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clock := NewFakeClock(time.Unix(1_700_000_000, 0))
+	source := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+	source.SetClock(clock)
+	source.Subscribe("0x000000000000000000000000000000000000000a")
+	source.SetNotificationPrefs("0x000000000000000000000000000000000000000a", NotificationPrefs{Direction: DirectionIn})
+	source.store.SubscribeUntil("0xttl", clock.Now().Add(time.Hour))
+	source.store.AddTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0x1", To: "0x000000000000000000000000000000000000000a", Block: 7})
+	for block := 1; block <= 9; block++ {
+		source.store.SetBlockHash(block, fmt.Sprintf("0xh%d", block))
+	}
+	source.store.SetCurrentBlock(9)
+
+	cp, err := source.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := WriteCheckpoint(path, cp); err != nil {
+		t.Fatalf("WriteCheckpoint error: %v", err)
+	}
+	if cp, err = ReadCheckpoint(path); err != nil {
+		t.Fatalf("ReadCheckpoint error: %v", err)
+	}
+	if len(cp.Index) != 2 || cp.Index[0] != (IndexSummary{Address: "0x000000000000000000000000000000000000000a", Transactions: 1, LastBlock: 7}) {
+		t.Errorf("unexpected index summaries %+v", cp.Index)
+	}
+
+	restored := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+	restored.SetClock(clock)
+	if err := restored.RestoreCheckpoint(cp); err != nil {
+		t.Fatalf("RestoreCheckpoint error: %v", err)
+	}
+	if restored.GetCurrentBlock() != 9 || !restored.store.IsSubscribed("0x000000000000000000000000000000000000000a") || !restored.store.IsSubscribed("0xttl") {
+		t.Errorf("expected progress and subscriptions to be restored")
+	}
+	if hash, _ := restored.store.GetBlockHash(9); hash != "0xh9" {
+		t.Errorf("expected block hashes to be restored, got %q", hash)
+	}
+	if prefs, _ := restored.store.GetNotificationPrefs("0x000000000000000000000000000000000000000a"); prefs.Direction != DirectionIn {
+		t.Errorf("expected preferences to be restored, got %+v", prefs)
+	}
+	clock.Advance(2 * time.Hour)
+	if subs := restored.store.(SubscriptionLister).Subscriptions(); len(subs) != 1 {
+		t.Errorf("expected the TTL subscription to lapse, got %+v", subs)
+	}
+	if err := restored.RestoreCheckpoint(cp); !errors.Is(err, ErrCheckpointStale) {
+		t.Errorf("expected ErrCheckpointStale, got %v", err)
+	}
+}
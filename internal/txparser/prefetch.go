@@ -0,0 +1,82 @@
+package txparser
+
+import (
+	"context"
+	"sync"
+)
+
+// prefetchEntry is a block fetch that may still be in flight.
+type prefetchEntry struct {
+	done  chan struct{}
+	block BlockResponse
+	err   error
+}
+
+// blockPrefetcher fetches upcoming blocks concurrently so network latency
+// overlaps with matching while the parser is catching up.
+type blockPrefetcher struct {
+	source BlockSource
+	depth  int
+
+	mu      sync.Mutex
+	entries map[int]*prefetchEntry
+}
+
+// newBlockPrefetcher creates a prefetcher that keeps up to depth blocks ahead.
+func newBlockPrefetcher(source BlockSource, depth int) *blockPrefetcher {
+	return &blockPrefetcher{
+		source:  source,
+		depth:   depth,
+		entries: make(map[int]*prefetchEntry),
+	}
+}
+
+// prefetch starts fetching the blocks after current, up to depth ahead and never past latest.
+// Entries at or below current are dropped. Fetches in flight are abandoned once ctx is done.
+func (b *blockPrefetcher) prefetch(ctx context.Context, current, latest int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for blockNum := range b.entries {
+		if blockNum <= current {
+			delete(b.entries, blockNum)
+		}
+	}
+	for blockNum := current + 1; blockNum <= min(current+b.depth, latest); blockNum++ {
+		if _, ok := b.entries[blockNum]; ok {
+			continue
+		}
+		entry := &prefetchEntry{done: make(chan struct{})}
+		b.entries[blockNum] = entry
+		go func(blockNum int) {
+			defer close(entry.done)
+			entry.block, entry.err = b.source.GetBlockByNumber(ctx, int64(blockNum))
+		}(blockNum)
+	}
+}
+
+// reset drops every entry, so blocks fetched before a rollback are not reused.
+// Fetches in flight finish into the dropped entries.
+func (b *blockPrefetcher) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.entries)
+}
+
+// take waits for a prefetched block and removes it from the cache.
+// It reports false if the block was never prefetched or its fetch failed.
+func (b *blockPrefetcher) take(blockNum int) (BlockResponse, bool) {
+	b.mu.Lock()
+	entry, ok := b.entries[blockNum]
+	delete(b.entries, blockNum)
+	b.mu.Unlock()
+	if !ok {
+		return BlockResponse{}, false
+	}
+
+	<-entry.done
+	if entry.err != nil {
+		return BlockResponse{}, false
+	}
+	return entry.block, true
+}
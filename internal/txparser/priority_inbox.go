@@ -0,0 +1,119 @@
+package txparser
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultPriorityInboxSize is the number of priority transactions kept unless overridden.
+const DefaultPriorityInboxSize = 1000
+
+// PriorityTransaction is a matched transaction classified as priority by at least one rule.
+type PriorityTransaction struct {
+	AddressTransaction
+	Rules []string `json:"rules"` // names of the matching priority rules
+}
+
+// priorityInbox keeps the newest priority transactions in a fixed-size ring, apart from
+// the store, so reads cost the same however large the transaction history grows.
+type priorityInbox struct {
+	rules []Rule
+
+	mu    sync.RWMutex
+	items []PriorityTransaction // ring buffer, oldest at next once full
+	next  int
+	full  bool
+}
+
+func newPriorityInbox(rules []Rule, size int) *priorityInbox {
+	return &priorityInbox{rules: rules, items: make([]PriorityTransaction, size)}
+}
+
+// classify returns the names of the priority rules matching in.
+func (b *priorityInbox) classify(in ruleInput) []string {
+	_, matched := evaluateRules(b.rules, in)
+	return matched
+}
+
+// add records t, evicting the oldest transaction once the inbox is full.
+func (b *priorityInbox) add(t PriorityTransaction) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.items[b.next] = t
+	b.next = (b.next + 1) % len(b.items)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// recent returns up to limit transactions, newest first, of address or of every
+// address when it is empty.
+func (b *priorityInbox) recent(address string, limit int) []PriorityTransaction {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	count := b.next
+	if b.full {
+		count = len(b.items)
+	}
+	out := []PriorityTransaction{}
+	for i := 1; i <= count && len(out) < limit; i++ {
+		t := b.items[(b.next-i+len(b.items))%len(b.items)]
+		if address == "" || t.Address == address {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// rollback drops transactions above block, keeping the rest in order.
+func (b *priorityInbox) rollback(block int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	count := b.next
+	if b.full {
+		count = len(b.items)
+	}
+	kept := make([]PriorityTransaction, 0, count)
+	for i := count; i >= 1; i-- {
+		t := b.items[(b.next-i+len(b.items))%len(b.items)]
+		if t.Block <= int64(block) {
+			kept = append(kept, t)
+		}
+	}
+	clear(b.items)
+	copy(b.items, kept)
+	b.next = len(kept) % len(b.items)
+	b.full = len(kept) == len(b.items)
+}
+
+// recordPriority adds tx to the priority inbox if a priority rule matches it from
+// address's point of view.
+func (p *EthParser) recordPriority(address string, tx Transaction, raw RawTx) {
+	if p.inbox == nil {
+		return
+	}
+	matched := p.inbox.classify(newRuleInput(address, tx, raw))
+	if len(matched) == 0 {
+		return
+	}
+	p.inbox.add(PriorityTransaction{AddressTransaction: AddressTransaction{Address: address, Transaction: tx}, Rules: matched})
+}
+
+// SetPriorityRules routes matched transactions satisfying any of rules into a priority
+// inbox holding the newest size of them. It must be called before StartParsing.
+func (p *EthParser) SetPriorityRules(rules []Rule, size int) {
+	if len(rules) == 0 || size <= 0 {
+		p.inbox = nil
+		return
+	}
+	p.inbox = newPriorityInbox(rules, size)
+}
+
+// GetPriorityTransactions returns up to limit priority transactions, newest first, of
+// address or of every subscribed address when it is empty.
+func (p *EthParser) GetPriorityTransactions(address string, limit int) []PriorityTransaction {
+	if p.inbox == nil {
+		return []PriorityTransaction{}
+	}
+	return p.inbox.recent(strings.ToLower(address), limit)
+}
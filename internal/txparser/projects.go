@@ -0,0 +1,79 @@
+package txparser
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// projectNamePattern restricts project names to path-safe identifiers.
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// ValidProjectName reports whether name can be served under /projects/{name}/.
+func ValidProjectName(name string) bool {
+	return projectNamePattern.MatchString(name)
+}
+
+// AddProject serves the API of a project, an independent watch list with its own
+// parser, store, rules and webhooks, under /projects/{name}/. Call it before Router.
+func (s *HTTPServer) AddProject(name string, project *HTTPServer) {
+	if s.projects == nil {
+		s.projects = make(map[string]*HTTPServer)
+	}
+	s.projects[name] = project
+}
+
+// Projects returns the names of every served project, sorted.
+func (s *HTTPServer) Projects() []string {
+	names := make([]string, 0, len(s.projects))
+	for name := range s.projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// routeProjects mounts each project's router below its /projects/{name} prefix.
+func (s *HTTPServer) routeProjects(mux *http.ServeMux) {
+	if len(s.projects) == 0 {
+		return
+	}
+	mux.HandleFunc("/projects", s.handleProjects)
+	for name, project := range s.projects {
+		prefix := "/projects/" + name
+		mux.Handle(prefix+"/", projectRoute(http.StripPrefix(prefix, project.Router())))
+	}
+}
+
+// projectRoute labels requests to a project's router with its route template below
+// /projects/{name}, so metrics don't carry one series per project and path.
+func projectRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+			if *route == "" {
+				*route = "/"
+			}
+			*route = "/projects/{name}" + *route
+		}
+	})
+}
+
+// handleProjects handles GET /projects, listing project names with their current block.
+func (s *HTTPServer) handleProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	type projectResp struct {
+		Name         string `json:"name"`
+		CurrentBlock int    `json:"currentBlock"`
+	}
+	resp := []projectResp{}
+	for _, name := range s.Projects() {
+		resp = append(resp, projectResp{Name: name, CurrentBlock: s.projects[name].parser.GetCurrentBlock()})
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
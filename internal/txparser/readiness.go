@@ -0,0 +1,139 @@
+package txparser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// DefaultReadinessTimeout bounds each dependency check in /readyz.
+const DefaultReadinessTimeout = 2 * time.Second
+
+// HealthChecker is implemented by dependencies that can report their own health.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// readinessCheck is a named dependency probed by /readyz.
+type readinessCheck struct {
+	name    string
+	checker HealthChecker
+}
+
+// DependencyStatus is the readiness result of a single dependency.
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"` // "ok" or "unavailable"
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// CheckHealth verifies the endpoint answers eth_blockNumber on the first attempt, so
+// /readyz reports a struggling endpoint rather than waiting out its retries.
+func (r *RPCClient) CheckHealth(ctx context.Context) error {
+	_, err := r.BlockNumber(WithRetryPolicy(ctx, NoRetries))
+	return err
+}
+
+// CheckHealth always succeeds; the memory store has no external dependency.
+func (m *MemoryStore) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+// AddReadinessCheck registers a dependency probed by /readyz.
+func (s *HTTPServer) AddReadinessCheck(name string, checker HealthChecker) {
+	s.readinessChecks = append(s.readinessChecks, readinessCheck{name: name, checker: checker})
+}
+
+// Drain makes the server report itself unready and refuse writes with 503, so load
+// balancers stop routing to it while reads in flight complete. Call it first on shutdown.
+func (s *HTTPServer) Drain() {
+	s.draining.Store(true)
+}
+
+// rejectWritesWhileDraining refuses requests other than GET and HEAD once Drain was called.
+func (s *HTTPServer) rejectWritesWhileDraining(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.draining.Load() && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Connection", "close")
+			apierror.Write(w, http.StatusServiceUnavailable, apierror.CodeShuttingDown, "server is shutting down")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleHealthz reports liveness; it never touches dependencies.
+func (s *HTTPServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz checks every registered dependency concurrently, each with its own timeout,
+// and responds 503 if any of them is unavailable.
+func (s *HTTPServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	if s.draining.Load() {
+		s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+
+	statuses := make([]DependencyStatus, len(s.readinessChecks))
+	var wg sync.WaitGroup
+	for i, check := range s.readinessChecks {
+		wg.Add(1)
+		go func(i int, check readinessCheck) {
+			defer wg.Done()
+			statuses[i] = runReadinessCheck(r.Context(), check, s.readinessTimeout)
+		}(i, check)
+	}
+	wg.Wait()
+
+	overall, code := "ok", http.StatusOK
+	for _, status := range statuses {
+		if status.Status != "ok" {
+			overall, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+	s.writeJSON(w, code, map[string]interface{}{
+		"status":       overall,
+		"dependencies": statuses,
+	})
+}
+
+// runReadinessCheck runs one check, giving up once timeout elapses even if the
+// dependency itself does not honor the context.
+func runReadinessCheck(ctx context.Context, check readinessCheck, timeout time.Duration) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- check.checker.CheckHealth(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	status := DependencyStatus{
+		Name:      check.name,
+		Status:    "ok",
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = "unavailable"
+		status.Error = err.Error()
+	}
+	return status
+}
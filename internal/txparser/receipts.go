@@ -0,0 +1,199 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// Receipt statuses of an enriched Transaction.
+const (
+	ReceiptSuccess = "success"
+	ReceiptFailed  = "failed" // reverted: gas was paid but no value moved
+)
+
+// RawReceipt is the part of an eth_getTransactionReceipt result used for enrichment.
+// Status is absent from pre-Byzantium receipts and EffectiveGasPrice from pre-London ones.
+type RawReceipt struct {
+	TransactionHash   string `json:"transactionHash"`
+	Status            string `json:"status"`
+	GasUsed           string `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice"`
+}
+
+// ReceiptSource is implemented by sources that can fetch transaction receipts in bulk.
+type ReceiptSource interface {
+	// GetReceipts returns the receipts of the given transactions keyed by hash.
+	// Transactions without a receipt yet are missing from the result.
+	GetReceipts(ctx context.Context, hashes []string) (map[string]RawReceipt, error)
+}
+
+// BlockReceiptSource is implemented by sources that can fetch all receipts of a block
+// in one call.
+type BlockReceiptSource interface {
+	// GetBlockReceipts returns the receipts of every transaction in a block.
+	GetBlockReceipts(ctx context.Context, blockNum int64) ([]RawReceipt, error)
+}
+
+// GetReceipts fetches receipts with one eth_getTransactionReceipt call per hash,
+// sent as a single JSON-RPC batch request.
+func (r *RPCClient) GetReceipts(ctx context.Context, hashes []string) (map[string]RawReceipt, error) {
+	reqs := make([]rpcRequest, 0, len(hashes))
+	for _, hash := range hashes {
+		reqs = append(reqs, r.newRequest("eth_getTransactionReceipt", hash))
+	}
+	entries, err := r.postBatch(ctx, "GetReceipts", reqs)
+	if err != nil {
+		return nil, err
+	}
+	receipts := make(map[string]RawReceipt, len(hashes))
+	for i, entry := range entries {
+		if entry == nil {
+			return nil, fmt.Errorf("%w: no response for receipt %s in batch", ErrInvalidResponse, hashes[i])
+		}
+		var result struct {
+			Result *RawReceipt `json:"result"`
+			Error  *RPCError   `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal(entry, &result); err != nil {
+			return nil, fmt.Errorf("GetReceipts unmarshal failed: %w", err)
+		}
+		if result.Error != nil {
+			return nil, fmt.Errorf("receipt %s: %w", hashes[i], result.Error)
+		}
+		if result.Result != nil {
+			receipts[hashes[i]] = *result.Result
+		}
+	}
+	return receipts, nil
+}
+
+// GetBlockReceipts fetches a block's receipts with eth_getBlockReceipts.
+func (r *RPCClient) GetBlockReceipts(ctx context.Context, blockNum int64) ([]RawReceipt, error) {
+	result, err := r.call(ctx, "eth_getBlockReceipts", hexutil.EncodeInt64(blockNum))
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockReceipts request failed: %w", err)
+	}
+	var receipts []RawReceipt
+	if err := json.Unmarshal(result, &receipts); err != nil {
+		return nil, fmt.Errorf("GetBlockReceipts unmarshal failed: %w", err)
+	}
+	return receipts, nil
+}
+
+// GetBlockReceipts fetches a block's receipts from a bulk endpoint.
+func (m *MultiClient) GetBlockReceipts(ctx context.Context, blockNum int64) ([]RawReceipt, error) {
+	return failover(ctx, m, m.bulk(), func(c *RPCClient) ([]RawReceipt, error) {
+		return c.GetBlockReceipts(ctx, blockNum)
+	})
+}
+
+// GetReceipts fetches a batch of receipts from a bulk endpoint.
+func (m *MultiClient) GetReceipts(ctx context.Context, hashes []string) (map[string]RawReceipt, error) {
+	return failover(ctx, m, m.bulk(), func(c *RPCClient) (map[string]RawReceipt, error) {
+		return c.GetReceipts(ctx, hashes)
+	})
+}
+
+// applyReceipt returns tx with the status, gas used, effective gas price and fee of its
+// receipt. Without an effective gas price, the fee is computed from the transaction's gas price.
+// With the block's baseFee, the fee is split into its burned and tipped parts.
+func applyReceipt(tx Transaction, receipt RawReceipt, baseFee string) Transaction {
+	switch receipt.Status {
+	case "0x1":
+		tx.Status = ReceiptSuccess
+	case "0x0":
+		tx.Status = ReceiptFailed
+	}
+	gasUsed, ok := parseWei(receipt.GasUsed)
+	if !ok {
+		return tx
+	}
+	tx.GasUsed = gasUsed.String()
+	tx.EffectiveGasPriceWei = weiDecimal(receipt.EffectiveGasPrice)
+	price, ok := parseWei(receipt.EffectiveGasPrice)
+	if !ok {
+		if price, ok = new(big.Int).SetString(tx.GasPriceWei, 10); !ok {
+			return tx
+		}
+	}
+	fee := new(big.Int).Mul(gasUsed, price)
+	tx.FeeWei = fee.String()
+	if base, ok := parseWei(baseFee); ok {
+		burned := new(big.Int).Mul(gasUsed, base)
+		if burned.Cmp(fee) > 0 { // a price below the base fee means inconsistent data
+			burned.Set(fee)
+		}
+		tx.BurnedFeeWei = burned.String()
+		tx.TipFeeWei = fee.Sub(fee, burned).String()
+	}
+	return tx
+}
+
+// enrichReceipts adds receipt data to the transactions of txs sent from or to a subscribed
+// address, fetching their receipts in one batch. baseFee is the block's baseFeePerGas,
+// empty before London. Failures are recorded and leave the transactions unenriched
+// rather than failing the block.
+func (p *EthParser) enrichReceipts(ctx context.Context, blockNum int, baseFee string, txs []Transaction) {
+	source, ok := p.client.(ReceiptSource)
+	if !ok || !p.receipts || !p.features.Enabled(FeatureReceipts) {
+		return
+	}
+	var hashes []string
+	for _, tx := range txs {
+		if p.store.IsSubscribed(tx.From) || p.store.IsSubscribed(tx.To) {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return
+	}
+	receipts, err := p.fetchReceipts(ctx, source, blockNum, hashes)
+	if err != nil {
+		p.logger.Warn("Failed to fetch transaction receipts", "block", blockNum, "count", len(hashes), "err", err)
+		p.errors.Record("receipts", blockNum, err)
+		return
+	}
+	for i, tx := range txs {
+		if receipt, ok := receipts[tx.Hash]; ok {
+			txs[i] = applyReceipt(tx, receipt, baseFee)
+		}
+	}
+}
+
+// fetchReceipts returns the receipts of hashes, from a single eth_getBlockReceipts call
+// when enabled, and otherwise or if that call fails from a batch of per-transaction calls.
+func (p *EthParser) fetchReceipts(ctx context.Context, source ReceiptSource, blockNum int, hashes []string) (map[string]RawReceipt, error) {
+	if block, ok := p.client.(BlockReceiptSource); ok && p.blockReceipts {
+		all, err := block.GetBlockReceipts(ctx, int64(blockNum))
+		if err == nil {
+			receipts := make(map[string]RawReceipt, len(hashes))
+			for _, receipt := range all {
+				if slices.Contains(hashes, receipt.TransactionHash) {
+					receipts[receipt.TransactionHash] = receipt
+				}
+			}
+			return receipts, nil
+		}
+		p.logger.Warn("Failed to fetch block receipts, fetching them per transaction", "block", blockNum, "err", err)
+	}
+	return source.GetReceipts(ctx, hashes)
+}
+
+// SetBlockReceipts makes receipt enrichment fetch a block's receipts with one
+// eth_getBlockReceipts call instead of one eth_getTransactionReceipt call per matched
+// transaction. Enable it only when the provider supports the method.
+func (p *EthParser) SetBlockReceipts(enabled bool) {
+	p.blockReceipts = enabled
+}
+
+// SetReceiptEnrichment enables adding receipt status, gas used and fee to matched
+// transactions, when the BlockSource supports fetching receipts. Receipts are fetched in
+// one batch per block, or per matched transaction in low-memory mode.
+func (p *EthParser) SetReceiptEnrichment(enabled bool) {
+	p.receipts = enabled
+}
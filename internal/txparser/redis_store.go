@@ -0,0 +1,702 @@
+package txparser
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Redis keys below a store's prefix. Each address's transactions are a sorted set
+// scored by block number, so the history of every address is shared by all replicas.
+const (
+	redisCurrentBlockKey  = "current_block"
+	redisSubscriptionsKey = "subscriptions" // hash of address to redisSubscription JSON
+	redisBlockHashesKey   = "block_hashes"  // hash of block number to block hash
+	redisTxsKeyPrefix     = "txs:"          // sorted set per address of transaction JSON
+	redisLeaseKeyPrefix   = "lease:"        // holder of a LeaderElector lease, expiring
+)
+
+// Lease scripts run atomically on the server. KEYS[1] is the lease, ARGV[1] the holder
+// and ARGV[2] the TTL in milliseconds.
+const (
+	redisAcquireLeaseScript = `local holder = redis.call('GET', KEYS[1])
+if holder == false then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+elseif holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0`
+	redisReleaseLeaseScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`
+)
+
+// redisTimeout bounds each command round trip, including dialing.
+const redisTimeout = 5 * time.Second
+
+// redisMaxIdle is how many idle connections the store keeps for reuse.
+const redisMaxIdle = 8
+
+// redisSubscription is the stored state of one subscription.
+type redisSubscription struct {
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"` // nil for permanent subscriptions
+	Prefs     NotificationPrefs `json:"prefs"`
+}
+
+// RedisStore is a Store kept in Redis, so several parser and API replicas share
+// subscriptions, the current block and transaction history. Like BoltStore, the Store
+// interface has no error returns; command failures are logged and recorded in the
+// error history, and CurrentBlock reports them to the parser.
+//
+// Transactions are sorted set members holding their JSON, scored by block. Members are
+// unique, so replicas indexing the same block store each transaction once; within a
+// block they are ordered by their JSON, i.e. by hash, rather than by arrival.
+// Read-modify-write operations such as Subscribe are not atomic across replicas; the
+// last writer wins.
+type RedisStore struct {
+	addr     string
+	tls      *tls.Config // nil for plain TCP
+	username string
+	password string
+	db       int
+	prefix   string
+	logger   *slog.Logger
+	clock    Clock
+
+	idle    chan *redisConn
+	closed  atomic.Bool
+	history atomic.Pointer[ErrorHistory] // receives failed commands, if set
+}
+
+// OpenRedisStore connects to the Redis server at rawURL, a redis:// or rediss:// URL
+// with an optional user, password and database number, e.g.
+// redis://:secret@localhost:6379/2. Every key is stored under prefix.
+func OpenRedisStore(rawURL, prefix string, logger *slog.Logger) (*RedisStore, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("redis url: %w", err)
+	}
+	s := &RedisStore{prefix: prefix, logger: logger, clock: SystemClock, idle: make(chan *redisConn, redisMaxIdle)}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		s.tls = &tls.Config{ServerName: u.Hostname()}
+	default:
+		return nil, fmt.Errorf("redis url %q must use redis or rediss", rawURL)
+	}
+	s.addr = u.Host
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		if s.db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("redis url database %q must be a number", path)
+		}
+	}
+	if err := s.CheckHealth(context.Background()); err != nil {
+		return nil, fmt.Errorf("connecting to redis failed: %w", err)
+	}
+	return s, nil
+}
+
+// Close closes the idle connections. Later commands fail.
+func (s *RedisStore) Close() error {
+	s.closed.Store(true)
+	for {
+		select {
+		case conn := <-s.idle:
+			conn.close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Available reports whether the store has not been closed.
+func (s *RedisStore) Available() bool {
+	return !s.closed.Load()
+}
+
+// SetClock replaces the time source used to expire TTL subscriptions.
+func (s *RedisStore) SetClock(c Clock) {
+	s.clock = c
+}
+
+// CheckHealth verifies the server answers a PING.
+func (s *RedisStore) CheckHealth(ctx context.Context) error {
+	_, err := s.pipeline(ctx, []string{"PING"})
+	return err
+}
+
+// key returns the full name of a key below the store's prefix.
+func (s *RedisStore) key(name string) string {
+	return s.prefix + name
+}
+
+// txsKey returns the sorted set holding address's transactions.
+func (s *RedisStore) txsKey(address string) string {
+	return s.prefix + redisTxsKeyPrefix + address
+}
+
+// SetErrorHistory records failed commands in h, so failures swallowed by methods
+// without error returns, e.g. IsSubscribed reporting false, show up in /admin/errors.
+// The parser sets it to its own history.
+func (s *RedisStore) SetErrorHistory(h *ErrorHistory) {
+	s.history.Store(h)
+}
+
+// fail logs a failed command and records it in the error history.
+func (s *RedisStore) fail(op string, err error) {
+	s.logger.Error("Redis store command failed", "op", op, "err", err)
+	if h := s.history.Load(); h != nil {
+		h.Record("store", 0, fmt.Errorf("%s: %w", op, err))
+	}
+}
+
+// do runs one command and logs failures.
+func (s *RedisStore) do(op string, args ...string) (interface{}, error) {
+	replies, err := s.pipeline(context.Background(), args)
+	if err != nil {
+		s.fail(op, err)
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// doAll runs commands in one round trip and logs failures.
+func (s *RedisStore) doAll(op string, cmds ...[]string) ([]interface{}, error) {
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	replies, err := s.pipeline(context.Background(), cmds...)
+	if err != nil {
+		s.fail(op, err)
+	}
+	return replies, err
+}
+
+// pipeline sends cmds on one connection and reads their replies. An error reply
+// fails the whole pipeline, but the commands before it have already run.
+func (s *RedisStore) pipeline(ctx context.Context, cmds ...[]string) ([]interface{}, error) {
+	if s.closed.Load() {
+		return nil, ErrStoreUnavailable
+	}
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := conn.pipeline(cmds)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.close() // the stream may be out of step with the commands
+		return nil, err
+	}
+	select {
+	case s.idle <- conn:
+	default:
+		conn.close()
+	}
+	return replies, err
+}
+
+// conn returns an idle connection or dials, authenticates and selects the database.
+func (s *RedisStore) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.idle:
+		return conn, nil
+	default:
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis dial failed: %w", err)
+	}
+	if s.tls != nil {
+		tlsConn := tls.Client(netConn, s.tls)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("redis tls handshake failed: %w", err)
+		}
+		netConn = tlsConn
+	}
+	conn := &redisConn{conn: netConn, br: bufio.NewReader(netConn)}
+	var setup [][]string
+	switch {
+	case s.username != "":
+		setup = append(setup, []string{"AUTH", s.username, s.password})
+	case s.password != "":
+		setup = append(setup, []string{"AUTH", s.password})
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	if len(setup) > 0 {
+		if _, err := conn.pipeline(setup); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("redis connection setup failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Subscribe adds a permanent subscription. Returns true if subscribed newly.
+func (s *RedisStore) Subscribe(address string) bool {
+	return s.SubscribeBatch([]string{address})[address]
+}
+
+// SubscribeBatch adds permanent subscriptions in one HSET. If it fails, no address is
+// reported as created.
+func (s *RedisStore) SubscribeBatch(addresses []string) map[string]bool {
+	created := make(map[string]bool, len(addresses))
+	if len(addresses) == 0 {
+		return created
+	}
+	subs, active, err := s.subscriptions("subscribe", addresses)
+	if err != nil {
+		return created
+	}
+	hset := []string{"HSET", s.key(redisSubscriptionsKey)}
+	for i, address := range addresses {
+		subs[i].ExpiresAt = nil // manual subscriptions are permanent
+		data, err := json.Marshal(subs[i])
+		if err != nil {
+			return created
+		}
+		hset = append(hset, address, string(data))
+	}
+	if _, err := s.do("subscribe", hset...); err != nil {
+		return created
+	}
+	for i, address := range addresses {
+		created[address] = !active[i]
+	}
+	return created
+}
+
+// SubscribeUntil adds or extends a subscription that expires at expiresAt.
+// It never shortens or replaces a permanent subscription.
+func (s *RedisStore) SubscribeUntil(address string, expiresAt time.Time) bool {
+	subs, active, err := s.subscriptions("subscribe until", []string{address})
+	if err != nil {
+		return false
+	}
+	sub := subs[0]
+	if active[0] && (sub.ExpiresAt == nil || !expiresAt.After(*sub.ExpiresAt)) {
+		return false
+	}
+	sub.ExpiresAt = &expiresAt
+	if !s.putSubscription("subscribe until", address, sub) {
+		return false
+	}
+	return !active[0]
+}
+
+// IsSubscribed checks if an address is subscribed and its subscription has not expired.
+func (s *RedisStore) IsSubscribed(address string) bool {
+	_, active, err := s.subscriptions("is subscribed", []string{address})
+	return err == nil && active[0]
+}
+
+// SubscriptionCount returns the number of unexpired subscriptions.
+func (s *RedisStore) SubscriptionCount() int {
+	return len(s.Subscriptions())
+}
+
+// Subscriptions returns every active subscription, sorted by address.
+func (s *RedisStore) Subscriptions() []SubscriptionState {
+	reply, err := s.do("list subscriptions", "HGETALL", s.key(redisSubscriptionsKey))
+	if err != nil {
+		return nil
+	}
+	fields, _ := reply.([]interface{})
+	var subs []SubscriptionState
+	for i := 0; i+1 < len(fields); i += 2 {
+		address, _ := fields[i].(string)
+		sub, active, err := s.decodeSubscription(address, fields[i+1])
+		if err != nil {
+			s.fail("list subscriptions", err)
+			continue
+		}
+		if active {
+			subs = append(subs, SubscriptionState{Address: address, ExpiresAt: sub.ExpiresAt, Prefs: sub.Prefs})
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Address < subs[j].Address })
+	return subs
+}
+
+// subscriptions loads the stored subscriptions of addresses and whether each is active.
+func (s *RedisStore) subscriptions(op string, addresses []string) ([]redisSubscription, []bool, error) {
+	reply, err := s.do(op, append([]string{"HMGET", s.key(redisSubscriptionsKey)}, addresses...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, _ := reply.([]interface{})
+	if len(values) != len(addresses) {
+		err := fmt.Errorf("HMGET returned %d values for %d fields", len(values), len(addresses))
+		s.fail(op, err)
+		return nil, nil, err
+	}
+	subs := make([]redisSubscription, len(addresses))
+	active := make([]bool, len(addresses))
+	for i, address := range addresses {
+		if subs[i], active[i], err = s.decodeSubscription(address, values[i]); err != nil {
+			s.fail(op, err)
+			return nil, nil, err
+		}
+	}
+	return subs, active, nil
+}
+
+// decodeSubscription decodes a stored subscription, absent if value is nil.
+func (s *RedisStore) decodeSubscription(address string, value interface{}) (redisSubscription, bool, error) {
+	var sub redisSubscription
+	data, ok := value.(string)
+	if !ok {
+		return sub, false, nil
+	}
+	if err := json.Unmarshal([]byte(data), &sub); err != nil {
+		return sub, false, fmt.Errorf("subscription of %s unmarshal failed: %w", address, err)
+	}
+	active := sub.ExpiresAt == nil || s.clock.Now().Before(*sub.ExpiresAt)
+	return sub, active, nil
+}
+
+// putSubscription stores sub, reporting whether it was written.
+func (s *RedisStore) putSubscription(op, address string, sub redisSubscription) bool {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return false
+	}
+	_, err = s.do(op, "HSET", s.key(redisSubscriptionsKey), address, string(data))
+	return err == nil
+}
+
+// AddTransaction adds a transaction to an address's history if subscribed.
+func (s *RedisStore) AddTransaction(address string, tx Transaction) {
+	if !s.IsSubscribed(address) {
+		return
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		s.fail("add transaction", err)
+		return
+	}
+	s.do("add transaction", "ZADD", s.txsKey(address), strconv.FormatInt(tx.Block, 10), string(data))
+}
+
+// GetTransactions returns the transactions for a given address.
+func (s *RedisStore) GetTransactions(address string) []Transaction {
+	return s.GetTransactionsInRange(address, 0, math.MaxInt64)
+}
+
+// GetTransactionsInRange returns the transactions of address within [fromBlock, toBlock].
+func (s *RedisStore) GetTransactionsInRange(address string, fromBlock, toBlock int64) []Transaction {
+	txs := []Transaction{}
+	s.scan("get transactions", address, max(fromBlock, 0), toBlock, func(tx Transaction) {
+		txs = append(txs, tx)
+	})
+	return txs
+}
+
+// QueryTransactions returns the page of address's transactions selected by q and the total match count.
+func (s *RedisStore) QueryTransactions(address string, q TxQuery) ([]Transaction, int) {
+	pager := newTxPager(q)
+	s.scan("query transactions", address, max(q.FromBlock, 0), q.ToBlock, func(tx Transaction) {
+		pager.add(address, tx)
+	})
+	return pager.page, pager.total
+}
+
+// scan visits the transactions of address within [fromBlock, toBlock] in block order.
+func (s *RedisStore) scan(op, address string, fromBlock, toBlock int64, visit func(Transaction)) {
+	reply, err := s.do(op, "ZRANGEBYSCORE", s.txsKey(address), strconv.FormatInt(fromBlock, 10), strconv.FormatInt(toBlock, 10))
+	if err != nil {
+		return
+	}
+	members, _ := reply.([]interface{})
+	for _, member := range members {
+		data, _ := member.(string)
+		var tx Transaction
+		if err := json.Unmarshal([]byte(data), &tx); err != nil {
+			s.fail(op, fmt.Errorf("transaction of %s unmarshal failed: %w", address, err))
+			return
+		}
+		visit(tx)
+	}
+}
+
+// SetCurrentBlock stores the last processed block.
+func (s *RedisStore) SetCurrentBlock(block int) {
+	s.do("set current block", "SET", s.key(redisCurrentBlockKey), strconv.Itoa(block))
+}
+
+// GetCurrentBlock returns the last processed block, 0 for a new store or if the
+// read fails; see CurrentBlock.
+func (s *RedisStore) GetCurrentBlock() int {
+	block, _ := s.CurrentBlock()
+	return block
+}
+
+// CurrentBlock returns the last processed block, 0 for a new store, or the error
+// that prevented reading it.
+func (s *RedisStore) CurrentBlock() (int, error) {
+	reply, err := s.do("get current block", "GET", s.key(redisCurrentBlockKey))
+	if err != nil {
+		return 0, err
+	}
+	if reply == nil {
+		return 0, nil
+	}
+	value, _ := reply.(string)
+	block, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("current block %q: %w", value, err)
+	}
+	return block, nil
+}
+
+// SetBlockHash records a block hash and forgets hashes older than BlockHashWindow.
+func (s *RedisStore) SetBlockHash(block int, hash string) {
+	cmds := [][]string{{"HSET", s.key(redisBlockHashesKey), strconv.Itoa(block), hash}}
+	if block >= BlockHashWindow {
+		cmds = append(cmds, []string{"HDEL", s.key(redisBlockHashesKey), strconv.Itoa(block - BlockHashWindow)})
+	}
+	s.doAll("set block hash", cmds...)
+}
+
+// GetBlockHash returns the recorded hash of a block.
+func (s *RedisStore) GetBlockHash(block int) (string, bool) {
+	reply, err := s.do("get block hash", "HGET", s.key(redisBlockHashesKey), strconv.Itoa(block))
+	if err != nil {
+		return "", false
+	}
+	hash, ok := reply.(string)
+	return hash, ok
+}
+
+// RollbackTo drops transactions and block hashes above block.
+func (s *RedisStore) RollbackTo(block int) {
+	reply, err := s.do("rollback", "HKEYS", s.key(redisBlockHashesKey))
+	if err != nil {
+		return
+	}
+	hdel := []string{"HDEL", s.key(redisBlockHashesKey)}
+	fields, _ := reply.([]interface{})
+	for _, field := range fields {
+		value, _ := field.(string)
+		if b, err := strconv.Atoi(value); err == nil && b > block {
+			hdel = append(hdel, value)
+		}
+	}
+	cmds := s.perAddress("rollback", "ZREMRANGEBYSCORE", "("+strconv.Itoa(block), "+inf")
+	if len(hdel) > 2 {
+		cmds = append(cmds, hdel)
+	}
+	s.doAll("rollback", cmds...)
+}
+
+// PruneBefore drops transactions below block, returning how many were dropped.
+func (s *RedisStore) PruneBefore(block int64) int {
+	return s.countRemoved("prune", s.perAddress("prune", "ZREMRANGEBYSCORE", "-inf", "("+strconv.FormatInt(block, 10)))
+}
+
+// TrimAddresses drops the oldest transactions of every address holding more than
+// limit, returning how many were dropped.
+func (s *RedisStore) TrimAddresses(limit int) int {
+	return s.countRemoved("trim", s.perAddress("trim", "ZREMRANGEBYRANK", "0", strconv.Itoa(-limit-1)))
+}
+
+// perAddress builds the command name key args... for the history of every address that
+// has ever subscribed, lapsed subscriptions included.
+func (s *RedisStore) perAddress(op, name string, args ...string) [][]string {
+	reply, err := s.do(op, "HKEYS", s.key(redisSubscriptionsKey))
+	if err != nil {
+		return nil
+	}
+	addresses, _ := reply.([]interface{})
+	cmds := make([][]string, 0, len(addresses))
+	for _, address := range addresses {
+		a, _ := address.(string)
+		cmds = append(cmds, append([]string{name, s.txsKey(a)}, args...))
+	}
+	return cmds
+}
+
+// countRemoved runs cmds and sums their integer replies.
+func (s *RedisStore) countRemoved(op string, cmds [][]string) int {
+	replies, err := s.doAll(op, cmds...)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, reply := range replies {
+		n, _ := reply.(int64)
+		removed += int(n)
+	}
+	return removed
+}
+
+// AcquireLease grants the lease name to holder for ttl, or renews it if holder
+// already has it, reporting whether holder has it now.
+func (s *RedisStore) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	replies, err := s.pipeline(context.Background(),
+		[]string{"EVAL", redisAcquireLeaseScript, "1", s.key(redisLeaseKeyPrefix + name), holder, strconv.FormatInt(ttl.Milliseconds(), 10)})
+	if err != nil {
+		return false, err
+	}
+	return replies[0] == int64(1), nil
+}
+
+// ReleaseLease gives up the lease name if holder has it.
+func (s *RedisStore) ReleaseLease(name, holder string) error {
+	_, err := s.pipeline(context.Background(), []string{"EVAL", redisReleaseLeaseScript, "1", s.key(redisLeaseKeyPrefix + name), holder})
+	return err
+}
+
+// SetNotificationPrefs stores notification preferences for a subscribed address.
+func (s *RedisStore) SetNotificationPrefs(address string, prefs NotificationPrefs) bool {
+	subs, active, err := s.subscriptions("set notification prefs", []string{address})
+	if err != nil || !active[0] {
+		return false
+	}
+	subs[0].Prefs = prefs
+	return s.putSubscription("set notification prefs", address, subs[0])
+}
+
+// GetNotificationPrefs returns the notification preferences of a subscribed address.
+func (s *RedisStore) GetNotificationPrefs(address string) (NotificationPrefs, bool) {
+	subs, active, err := s.subscriptions("get notification prefs", []string{address})
+	if err != nil {
+		return NotificationPrefs{}, false
+	}
+	return subs[0].Prefs, active[0]
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn is a connection speaking RESP, the Redis protocol. Replies decode to
+// string for simple and bulk strings, int64 for integers, []interface{} for arrays
+// and nil for null bulk strings and arrays.
+type redisConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func (c *redisConn) close() {
+	c.conn.Close()
+}
+
+// pipeline writes cmds and reads one reply for each. The first error reply is
+// returned after all replies are read, keeping the connection usable.
+func (c *redisConn) pipeline(cmds [][]string) ([]interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	var b strings.Builder
+	for _, cmd := range cmds {
+		fmt.Fprintf(&b, "*%d\r\n", len(cmd))
+		for _, arg := range cmd {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, fmt.Errorf("redis write error: %w", err)
+	}
+	replies := make([]interface{}, len(cmds))
+	var firstErr error
+	for i := range cmds {
+		reply, err := c.readReply()
+		var replyErr redisError
+		if errors.As(err, &replyErr) {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s failed: %w", cmds[i][0], err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redis read error: %w", err)
+		}
+		replies[i] = reply
+	}
+	return replies, firstErr
+}
+
+// readReply reads one reply. Error replies are returned as redisError.
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err // a null bulk string is nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.br, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		var firstErr error
+		for i := range items {
+			item, err := c.readReply()
+			var replyErr redisError
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			items[i] = item
+		}
+		return items, firstErr
+	default:
+		return nil, fmt.Errorf("unknown reply type %q", kind)
+	}
+}
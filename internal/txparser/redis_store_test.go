@@ -0,0 +1,431 @@
+package txparser
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the subset of Redis commands used by RedisStore.
+type fakeRedis struct {
+	mu      sync.Mutex
+	strings map[string]string
+	hashes  map[string]map[string]string
+	zsets   map[string]map[string]float64
+	expiry  map[string]time.Time // of keys in strings set with a TTL
+}
+
+// startFakeRedis serves a fakeRedis on a loopback port until the test ends and
+// returns its URL.
+func startFakeRedis(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	f := &fakeRedis{strings: map[string]string{}, hashes: map[string]map[string]string{}, zsets: map[string]map[string]float64{}, expiry: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return "redis://" + listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	for {
+		var n int
+		if _, err := fmt.Fscanf(br, "*%d\r\n", &n); err != nil {
+			return
+		}
+		args := make([]string, n)
+		for i := range args {
+			var size int
+			if _, err := fmt.Fscanf(br, "$%d\r\n", &size); err != nil {
+				return
+			}
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return
+			}
+			args[i] = string(data[:size])
+		}
+		f.mu.Lock()
+		reply := f.exec(strings.ToUpper(args[0]), args[1:])
+		f.mu.Unlock()
+		io.WriteString(conn, reply)
+	}
+}
+
+func (f *fakeRedis) exec(cmd string, args []string) string {
+	switch cmd {
+	case "PING":
+		return "+PONG\r\n"
+	case "EVAL": // only the lease scripts, with one key
+		key, holder := args[2], args[3]
+		if deadline, ok := f.expiry[key]; ok && !time.Now().Before(deadline) {
+			delete(f.strings, key)
+			delete(f.expiry, key)
+		}
+		current, held := f.strings[key]
+		switch {
+		case args[0] == redisAcquireLeaseScript && (!held || current == holder):
+			ttl, _ := strconv.Atoi(args[4])
+			f.strings[key] = holder
+			f.expiry[key] = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+			return ":1\r\n"
+		case args[0] == redisReleaseLeaseScript && held && current == holder:
+			delete(f.strings, key)
+			delete(f.expiry, key)
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "GET":
+		if v, ok := f.strings[args[0]]; ok {
+			return redisBulk(v)
+		}
+		return "$-1\r\n"
+	case "SET":
+		f.strings[args[0]] = args[1]
+		return "+OK\r\n"
+	case "HSET":
+		h := f.hashes[args[0]]
+		if h == nil {
+			h = map[string]string{}
+			f.hashes[args[0]] = h
+		}
+		for i := 1; i+1 < len(args); i += 2 {
+			h[args[i]] = args[i+1]
+		}
+		return ":1\r\n"
+	case "HGET", "HMGET":
+		var b strings.Builder
+		if cmd == "HMGET" {
+			fmt.Fprintf(&b, "*%d\r\n", len(args)-1)
+		}
+		for _, field := range args[1:] {
+			if v, ok := f.hashes[args[0]][field]; ok {
+				b.WriteString(redisBulk(v))
+			} else {
+				b.WriteString("$-1\r\n")
+			}
+		}
+		return b.String()
+	case "HDEL":
+		for _, field := range args[1:] {
+			delete(f.hashes[args[0]], field)
+		}
+		return ":1\r\n"
+	case "HKEYS", "HGETALL":
+		var items []string
+		for field, v := range f.hashes[args[0]] {
+			items = append(items, field)
+			if cmd == "HGETALL" {
+				items = append(items, v)
+			}
+		}
+		return redisArray(items)
+	case "ZADD":
+		z := f.zsets[args[0]]
+		if z == nil {
+			z = map[string]float64{}
+			f.zsets[args[0]] = z
+		}
+		score, _ := strconv.ParseFloat(args[1], 64)
+		z[args[2]] = score
+		return ":1\r\n"
+	case "ZRANGEBYSCORE", "ZREMRANGEBYSCORE", "ZREMRANGEBYRANK":
+		members := f.sorted(args[0])
+		var selected []string
+		for rank, m := range members {
+			if cmd == "ZREMRANGEBYRANK" {
+				start, stop := rankBound(args[1], len(members)), rankBound(args[2], len(members))
+				if rank >= start && rank <= stop {
+					selected = append(selected, m)
+				}
+			} else if score := f.zsets[args[0]][m]; scoreAbove(score, args[1]) && scoreBelow(score, args[2]) {
+				selected = append(selected, m)
+			}
+		}
+		if cmd == "ZRANGEBYSCORE" {
+			return redisArray(selected)
+		}
+		for _, m := range selected {
+			delete(f.zsets[args[0]], m)
+		}
+		return fmt.Sprintf(":%d\r\n", len(selected))
+	}
+	return "-ERR unknown command '" + cmd + "'\r\n"
+}
+
+// sorted returns the members of a sorted set by score, then member.
+func (f *fakeRedis) sorted(key string) []string {
+	z := f.zsets[key]
+	members := make([]string, 0, len(z))
+	for m := range z {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if z[members[i]] != z[members[j]] {
+			return z[members[i]] < z[members[j]]
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+func parseRedisScore(bound string) (float64, bool) {
+	exclusive := strings.HasPrefix(bound, "(")
+	bound = strings.TrimPrefix(bound, "(")
+	switch bound {
+	case "-inf":
+		return math.Inf(-1), exclusive
+	case "+inf":
+		return math.Inf(1), exclusive
+	}
+	score, _ := strconv.ParseFloat(bound, 64)
+	return score, exclusive
+}
+
+func scoreAbove(score float64, lo string) bool {
+	bound, exclusive := parseRedisScore(lo)
+	return score > bound || (!exclusive && score == bound)
+}
+
+func scoreBelow(score float64, hi string) bool {
+	bound, exclusive := parseRedisScore(hi)
+	return score < bound || (!exclusive && score == bound)
+}
+
+func rankBound(rank string, n int) int {
+	r, _ := strconv.Atoi(rank)
+	if r < 0 {
+		r += n
+	}
+	return r
+}
+
+func redisBulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func redisArray(items []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(items))
+	for _, item := range items {
+		b.WriteString(redisBulk(item))
+	}
+	return b.String()
+}
+
+// TestRedisStore verifies two stores on the same server share state, and that store
+// prefixes keep independent state apart.
+func TestRedisStore(t *testing.T) {
+	url := startFakeRedis(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	open := func(prefix string) *RedisStore {
+		store, err := OpenRedisStore(url, prefix, logger)
+		if err != nil {
+			t.Fatalf("OpenRedisStore error: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+	store, replica, other := open("txparser:"), open("txparser:"), open("txparser:project-b:")
+
+	if !store.Subscribe("0xa") || replica.Subscribe("0xa") {
+		t.Fatalf("expected only the first Subscribe across replicas to report a new subscription")
+	}
+	if created := replica.SubscribeBatch([]string{"0xa", "0xb"}); len(created) != 2 || created["0xa"] || !created["0xb"] {
+		t.Fatalf("expected SubscribeBatch to create only 0xb, got %v", created)
+	}
+	store.SetNotificationPrefs("0xa", NotificationPrefs{Direction: DirectionIn})
+	for block := int64(1); block <= 300; block++ {
+		store.AddTransaction("0xa", Transaction{Hash: "0xt", Value: "0x1", Block: block})
+		store.SetBlockHash(int(block), "0xb")
+	}
+	replica.AddTransaction("0xa", Transaction{Hash: "0xt", Value: "0x1", Block: 300}) // indexed twice
+	store.AddTransaction("0xnot", Transaction{Hash: "0xt", Block: 1})
+	store.SetCurrentBlock(300)
+
+	if replica.GetCurrentBlock() != 300 || !replica.IsSubscribed("0xb") || other.IsSubscribed("0xa") || other.GetCurrentBlock() != 0 {
+		t.Fatalf("expected state shared by prefix")
+	}
+	if prefs, _ := replica.GetNotificationPrefs("0xa"); prefs.Direction != DirectionIn {
+		t.Errorf("expected shared prefs, got %+v", prefs)
+	}
+	if subs := replica.Subscriptions(); len(subs) != 2 || subs[0].Address != "0xa" || replica.SubscriptionCount() != 2 {
+		t.Errorf("expected 0xa and 0xb, got %+v", subs)
+	}
+	if txs := replica.GetTransactions("0xa"); len(txs) != 300 {
+		t.Errorf("expected 300 transactions without duplicates, got %d", len(txs))
+	}
+	if txs, total := replica.QueryTransactions("0xa", TxQuery{FromBlock: 10, ToBlock: 19, Offset: 8, Limit: 5}); len(txs) != 2 || txs[0].Block != 18 || total != 10 {
+		t.Errorf("expected blocks 18..19 of 10, got %+v (total %d)", txs, total)
+	}
+	if len(store.GetTransactions("0xnot")) != 0 {
+		t.Errorf("expected no transactions for unsubscribed address")
+	}
+	if _, ok := store.GetBlockHash(300 - BlockHashWindow); ok {
+		t.Errorf("expected hashes outside the window to be pruned")
+	}
+
+	store.RollbackTo(250)
+	if txs := replica.GetTransactions("0xa"); len(txs) != 250 {
+		t.Errorf("expected 250 transactions after rollback, got %d", len(txs))
+	}
+	if _, ok := replica.GetBlockHash(251); ok {
+		t.Errorf("expected block hash above rollback point to be removed")
+	}
+	if pruned := store.PruneBefore(101); pruned != 100 {
+		t.Errorf("expected 100 transactions pruned, got %d", pruned)
+	}
+	if trimmed := store.TrimAddresses(100); trimmed != 50 {
+		t.Errorf("expected 50 transactions trimmed, got %d", trimmed)
+	}
+	if txs := store.GetTransactions("0xa"); len(txs) != 100 || txs[0].Block != 151 {
+		t.Errorf("expected blocks 151..250 after trimming, got %d", len(txs))
+	}
+
+	// A parser must not take a failed read of the current block for a new store and
+	// overwrite the progress replicas share; the failure is recorded instead.
+	parser := NewEthParser(&mockClient{latestBlock: "0x400"}, store, logger)
+	store.Close()
+	if store.Available() || store.IsSubscribed("0xa") {
+		t.Errorf("expected a closed store to be unavailable")
+	}
+	if _, err := store.CurrentBlock(); err == nil {
+		t.Errorf("expected reading the current block of a closed store to fail")
+	}
+	if err := parser.processNextBlock(context.Background()); err == nil || replica.GetCurrentBlock() != 300 {
+		t.Errorf("expected the poll to fail and keep block 300, got %v and block %d", err, replica.GetCurrentBlock())
+	}
+	recorded := slices.ContainsFunc(parser.RecentErrors(), func(r ErrorRecord) bool {
+		return r.Source == "store" && strings.HasPrefix(r.Message, "is subscribed")
+	})
+	if !recorded {
+		t.Errorf("expected the failed IsSubscribed in the error history, got %+v", parser.RecentErrors())
+	}
+}
+
+// TestLeaderElection verifies only one replica holds the parsing lease, and that it
+// passes to another when released or expired.
+func TestLeaderElection(t *testing.T) {
+	url := startFakeRedis(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := OpenRedisStore(url, "txparser:", logger)
+	if err != nil {
+		t.Fatalf("OpenRedisStore error: %v", err)
+	}
+	defer store.Close()
+
+	if held, err := store.AcquireLease("parser", "a", time.Minute); err != nil || !held {
+		t.Fatalf("expected a to acquire the lease, got %v %v", held, err)
+	}
+	if held, _ := store.AcquireLease("parser", "b", time.Minute); held {
+		t.Errorf("expected b to be refused a held lease")
+	}
+	if held, _ := store.AcquireLease("parser", "a", time.Minute); !held {
+		t.Errorf("expected a to renew its lease")
+	}
+	if err := store.ReleaseLease("parser", "b"); err != nil {
+		t.Fatalf("ReleaseLease error: %v", err)
+	}
+	if held, _ := store.AcquireLease("parser", "b", 20*time.Millisecond); held {
+		t.Errorf("expected releasing another holder's lease to do nothing")
+	}
+	store.ReleaseLease("parser", "a")
+	if held, _ := store.AcquireLease("parser", "b", 20*time.Millisecond); !held {
+		t.Errorf("expected b to acquire the released lease")
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	a := NewLeaderElector(store, "parser", "a", time.Minute, logger)
+	b := NewLeaderElector(store, "parser", "b", time.Minute, logger)
+	ctxA, cancelA := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctxA)
+		close(done)
+	}()
+	waitFor(t, a.IsLeader)
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	clock := NewFakeClock(time.Now()) // b renews only when advanced
+	b.SetClock(clock)
+	go b.Run(ctxB)
+	waitFor(t, func() bool { return clock.Waiters() == 1 }) // refused while a leads
+	cancelA()
+	<-done
+	if a.IsLeader() || b.IsLeader() {
+		t.Fatalf("expected no leader before b retries")
+	}
+	clock.Advance(time.Minute)
+	waitFor(t, b.IsLeader)
+}
+
+// TestFollowerReplica verifies a replica sharing the lease store with the leader keeps
+// its chain tip current and refuses routes served from in-process state.
+func TestFollowerReplica(t *testing.T) {
+	url := startFakeRedis(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := OpenRedisStore(url, "txparser:", logger)
+	if err != nil {
+		t.Fatalf("OpenRedisStore error: %v", err)
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := NewLeaderElector(store, "parser", "a", time.Minute, logger)
+	go a.Run(ctx)
+	waitFor(t, a.IsLeader)
+	leader := NewEthParser(&mockClient{latestBlock: "0x10"}, store, logger)
+	leader.SetLeaderElector(a)
+	follower := NewEthParser(&mockClient{latestBlock: "0x10"}, store, logger)
+	follower.SetLeaderElector(NewLeaderElector(store, "parser", "b", time.Minute, logger))
+	if leader.IsFollower() || !follower.IsFollower() {
+		t.Fatalf("expected a to lead and b to follow")
+	}
+
+	go follower.StartParsing(ctx, time.Minute)
+	waitFor(t, func() bool { return follower.SafeBlock() == 16 })
+	handler := NewHTTPServer(follower, logger).Router()
+	for target, want := range map[string]int{"/events": http.StatusServiceUnavailable, "/stats": http.StatusServiceUnavailable, "/current-block": http.StatusOK} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d %s", target, want, rec.Code, rec.Body)
+		}
+	}
+}
+
+// waitFor polls cond for up to two seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+	}
+}
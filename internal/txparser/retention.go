@@ -0,0 +1,251 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultPruneInterval is the delay between retention pruning runs.
+const DefaultPruneInterval = time.Minute
+
+// RetentionPolicy bounds how much transaction history a store keeps.
+type RetentionPolicy struct {
+	// MaxBlockAge is how many blocks behind the current one transactions are kept;
+	// older ones are pruned. Zero keeps every block.
+	MaxBlockAge int64 `json:"maxBlockAge"`
+	// MemoryBudgetBytes caps the estimated memory of an in-memory store, evicting the
+	// oldest transactions beyond it. Zero disables the cap.
+	MemoryBudgetBytes int64 `json:"memoryBudgetBytes"`
+	// MaxTransactionsPerAddress keeps only the newest transactions of each address.
+	// Zero disables the cap.
+	MaxTransactionsPerAddress int `json:"maxTransactionsPerAddress"`
+}
+
+// Validate rejects negative limits.
+func (p RetentionPolicy) Validate() error {
+	if p.MaxBlockAge < 0 || p.MemoryBudgetBytes < 0 || p.MaxTransactionsPerAddress < 0 {
+		return fmt.Errorf("maxBlockAge %d, memoryBudgetBytes %d and maxTransactionsPerAddress %d must not be negative",
+			p.MaxBlockAge, p.MemoryBudgetBytes, p.MaxTransactionsPerAddress)
+	}
+	return nil
+}
+
+// Reasons transactions are evicted, reported in RetentionStatus and metrics.
+const (
+	EvictionBlockAge     = "block_age"
+	EvictionPerAddress   = "per_address"
+	EvictionMemoryBudget = "memory_budget"
+)
+
+// RetentionStatus is the active policy with what it has evicted since startup.
+type RetentionStatus struct {
+	RetentionPolicy
+	PrunedTransactions int64            `json:"prunedTransactions"` // across all reasons
+	Evicted            map[string]int64 `json:"evicted"`            // by reason
+	LastPruneAt        *time.Time       `json:"lastPruneAt,omitempty"`
+}
+
+// Pruner is implemented by stores that can drop old transactions.
+type Pruner interface {
+	// PruneBefore drops transactions below block, returning how many were dropped.
+	PruneBefore(block int64) int
+	// TrimAddresses drops the oldest transactions of every address holding more than
+	// limit, returning how many were dropped.
+	TrimAddresses(limit int) int
+}
+
+// SubscriptionPurger is implemented by stores that keep lapsed TTL subscriptions in
+// memory until they are purged.
+type SubscriptionPurger interface {
+	// PurgeExpiredSubscriptions forgets subscriptions whose TTL has passed, returning
+	// how many were purged.
+	PurgeExpiredSubscriptions() int
+}
+
+// RetentionManager applies a RetentionPolicy that can be changed at runtime. Changes
+// are saved to a file, so they survive restarts, and take effect immediately.
+type RetentionManager struct {
+	store   Store
+	path    string // persisted policy; empty keeps changes in memory only
+	logger  *slog.Logger
+	clock   Clock
+	metrics Metrics
+	prune   chan struct{} // requests an immediate pruning run
+
+	mu         sync.Mutex
+	policy     RetentionPolicy
+	evicted    map[string]int64 // by reason
+	budgetSeen int64            // memory budget evictions already counted
+	lastRun    time.Time
+}
+
+// NewRetentionManager creates a manager for store using the policy saved at path, or
+// defaults if there is none yet, and applies its memory budget.
+func NewRetentionManager(store Store, defaults RetentionPolicy, path string, logger *slog.Logger) (*RetentionManager, error) {
+	r := &RetentionManager{
+		store:   store,
+		path:    path,
+		logger:  logger,
+		clock:   SystemClock,
+		metrics: NoopMetrics{},
+		prune:   make(chan struct{}, 1),
+		policy:  defaults,
+		evicted: make(map[string]int64),
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("reading retention policy failed: %w", err)
+		default:
+			if err := json.Unmarshal(data, &r.policy); err != nil {
+				return nil, fmt.Errorf("retention policy unmarshal failed: %w", err)
+			}
+		}
+	}
+	if err := r.policy.Validate(); err != nil {
+		return nil, err
+	}
+	r.applyBudgetLocked()
+	return r, nil
+}
+
+// SetClock replaces the time source used to schedule pruning runs.
+func (r *RetentionManager) SetClock(c Clock) {
+	r.clock = c
+}
+
+// SetMetrics sets the collector counting evicted transactions.
+func (r *RetentionManager) SetMetrics(m Metrics) {
+	r.metrics = m
+}
+
+// Status returns the active policy and eviction totals.
+func (r *RetentionManager) Status() RetentionStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := RetentionStatus{RetentionPolicy: r.policy, Evicted: make(map[string]int64, len(r.evicted))}
+	for reason, n := range r.evicted {
+		status.Evicted[reason] = n
+		status.PrunedTransactions += n
+	}
+	if !r.lastRun.IsZero() {
+		last := r.lastRun
+		status.LastPruneAt = &last
+	}
+	return status
+}
+
+// SetPolicy validates, saves and applies policy, then requests a pruning run.
+func (r *RetentionManager) SetPolicy(policy RetentionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.path != "" {
+		data, err := json.Marshal(policy)
+		if err != nil {
+			return fmt.Errorf("retention policy marshal failed: %w", err)
+		}
+		tmp := r.path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o600); err != nil {
+			return fmt.Errorf("writing retention policy failed: %w", err)
+		}
+		if err := os.Rename(tmp, r.path); err != nil {
+			return fmt.Errorf("replacing retention policy failed: %w", err)
+		}
+	}
+	r.policy = policy
+	r.applyBudgetLocked()
+	select {
+	case r.prune <- struct{}{}:
+	default: // a run is already pending
+	}
+	return nil
+}
+
+// applyBudgetLocked passes the memory budget to stores supporting one. Callers hold r.mu
+// or own r exclusively.
+func (r *RetentionManager) applyBudgetLocked() {
+	budgeted, ok := r.store.(interface {
+		SetMemoryBudget(budgetBytes int64, logger *slog.Logger)
+	})
+	if ok {
+		budgeted.SetMemoryBudget(r.policy.MemoryBudgetBytes, r.logger)
+	} else if r.policy.MemoryBudgetBytes > 0 {
+		r.logger.Warn("Store does not support memory budgets, ignoring memoryBudgetBytes")
+	}
+}
+
+// Prune drops transactions older than the policy's MaxBlockAge and beyond its
+// MaxTransactionsPerAddress, returning how many were dropped. It also counts the
+// evictions the store's memory budget made since the last run, and purges expired
+// subscriptions from stores that keep them.
+func (r *RetentionManager) Prune() int {
+	r.mu.Lock()
+	policy := r.policy
+	r.mu.Unlock()
+	if purger, ok := r.store.(SubscriptionPurger); ok {
+		if n := purger.PurgeExpiredSubscriptions(); n > 0 {
+			r.logger.Info("Purged expired subscriptions", "count", n)
+		}
+	}
+	pruned := make(map[string]int)
+	if pruner, ok := r.store.(Pruner); ok {
+		if before := int64(r.store.GetCurrentBlock()) - policy.MaxBlockAge; policy.MaxBlockAge > 0 && before > 0 {
+			pruned[EvictionBlockAge] = pruner.PruneBefore(before)
+		}
+		if policy.MaxTransactionsPerAddress > 0 {
+			pruned[EvictionPerAddress] = pruner.TrimAddresses(policy.MaxTransactionsPerAddress)
+		}
+	}
+
+	r.mu.Lock()
+	if memory, ok := r.store.(MemoryReporter); ok {
+		total := memory.MemoryUsage().EvictedTransactions
+		pruned[EvictionMemoryBudget] = int(total - r.budgetSeen)
+		r.budgetSeen = total
+	}
+	r.lastRun = r.clock.Now()
+	dropped := 0
+	for reason, n := range pruned {
+		if n > 0 {
+			r.evicted[reason] += int64(n)
+			r.metrics.TransactionsEvicted(reason, n)
+			if reason != EvictionMemoryBudget { // the store logs its own evictions
+				dropped += n
+			}
+		}
+	}
+	r.mu.Unlock()
+	if dropped > 0 {
+		r.logger.Info("Pruned transactions past retention",
+			"block_age", pruned[EvictionBlockAge],
+			"per_address", pruned[EvictionPerAddress],
+		)
+	}
+	return dropped
+}
+
+// Start prunes every interval, and right after each policy change, until ctx is canceled.
+func (r *RetentionManager) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.clock.After(interval):
+			case <-r.prune:
+			}
+			r.Prune()
+		}
+	}()
+}
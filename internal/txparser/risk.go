@@ -0,0 +1,124 @@
+package txparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// MaxRiskScore is the highest score a RiskScorer may return.
+const MaxRiskScore = 100
+
+// RiskScorer rates the counterparty of a matched transaction from 0 (no known risk)
+// to MaxRiskScore. Scores are stored with the transaction.
+type RiskScorer interface {
+	Score(address string) (int, error)
+}
+
+// NoopRiskScorer scores every address 0. It is the parser's default.
+type NoopRiskScorer struct{}
+
+// Score returns 0.
+func (NoopRiskScorer) Score(string) (int, error) { return 0, nil }
+
+// HTTPRiskScorer queries an external risk provider with GET <endpoint>?address=0x...,
+// expecting a JSON body like {"score": 42}. Scores are cached per address for ttl.
+type HTTPRiskScorer struct {
+	endpoint string
+	client   *http.Client
+	ttl      time.Duration
+	clock    Clock
+
+	mu    sync.Mutex
+	cache map[string]cachedScore
+}
+
+type cachedScore struct {
+	score   int
+	expires time.Time
+}
+
+// NewHTTPRiskScorer creates a scorer for the provider at endpoint. A zero ttl disables caching.
+func NewHTTPRiskScorer(endpoint string, ttl time.Duration) *HTTPRiskScorer {
+	return &HTTPRiskScorer{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		ttl:      ttl,
+		clock:    SystemClock,
+		cache:    make(map[string]cachedScore),
+	}
+}
+
+// SetClock replaces the time source used for cache expiry.
+func (h *HTTPRiskScorer) SetClock(c Clock) {
+	h.clock = c
+}
+
+// Score returns the provider's score for address, from cache when fresh.
+func (h *HTTPRiskScorer) Score(address string) (int, error) {
+	now := h.clock.Now()
+	h.mu.Lock()
+	cached, ok := h.cache[address]
+	h.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.score, nil
+	}
+
+	u, err := url.Parse(h.endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("invalid risk provider endpoint: %w", err)
+	}
+	query := u.Query()
+	query.Set("address", address)
+	u.RawQuery = query.Encode()
+
+	resp, err := h.client.Get(u.String())
+	if err != nil {
+		return 0, fmt.Errorf("risk provider request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("risk provider returned status %d", resp.StatusCode)
+	}
+	var body struct {
+		Score *int `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("risk provider response unmarshal failed: %w", err)
+	}
+	if body.Score == nil || *body.Score < 0 || *body.Score > MaxRiskScore {
+		return 0, fmt.Errorf("risk provider returned no score between 0 and %d", MaxRiskScore)
+	}
+
+	if h.ttl > 0 {
+		h.mu.Lock()
+		h.cache[address] = cachedScore{score: *body.Score, expires: now.Add(h.ttl)}
+		h.mu.Unlock()
+	}
+	return *body.Score, nil
+}
+
+// counterparty returns the other side of tx relative to address.
+func counterparty(address string, tx Transaction) string {
+	if tx.From == address {
+		return tx.To
+	}
+	return tx.From
+}
+
+// filterMinRisk drops transactions whose counterparty scored below minRisk.
+func filterMinRisk[T interface{ riskScore() int }](txs []T, minRisk int) []T {
+	if minRisk <= 0 {
+		return txs
+	}
+	risky := make([]T, 0, len(txs))
+	for _, tx := range txs {
+		if tx.riskScore() >= minRisk {
+			risky = append(risky, tx)
+		}
+	}
+	return risky
+}
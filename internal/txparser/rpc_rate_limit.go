@@ -0,0 +1,79 @@
+package txparser
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket capping the HTTP requests a client sends to a provider.
+// Every request waits for a token, whatever it fetches, so block, receipt and log
+// requests share one budget. A JSON-RPC batch is one request.
+type RateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64 // bucket capacity
+	clock Clock
+
+	mu     sync.Mutex
+	tokens float64 // negative while waiters hold reservations
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second on average and
+// bursts of up to burst requests, starting full.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{rate: rps, burst: float64(max(burst, 1)), tokens: float64(max(burst, 1)), clock: SystemClock}
+}
+
+// SetClock replaces the time source used for refills and waits.
+func (l *RateLimiter) SetClock(c Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = c
+	l.last = time.Time{}
+}
+
+// Wait blocks until a request may be sent, or returns ctx's error, giving its
+// reservation back, if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := l.clock.Now()
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	clock := l.clock
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-clock.After(wait):
+		return nil
+	}
+}
+
+// SetRateLimit caps the requests sent to the endpoint at rps per second with bursts of
+// up to burst. Zero rps removes the limit.
+func (r *RPCClient) SetRateLimit(rps float64, burst int) {
+	r.limiter = nil
+	if rps > 0 {
+		r.limiter = NewRateLimiter(rps, burst)
+	}
+}
+
+// SetRateLimit caps the requests sent to each endpoint, since providers enforce their
+// limits separately.
+func (m *MultiClient) SetRateLimit(rps float64, burst int) {
+	for _, e := range m.endpoints {
+		e.client.SetRateLimit(rps, burst)
+	}
+}
@@ -0,0 +1,165 @@
+package txparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how RPCClient retries a request failing with a transient error:
+// HTTP 429 or 5xx, or a transport error such as a timeout or refused connection.
+// JSON-RPC errors in a successful response are answers and never retried.
+type RetryPolicy struct {
+	MaxAttempts int           // attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // delay before the first retry, doubled for each further one
+	MaxDelay    time.Duration // cap on a single delay, including a server's Retry-After
+	Jitter      float64       // fraction of each delay randomized away, 0 to 1
+}
+
+// DefaultRetryPolicy is the retry policy of new clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.5,
+}
+
+// NoRetries is a RetryPolicy making a single attempt.
+var NoRetries = RetryPolicy{MaxAttempts: 1}
+
+// delay returns the wait before retry number retry (1 for the first), honoring a
+// server's retryAfter when it is longer than the backoff.
+func (p RetryPolicy) delay(retry int, retryAfter time.Duration) time.Duration {
+	d := p.BaseDelay << min(retry-1, 30)
+	if d < p.BaseDelay || (p.MaxDelay > 0 && d > p.MaxDelay) { // overflowed or capped
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(d))
+	}
+	if retryAfter > d {
+		d = retryAfter
+		if p.MaxDelay > 0 {
+			d = min(d, p.MaxDelay)
+		}
+	}
+	return d
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context whose calls use policy instead of the client's
+// retry policy, e.g. NoRetries for a latency-sensitive probe.
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicy returns the policy for a call made with ctx.
+func (r *RPCClient) retryPolicy(ctx context.Context) RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return policy
+	}
+	return r.retry
+}
+
+// SetRetryPolicy replaces the retry policy of every call without a per-call override.
+func (r *RPCClient) SetRetryPolicy(policy RetryPolicy) {
+	r.retry = policy
+}
+
+// SetClock replaces the time source pacing retry backoff.
+func (r *RPCClient) SetClock(c Clock) {
+	r.clock = c
+}
+
+// SetLogger sets the logger reporting retried requests.
+func (r *RPCClient) SetLogger(logger *slog.Logger) {
+	r.logger = logger
+}
+
+// SetRetryPolicy replaces the retry policy of every endpoint. Retries happen on the
+// same endpoint before the call fails over.
+func (m *MultiClient) SetRetryPolicy(policy RetryPolicy) {
+	for _, e := range m.endpoints {
+		e.client.SetRetryPolicy(policy)
+	}
+}
+
+// SetLogger sets the logger reporting retried requests of every endpoint.
+func (m *MultiClient) SetLogger(logger *slog.Logger) {
+	for _, e := range m.endpoints {
+		e.client.SetLogger(logger)
+	}
+}
+
+// statusError is a non-2xx HTTP response from the endpoint.
+type statusError struct {
+	code       int
+	retryAfter time.Duration // from a Retry-After header in seconds, if any
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.code)
+}
+
+// newStatusError builds the error for resp, which must not be successful.
+func newStatusError(resp *http.Response) *statusError {
+	err := &statusError{code: resp.StatusCode}
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		err.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return err
+}
+
+// retryable reports whether err is transient and worth retrying.
+func retryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusTooManyRequests || status.code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// post sends a JSON-RPC request, or a batch of them, and returns the successful HTTP
+// response, retrying transient failures under the call's RetryPolicy.
+// The caller must close the response body.
+func (r *RPCClient) post(ctx context.Context, data interface{}) (*http.Response, error) {
+	policy := r.retryPolicy(ctx)
+	for attempt := 1; ; attempt++ {
+		resp, err := r.postOnce(ctx, data)
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		if attempt >= policy.MaxAttempts {
+			if attempt > 1 {
+				return nil, fmt.Errorf("%w (gave up after %d attempts)", err, attempt)
+			}
+			return nil, err
+		}
+		var retryAfter time.Duration
+		var status *statusError
+		if errors.As(err, &status) {
+			retryAfter = status.retryAfter
+		}
+		delay := policy.delay(attempt, retryAfter)
+		r.logger.Warn("Retrying JSON-RPC request",
+			"method", requestMethod(data),
+			"provider", r.Provider(),
+			"attempt", attempt+1,
+			"delay", delay.String(),
+			"err", err,
+		)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-r.clock.After(delay):
+		}
+	}
+}
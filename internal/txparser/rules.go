@@ -0,0 +1,273 @@
+package txparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// Direction describes a transaction relative to the watched address.
+type Direction string
+
+const (
+	// DirectionIn means the watched address received the transaction.
+	DirectionIn Direction = "in"
+	// DirectionOut means the watched address sent the transaction.
+	DirectionOut Direction = "out"
+)
+
+// Rule tags matched transactions whose condition evaluates to true.
+type Rule struct {
+	Name string    `json:"name"`
+	Tags []string  `json:"tags"`
+	When Condition `json:"when"`
+}
+
+// Condition is a composable predicate over a matched transaction.
+// All leaf fields that are set must hold; All, Any and Not nest further conditions.
+// Values and gas prices are wei amounts, written in decimal or 0x-prefixed hex.
+type Condition struct {
+	All []Condition `json:"all,omitempty"`
+	Any []Condition `json:"any,omitempty"`
+	Not *Condition  `json:"not,omitempty"`
+
+	MinValue       string    `json:"minValue,omitempty"`
+	MaxValue       string    `json:"maxValue,omitempty"`
+	Counterparties []string  `json:"counterparties,omitempty"`
+	Direction      Direction `json:"direction,omitempty"`
+	Methods        []string  `json:"methods,omitempty"` // 4-byte selectors such as 0xa9059cbb
+	MinGasPrice    string    `json:"minGasPrice,omitempty"`
+
+	minValue, maxValue, minGasPrice *big.Int
+}
+
+// ruleInput is the view of a matched transaction that conditions are evaluated against.
+type ruleInput struct {
+	address  string
+	tx       Transaction
+	gasPrice *big.Int
+	selector string
+}
+
+// LoadRules reads a JSON array of rules from path.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules file failed: %w", err)
+	}
+	return ParseRules(data)
+}
+
+// ParseRules decodes and validates a JSON array of rules.
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("rules unmarshal failed: %w", err)
+	}
+	for i := range rules {
+		if rules[i].Name == "" {
+			return nil, fmt.Errorf("rule %d: name is required", i)
+		}
+		if err := rules[i].When.compile(); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rules[i].Name, err)
+		}
+	}
+	return rules, nil
+}
+
+// compile validates the condition tree and parses its numeric thresholds.
+func (c *Condition) compile() error {
+	var err error
+	if c.minValue, err = parseOptionalWei(c.MinValue); err != nil {
+		return fmt.Errorf("minValue: %w", err)
+	}
+	if c.maxValue, err = parseOptionalWei(c.MaxValue); err != nil {
+		return fmt.Errorf("maxValue: %w", err)
+	}
+	if c.minGasPrice, err = parseOptionalWei(c.MinGasPrice); err != nil {
+		return fmt.Errorf("minGasPrice: %w", err)
+	}
+	if c.Direction != "" && c.Direction != DirectionIn && c.Direction != DirectionOut {
+		return fmt.Errorf("invalid direction %q", c.Direction)
+	}
+	for i := range c.Counterparties {
+		c.Counterparties[i] = strings.ToLower(c.Counterparties[i])
+	}
+	for i := range c.Methods {
+		c.Methods[i] = strings.ToLower(c.Methods[i])
+	}
+	for i := range c.All {
+		if err := c.All[i].compile(); err != nil {
+			return err
+		}
+	}
+	for i := range c.Any {
+		if err := c.Any[i].compile(); err != nil {
+			return err
+		}
+	}
+	if c.Not != nil {
+		return c.Not.compile()
+	}
+	return nil
+}
+
+// matches evaluates the condition against in.
+func (c *Condition) matches(in ruleInput) bool {
+	if c.minValue != nil || c.maxValue != nil {
+		value, ok := in.tx.WeiValue()
+		if !ok ||
+			(c.minValue != nil && value.Cmp(c.minValue) < 0) ||
+			(c.maxValue != nil && value.Cmp(c.maxValue) > 0) {
+			return false
+		}
+	}
+	if c.minGasPrice != nil && (in.gasPrice == nil || in.gasPrice.Cmp(c.minGasPrice) < 0) {
+		return false
+	}
+	if c.Direction != "" && c.Direction != in.direction() {
+		return false
+	}
+	if len(c.Counterparties) > 0 && !containsString(c.Counterparties, strings.ToLower(in.counterparty())) {
+		return false
+	}
+	if len(c.Methods) > 0 && !containsString(c.Methods, in.selector) {
+		return false
+	}
+	for i := range c.All {
+		if !c.All[i].matches(in) {
+			return false
+		}
+	}
+	if len(c.Any) > 0 {
+		matched := false
+		for i := range c.Any {
+			if c.Any[i].matches(in) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if c.Not != nil && c.Not.matches(in) {
+		return false
+	}
+	return true
+}
+
+// direction reports whether the watched address sent or received the transaction.
+func (in ruleInput) direction() Direction {
+	if strings.EqualFold(in.tx.From, in.address) {
+		return DirectionOut
+	}
+	return DirectionIn
+}
+
+// counterparty returns the other side of the transaction.
+func (in ruleInput) counterparty() string {
+	if in.direction() == DirectionOut {
+		return in.tx.To
+	}
+	return in.tx.From
+}
+
+// newRuleInput builds the evaluation view for a transaction matched on address.
+func newRuleInput(address string, tx Transaction, raw RawTx) ruleInput {
+	in := ruleInput{address: address, tx: tx}
+	if gasPrice, ok := parseWei(raw.GasPrice); ok {
+		in.gasPrice = gasPrice
+	}
+	if len(raw.Input) >= 10 {
+		in.selector = strings.ToLower(raw.Input[:10])
+	}
+	return in
+}
+
+// evaluateRules returns the deduplicated tags of every rule matching in.
+func evaluateRules(rules []Rule, in ruleInput) (tags []string, matched []string) {
+	for i := range rules {
+		if !rules[i].When.matches(in) {
+			continue
+		}
+		matched = append(matched, rules[i].Name)
+		for _, tag := range rules[i].Tags {
+			if !containsString(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, matched
+}
+
+// parseWei parses a decimal amount or a hex quantity.
+func parseWei(s string) (*big.Int, bool) {
+	if s == "" {
+		return nil, false
+	}
+	if strings.HasPrefix(s, "0x") {
+		v, err := hexutil.DecodeBig(s)
+		return v, err == nil
+	}
+	return new(big.Int).SetString(s, 10)
+}
+
+// weiDecimal formats a hex or decimal amount as a base-10 string, or "" if it is invalid.
+func weiDecimal(s string) string {
+	v, ok := parseWei(s)
+	if !ok {
+		return ""
+	}
+	return v.String()
+}
+
+// quantityDecimal formats a hex quantity that is not an amount, such as a nonce, as a
+// base-10 string, or "" if it is invalid.
+func quantityDecimal(s string) string {
+	v, err := hexutil.DecodeUint64(s)
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// etherDecimals is the number of decimal places of one ether in wei.
+const etherDecimals = 18
+
+// etherDecimal formats a hex or decimal wei amount in ether, e.g. "0xde0b6b3a7640000" as "1",
+// or "" if it is invalid or negative.
+func etherDecimal(s string) string {
+	v, ok := parseWei(s)
+	if !ok || v.Sign() < 0 {
+		return ""
+	}
+	return formatTokenAmount(v, etherDecimals)
+}
+
+// parseOptionalWei parses s, returning nil for an empty string.
+func parseOptionalWei(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, ok := parseWei(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return v, nil
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
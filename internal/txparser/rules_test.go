@@ -0,0 +1,43 @@
+package txparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRules verifies composed conditions tag matching transactions.
+func TestRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`[
+		{"name": "large-inbound", "tags": ["large"], "when": {"direction": "in", "minValue": "1000"}},
+		{"name": "exchange-or-transfer", "tags": ["watch"], "when": {
+			"any": [{"counterparties": ["0xEXCHANGE"]}, {"methods": ["0xa9059cbb"]}],
+			"not": {"minGasPrice": "0x64"}
+		}}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseRules error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		raw     RawTx
+		want    []string
+	}{
+		{"large inbound", "0xme", RawTx{From: "0xexchange", To: "0xme", Value: "0x3e8", GasPrice: "0x1"}, []string{"large", "watch"}},
+		{"outbound transfer call", "0xme", RawTx{From: "0xme", To: "0xtoken", Value: "0x0", GasPrice: "0x1", Input: "0xa9059cbb0000"}, []string{"watch"}},
+		{"expensive gas excluded", "0xme", RawTx{From: "0xme", To: "0xexchange", Value: "0x0", GasPrice: "0x64"}, nil},
+		{"small inbound", "0xme", RawTx{From: "0xother", To: "0xme", Value: "0x1"}, nil},
+	}
+	for _, tc := range tests {
+		tx := Transaction{Hash: tc.raw.Hash, From: tc.raw.From, To: tc.raw.To, Value: tc.raw.Value}
+		tags, _ := evaluateRules(rules, newRuleInput(tc.address, tx, tc.raw))
+		if !reflect.DeepEqual(tags, tc.want) {
+			t.Errorf("%s: expected tags %v, got %v", tc.name, tc.want, tags)
+		}
+	}
+
+	if _, err := ParseRules([]byte(`[{"name": "bad", "when": {"minValue": "lots"}}]`)); err == nil {
+		t.Errorf("expected error for invalid minValue")
+	}
+}
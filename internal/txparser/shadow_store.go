@@ -0,0 +1,308 @@
+package txparser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultShadowQueueSize bounds the comparisons waiting for the candidate store.
+const DefaultShadowQueueSize = 1000
+
+// ShadowStore serves a primary Store while mirroring every write to a candidate
+// Store, so a new backend can be validated against production traffic before a
+// migration. Reads are answered by the primary; the same read is replayed against
+// the candidate in the background and the results compared. Reads racing with
+// writes can report transient mismatches, so look at trends rather than single counts.
+type ShadowStore struct {
+	primary   Store
+	candidate Store
+	logger    *slog.Logger
+	queue     chan shadowComparison
+
+	mu    sync.Mutex
+	stats ShadowStats
+}
+
+// ShadowStats counts read comparisons between the primary and candidate stores.
+type ShadowStats struct {
+	Comparisons int64            `json:"comparisons"`
+	Mismatches  int64            `json:"mismatches"`
+	ByMethod    map[string]int64 `json:"mismatchesByMethod"`
+	Dropped     int64            `json:"dropped"` // skipped because the queue was full
+}
+
+// shadowComparison replays a read against the candidate and compares it to the primary's result.
+type shadowComparison struct {
+	method  string
+	key     string
+	primary interface{}
+	replay  func(Store) interface{}
+}
+
+// NewShadowStore creates a ShadowStore. Call Start to begin comparing reads.
+func NewShadowStore(primary, candidate Store, logger *slog.Logger) *ShadowStore {
+	return &ShadowStore{
+		primary:   primary,
+		candidate: candidate,
+		logger:    logger,
+		queue:     make(chan shadowComparison, DefaultShadowQueueSize),
+		stats:     ShadowStats{ByMethod: make(map[string]int64)},
+	}
+}
+
+// Flush flushes both stores, when they support it.
+func (s *ShadowStore) Flush() error {
+	var errs []error
+	for _, store := range []Store{s.primary, s.candidate} {
+		if f, ok := store.(Flusher); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start compares queued reads until ctx is canceled.
+func (s *ShadowStore) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case c := <-s.queue:
+				s.compare(c)
+			}
+		}
+	}()
+}
+
+// Stats returns the comparison counters since startup.
+func (s *ShadowStore) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.ByMethod = make(map[string]int64, len(s.stats.ByMethod))
+	for method, n := range s.stats.ByMethod {
+		stats.ByMethod[method] = n
+	}
+	return stats
+}
+
+// shadow queues a comparison of a primary read result without blocking.
+func (s *ShadowStore) shadow(method, key string, primary interface{}, replay func(Store) interface{}) {
+	select {
+	case s.queue <- shadowComparison{method: method, key: key, primary: primary, replay: replay}:
+	default:
+		s.mu.Lock()
+		s.stats.Dropped++
+		s.mu.Unlock()
+	}
+}
+
+// compare replays c against the candidate. Results are compared in their JSON form,
+// since backends may legitimately differ in nil versus empty slices.
+func (s *ShadowStore) compare(c shadowComparison) {
+	want, errWant := json.Marshal(c.primary)
+	got, errGot := json.Marshal(c.replay(s.candidate))
+	mismatch := errWant != nil || errGot != nil || !bytes.Equal(want, got)
+
+	s.mu.Lock()
+	s.stats.Comparisons++
+	if mismatch {
+		s.stats.Mismatches++
+		s.stats.ByMethod[c.method]++
+	}
+	s.mu.Unlock()
+	if mismatch {
+		s.logger.Warn("Shadow store mismatch", "method", c.method, "key", c.key)
+	}
+}
+
+// Subscribe subscribes on both stores, reporting the primary's result.
+func (s *ShadowStore) Subscribe(address string) bool {
+	s.candidate.Subscribe(address)
+	return s.primary.Subscribe(address)
+}
+
+// SubscribeBatch subscribes on both stores, reporting the primary's results.
+func (s *ShadowStore) SubscribeBatch(addresses []string) map[string]bool {
+	subscribeBatch(s.candidate, addresses)
+	return subscribeBatch(s.primary, addresses)
+}
+
+// Subscriptions lists the primary's subscriptions, or none if it cannot list them.
+func (s *ShadowStore) Subscriptions() []SubscriptionState {
+	if lister, ok := s.primary.(SubscriptionLister); ok {
+		return lister.Subscriptions()
+	}
+	return nil
+}
+
+// SubscribeUntil subscribes on both stores, reporting the primary's result.
+func (s *ShadowStore) SubscribeUntil(address string, expiresAt time.Time) bool {
+	s.candidate.SubscribeUntil(address, expiresAt)
+	return s.primary.SubscribeUntil(address, expiresAt)
+}
+
+// IsSubscribed reads the primary.
+func (s *ShadowStore) IsSubscribed(address string) bool {
+	result := s.primary.IsSubscribed(address)
+	s.shadow("IsSubscribed", address, result, func(c Store) interface{} { return c.IsSubscribed(address) })
+	return result
+}
+
+// AddTransaction writes to both stores.
+func (s *ShadowStore) AddTransaction(address string, tx Transaction) {
+	s.primary.AddTransaction(address, tx)
+	s.candidate.AddTransaction(address, tx)
+}
+
+// GetTransactions reads the primary.
+func (s *ShadowStore) GetTransactions(address string) []Transaction {
+	result := s.primary.GetTransactions(address)
+	s.shadow("GetTransactions", address, result, func(c Store) interface{} { return c.GetTransactions(address) })
+	return result
+}
+
+// GetTransactionsInRange reads the primary.
+func (s *ShadowStore) GetTransactionsInRange(address string, fromBlock, toBlock int64) []Transaction {
+	result := s.primary.GetTransactionsInRange(address, fromBlock, toBlock)
+	s.shadow("GetTransactionsInRange", address, result, func(c Store) interface{} {
+		return c.GetTransactionsInRange(address, fromBlock, toBlock)
+	})
+	return result
+}
+
+// QueryTransactions reads the primary.
+func (s *ShadowStore) QueryTransactions(address string, q TxQuery) ([]Transaction, int) {
+	txs, total := s.primary.QueryTransactions(address, q)
+	s.shadow("QueryTransactions", address, TransactionPage{Transactions: txs, Total: total}, func(c Store) interface{} {
+		txs, total := c.QueryTransactions(address, q)
+		return TransactionPage{Transactions: txs, Total: total}
+	})
+	return txs, total
+}
+
+// SetCurrentBlock writes to both stores.
+func (s *ShadowStore) SetCurrentBlock(block int) {
+	s.primary.SetCurrentBlock(block)
+	s.candidate.SetCurrentBlock(block)
+}
+
+// GetCurrentBlock reads the primary.
+func (s *ShadowStore) GetCurrentBlock() int {
+	result := s.primary.GetCurrentBlock()
+	s.shadow("GetCurrentBlock", "", result, func(c Store) interface{} { return c.GetCurrentBlock() })
+	return result
+}
+
+// CurrentBlock reads the primary, reporting its read errors if it can.
+func (s *ShadowStore) CurrentBlock() (int, error) {
+	if reader, ok := s.primary.(CurrentBlockReader); ok {
+		return reader.CurrentBlock()
+	}
+	return s.primary.GetCurrentBlock(), nil
+}
+
+// SetErrorHistory passes h to the primary if it records its own failures.
+func (s *ShadowStore) SetErrorHistory(h *ErrorHistory) {
+	if setter, ok := s.primary.(ErrorHistorySetter); ok {
+		setter.SetErrorHistory(h)
+	}
+}
+
+// SetBlockHash writes to both stores.
+func (s *ShadowStore) SetBlockHash(block int, hash string) {
+	s.primary.SetBlockHash(block, hash)
+	s.candidate.SetBlockHash(block, hash)
+}
+
+// GetBlockHash reads the primary.
+func (s *ShadowStore) GetBlockHash(block int) (string, bool) {
+	hash, ok := s.primary.GetBlockHash(block)
+	s.shadow("GetBlockHash", strconv.Itoa(block), []interface{}{hash, ok}, func(c Store) interface{} {
+		hash, ok := c.GetBlockHash(block)
+		return []interface{}{hash, ok}
+	})
+	return hash, ok
+}
+
+// RollbackTo rolls back both stores.
+func (s *ShadowStore) RollbackTo(block int) {
+	s.primary.RollbackTo(block)
+	s.candidate.RollbackTo(block)
+}
+
+// PruneBefore prunes both stores that support it, reporting the primary's count.
+func (s *ShadowStore) PruneBefore(block int64) int {
+	if pruner, ok := s.candidate.(Pruner); ok {
+		pruner.PruneBefore(block)
+	}
+	if pruner, ok := s.primary.(Pruner); ok {
+		return pruner.PruneBefore(block)
+	}
+	return 0
+}
+
+// TrimAddresses trims both stores that support it, reporting the primary's count.
+func (s *ShadowStore) TrimAddresses(limit int) int {
+	if pruner, ok := s.candidate.(Pruner); ok {
+		pruner.TrimAddresses(limit)
+	}
+	if pruner, ok := s.primary.(Pruner); ok {
+		return pruner.TrimAddresses(limit)
+	}
+	return 0
+}
+
+// SetNotificationPrefs writes to both stores, reporting the primary's result.
+func (s *ShadowStore) SetNotificationPrefs(address string, prefs NotificationPrefs) bool {
+	s.candidate.SetNotificationPrefs(address, prefs)
+	return s.primary.SetNotificationPrefs(address, prefs)
+}
+
+// GetNotificationPrefs reads the primary.
+func (s *ShadowStore) GetNotificationPrefs(address string) (NotificationPrefs, bool) {
+	prefs, ok := s.primary.GetNotificationPrefs(address)
+	s.shadow("GetNotificationPrefs", address, []interface{}{prefs, ok}, func(c Store) interface{} {
+		prefs, ok := c.GetNotificationPrefs(address)
+		return []interface{}{prefs, ok}
+	})
+	return prefs, ok
+}
+
+// SetClock forwards the clock to both stores when they support it.
+func (s *ShadowStore) SetClock(c Clock) {
+	for _, store := range []Store{s.primary, s.candidate} {
+		if clocked, ok := store.(interface{ SetClock(Clock) }); ok {
+			clocked.SetClock(c)
+		}
+	}
+}
+
+// SubscriptionCount counts the primary's subscriptions, or returns 0 if it cannot.
+func (s *ShadowStore) SubscriptionCount() int {
+	if counter, ok := s.primary.(SubscriptionCounter); ok {
+		return counter.SubscriptionCount()
+	}
+	return 0
+}
+
+// Available reports the primary's availability; a failing candidate never blocks requests.
+func (s *ShadowStore) Available() bool {
+	a, ok := s.primary.(AvailabilityReporter)
+	return !ok || a.Available()
+}
+
+// CheckHealth checks the primary.
+func (s *ShadowStore) CheckHealth(ctx context.Context) error {
+	if checker, ok := s.primary.(HealthChecker); ok {
+		return checker.CheckHealth(ctx)
+	}
+	return nil
+}
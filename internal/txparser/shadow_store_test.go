@@ -0,0 +1,48 @@
+package txparser
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// TestShadowStore verifies that writes reach both stores, reads are served by the
+// primary, and diverging candidate reads are counted as mismatches.
+func TestShadowStore(t *testing.T) {
+	primary, candidate := NewMemoryStore(), NewMemoryStore()
+	shadow := NewShadowStore(primary, candidate, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	shadow.Start(ctx)
+
+	shadow.Subscribe("0xa")
+	shadow.AddTransaction("0xa", Transaction{Hash: "0x1", From: "0xb", To: "0xa", Block: 1})
+	shadow.SetCurrentBlock(1)
+	if !candidate.IsSubscribed("0xa") || len(candidate.GetTransactions("0xa")) != 1 || candidate.GetCurrentBlock() != 1 {
+		t.Fatal("expected writes to reach the candidate store")
+	}
+
+	waitForComparisons := func(n int64) {
+		deadline := time.Now().Add(5 * time.Second)
+		for stats := shadow.Stats(); stats.Comparisons < n; stats = shadow.Stats() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for comparisons, stats %+v", stats)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	shadow.GetTransactions("0xa")
+	shadow.GetCurrentBlock()
+	waitForComparisons(2)
+
+	candidate.AddTransaction("0xa", Transaction{Hash: "0x2", From: "0xa", To: "0xc", Block: 2})
+	if txs := shadow.GetTransactions("0xa"); len(txs) != 1 {
+		t.Fatalf("expected reads from the primary, got %d transactions", len(txs))
+	}
+	waitForComparisons(3)
+	if stats := shadow.Stats(); stats.Mismatches != 1 || stats.ByMethod["GetTransactions"] != 1 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,325 @@
+package txparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FlaggedExporter forwards rule-tagged transactions to an external system such as a SIEM.
+type FlaggedExporter interface {
+	ExportFlagged(address string, tx Transaction) error
+}
+
+// SIEM output formats.
+const (
+	SIEMFormatSyslog = "syslog" // RFC 5424 with key=value message
+	SIEMFormatCEF    = "cef"    // ArcSight Common Event Format
+)
+
+// SIEM export limits.
+const (
+	DefaultSIEMQueueSize    = 1000            // records waiting for the collector
+	DefaultSIEMWriteTimeout = 5 * time.Second // per record write, and per reconnect
+)
+
+// ErrSIEMQueueFull is returned by ExportFlagged when the collector is too slow to keep up.
+var ErrSIEMQueueFull = errors.New("SIEM export queue is full")
+
+// SIEMConfig describes where and how flagged transactions are forwarded.
+type SIEMConfig struct {
+	Network string   `json:"network"` // "udp" or "tcp"
+	Address string   `json:"address"` // host:port of the collector
+	Format  string   `json:"format"`  // SIEMFormatSyslog or SIEMFormatCEF
+	Tags    []string `json:"tags,omitempty"`
+
+	// Fields maps output keys to transaction fields: hash, from, to, value, valueWei, block,
+	// tags, address, matchType, feeWei, burnedFeeWei or tipFeeWei. Defaults to
+	// defaultSIEMFields.
+	Fields map[string]string `json:"fields,omitempty"`
+
+	AppName  string `json:"appName,omitempty"`
+	Severity int    `json:"severity,omitempty"` // CEF severity 0-10, default 5
+}
+
+// defaultSIEMFields is the output mapping used when SIEMConfig.Fields is empty.
+// The keys follow CEF extension names so the default works for both formats.
+var defaultSIEMFields = map[string]string{
+	"suser": "from",
+	"duser": "to",
+	"duid":  "address",
+	"cs1":   "hash",
+	"cs2":   "valueWei",
+	"cs3":   "tags",
+	"cn1":   "block",
+}
+
+// siemFieldNames are the transaction fields that can be mapped.
+var siemFieldNames = []string{"hash", "from", "to", "value", "valueWei", "block", "tags", "address", "matchType", "feeWei", "burnedFeeWei", "tipFeeWei"}
+
+// LoadSIEMConfig reads and validates a JSON SIEM configuration file.
+func LoadSIEMConfig(path string) (SIEMConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SIEMConfig{}, fmt.Errorf("reading SIEM config failed: %w", err)
+	}
+	var cfg SIEMConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return SIEMConfig{}, fmt.Errorf("SIEM config unmarshal failed: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks the format, severity and field mapping.
+func (c SIEMConfig) Validate() error {
+	if c.Format != SIEMFormatSyslog && c.Format != SIEMFormatCEF {
+		return fmt.Errorf("format must be %q or %q", SIEMFormatSyslog, SIEMFormatCEF)
+	}
+	if c.Severity < 0 || c.Severity > 10 {
+		return fmt.Errorf("severity must be between 0 and 10")
+	}
+	for key, field := range c.Fields {
+		if !containsString(siemFieldNames, field) {
+			return fmt.Errorf("field %q: unknown transaction field %q", key, field)
+		}
+	}
+	return nil
+}
+
+// SIEMExporter writes one syslog or CEF line per flagged transaction. Records are
+// queued and written by a background goroutine, so a slow or unreachable collector
+// never stalls parsing; records beyond the queue are dropped.
+type SIEMExporter struct {
+	cfg          SIEMConfig
+	hostname     string
+	clock        Clock
+	logger       *slog.Logger
+	writeTimeout time.Duration
+
+	// w and dial are used by the writer goroutine only. dial is nil for exporters of a
+	// fixed writer, which are never reconnected.
+	w    io.Writer
+	dial func() (io.Writer, error)
+
+	mu     sync.Mutex
+	queue  chan string
+	closed bool
+	done   chan struct{} // closed when the writer goroutine exits
+}
+
+// DialSIEM connects to the collector in cfg and returns an exporter writing to it.
+// After a failed write the exporter reconnects before writing the next record.
+func DialSIEM(cfg SIEMConfig, logger *slog.Logger) (*SIEMExporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	dial := func() (io.Writer, error) {
+		conn, err := net.DialTimeout(cfg.Network, cfg.Address, DefaultSIEMWriteTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to SIEM collector failed: %w", err)
+		}
+		return conn, nil
+	}
+	w, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	return newSIEMExporter(cfg, w, dial, logger), nil
+}
+
+// NewSIEMExporter returns an exporter writing records to w.
+func NewSIEMExporter(cfg SIEMConfig, w io.Writer) *SIEMExporter {
+	return newSIEMExporter(cfg, w, nil, nil)
+}
+
+func newSIEMExporter(cfg SIEMConfig, w io.Writer, dial func() (io.Writer, error), logger *slog.Logger) *SIEMExporter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = defaultSIEMFields
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "tx-parser"
+	}
+	if cfg.Severity == 0 {
+		cfg.Severity = 5
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	e := &SIEMExporter{
+		cfg:          cfg,
+		hostname:     hostname,
+		clock:        SystemClock,
+		logger:       logger,
+		writeTimeout: DefaultSIEMWriteTimeout,
+		w:            w,
+		dial:         dial,
+		queue:        make(chan string, DefaultSIEMQueueSize),
+		done:         make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// ExportFlagged queues tx unless none of its tags are selected by the configuration.
+// It returns ErrSIEMQueueFull instead of waiting for a slow collector.
+func (e *SIEMExporter) ExportFlagged(address string, tx Transaction) error {
+	if len(e.cfg.Tags) > 0 && !containsAny(e.cfg.Tags, tx.Tags) {
+		return nil
+	}
+	var line string
+	if e.cfg.Format == SIEMFormatCEF {
+		line = e.formatCEF(address, tx)
+	} else {
+		line = e.formatSyslog(address, tx)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errors.New("SIEM exporter is closed")
+	}
+	select {
+	case e.queue <- line:
+		return nil
+	default:
+		return ErrSIEMQueueFull
+	}
+}
+
+// run writes queued records until Close.
+func (e *SIEMExporter) run() {
+	defer close(e.done)
+	for line := range e.queue {
+		if err := e.write(line); err != nil {
+			e.logger.Warn("Failed to write SIEM record", "err", err)
+		}
+	}
+}
+
+// write sends one record under the write timeout. After a failure the connection is
+// dropped and, for dialed exporters, the record is retried once on a new one.
+func (e *SIEMExporter) write(line string) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if e.w == nil {
+			if e.dial == nil {
+				return errors.New("SIEM collector connection is closed")
+			}
+			if e.w, err = e.dial(); err != nil {
+				return err
+			}
+		}
+		if conn, ok := e.w.(interface{ SetWriteDeadline(time.Time) error }); ok {
+			conn.SetWriteDeadline(time.Now().Add(e.writeTimeout))
+		}
+		if _, err = io.WriteString(e.w, line+"\n"); err == nil {
+			return nil
+		}
+		if e.dial == nil {
+			break
+		}
+		if c, ok := e.w.(io.Closer); ok {
+			c.Close()
+		}
+		e.w = nil
+	}
+	return fmt.Errorf("writing SIEM record failed: %w", err)
+}
+
+// Close writes the queued records, then closes the underlying connection, if any.
+func (e *SIEMExporter) Close() error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mu.Unlock()
+	<-e.done
+	if c, ok := e.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// fields returns the mapped output key/value pairs sorted by key.
+func (e *SIEMExporter) fields(address string, tx Transaction) [][2]string {
+	values := map[string]string{
+		"hash":      tx.Hash,
+		"from":      tx.From,
+		"to":        tx.To,
+		"value":     tx.Value,
+		"valueWei":  tx.ValueWei,
+		"block":     strconv.FormatInt(tx.Block, 10),
+		"tags":      strings.Join(tx.Tags, ","),
+		"address":   address,
+		"matchType": tx.MatchType,
+
+		"feeWei":       tx.FeeWei,
+		"burnedFeeWei": tx.BurnedFeeWei,
+		"tipFeeWei":    tx.TipFeeWei,
+	}
+	pairs := make([][2]string, 0, len(e.cfg.Fields))
+	for key, field := range e.cfg.Fields {
+		pairs = append(pairs, [2]string{key, values[field]})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// formatCEF renders a CEF:0 record; the signature ID is the first tag.
+func (e *SIEMExporter) formatCEF(address string, tx Transaction) string {
+	signature := "flagged"
+	if len(tx.Tags) > 0 {
+		signature = tx.Tags[0]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|tx-parser-svc|%s|1.0|%s|Flagged transaction|%d|",
+		cefHeaderEscaper.Replace(e.cfg.AppName), cefHeaderEscaper.Replace(signature), e.cfg.Severity)
+	for i, kv := range e.fields(address, tx) {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(kv[0] + "=" + cefValueEscaper.Replace(kv[1]))
+	}
+	return b.String()
+}
+
+// formatSyslog renders an RFC 5424 record at facility local0, severity warning.
+func (e *SIEMExporter) formatSyslog(address string, tx Transaction) string {
+	const priority = 16*8 + 4
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s - flagged -", priority,
+		e.clock.Now().UTC().Format(time.RFC3339), e.hostname, e.cfg.AppName)
+	for _, kv := range e.fields(address, tx) {
+		fmt.Fprintf(&b, " %s=%q", kv[0], kv[1])
+	}
+	return b.String()
+}
+
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefValueEscaper  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// containsAny reports whether any of values is in list.
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		if containsString(list, v) {
+			return true
+		}
+	}
+	return false
+}
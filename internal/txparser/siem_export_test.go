@@ -0,0 +1,100 @@
+package txparser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSIEMExporter verifies CEF and syslog rendering, field mapping and tag filtering.
+func TestSIEMExporter(t *testing.T) {
+	tx := Transaction{Hash: "0xh", From: "0xa", To: "0xb", Value: "0x10", Block: 7, Tags: []string{"deny|list"}}
+
+	var buf bytes.Buffer
+	cef := NewSIEMExporter(SIEMConfig{Format: SIEMFormatCEF, Severity: 8, Fields: map[string]string{
+		"src":  "from",
+		"msg":  "tags",
+		"cnt1": "block",
+	}}, &buf)
+	if err := cef.ExportFlagged("0xa", tx); err != nil {
+		t.Fatalf("ExportFlagged error: %v", err)
+	}
+	cef.Close() // writes the queued record
+	want := `CEF:0|tx-parser-svc|tx-parser|1.0|deny\|list|Flagged transaction|8|cnt1=7 msg=deny|list src=0xa` + "\n"
+	if buf.String() != want {
+		t.Errorf("unexpected CEF record\n got: %s\nwant: %s", buf.String(), want)
+	}
+
+	buf.Reset()
+	syslog := NewSIEMExporter(SIEMConfig{Format: SIEMFormatSyslog, Tags: []string{"threshold"}, Fields: map[string]string{
+		"hash": "hash",
+	}}, &buf)
+	syslog.hostname = "host"
+	syslog.clock = NewFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err := syslog.ExportFlagged("0xa", tx); err != nil {
+		t.Fatalf("ExportFlagged error: %v", err)
+	}
+	tx.Tags = append(tx.Tags, "threshold")
+	tx.Hash = "0xh2"
+	syslog.ExportFlagged("0xa", tx)
+	syslog.Close()
+	// The first transaction, without selected tags, is skipped.
+	want = `<132>1 2024-01-02T03:04:05Z host tx-parser - flagged - hash="0xh2"` + "\n"
+	if buf.String() != want {
+		t.Errorf("unexpected syslog record\n got: %s\nwant: %s", buf.String(), want)
+	}
+
+	err := SIEMConfig{Format: SIEMFormatCEF, Fields: map[string]string{"x": "gasPrice"}}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "gasPrice") {
+		t.Errorf("expected unknown field to be rejected, got %v", err)
+	}
+}
+
+// failingWriter fails every write, like a connection the collector dropped.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+
+// blockingWriter blocks writes until release is closed, like a stalled collector.
+type blockingWriter struct{ release chan struct{} }
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+// TestSIEMExporterQueue verifies records are retried on a new connection after a failed
+// write, and dropped rather than blocking once the queue is full.
+func TestSIEMExporterQueue(t *testing.T) {
+	tx := Transaction{Hash: "0xh", Tags: []string{"denylist"}}
+	cfg := SIEMConfig{Format: SIEMFormatCEF, Fields: map[string]string{"cs1": "hash"}}
+
+	var buf bytes.Buffer
+	dials := 0
+	redial := newSIEMExporter(cfg, failingWriter{}, func() (io.Writer, error) {
+		dials++
+		return &buf, nil
+	}, nil)
+	if err := redial.ExportFlagged("0xa", tx); err != nil {
+		t.Fatalf("ExportFlagged error: %v", err)
+	}
+	redial.Close()
+	if dials != 1 || !strings.Contains(buf.String(), "cs1=0xh") {
+		t.Errorf("expected the record on a new connection after 1 dial, got %d dials and %q", dials, buf.String())
+	}
+
+	stalled := blockingWriter{release: make(chan struct{})}
+	slow := NewSIEMExporter(cfg, stalled)
+	var err error
+	for i := 0; i <= DefaultSIEMQueueSize+1 && err == nil; i++ {
+		err = slow.ExportFlagged("0xa", tx)
+	}
+	if !errors.Is(err, ErrSIEMQueueFull) {
+		t.Errorf("expected a full queue to be reported, got %v", err)
+	}
+	close(stalled.release)
+	slow.Close()
+}
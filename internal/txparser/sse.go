@@ -0,0 +1,127 @@
+package txparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// sseHeartbeatInterval is how often an event stream sends a heartbeat, keeping idle
+// connections open through proxies.
+var sseHeartbeatInterval = 15 * time.Second
+
+// handleTransactionStream handles GET /transactions/stream?address=0x1234, pushing each
+// transaction stored for a subscribed address as a Server-Sent Event named
+// "transaction". Event IDs are changefeed cursors: a client reconnecting with
+// Last-Event-ID receives the transactions it missed, or 410 Gone once they have left
+// the changefeed or the ID was issued before a restart. Heartbeat comments carry the current cursor as the event ID, so an
+// idle client resumes from the head rather than from its last transaction.
+// Each watch=0x<hash> parameter also watches that transaction and streams its state
+// changes as events named "tx_watch".
+func (s *HTTPServer) handleTransactionStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "only GET is allowed")
+		return
+	}
+	address, err := canonicalAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if _, err := s.parser.GetNotificationPrefs(address); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	hashes := make(map[string]bool)
+	for _, hash := range r.URL.Query()["watch"] {
+		if _, err := s.parser.WatchTx(hash, DefaultWatchConfirmations); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		hashes[strings.ToLower(hash)] = true
+	}
+	epoch := s.parser.EventEpoch()
+	_, cursor, _ := s.parser.EventsSince(math.MaxUint64, 0)
+	if raw := r.Header.Get("Last-Event-ID"); raw != "" {
+		parsed, err := parseEventCursor(epoch, raw)
+		if errors.Is(err, ErrCursorExpired) {
+			s.writeError(w, err)
+			return
+		}
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Last-Event-ID must be the id of a previous event")
+			return
+		}
+		if _, _, err := s.parser.EventsSince(parsed, 1); errors.Is(err, ErrCursorExpired) {
+			s.writeError(w, err)
+			return
+		}
+		cursor = parsed
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{}) // the stream outlives the server's write timeout, if any
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		s.logger.Debug("Closing event stream", "err", err)
+		return
+	}
+
+	poll := time.NewTicker(wsPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat\nid: %s\n\n", formatEventCursor(epoch, cursor))
+		case <-poll.C:
+			events, next, err := s.parser.EventsSince(cursor, 1000)
+			if errors.Is(err, ErrCursorExpired) {
+				// Closing makes the client reconnect from its last event and get 410,
+				// rather than silently missing transactions.
+				s.logger.Warn("Event stream fell behind the changefeed, closing it", "address", address)
+				return
+			}
+			cursor = next
+			for _, e := range events {
+				var payload interface{}
+				switch {
+				case e.Type == EventTxAdded && strings.EqualFold(e.Address, address):
+					payload = e.Transaction
+				case e.Type == EventTxWatch && hashes[strings.ToLower(e.TxWatch.Hash)]:
+					payload = e.TxWatch
+				default:
+					continue
+				}
+				data, err := json.Marshal(payload)
+				if err != nil {
+					s.logger.Error("Failed to encode streamed event", "type", e.Type, "err", err)
+					continue
+				}
+				name := "transaction"
+				if e.Type == EventTxWatch {
+					name = string(EventTxWatch)
+				}
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", formatEventCursor(epoch, e.Seq), name, data)
+			}
+		}
+		if s.draining.Load() {
+			return // let shutdown finish; clients reconnect to another instance
+		}
+		if err := rc.Flush(); err != nil {
+			s.logger.Debug("Closing event stream", "err", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package txparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartBlock selects where a parser without stored progress begins indexing.
+// The zero value starts from genesis.
+type StartBlock struct {
+	Latest bool  // count from the chain tip seen on the first poll
+	Number int64 // block number, or how many blocks behind the tip when Latest
+}
+
+// ParseStartBlock parses "latest", "latest-N" or a block number.
+func ParseStartBlock(s string) (StartBlock, error) {
+	if rest, ok := strings.CutPrefix(s, "latest"); ok {
+		if rest == "" {
+			return StartBlock{Latest: true}, nil
+		}
+		behind, err := strconv.ParseInt(strings.TrimPrefix(rest, "-"), 10, 64)
+		if !strings.HasPrefix(rest, "-") || err != nil || behind < 0 {
+			return StartBlock{}, fmt.Errorf("invalid start block %q: expected latest, latest-N or a block number", s)
+		}
+		return StartBlock{Latest: true, Number: behind}, nil
+	}
+	number, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || number < 0 {
+		return StartBlock{}, fmt.Errorf("invalid start block %q: expected latest, latest-N or a block number", s)
+	}
+	return StartBlock{Number: number}, nil
+}
+
+// String formats the start block the way ParseStartBlock accepts it.
+func (s StartBlock) String() string {
+	switch {
+	case s.Latest && s.Number == 0:
+		return "latest"
+	case s.Latest:
+		return fmt.Sprintf("latest-%d", s.Number)
+	default:
+		return strconv.FormatInt(s.Number, 10)
+	}
+}
+
+// resolve returns the first block to parse given the chain tip, never below 1.
+func (s StartBlock) resolve(latest int64) int64 {
+	start := s.Number
+	if s.Latest {
+		start = latest - s.Number
+	}
+	return max(start, 1)
+}
@@ -0,0 +1,59 @@
+package txparser
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Defaults for sweep correlation when a query does not override them.
+const (
+	DefaultSweepWindow = 10                  // blocks after the deposit
+	DefaultSweepMaxFee = "10000000000000000" // 0.01 ether in wei
+)
+
+// SweepLink pairs an inbound deposit with the outbound transaction that swept it onward.
+type SweepLink struct {
+	Deposit Transaction `json:"deposit"`
+	Sweep   Transaction `json:"sweep"`
+	Fee     string      `json:"fee"` // deposit value minus swept value, in decimal wei
+}
+
+// CorrelateSweeps links inbound deposits to address with later outbound transfers
+// of the same value minus at most maxFee, sent within window blocks.
+// txs must be in block order; each outbound transfer sweeps at most one deposit.
+func CorrelateSweeps(address string, txs []Transaction, window int64, maxFee *big.Int) []SweepLink {
+	links := []SweepLink{}
+	used := make(map[int]bool)
+
+	for i, deposit := range txs {
+		if !strings.EqualFold(deposit.To, address) || strings.EqualFold(deposit.From, address) {
+			continue
+		}
+		depositValue, ok := deposit.WeiValue()
+		if !ok || depositValue.Sign() == 0 {
+			continue
+		}
+
+		for j := i + 1; j < len(txs); j++ {
+			sweep := txs[j]
+			if sweep.Block-deposit.Block > window {
+				break
+			}
+			if used[j] || !strings.EqualFold(sweep.From, address) {
+				continue
+			}
+			sweepValue, ok := sweep.WeiValue()
+			if !ok {
+				continue
+			}
+			fee := new(big.Int).Sub(depositValue, sweepValue)
+			if fee.Sign() < 0 || fee.Cmp(maxFee) > 0 {
+				continue
+			}
+			used[j] = true
+			links = append(links, SweepLink{Deposit: deposit, Sweep: sweep, Fee: fee.String()})
+			break
+		}
+	}
+	return links
+}
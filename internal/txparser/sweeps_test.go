@@ -0,0 +1,23 @@
+package txparser
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestCorrelateSweeps verifies deposits link to the first fitting sweep inside the window.
+func TestCorrelateSweeps(t *testing.T) {
+	txs := []Transaction{
+		{Hash: "0xd1", From: "0xuser1", To: "0xdep", Value: "0x64", Block: 1},
+		{Hash: "0xd2", From: "0xuser2", To: "0xdep", Value: "0xc8", Block: 2},
+		{Hash: "0xs1", From: "0xdep", To: "0xhot", Value: "0x60", Block: 3},
+		{Hash: "0xs2", From: "0xdep", To: "0xhot", Value: "0xc6", Block: 20},
+	}
+	links := CorrelateSweeps("0xdep", txs, 10, big.NewInt(5))
+	if len(links) != 1 {
+		t.Fatalf("expected 1 link, got %d: %+v", len(links), links)
+	}
+	if links[0].Deposit.Hash != "0xd1" || links[0].Sweep.Hash != "0xs1" || links[0].Fee != "4" {
+		t.Errorf("unexpected link %+v", links[0])
+	}
+}
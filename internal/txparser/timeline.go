@@ -0,0 +1,96 @@
+package txparser
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimelineBucket is the activity of an address within one time bucket.
+type TimelineBucket struct {
+	Start int64  `json:"start"` // bucket start in Unix seconds
+	Count int64  `json:"count"`
+	Total string `json:"total"` // summed transfer values in decimal wei
+}
+
+// timelineWidths lists the supported bucket widths by their query name.
+var timelineWidths = map[string]time.Duration{
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// ParseTimelineBucket returns the width of a named bucket ("1h" or "1d").
+func ParseTimelineBucket(s string) (time.Duration, error) {
+	width, ok := timelineWidths[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown bucket %q (want 1h or 1d)", s)
+	}
+	return width, nil
+}
+
+// timelineEntry accumulates one bucket.
+type timelineEntry struct {
+	count int64
+	total *big.Int
+}
+
+// timeline maintains per-address activity buckets for every supported width,
+// keyed by bucket start. It is updated as transactions are stored.
+type timeline struct {
+	mu      sync.Mutex
+	buckets map[string]map[time.Duration]map[int64]*timelineEntry
+}
+
+func newTimeline() *timeline {
+	return &timeline{buckets: make(map[string]map[time.Duration]map[int64]*timelineEntry)}
+}
+
+// observe records a transaction stored for address. Transactions without a block
+// timestamp are skipped; unparseable or negative values count with a zero value.
+func (t *timeline) observe(address string, tx Transaction) {
+	if tx.Timestamp <= 0 {
+		return
+	}
+	v, ok := tx.WeiValue()
+	if !ok || v.Sign() < 0 {
+		v = new(big.Int)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	byWidth, ok := t.buckets[address]
+	if !ok {
+		byWidth = make(map[time.Duration]map[int64]*timelineEntry)
+		t.buckets[address] = byWidth
+	}
+	for _, width := range timelineWidths {
+		entries, ok := byWidth[width]
+		if !ok {
+			entries = make(map[int64]*timelineEntry)
+			byWidth[width] = entries
+		}
+		seconds := int64(width / time.Second)
+		start := tx.Timestamp - tx.Timestamp%seconds
+		e, ok := entries[start]
+		if !ok {
+			e = &timelineEntry{total: new(big.Int)}
+			entries[start] = e
+		}
+		e.count++
+		e.total.Add(e.total, v)
+	}
+}
+
+// get returns the non-empty buckets of address for width, oldest first.
+func (t *timeline) get(address string, width time.Duration) []TimelineBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := t.buckets[address][width]
+	result := make([]TimelineBucket, 0, len(entries))
+	for start, e := range entries {
+		result = append(result, TimelineBucket{Start: start, Count: e.count, Total: e.total.String()})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	return result
+}
@@ -0,0 +1,249 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// TransferEventTopic is the topic of ERC-20 Transfer(address,address,uint256) events.
+const TransferEventTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// MatchTypeToken marks transactions stored from an ERC-20 Transfer event rather than a native transfer.
+const MatchTypeToken = "token"
+
+// decimalsSelector is the method selector of the ERC-20 decimals() view.
+const decimalsSelector = "0x313ce567"
+
+// ErrNoDecimals is returned when a token contract does not report valid decimals.
+var ErrNoDecimals = errors.New("token has no valid decimals")
+
+// RawLog is an event log as returned by eth_getLogs.
+type RawLog struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	TransactionHash string   `json:"transactionHash"`
+	BlockNumber     string   `json:"blockNumber"`
+	LogIndex        string   `json:"logIndex"`
+	Removed         bool     `json:"removed"`
+}
+
+// TokenLogSource is implemented by sources that can return the ERC-20 Transfer logs
+// of a block and the decimals of a token contract.
+type TokenLogSource interface {
+	GetTransferLogs(ctx context.Context, blockNum int64) ([]RawLog, error)
+	TokenDecimals(ctx context.Context, contract string) (int, error)
+}
+
+// tokenTransfer is a decoded ERC-20 Transfer event.
+type tokenTransfer struct {
+	contract string
+	from     string
+	to       string
+	amount   *big.Int
+	txHash   string
+	logIndex int64
+}
+
+// decodeTransferLog decodes an ERC-20 Transfer log. ERC-721 transfers share the topic
+// but index the token ID as a fourth topic, so they are rejected.
+func decodeTransferLog(log RawLog) (tokenTransfer, bool) {
+	if log.Removed || len(log.Topics) != 3 || !strings.EqualFold(log.Topics[0], TransferEventTopic) {
+		return tokenTransfer{}, false
+	}
+	from, okFrom := topicAddress(log.Topics[1])
+	to, okTo := topicAddress(log.Topics[2])
+	data, err := hexutil.Decode(log.Data)
+	if !okFrom || !okTo || err != nil || len(data) != 32 {
+		return tokenTransfer{}, false
+	}
+	logIndex, _ := hexutil.DecodeInt64(log.LogIndex) // 0 if the source omits it
+	return tokenTransfer{
+		contract: strings.ToLower(log.Address),
+		from:     from,
+		to:       to,
+		amount:   new(big.Int).SetBytes(data),
+		txHash:   log.TransactionHash,
+		logIndex: logIndex,
+	}, true
+}
+
+// topicAddress extracts the address from a 32-byte indexed topic.
+func topicAddress(topic string) (string, bool) {
+	word := strings.ToLower(strings.TrimPrefix(topic, "0x"))
+	if len(word) != 64 || strings.Trim(word[:24], "0") != "" {
+		return "", false
+	}
+	return "0x" + word[24:], true
+}
+
+// formatTokenAmount renders amount base units as a decimal with the token's decimals,
+// trimming trailing fractional zeros, e.g. 1500000 with 6 decimals is "1.5".
+func formatTokenAmount(amount *big.Int, decimals int) string {
+	if decimals <= 0 {
+		return amount.String()
+	}
+	digits := amount.String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
+
+// GetTransferLogs returns the ERC-20 Transfer logs emitted in a block.
+func (r *RPCClient) GetTransferLogs(ctx context.Context, blockNum int64) ([]RawLog, error) {
+	hexBlockNum := hexutil.EncodeInt64(blockNum)
+	result, err := r.call(ctx, "eth_getLogs", map[string]interface{}{
+		"fromBlock": hexBlockNum,
+		"toBlock":   hexBlockNum,
+		"topics":    []string{TransferEventTopic},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetTransferLogs request failed: %w", err)
+	}
+	var logs []RawLog
+	if err := json.Unmarshal(result, &logs); err != nil {
+		return nil, fmt.Errorf("GetTransferLogs unmarshal failed: %w", err)
+	}
+	return logs, nil
+}
+
+// GetAddressTransferLogs returns the ERC-20 Transfer logs sent or received by address
+// in blocks fromBlock through toBlock, with one request per direction.
+func (r *RPCClient) GetAddressTransferLogs(ctx context.Context, address string, fromBlock, toBlock int64) ([]RawLog, error) {
+	topic := "0x" + strings.Repeat("0", 24) + strings.ToLower(strings.TrimPrefix(address, "0x"))
+	var logs []RawLog
+	for _, topics := range [][]interface{}{
+		{TransferEventTopic, topic},
+		{TransferEventTopic, nil, topic},
+	} {
+		result, err := r.call(ctx, "eth_getLogs", map[string]interface{}{
+			"fromBlock": hexutil.EncodeInt64(fromBlock),
+			"toBlock":   hexutil.EncodeInt64(toBlock),
+			"topics":    topics,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("GetAddressTransferLogs request failed: %w", err)
+		}
+		var page []RawLog
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("GetAddressTransferLogs unmarshal failed: %w", err)
+		}
+		logs = append(logs, page...)
+	}
+	return logs, nil
+}
+
+// TokenDecimals calls decimals() on a token contract at the latest block.
+func (r *RPCClient) TokenDecimals(ctx context.Context, contract string) (int, error) {
+	result, err := r.call(ctx, "eth_call", map[string]interface{}{
+		"to":   contract,
+		"data": decimalsSelector,
+	}, "latest")
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return 0, fmt.Errorf("contract %s: %w: %v", contract, ErrNoDecimals, rpcErr) // e.g. execution reverted
+	}
+	if err != nil {
+		return 0, fmt.Errorf("TokenDecimals request failed: %w", err)
+	}
+	var data string
+	if err := json.Unmarshal(result, &data); err != nil {
+		return 0, fmt.Errorf("TokenDecimals unmarshal failed: %w", err)
+	}
+	word, err := hexutil.Decode(data)
+	decimals := new(big.Int).SetBytes(word)
+	if err != nil || len(word) == 0 || !decimals.IsInt64() || decimals.Int64() > 77 {
+		return 0, fmt.Errorf("contract %s: %w", contract, ErrNoDecimals)
+	}
+	return int(decimals.Int64()), nil
+}
+
+// storeTokenTransfers fetches the ERC-20 Transfer logs of a block and stores each
+// transfer touching a subscribed address as a MatchTypeToken transaction. Failures are
+// recorded without failing the block, since its native transfers are already stored.
+func (p *EthParser) storeTokenTransfers(ctx context.Context, blockNum int, timestamp int64) {
+	source, ok := p.client.(TokenLogSource)
+	if !ok || !p.trackTokens || !p.features.Enabled(FeatureTokens) {
+		return
+	}
+	logs, err := source.GetTransferLogs(ctx, int64(blockNum))
+	if err != nil {
+		p.logger.Warn("Failed to fetch token transfer logs", "block", blockNum, "err", err)
+		p.errors.Record("tokens", blockNum, err)
+		return
+	}
+	for _, log := range logs {
+		transfer, ok := decodeTransferLog(log)
+		if !ok {
+			continue
+		}
+		fromSubscribed, toSubscribed := p.store.IsSubscribed(transfer.from), p.store.IsSubscribed(transfer.to)
+		if !fromSubscribed && !toSubscribed {
+			continue
+		}
+		tx := p.tokenTransaction(ctx, source, transfer, blockNum, timestamp)
+		if fromSubscribed {
+			p.addTransaction(transfer.from, tx, RawTx{})
+		}
+		if toSubscribed && transfer.to != transfer.from {
+			p.addTransaction(transfer.to, tx, RawTx{})
+		}
+	}
+}
+
+// tokenTransaction converts a transfer included in the given block to a MatchTypeToken
+// transaction, with a decimals-adjusted value when the token reports its decimals.
+func (p *EthParser) tokenTransaction(ctx context.Context, source TokenLogSource, transfer tokenTransfer, blockNum int, timestamp int64) Transaction {
+	tx := Transaction{
+		Hash:        transfer.txHash,
+		From:        transfer.from,
+		To:          transfer.to,
+		Value:       "0x0",
+		Block:       int64(blockNum),
+		Timestamp:   timestamp,
+		MatchType:   MatchTypeToken,
+		Token:       transfer.contract,
+		TokenAmount: transfer.amount.String(),
+		LogIndex:    transfer.logIndex,
+	}
+	if decimals, ok := p.tokenDecimals(ctx, source, transfer.contract); ok {
+		tx.TokenValue = formatTokenAmount(transfer.amount, decimals)
+	}
+	return tx
+}
+
+// tokenDecimals returns the cached decimals of a token contract, fetching them once.
+// Contracts without valid decimals are remembered as unknown; other failures are retried
+// on the contract's next transfer.
+func (p *EthParser) tokenDecimals(ctx context.Context, source TokenLogSource, contract string) (int, bool) {
+	p.tokenMu.Lock()
+	decimals, ok := p.decimals[contract]
+	p.tokenMu.Unlock()
+	if ok {
+		return decimals, decimals >= 0
+	}
+
+	decimals, err := source.TokenDecimals(ctx, contract)
+	if err != nil {
+		p.logger.Debug("Token decimals unavailable", "contract", contract, "err", err)
+		if !errors.Is(err, ErrNoDecimals) {
+			return 0, false
+		}
+		decimals = -1
+	}
+	p.tokenMu.Lock()
+	p.decimals[contract] = decimals
+	p.tokenMu.Unlock()
+	return decimals, decimals >= 0
+}
@@ -0,0 +1,166 @@
+package txparser
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFilter is returned for malformed filter expressions.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// filterTerm matches one comparison of a filter expression, e.g. "value>=1.5eth".
+var filterTerm = regexp.MustCompile(`^([a-zA-Z]+)\s*(>=|<=|=|>|<)\s*(\S+)$`)
+
+// filterAnd separates the comparisons of a filter expression.
+var filterAnd = regexp.MustCompile(`(?i)\s+AND\s+`)
+
+// valueUnits are the multipliers of the unit suffixes accepted for value.
+var valueUnits = map[string]*big.Int{
+	"eth":  big.NewInt(1_000_000_000_000_000_000),
+	"gwei": big.NewInt(1_000_000_000),
+	"wei":  big.NewInt(1),
+}
+
+// ApplyFilter narrows q by a filter expression: comparisons joined by AND, such as
+//
+//	value>1eth AND direction=in AND block>19000000
+//
+// Fields are value (wei, or with a gwei or eth suffix; compared with any operator),
+// block (any operator), direction (= in or out), risk (> or >=) and tag (=).
+// Bounds combine with those already set on q, so the result matches both.
+func (q *TxQuery) ApplyFilter(expr string) error {
+	for _, term := range filterAnd.Split(strings.TrimSpace(expr), -1) {
+		m := filterTerm.FindStringSubmatch(strings.TrimSpace(term))
+		if m == nil {
+			return fmt.Errorf("%w: %q is not a comparison like value>1eth", ErrInvalidFilter, term)
+		}
+		field, op, operand := strings.ToLower(m[1]), m[2], m[3]
+		var err error
+		switch field {
+		case "value":
+			err = q.filterValue(op, operand)
+		case "block":
+			err = q.filterBlock(op, operand)
+		case "direction":
+			if op != "=" || (operand != string(DirectionIn) && operand != string(DirectionOut)) {
+				err = errors.New("direction must be compared with = to in or out")
+			} else if q.Direction != "" && q.Direction != Direction(operand) {
+				err = errors.New("conflicting directions")
+			}
+			q.Direction = Direction(operand)
+		case "risk":
+			err = q.filterRisk(op, operand)
+		case "tag":
+			if op != "=" {
+				err = errors.New("tag must be compared with =")
+			}
+			q.Tag = operand
+		default:
+			err = fmt.Errorf("unknown field %q, expected value, block, direction, risk or tag", field)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+		}
+	}
+	return nil
+}
+
+// filterValue narrows the value bounds of q.
+func (q *TxQuery) filterValue(op, operand string) error {
+	wei, err := parseValueOperand(operand)
+	if err != nil {
+		return err
+	}
+	one := big.NewInt(1)
+	switch op {
+	case ">":
+		q.MinValue = maxBig(q.MinValue, new(big.Int).Add(wei, one))
+	case ">=":
+		q.MinValue = maxBig(q.MinValue, wei)
+	case "<":
+		q.MaxValue = minBig(q.MaxValue, new(big.Int).Sub(wei, one))
+	case "<=":
+		q.MaxValue = minBig(q.MaxValue, wei)
+	case "=":
+		q.MinValue, q.MaxValue = maxBig(q.MinValue, wei), minBig(q.MaxValue, wei)
+	}
+	return nil
+}
+
+// parseValueOperand parses an amount like 1.5eth, 30gwei or 1000 (wei) into wei.
+func parseValueOperand(s string) (*big.Int, error) {
+	number, unit := strings.ToLower(s), "wei"
+	for _, suffix := range []string{"gwei", "eth", "wei"} { // gwei before its suffix wei
+		if trimmed, ok := strings.CutSuffix(number, suffix); ok {
+			number, unit = trimmed, suffix
+			break
+		}
+	}
+	amount, ok := new(big.Rat).SetString(number)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	amount.Mul(amount, new(big.Rat).SetInt(valueUnits[unit]))
+	if !amount.IsInt() {
+		return nil, fmt.Errorf("value %q is not a whole number of wei", s)
+	}
+	return amount.Num(), nil
+}
+
+// filterBlock narrows the block range of q.
+func (q *TxQuery) filterBlock(op, operand string) error {
+	block, err := strconv.ParseInt(operand, 10, 64)
+	if err != nil || block < 0 {
+		return fmt.Errorf("invalid block %q", operand)
+	}
+	switch op {
+	case ">":
+		q.FromBlock = max(q.FromBlock, block+1)
+	case ">=":
+		q.FromBlock = max(q.FromBlock, block)
+	case "<":
+		q.ToBlock = min(q.ToBlock, block-1)
+	case "<=":
+		q.ToBlock = min(q.ToBlock, block)
+	case "=":
+		q.FromBlock, q.ToBlock = max(q.FromBlock, block), min(q.ToBlock, block)
+	}
+	return nil
+}
+
+// filterRisk raises the minimum risk score of q.
+func (q *TxQuery) filterRisk(op, operand string) error {
+	score, err := strconv.Atoi(operand)
+	if err != nil || score < 0 || score > MaxRiskScore {
+		return fmt.Errorf("risk must be an integer between 0 and %d", MaxRiskScore)
+	}
+	switch op {
+	case ">":
+		q.MinRisk = max(q.MinRisk, score+1)
+	case ">=":
+		q.MinRisk = max(q.MinRisk, score)
+	default:
+		return errors.New("risk supports only > and >=")
+	}
+	return nil
+}
+
+// maxBig returns the larger of a and b, treating nil as unbounded below.
+func maxBig(a, b *big.Int) *big.Int {
+	if a == nil || b.Cmp(a) > 0 {
+		return b
+	}
+	return a
+}
+
+// minBig returns the smaller of a and b, treating nil as unbounded above.
+func minBig(a, b *big.Int) *big.Int {
+	if a == nil || b.Cmp(a) < 0 {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,82 @@
+package txparser
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Page size limits for transaction queries.
+const (
+	DefaultTxPageLimit = 100
+	MaxTxPageLimit     = 1000
+)
+
+// TxQuery selects a page of an address's transactions, oldest first.
+type TxQuery struct {
+	FromBlock int64     // inclusive lower block bound
+	ToBlock   int64     // inclusive upper block bound
+	Direction Direction // in, out, or empty for both
+	MinRisk   int       // lowest counterparty RiskScore to include
+	Offset    int       // matching transactions to skip
+	Limit     int       // maximum transactions to return, 0 for no limit
+
+	// MinValue and MaxValue are inclusive wei bounds on Value, nil for none.
+	MinValue, MaxValue *big.Int
+	// Tag, if set, selects transactions carrying that rule tag.
+	Tag string
+}
+
+// matches reports whether tx, stored under address, passes the non-range filters.
+func (q TxQuery) matches(address string, tx Transaction) bool {
+	if q.Direction != "" {
+		direction := DirectionIn
+		if strings.EqualFold(tx.From, address) {
+			direction = DirectionOut
+		}
+		if direction != q.Direction {
+			return false
+		}
+	}
+	if q.Tag != "" && !containsString(tx.Tags, q.Tag) {
+		return false
+	}
+	if q.MinValue != nil || q.MaxValue != nil {
+		value, ok := tx.WeiValue()
+		if !ok || (q.MinValue != nil && value.Cmp(q.MinValue) < 0) || (q.MaxValue != nil && value.Cmp(q.MaxValue) > 0) {
+			return false
+		}
+	}
+	return tx.RiskScore >= q.MinRisk
+}
+
+// txPager collects the requested page from transactions visited in block order,
+// counting every match so callers can report the total.
+type txPager struct {
+	query TxQuery
+	total int
+	page  []Transaction
+}
+
+func newTxPager(q TxQuery) *txPager {
+	return &txPager{query: q, page: []Transaction{}}
+}
+
+// add visits one transaction within the query's block range.
+func (p *txPager) add(address string, tx Transaction) {
+	if !p.query.matches(address, tx) {
+		return
+	}
+	if p.total >= p.query.Offset && (p.query.Limit == 0 || len(p.page) < p.query.Limit) {
+		p.page = append(p.page, tx)
+	}
+	p.total++
+}
+
+// TransactionPage is one page of an address's transactions with pagination metadata.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	Total        int           `json:"total"`  // matching transactions across all pages
+	Offset       int           `json:"offset"` // matching transactions skipped
+	Limit        int           `json:"limit"`
+	NextOffset   *int          `json:"nextOffset,omitempty"` // offset of the next page, absent on the last
+}
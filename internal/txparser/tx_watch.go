@@ -0,0 +1,188 @@
+package txparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TxWatchState is the lifecycle state of a watched transaction hash.
+type TxWatchState string
+
+const (
+	TxPending   TxWatchState = "pending"   // not yet seen in a processed block
+	TxMined     TxWatchState = "mined"     // included, waiting for confirmations
+	TxConfirmed TxWatchState = "confirmed" // reached the requested confirmations
+	TxDropped   TxWatchState = "dropped"   // not mined within the drop window
+)
+
+// Defaults for watched transactions.
+const (
+	DefaultWatchConfirmations = 12
+	DefaultWatchDropBlocks    = 100           // blocks a hash may stay pending before it is considered dropped
+	DefaultMaxWatches         = 10000         // hashes watched at once
+	DefaultWatchRetention     = 1 * time.Hour // how long confirmed and dropped watches stay readable
+)
+
+// Errors returned by WatchTx.
+var (
+	ErrInvalidTxHash  = errors.New("invalid transaction hash")
+	ErrTooManyWatches = errors.New("too many watched transactions, retry later")
+)
+
+// validateTxHash checks that hash is 0x followed by exactly 64 hex digits.
+func validateTxHash(hash string) error {
+	if len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return fmt.Errorf("%w %q: expected 0x followed by 64 hex digits", ErrInvalidTxHash, hash)
+	}
+	for _, c := range hash[2:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return fmt.Errorf("%w %q: non-hex character %q", ErrInvalidTxHash, hash, c)
+		}
+	}
+	return nil
+}
+
+// TxWatch tracks a single transaction hash until it confirms or is dropped.
+type TxWatch struct {
+	Hash          string       `json:"hash"`
+	State         TxWatchState `json:"state"`
+	Block         int64        `json:"block,omitempty"` // block the tx was mined in
+	Confirmations int          `json:"confirmations"`
+	Target        int          `json:"targetConfirmations"`
+	WatchedAt     int          `json:"watchedAtBlock"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+}
+
+// txWatcher holds the watched hashes, keyed by lowercase hash. Confirmed and dropped
+// watches are forgotten retention after their last transition.
+type txWatcher struct {
+	mu         sync.Mutex
+	watches    map[string]*TxWatch
+	dropBlocks int
+	limit      int
+	retention  time.Duration
+	onChange   func(TxWatch)
+	clock      Clock
+}
+
+// newTxWatcher creates a watcher that calls onChange on every state transition.
+func newTxWatcher(onChange func(TxWatch)) *txWatcher {
+	return &txWatcher{
+		watches:    make(map[string]*TxWatch),
+		dropBlocks: DefaultWatchDropBlocks,
+		limit:      DefaultMaxWatches,
+		retention:  DefaultWatchRetention,
+		onChange:   onChange,
+		clock:      SystemClock,
+	}
+}
+
+// add starts watching hash at currentBlock; re-watching an existing hash returns its state.
+// It fails with ErrTooManyWatches once limit hashes are watched.
+func (w *txWatcher) add(hash string, confirmations, currentBlock int) (TxWatch, error) {
+	if err := validateTxHash(hash); err != nil {
+		return TxWatch{}, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := strings.ToLower(hash)
+	if watch, ok := w.watches[key]; ok {
+		return *watch, nil
+	}
+	if len(w.watches) >= w.limit {
+		w.expireLocked()
+		if len(w.watches) >= w.limit {
+			return TxWatch{}, ErrTooManyWatches
+		}
+	}
+	watch := &TxWatch{
+		Hash:      hash,
+		State:     TxPending,
+		Target:    max(confirmations, 1),
+		WatchedAt: currentBlock,
+		UpdatedAt: w.clock.Now(),
+	}
+	w.watches[key] = watch
+	return *watch, nil
+}
+
+// get returns the watch for hash.
+func (w *txWatcher) get(hash string) (TxWatch, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	watch, ok := w.watches[strings.ToLower(hash)]
+	if !ok {
+		return TxWatch{}, false
+	}
+	return *watch, true
+}
+
+// observe marks a watched hash as mined when it appears in a processed block.
+func (w *txWatcher) observe(hash string, block int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	watch, ok := w.watches[strings.ToLower(hash)]
+	if !ok || (watch.State != TxPending && watch.State != TxDropped) {
+		return
+	}
+	watch.Block = block
+	w.transition(watch, TxMined)
+}
+
+// advance updates confirmation counts and drop deadlines after currentBlock was processed,
+// and forgets expired confirmed and dropped watches.
+func (w *txWatcher) advance(currentBlock int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expireLocked()
+	for _, watch := range w.watches {
+		switch watch.State {
+		case TxPending:
+			if currentBlock-watch.WatchedAt >= w.dropBlocks {
+				w.transition(watch, TxDropped)
+			}
+		case TxMined:
+			watch.Confirmations = currentBlock - int(watch.Block) + 1
+			watch.UpdatedAt = w.clock.Now()
+			if watch.Confirmations >= watch.Target {
+				w.transition(watch, TxConfirmed)
+			}
+		}
+	}
+}
+
+// rollback returns hashes mined above block to pending after a reorg.
+func (w *txWatcher) rollback(block int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, watch := range w.watches {
+		if (watch.State == TxMined || watch.State == TxConfirmed) && watch.Block > int64(block) {
+			watch.Block = 0
+			watch.Confirmations = 0
+			w.transition(watch, TxPending)
+		}
+	}
+}
+
+// expireLocked deletes confirmed and dropped watches older than the retention. Callers hold w.mu.
+func (w *txWatcher) expireLocked() {
+	cutoff := w.clock.Now().Add(-w.retention)
+	for key, watch := range w.watches {
+		if (watch.State == TxConfirmed || watch.State == TxDropped) && watch.UpdatedAt.Before(cutoff) {
+			delete(w.watches, key)
+		}
+	}
+}
+
+// transition moves watch to state and notifies the listener. Callers hold w.mu.
+func (w *txWatcher) transition(watch *TxWatch, state TxWatchState) {
+	watch.State = state
+	watch.UpdatedAt = w.clock.Now()
+	if w.onChange != nil {
+		w.onChange(*watch)
+	}
+}
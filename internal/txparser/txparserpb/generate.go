@@ -0,0 +1,4 @@
+// Package txparserpb holds the gRPC API of the parser, generated from txparser.proto.
+package txparserpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative txparser.proto
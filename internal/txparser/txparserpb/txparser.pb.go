@@ -0,0 +1,798 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: txparser.proto
+
+package txparserpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetCurrentBlockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentBlockRequest) Reset() {
+	*x = GetCurrentBlockRequest{}
+	mi := &file_txparser_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentBlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentBlockRequest) ProtoMessage() {}
+
+func (x *GetCurrentBlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentBlockRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentBlockRequest) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{0}
+}
+
+type GetCurrentBlockResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Block int64                  `protobuf:"varint,1,opt,name=block,proto3" json:"block,omitempty"`
+	// safe_block is the highest block deep enough below the chain tip to be indexed.
+	SafeBlock     int64 `protobuf:"varint,2,opt,name=safe_block,json=safeBlock,proto3" json:"safe_block,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentBlockResponse) Reset() {
+	*x = GetCurrentBlockResponse{}
+	mi := &file_txparser_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentBlockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentBlockResponse) ProtoMessage() {}
+
+func (x *GetCurrentBlockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentBlockResponse.ProtoReflect.Descriptor instead.
+func (*GetCurrentBlockResponse) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetCurrentBlockResponse) GetBlock() int64 {
+	if x != nil {
+		return x.Block
+	}
+	return 0
+}
+
+func (x *GetCurrentBlockResponse) GetSafeBlock() int64 {
+	if x != nil {
+		return x.SafeBlock
+	}
+	return 0
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_txparser_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubscribeRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type SubscribeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Created is false if the address was already subscribed.
+	Created       bool `protobuf:"varint,1,opt,name=created,proto3" json:"created,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeResponse) Reset() {
+	*x = SubscribeResponse{}
+	mi := &file_txparser_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeResponse) ProtoMessage() {}
+
+func (x *SubscribeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeResponse) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SubscribeResponse) GetCreated() bool {
+	if x != nil {
+		return x.Created
+	}
+	return false
+}
+
+type GetTransactionsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Address   string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	FromBlock int64                  `protobuf:"varint,2,opt,name=from_block,json=fromBlock,proto3" json:"from_block,omitempty"`
+	// To_block is inclusive; 0 means up to the current block.
+	ToBlock int64 `protobuf:"varint,3,opt,name=to_block,json=toBlock,proto3" json:"to_block,omitempty"`
+	// Limit defaults to the HTTP API's page size when 0.
+	Limit         int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTransactionsRequest) Reset() {
+	*x = GetTransactionsRequest{}
+	mi := &file_txparser_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionsRequest) ProtoMessage() {}
+
+func (x *GetTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*GetTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetTransactionsRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *GetTransactionsRequest) GetFromBlock() int64 {
+	if x != nil {
+		return x.FromBlock
+	}
+	return 0
+}
+
+func (x *GetTransactionsRequest) GetToBlock() int64 {
+	if x != nil {
+		return x.ToBlock
+	}
+	return 0
+}
+
+func (x *GetTransactionsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetTransactionsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetTransactionsResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Transactions []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	// Total counts matching transactions across all pages.
+	Total         int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTransactionsResponse) Reset() {
+	*x = GetTransactionsResponse{}
+	mi := &file_txparser_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionsResponse) ProtoMessage() {}
+
+func (x *GetTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*GetTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetTransactionsResponse) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *GetTransactionsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type NotifyTransactionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Addresses are subscribed if they are not already.
+	Addresses     []string `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NotifyTransactionsRequest) Reset() {
+	*x = NotifyTransactionsRequest{}
+	mi := &file_txparser_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotifyTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotifyTransactionsRequest) ProtoMessage() {}
+
+func (x *NotifyTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotifyTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*NotifyTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *NotifyTransactionsRequest) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type TransactionNotification struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Address is the subscribed address the transaction was matched for.
+	Address       string       `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Transaction   *Transaction `protobuf:"bytes,2,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactionNotification) Reset() {
+	*x = TransactionNotification{}
+	mi := &file_txparser_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactionNotification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactionNotification) ProtoMessage() {}
+
+func (x *TransactionNotification) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactionNotification.ProtoReflect.Descriptor instead.
+func (*TransactionNotification) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TransactionNotification) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *TransactionNotification) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+// Transaction mirrors the HTTP API's transaction, with amounts as strings.
+type Transaction struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Hash                    string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	From                    string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                      string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Value                   string                 `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Block                   int64                  `protobuf:"varint,5,opt,name=block,proto3" json:"block,omitempty"`
+	Timestamp               int64                  `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ValueWei                string                 `protobuf:"bytes,7,opt,name=value_wei,json=valueWei,proto3" json:"value_wei,omitempty"`
+	ValueEther              string                 `protobuf:"bytes,8,opt,name=value_ether,json=valueEther,proto3" json:"value_ether,omitempty"`
+	GasPriceWei             string                 `protobuf:"bytes,9,opt,name=gas_price_wei,json=gasPriceWei,proto3" json:"gas_price_wei,omitempty"`
+	Status                  string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	GasUsed                 string                 `protobuf:"bytes,11,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	EffectiveGasPriceWei    string                 `protobuf:"bytes,12,opt,name=effective_gas_price_wei,json=effectiveGasPriceWei,proto3" json:"effective_gas_price_wei,omitempty"`
+	FeeWei                  string                 `protobuf:"bytes,13,opt,name=fee_wei,json=feeWei,proto3" json:"fee_wei,omitempty"`
+	Tags                    []string               `protobuf:"bytes,14,rep,name=tags,proto3" json:"tags,omitempty"`
+	MatchType               string                 `protobuf:"bytes,15,opt,name=match_type,json=matchType,proto3" json:"match_type,omitempty"`
+	Token                   string                 `protobuf:"bytes,16,opt,name=token,proto3" json:"token,omitempty"`
+	TokenAmount             string                 `protobuf:"bytes,17,opt,name=token_amount,json=tokenAmount,proto3" json:"token_amount,omitempty"`
+	TokenValue              string                 `protobuf:"bytes,18,opt,name=token_value,json=tokenValue,proto3" json:"token_value,omitempty"`
+	RiskScore               int32                  `protobuf:"varint,19,opt,name=risk_score,json=riskScore,proto3" json:"risk_score,omitempty"`
+	BurnedFeeWei            string                 `protobuf:"bytes,20,opt,name=burned_fee_wei,json=burnedFeeWei,proto3" json:"burned_fee_wei,omitempty"`
+	TipFeeWei               string                 `protobuf:"bytes,21,opt,name=tip_fee_wei,json=tipFeeWei,proto3" json:"tip_fee_wei,omitempty"`
+	MaxFeePerGasWei         string                 `protobuf:"bytes,22,opt,name=max_fee_per_gas_wei,json=maxFeePerGasWei,proto3" json:"max_fee_per_gas_wei,omitempty"`
+	MaxPriorityFeePerGasWei string                 `protobuf:"bytes,23,opt,name=max_priority_fee_per_gas_wei,json=maxPriorityFeePerGasWei,proto3" json:"max_priority_fee_per_gas_wei,omitempty"`
+	Nonce                   string                 `protobuf:"bytes,24,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	MethodSelector          string                 `protobuf:"bytes,25,opt,name=method_selector,json=methodSelector,proto3" json:"method_selector,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_txparser_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_txparser_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_txparser_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Transaction) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Transaction) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Transaction) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Transaction) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Transaction) GetBlock() int64 {
+	if x != nil {
+		return x.Block
+	}
+	return 0
+}
+
+func (x *Transaction) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Transaction) GetValueWei() string {
+	if x != nil {
+		return x.ValueWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetValueEther() string {
+	if x != nil {
+		return x.ValueEther
+	}
+	return ""
+}
+
+func (x *Transaction) GetGasPriceWei() string {
+	if x != nil {
+		return x.GasPriceWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Transaction) GetGasUsed() string {
+	if x != nil {
+		return x.GasUsed
+	}
+	return ""
+}
+
+func (x *Transaction) GetEffectiveGasPriceWei() string {
+	if x != nil {
+		return x.EffectiveGasPriceWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetFeeWei() string {
+	if x != nil {
+		return x.FeeWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Transaction) GetMatchType() string {
+	if x != nil {
+		return x.MatchType
+	}
+	return ""
+}
+
+func (x *Transaction) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Transaction) GetTokenAmount() string {
+	if x != nil {
+		return x.TokenAmount
+	}
+	return ""
+}
+
+func (x *Transaction) GetTokenValue() string {
+	if x != nil {
+		return x.TokenValue
+	}
+	return ""
+}
+
+func (x *Transaction) GetRiskScore() int32 {
+	if x != nil {
+		return x.RiskScore
+	}
+	return 0
+}
+
+func (x *Transaction) GetBurnedFeeWei() string {
+	if x != nil {
+		return x.BurnedFeeWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetTipFeeWei() string {
+	if x != nil {
+		return x.TipFeeWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetMaxFeePerGasWei() string {
+	if x != nil {
+		return x.MaxFeePerGasWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetMaxPriorityFeePerGasWei() string {
+	if x != nil {
+		return x.MaxPriorityFeePerGasWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *Transaction) GetMethodSelector() string {
+	if x != nil {
+		return x.MethodSelector
+	}
+	return ""
+}
+
+var File_txparser_proto protoreflect.FileDescriptor
+
+const file_txparser_proto_rawDesc = "" +
+	"\n" +
+	"\x0etxparser.proto\x12\vtxparser.v1\"\x18\n" +
+	"\x16GetCurrentBlockRequest\"N\n" +
+	"\x17GetCurrentBlockResponse\x12\x14\n" +
+	"\x05block\x18\x01 \x01(\x03R\x05block\x12\x1d\n" +
+	"\n" +
+	"safe_block\x18\x02 \x01(\x03R\tsafeBlock\",\n" +
+	"\x10SubscribeRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"-\n" +
+	"\x11SubscribeResponse\x12\x18\n" +
+	"\acreated\x18\x01 \x01(\bR\acreated\"\x9a\x01\n" +
+	"\x16GetTransactionsRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x1d\n" +
+	"\n" +
+	"from_block\x18\x02 \x01(\x03R\tfromBlock\x12\x19\n" +
+	"\bto_block\x18\x03 \x01(\x03R\atoBlock\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\"m\n" +
+	"\x17GetTransactionsResponse\x12<\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x18.txparser.v1.TransactionR\ftransactions\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"9\n" +
+	"\x19NotifyTransactionsRequest\x12\x1c\n" +
+	"\taddresses\x18\x01 \x03(\tR\taddresses\"o\n" +
+	"\x17TransactionNotification\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12:\n" +
+	"\vtransaction\x18\x02 \x01(\v2\x18.txparser.v1.TransactionR\vtransaction\"\x92\x06\n" +
+	"\vTransaction\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\tR\x05value\x12\x14\n" +
+	"\x05block\x18\x05 \x01(\x03R\x05block\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\x03R\ttimestamp\x12\x1b\n" +
+	"\tvalue_wei\x18\a \x01(\tR\bvalueWei\x12\x1f\n" +
+	"\vvalue_ether\x18\b \x01(\tR\n" +
+	"valueEther\x12\"\n" +
+	"\rgas_price_wei\x18\t \x01(\tR\vgasPriceWei\x12\x16\n" +
+	"\x06status\x18\n" +
+	" \x01(\tR\x06status\x12\x19\n" +
+	"\bgas_used\x18\v \x01(\tR\agasUsed\x125\n" +
+	"\x17effective_gas_price_wei\x18\f \x01(\tR\x14effectiveGasPriceWei\x12\x17\n" +
+	"\afee_wei\x18\r \x01(\tR\x06feeWei\x12\x12\n" +
+	"\x04tags\x18\x0e \x03(\tR\x04tags\x12\x1d\n" +
+	"\n" +
+	"match_type\x18\x0f \x01(\tR\tmatchType\x12\x14\n" +
+	"\x05token\x18\x10 \x01(\tR\x05token\x12!\n" +
+	"\ftoken_amount\x18\x11 \x01(\tR\vtokenAmount\x12\x1f\n" +
+	"\vtoken_value\x18\x12 \x01(\tR\n" +
+	"tokenValue\x12\x1d\n" +
+	"\n" +
+	"risk_score\x18\x13 \x01(\x05R\triskScore\x12$\n" +
+	"\x0eburned_fee_wei\x18\x14 \x01(\tR\fburnedFeeWei\x12\x1e\n" +
+	"\vtip_fee_wei\x18\x15 \x01(\tR\ttipFeeWei\x12,\n" +
+	"\x13max_fee_per_gas_wei\x18\x16 \x01(\tR\x0fmaxFeePerGasWei\x12=\n" +
+	"\x1cmax_priority_fee_per_gas_wei\x18\x17 \x01(\tR\x17maxPriorityFeePerGasWei\x12\x14\n" +
+	"\x05nonce\x18\x18 \x01(\tR\x05nonce\x12'\n" +
+	"\x0fmethod_selector\x18\x19 \x01(\tR\x0emethodSelector2\xf8\x02\n" +
+	"\bTxParser\x12\\\n" +
+	"\x0fGetCurrentBlock\x12#.txparser.v1.GetCurrentBlockRequest\x1a$.txparser.v1.GetCurrentBlockResponse\x12J\n" +
+	"\tSubscribe\x12\x1d.txparser.v1.SubscribeRequest\x1a\x1e.txparser.v1.SubscribeResponse\x12\\\n" +
+	"\x0fGetTransactions\x12#.txparser.v1.GetTransactionsRequest\x1a$.txparser.v1.GetTransactionsResponse\x12d\n" +
+	"\x12NotifyTransactions\x12&.txparser.v1.NotifyTransactionsRequest\x1a$.txparser.v1.TransactionNotification0\x01BEZCgithub.com/bhaweshksingh/tx-parser-svc/internal/txparser/txparserpbb\x06proto3"
+
+var (
+	file_txparser_proto_rawDescOnce sync.Once
+	file_txparser_proto_rawDescData []byte
+)
+
+func file_txparser_proto_rawDescGZIP() []byte {
+	file_txparser_proto_rawDescOnce.Do(func() {
+		file_txparser_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_txparser_proto_rawDesc), len(file_txparser_proto_rawDesc)))
+	})
+	return file_txparser_proto_rawDescData
+}
+
+var file_txparser_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_txparser_proto_goTypes = []any{
+	(*GetCurrentBlockRequest)(nil),    // 0: txparser.v1.GetCurrentBlockRequest
+	(*GetCurrentBlockResponse)(nil),   // 1: txparser.v1.GetCurrentBlockResponse
+	(*SubscribeRequest)(nil),          // 2: txparser.v1.SubscribeRequest
+	(*SubscribeResponse)(nil),         // 3: txparser.v1.SubscribeResponse
+	(*GetTransactionsRequest)(nil),    // 4: txparser.v1.GetTransactionsRequest
+	(*GetTransactionsResponse)(nil),   // 5: txparser.v1.GetTransactionsResponse
+	(*NotifyTransactionsRequest)(nil), // 6: txparser.v1.NotifyTransactionsRequest
+	(*TransactionNotification)(nil),   // 7: txparser.v1.TransactionNotification
+	(*Transaction)(nil),               // 8: txparser.v1.Transaction
+}
+var file_txparser_proto_depIdxs = []int32{
+	8, // 0: txparser.v1.GetTransactionsResponse.transactions:type_name -> txparser.v1.Transaction
+	8, // 1: txparser.v1.TransactionNotification.transaction:type_name -> txparser.v1.Transaction
+	0, // 2: txparser.v1.TxParser.GetCurrentBlock:input_type -> txparser.v1.GetCurrentBlockRequest
+	2, // 3: txparser.v1.TxParser.Subscribe:input_type -> txparser.v1.SubscribeRequest
+	4, // 4: txparser.v1.TxParser.GetTransactions:input_type -> txparser.v1.GetTransactionsRequest
+	6, // 5: txparser.v1.TxParser.NotifyTransactions:input_type -> txparser.v1.NotifyTransactionsRequest
+	1, // 6: txparser.v1.TxParser.GetCurrentBlock:output_type -> txparser.v1.GetCurrentBlockResponse
+	3, // 7: txparser.v1.TxParser.Subscribe:output_type -> txparser.v1.SubscribeResponse
+	5, // 8: txparser.v1.TxParser.GetTransactions:output_type -> txparser.v1.GetTransactionsResponse
+	7, // 9: txparser.v1.TxParser.NotifyTransactions:output_type -> txparser.v1.TransactionNotification
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_txparser_proto_init() }
+func file_txparser_proto_init() {
+	if File_txparser_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_txparser_proto_rawDesc), len(file_txparser_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_txparser_proto_goTypes,
+		DependencyIndexes: file_txparser_proto_depIdxs,
+		MessageInfos:      file_txparser_proto_msgTypes,
+	}.Build()
+	File_txparser_proto = out.File
+	file_txparser_proto_goTypes = nil
+	file_txparser_proto_depIdxs = nil
+}
@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: txparser.proto
+
+package txparserpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TxParser_GetCurrentBlock_FullMethodName    = "/txparser.v1.TxParser/GetCurrentBlock"
+	TxParser_Subscribe_FullMethodName          = "/txparser.v1.TxParser/Subscribe"
+	TxParser_GetTransactions_FullMethodName    = "/txparser.v1.TxParser/GetTransactions"
+	TxParser_NotifyTransactions_FullMethodName = "/txparser.v1.TxParser/NotifyTransactions"
+)
+
+// TxParserClient is the client API for TxParser service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TxParser exposes the parser to internal consumers over gRPC, alongside the HTTP API.
+type TxParserClient interface {
+	// GetCurrentBlock returns the last parsed block and the safe block.
+	GetCurrentBlock(ctx context.Context, in *GetCurrentBlockRequest, opts ...grpc.CallOption) (*GetCurrentBlockResponse, error)
+	// Subscribe adds an address to the watch list.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (*SubscribeResponse, error)
+	// GetTransactions returns a page of an address's stored transactions, oldest first.
+	GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error)
+	// NotifyTransactions streams transactions of the given addresses as they are parsed,
+	// starting with those detected after the call.
+	NotifyTransactions(ctx context.Context, in *NotifyTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransactionNotification], error)
+}
+
+type txParserClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTxParserClient(cc grpc.ClientConnInterface) TxParserClient {
+	return &txParserClient{cc}
+}
+
+func (c *txParserClient) GetCurrentBlock(ctx context.Context, in *GetCurrentBlockRequest, opts ...grpc.CallOption) (*GetCurrentBlockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCurrentBlockResponse)
+	err := c.cc.Invoke(ctx, TxParser_GetCurrentBlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *txParserClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (*SubscribeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubscribeResponse)
+	err := c.cc.Invoke(ctx, TxParser_Subscribe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *txParserClient) GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTransactionsResponse)
+	err := c.cc.Invoke(ctx, TxParser_GetTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *txParserClient) NotifyTransactions(ctx context.Context, in *NotifyTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransactionNotification], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TxParser_ServiceDesc.Streams[0], TxParser_NotifyTransactions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[NotifyTransactionsRequest, TransactionNotification]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TxParser_NotifyTransactionsClient = grpc.ServerStreamingClient[TransactionNotification]
+
+// TxParserServer is the server API for TxParser service.
+// All implementations must embed UnimplementedTxParserServer
+// for forward compatibility.
+//
+// TxParser exposes the parser to internal consumers over gRPC, alongside the HTTP API.
+type TxParserServer interface {
+	// GetCurrentBlock returns the last parsed block and the safe block.
+	GetCurrentBlock(context.Context, *GetCurrentBlockRequest) (*GetCurrentBlockResponse, error)
+	// Subscribe adds an address to the watch list.
+	Subscribe(context.Context, *SubscribeRequest) (*SubscribeResponse, error)
+	// GetTransactions returns a page of an address's stored transactions, oldest first.
+	GetTransactions(context.Context, *GetTransactionsRequest) (*GetTransactionsResponse, error)
+	// NotifyTransactions streams transactions of the given addresses as they are parsed,
+	// starting with those detected after the call.
+	NotifyTransactions(*NotifyTransactionsRequest, grpc.ServerStreamingServer[TransactionNotification]) error
+	mustEmbedUnimplementedTxParserServer()
+}
+
+// UnimplementedTxParserServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTxParserServer struct{}
+
+func (UnimplementedTxParserServer) GetCurrentBlock(context.Context, *GetCurrentBlockRequest) (*GetCurrentBlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentBlock not implemented")
+}
+func (UnimplementedTxParserServer) Subscribe(context.Context, *SubscribeRequest) (*SubscribeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedTxParserServer) GetTransactions(context.Context, *GetTransactionsRequest) (*GetTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactions not implemented")
+}
+func (UnimplementedTxParserServer) NotifyTransactions(*NotifyTransactionsRequest, grpc.ServerStreamingServer[TransactionNotification]) error {
+	return status.Errorf(codes.Unimplemented, "method NotifyTransactions not implemented")
+}
+func (UnimplementedTxParserServer) mustEmbedUnimplementedTxParserServer() {}
+func (UnimplementedTxParserServer) testEmbeddedByValue()                  {}
+
+// UnsafeTxParserServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TxParserServer will
+// result in compilation errors.
+type UnsafeTxParserServer interface {
+	mustEmbedUnimplementedTxParserServer()
+}
+
+func RegisterTxParserServer(s grpc.ServiceRegistrar, srv TxParserServer) {
+	// If the following call pancis, it indicates UnimplementedTxParserServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TxParser_ServiceDesc, srv)
+}
+
+func _TxParser_GetCurrentBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxParserServer).GetCurrentBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TxParser_GetCurrentBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxParserServer).GetCurrentBlock(ctx, req.(*GetCurrentBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TxParser_Subscribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxParserServer).Subscribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TxParser_Subscribe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxParserServer).Subscribe(ctx, req.(*SubscribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TxParser_GetTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxParserServer).GetTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TxParser_GetTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxParserServer).GetTransactions(ctx, req.(*GetTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TxParser_NotifyTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(NotifyTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TxParserServer).NotifyTransactions(m, &grpc.GenericServerStream[NotifyTransactionsRequest, TransactionNotification]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TxParser_NotifyTransactionsServer = grpc.ServerStreamingServer[TransactionNotification]
+
+// TxParser_ServiceDesc is the grpc.ServiceDesc for TxParser service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TxParser_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "txparser.v1.TxParser",
+	HandlerType: (*TxParserServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCurrentBlock",
+			Handler:    _TxParser_GetCurrentBlock_Handler,
+		},
+		{
+			MethodName: "Subscribe",
+			Handler:    _TxParser_Subscribe_Handler,
+		},
+		{
+			MethodName: "GetTransactions",
+			Handler:    _TxParser_GetTransactions_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "NotifyTransactions",
+			Handler:       _TxParser_NotifyTransactions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "txparser.proto",
+}
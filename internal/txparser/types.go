@@ -1,5 +1,10 @@
 package txparser
 
+import (
+	"fmt"
+	"math/big"
+)
+
 // Transaction is the internal representation of an Ethereum transaction
 type Transaction struct {
 	Hash  string `json:"hash"`
@@ -7,4 +12,124 @@ type Transaction struct {
 	To    string `json:"to"`
 	Value string `json:"value"`
 	Block int64  `json:"block"`
+	// Timestamp is the block time in Unix seconds, 0 if the source did not provide it.
+	Timestamp int64 `json:"timestamp,omitempty"`
+
+	// ValueWei and GasPriceWei restate the hex quantities as exact base-10 wei, converted
+	// with math/big so values of any size are never rounded. ValueEther is the value in
+	// ether as a fixed-point decimal, e.g. "1.5". Empty if the source was not a valid
+	// hex quantity.
+	ValueWei    string `json:"valueWei,omitempty"`
+	ValueEther  string `json:"valueEther,omitempty"`
+	GasPriceWei string `json:"gasPriceWei,omitempty"`
+	// MaxFeePerGasWei and MaxPriorityFeePerGasWei are the fee caps of EIP-1559
+	// transactions in decimal wei, empty for legacy ones. Nonce is the sender's
+	// transaction count, also in decimal.
+	MaxFeePerGasWei         string `json:"maxFeePerGasWei,omitempty"`
+	MaxPriorityFeePerGasWei string `json:"maxPriorityFeePerGasWei,omitempty"`
+	Nonce                   string `json:"nonce,omitempty"`
+	// MethodSelector is the 4-byte selector heading the input of a contract call, e.g.
+	// "0xa9059cbb" for an ERC-20 transfer, and empty for plain transfers without input.
+	MethodSelector string `json:"methodSelector,omitempty"`
+
+	// Status, GasUsed, EffectiveGasPriceWei and FeeWei come from the transaction receipt
+	// when receipt enrichment is enabled, with amounts in decimal wei. Status is
+	// ReceiptFailed for reverted transactions, whose Value was never transferred.
+	Status               string `json:"status,omitempty"`
+	GasUsed              string `json:"gasUsed,omitempty"`
+	EffectiveGasPriceWei string `json:"effectiveGasPriceWei,omitempty"`
+	FeeWei               string `json:"feeWei,omitempty"`
+	// BurnedFeeWei is the part of FeeWei burned at the block's base fee and TipFeeWei
+	// the priority fee paid to the block producer. Empty for blocks without a base fee.
+	BurnedFeeWei string `json:"burnedFeeWei,omitempty"`
+	TipFeeWei    string `json:"tipFeeWei,omitempty"`
+
+	// Tags are attached by matching rules, relative to the address the tx is stored under.
+	Tags []string `json:"tags,omitempty"`
+	// MatchType is set when the tx matched other than by from/to, e.g. MatchTypeInput.
+	MatchType string `json:"matchType,omitempty"`
+	// Token is the ERC-20 contract of a MatchTypeToken transfer. TokenAmount is the amount
+	// in base units and TokenValue the same amount adjusted by the token's decimals, empty
+	// if the contract does not report them. LogIndex is the position of the Transfer
+	// event in its block, telling apart several transfers of one transaction.
+	Token       string `json:"token,omitempty"`
+	TokenAmount string `json:"tokenAmount,omitempty"`
+	TokenValue  string `json:"tokenValue,omitempty"`
+	LogIndex    int64  `json:"logIndex,omitempty"`
+	// RiskScore is the RiskScorer's rating of the counterparty, 0 to MaxRiskScore.
+	RiskScore int `json:"riskScore,omitempty"`
+}
+
+func (t Transaction) blockNumber() int64 { return t.Block }
+func (t Transaction) riskScore() int     { return t.RiskScore }
+
+// WeiValue returns Value as an exact amount of wei, reporting false if it is not a valid quantity.
+func (t Transaction) WeiValue() (*big.Int, bool) {
+	return parseWei(t.Value)
+}
+
+// AddressTransaction attributes a Transaction to the watched address it was returned for.
+type AddressTransaction struct {
+	Address string `json:"address"`
+	Transaction
+}
+
+// The types below are the bodies and parameters of API requests and responses. Their
+// json, query and doc tags also generate the OpenAPI document served at /openapi.json.
+
+// CurrentBlockResponse is the response of GET /current-block.
+type CurrentBlockResponse struct {
+	CurrentBlock int `json:"currentBlock" doc:"last fully parsed block"`
+	SafeBlock    int `json:"safeBlock" doc:"highest block the configured confirmations allow indexing, which currentBlock catches up to"`
+}
+
+// SubscribeRequest is the body of POST /subscribe.
+type SubscribeRequest struct {
+	Address       string             `json:"address" doc:"address to watch"`
+	Notifications *NotificationPrefs `json:"notifications,omitempty" doc:"webhook delivery preferences"`
+	WebhookURL    string             `json:"webhookUrl,omitempty" doc:"shorthand for notifications.webhookUrl"`
+	FromBlock     *int64             `json:"fromBlock,omitempty" doc:"backfill history from this block with a background job"`
+}
+
+// SubscribeResponse is the response of POST /subscribe.
+type SubscribeResponse struct {
+	Subscribed bool `json:"subscribed" doc:"false if the address was already subscribed"`
+	Job        *Job `json:"job,omitempty" doc:"backfill job, when fromBlock is before the current block"`
+}
+
+// TransactionsParams are the query parameters of GET /transactions.
+type TransactionsParams struct {
+	Address   string     `query:"address" doc:"address whose transactions to list; required unless addresses is set"`
+	Addresses string     `query:"addresses" doc:"comma-separated addresses, returning an array of their transactions with the address of each instead of a page"`
+	FromBlock int64      `query:"fromBlock" doc:"lowest block, inclusive"`
+	ToBlock   int64      `query:"toBlock" doc:"highest block, inclusive"`
+	Direction Direction  `query:"direction" doc:"in or out, relative to address"`
+	Q         string     `query:"q" doc:"filter expression, e.g. value>1eth"`
+	MinRisk   int        `query:"minRisk" doc:"lowest counterparty risk score"`
+	Limit     int        `query:"limit" doc:"page size"`
+	Offset    int        `query:"offset" doc:"matching transactions to skip"`
+	ServeOnly Visibility `query:"serveOnly" doc:"all, confirmed or finalized; defaults to the server setting"`
+	Chain     string     `query:"chain" doc:"chain to query, when several are indexed"`
+}
+
+// Visibility controls which transactions are served based on how final their block is.
+type Visibility string
+
+const (
+	// VisibilityAll serves every stored transaction, including ones at the chain tip.
+	VisibilityAll Visibility = "all"
+	// VisibilityConfirmed serves transactions whose block has the configured number of confirmations.
+	VisibilityConfirmed Visibility = "confirmed"
+	// VisibilityFinalized serves transactions at or below the chain's finalized block.
+	VisibilityFinalized Visibility = "finalized"
+)
+
+// ParseVisibility converts a config or query value into a Visibility.
+func ParseVisibility(s string) (Visibility, error) {
+	switch v := Visibility(s); v {
+	case VisibilityAll, VisibilityConfirmed, VisibilityFinalized:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid visibility %q: expected confirmed, finalized or all", s)
+	}
 }
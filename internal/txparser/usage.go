@@ -0,0 +1,180 @@
+package txparser
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// APIKeyHeader carries the caller's API key.
+const APIKeyHeader = "X-API-Key"
+
+// anonymousKey accounts requests without an accepted API key.
+const anonymousKey = "anonymous"
+
+// UsageLimits configures per-key rate limiting and quotas. Zero values disable a limit.
+type UsageLimits struct {
+	RatePerSecond float64 // sustained requests per second
+	Burst         int     // requests allowed above the sustained rate
+	MonthlyQuota  int64   // requests per calendar month (UTC)
+}
+
+// KeyUsage is the usage accounted to one API key in the current month.
+type KeyUsage struct {
+	Key          string `json:"key"`
+	Month        string `json:"month"` // YYYY-MM, UTC
+	Requests     int64  `json:"requests"`
+	Bytes        int64  `json:"bytes"` // response bytes written
+	MonthlyQuota int64  `json:"monthlyQuota,omitempty"`
+}
+
+// keyState is the mutable accounting for one key.
+type keyState struct {
+	usage      KeyUsage
+	tokens     float64
+	lastRefill time.Time
+}
+
+// UsageTracker enforces per-key rate limits and quotas and accounts requests and bytes.
+type UsageTracker struct {
+	limits UsageLimits
+
+	auth *Authenticator // verifies keys before they are accounted
+
+	mu    sync.Mutex
+	keys  map[string]*keyState
+	clock Clock
+}
+
+// NewUsageTracker creates a tracker applying the same limits to every key.
+func NewUsageTracker(limits UsageLimits) *UsageTracker {
+	return &UsageTracker{
+		limits: limits,
+		keys:   make(map[string]*keyState),
+		clock:  SystemClock,
+	}
+}
+
+// SetClock replaces the time source used for token refills and monthly quotas.
+func (u *UsageTracker) SetClock(c Clock) {
+	u.clock = c
+}
+
+// SetAuthenticator accounts requests to their API key when a accepts it. Other
+// requests, and all of them without an Authenticator, are accounted to the anonymous
+// key, so made-up keys can neither grow the accounting nor get limits of their own.
+func (u *UsageTracker) SetAuthenticator(a *Authenticator) {
+	u.auth = a
+}
+
+// Usage returns the current month's usage for a key.
+func (u *UsageTracker) Usage(key string) KeyUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.stateLocked(key, u.clock.Now()).usage
+}
+
+// Middleware rejects requests over the caller's rate limit or quota with 429
+// and accounts every admitted request and its response size.
+func (u *UsageTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := u.accountKey(r)
+		if retryAfter, reason := u.admit(key, u.clock.Now()); reason != "" {
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			}
+			apierror.Write(w, http.StatusTooManyRequests, apierror.CodeRateLimited, reason)
+			return
+		}
+
+		cw := &countingResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+		u.addBytes(key, cw.bytes)
+	})
+}
+
+// admit consumes a token and a quota unit for key, or explains why the request is rejected.
+func (u *UsageTracker) admit(key string, now time.Time) (time.Duration, string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	state := u.stateLocked(key, now)
+
+	if quota := u.limits.MonthlyQuota; quota > 0 && state.usage.Requests >= quota {
+		return untilNextMonth(now), "monthly quota exceeded"
+	}
+	if rate := u.limits.RatePerSecond; rate > 0 {
+		burst := float64(max(u.limits.Burst, 1))
+		elapsed := now.Sub(state.lastRefill).Seconds()
+		state.tokens = min(burst, state.tokens+elapsed*rate)
+		state.lastRefill = now
+		if state.tokens < 1 {
+			wait := time.Duration((1 - state.tokens) / rate * float64(time.Second))
+			return wait, "rate limit exceeded"
+		}
+		state.tokens--
+	}
+	state.usage.Requests++
+	return 0, ""
+}
+
+// addBytes accounts response bytes written for key.
+func (u *UsageTracker) addBytes(key string, n int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.stateLocked(key, u.clock.Now()).usage.Bytes += n
+}
+
+// stateLocked returns the key's state, resetting counters when a new month starts. Callers hold u.mu.
+func (u *UsageTracker) stateLocked(key string, now time.Time) *keyState {
+	month := now.UTC().Format("2006-01")
+	state, ok := u.keys[key]
+	if !ok {
+		state = &keyState{
+			tokens:     float64(max(u.limits.Burst, 1)),
+			lastRefill: now,
+		}
+		u.keys[key] = state
+	}
+	if state.usage.Month != month {
+		state.usage = KeyUsage{Key: key, Month: month, MonthlyQuota: u.limits.MonthlyQuota}
+	}
+	return state
+}
+
+// accountKey returns the key r is accounted to: its API key if the Authenticator
+// accepts it, and the anonymous key otherwise.
+func (u *UsageTracker) accountKey(r *http.Request) string {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" || u.auth == nil || !u.auth.known(key) {
+		return anonymousKey
+	}
+	return key
+}
+
+// untilNextMonth returns the time left until the next calendar month in UTC.
+func untilNextMonth(now time.Time) time.Duration {
+	utc := now.UTC()
+	next := time.Date(utc.Year(), utc.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	return next.Sub(utc)
+}
+
+// countingResponseWriter counts the bytes written through it.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	bytes int64
+}
+
+func (c *countingResponseWriter) Write(b []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(b)
+	c.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *countingResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,247 @@
+package txparser
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// histogramDigits is the number of significant decimal digits a value bucket keeps,
+// bounding the relative error of reported percentiles to about 10%.
+const histogramDigits = 2
+
+// ValueStats summarizes the transfer values seen for an address, in decimal wei.
+// Percentiles are approximate: they report the lower bound of the matching histogram bucket.
+type ValueStats struct {
+	Address string `json:"address"`
+	Count   int64  `json:"count"`
+	Total   string `json:"total"`
+	Min     string `json:"min"`
+	Max     string `json:"max"`
+	P50     string `json:"p50"`
+	P90     string `json:"p90"`
+	P95     string `json:"p95"`
+	P99     string `json:"p99"`
+
+	// Fees totals the fees of transactions the address sent, nil if none had receipt data.
+	Fees *FeeStats `json:"fees,omitempty"`
+}
+
+// FeeStats totals transaction fees in decimal wei. BurnedWei and TipWei cover the
+// transactions whose block had a base fee, counted by SplitCount.
+type FeeStats struct {
+	Count      int64  `json:"count"`
+	TotalWei   string `json:"totalWei"`
+	SplitCount int64  `json:"splitCount"`
+	BurnedWei  string `json:"burnedWei"`
+	TipWei     string `json:"tipWei"`
+}
+
+// feeTotals accumulates FeeStats.
+type feeTotals struct {
+	count, splitCount     int64
+	total, burned, tipped *big.Int
+}
+
+// valueBucket identifies values sharing a digit count and leading significant digits.
+type valueBucket struct {
+	digits  int // number of decimal digits in the value, 0 for zero
+	leading int // first histogramDigits digits
+}
+
+// lowerBound returns the smallest value falling in the bucket.
+func (b valueBucket) lowerBound() *big.Int {
+	if b.digits == 0 {
+		return new(big.Int)
+	}
+	v := big.NewInt(int64(b.leading))
+	if shift := b.digits - histogramDigits; shift > 0 {
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(shift)), nil))
+	}
+	return v
+}
+
+// bucketFor returns the histogram bucket for a non-negative value.
+func bucketFor(v *big.Int) valueBucket {
+	if v.Sign() == 0 {
+		return valueBucket{}
+	}
+	digits := v.String()
+	leadingDigits := digits[:min(len(digits), histogramDigits)]
+	leading := 0
+	for _, d := range leadingDigits {
+		leading = leading*10 + int(d-'0')
+	}
+	return valueBucket{digits: len(digits), leading: leading}
+}
+
+// valueHistogram is a streaming, log-linear histogram of transfer values.
+// Memory grows with the number of distinct buckets, not with the number of values.
+type valueHistogram struct {
+	count    int64
+	total    *big.Int
+	min, max *big.Int
+	buckets  map[valueBucket]int64
+}
+
+// observe adds a value to the histogram.
+func (h *valueHistogram) observe(v *big.Int) {
+	if h.count == 0 {
+		h.min, h.max = new(big.Int).Set(v), new(big.Int).Set(v)
+	} else {
+		if v.Cmp(h.min) < 0 {
+			h.min.Set(v)
+		}
+		if v.Cmp(h.max) > 0 {
+			h.max.Set(v)
+		}
+	}
+	h.count++
+	h.total.Add(h.total, v)
+	h.buckets[bucketFor(v)]++
+}
+
+// quantiles returns the approximate value at each quantile, given in per mille
+// (500 is the median), in order. Integer ranks keep floats out of the value path.
+func (h *valueHistogram) quantiles(qs ...int64) []*big.Int {
+	buckets := make([]valueBucket, 0, len(h.buckets))
+	for b := range h.buckets {
+		buckets = append(buckets, b)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].digits != buckets[j].digits {
+			return buckets[i].digits < buckets[j].digits
+		}
+		return buckets[i].leading < buckets[j].leading
+	})
+
+	results := make([]*big.Int, len(qs))
+	for i, q := range qs {
+		rank := q*(h.count-1)/1000 + 1 // 1-based rank of the quantile value
+		var seen int64
+		for _, b := range buckets {
+			seen += h.buckets[b]
+			if seen >= rank {
+				results[i] = b.lowerBound()
+				break
+			}
+		}
+	}
+	return results
+}
+
+// valueStats maintains a histogram and fee totals per address.
+type valueStats struct {
+	mu         sync.Mutex
+	histograms map[string]*valueHistogram
+	fees       map[string]*feeTotals
+}
+
+func newValueStats() *valueStats {
+	return &valueStats{histograms: make(map[string]*valueHistogram), fees: make(map[string]*feeTotals)}
+}
+
+// observeFee records the fee of a transaction sent by address. Transactions without
+// receipt data are ignored.
+func (s *valueStats) observeFee(address string, tx Transaction) {
+	fee, ok := new(big.Int).SetString(tx.FeeWei, 10)
+	if !ok {
+		return
+	}
+	burned, splitOK := new(big.Int).SetString(tx.BurnedFeeWei, 10)
+	tipped, tipOK := new(big.Int).SetString(tx.TipFeeWei, 10)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.fees[address]
+	if !ok {
+		t = &feeTotals{total: new(big.Int), burned: new(big.Int), tipped: new(big.Int)}
+		s.fees[address] = t
+	}
+	t.count++
+	t.total.Add(t.total, fee)
+	if splitOK && tipOK {
+		t.splitCount++
+		t.burned.Add(t.burned, burned)
+		t.tipped.Add(t.tipped, tipped)
+	}
+}
+
+// observe records the value of a transaction stored for address. Unparseable values are ignored.
+func (s *valueStats) observe(address, value string) {
+	v, ok := parseWei(value)
+	if !ok || v.Sign() < 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.histograms[address]
+	if !ok {
+		h = &valueHistogram{total: new(big.Int), buckets: make(map[valueBucket]int64)}
+		s.histograms[address] = h
+	}
+	h.observe(v)
+}
+
+// get summarizes the histogram of address.
+func (s *valueStats) get(address string) (ValueStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, hasValues := s.histograms[address]
+	fees, hasFees := s.fees[address]
+	stats := ValueStats{Address: address, Total: "0", Min: "0", Max: "0", P50: "0", P90: "0", P95: "0", P99: "0"}
+	if hasValues {
+		q := h.quantiles(500, 900, 950, 990)
+		stats = ValueStats{
+			Address: address,
+			Count:   h.count,
+			Total:   h.total.String(),
+			Min:     h.min.String(),
+			Max:     h.max.String(),
+			P50:     q[0].String(),
+			P90:     q[1].String(),
+			P95:     q[2].String(),
+			P99:     q[3].String(),
+		}
+	}
+	if hasFees {
+		stats.Fees = &FeeStats{
+			Count:      fees.count,
+			TotalWei:   fees.total.String(),
+			SplitCount: fees.splitCount,
+			BurnedWei:  fees.burned.String(),
+			TipWei:     fees.tipped.String(),
+		}
+	}
+	return stats, hasValues || hasFees
+}
+
+// observeStats feeds a stored transaction of address into value, fee and timeline statistics.
+func (p *EthParser) observeStats(address string, tx Transaction) {
+	if tx.MatchType != MatchTypeToken && tx.Status != ReceiptFailed {
+		p.stats.observe(address, tx.Value)
+	}
+	if tx.From == address {
+		p.stats.observeFee(address, tx)
+	}
+	p.timeline.observe(address, tx)
+}
+
+// rebuildStats replays the stored transactions of every subscription into the
+// statistics, which are kept in memory, so a restart over a persistent store does
+// not reset them. Stores that cannot list subscriptions start with empty statistics.
+func (p *EthParser) rebuildStats() {
+	lister, ok := p.store.(SubscriptionLister)
+	if !ok {
+		return
+	}
+	count := 0
+	for _, sub := range lister.Subscriptions() {
+		for _, tx := range p.store.GetTransactions(sub.Address) {
+			p.observeStats(sub.Address, tx)
+			count++
+		}
+	}
+	if count > 0 {
+		p.logger.Info("Rebuilt value statistics from stored transactions", "transactions", count)
+	}
+}
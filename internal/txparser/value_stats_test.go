@@ -0,0 +1,53 @@
+package txparser
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// TestValueStats verifies percentiles stay within the histogram's precision.
+func TestValueStats(t *testing.T) {
+	stats := newValueStats()
+	for v := 1; v <= 1000; v++ {
+		stats.observe("0xa", fmt.Sprintf("0x%x", v))
+	}
+
+	got, ok := stats.get("0xa")
+	if !ok {
+		t.Fatalf("expected stats for 0xa")
+	}
+	want := ValueStats{Address: "0xa", Count: 1000, Total: "500500", Min: "1", Max: "1000",
+		P50: "500", P90: "900", P95: "950", P99: "990"}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if _, ok := stats.get("0xb"); ok {
+		t.Errorf("expected no stats for unseen address")
+	}
+}
+
+// TestRebuildStats verifies value statistics are rebuilt from stored transactions,
+// so restarting over a persistent store does not reset them.
+func TestRebuildStats(t *testing.T) {
+	address := "0x000000000000000000000000000000000000000a"
+	store := NewMemoryStore()
+	store.Subscribe(address)
+	store.AddTransaction(address, Transaction{Hash: "0xt1", Block: 1, To: address, Value: "0x64"})
+	store.AddTransaction(address, Transaction{Hash: "0xt2", Block: 2, To: address, Value: "0xc8"})
+	store.AddTransaction(address, Transaction{Hash: "0xt3", Block: 3, To: address, Value: "0x1", MatchType: MatchTypeToken})
+
+	parser := NewEthParser(&mockClient{}, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, ok := parser.GetValueStats(address); ok {
+		t.Fatalf("expected no stats before rebuilding")
+	}
+	parser.rebuildStats()
+	got, ok := parser.GetValueStats(address)
+	if !ok {
+		t.Fatalf("expected stats after rebuilding")
+	}
+	if got.Count != 2 || got.Total != "300" || got.Min != "100" || got.Max != "200" {
+		t.Errorf("expected 2 native transfers totalling 300, got %+v", got)
+	}
+}
@@ -0,0 +1,283 @@
+package txparser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults for webhook delivery.
+const (
+	DefaultWebhookQueueSize   = 1000
+	DefaultWebhookMaxAttempts = 5
+	DefaultWebhookBackoff     = time.Second      // delay before the first retry, doubled per attempt
+	maxWebhookBackoff         = 5 * time.Minute  // cap on the delay between retries
+	webhookTimeout            = 10 * time.Second // per-attempt HTTP timeout
+)
+
+// WebhookPayload is the JSON body POSTed to a subscriber's webhook URL.
+type WebhookPayload struct {
+	Address     string      `json:"address"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// webhookDelivery is a queued, encoded payload for one URL.
+type webhookDelivery struct {
+	id      uint64 // DeliveryRecord in the history
+	url     string
+	address string
+	hash    string
+	body    []byte
+}
+
+// WebhookNotifier POSTs matched transactions to subscriber webhook URLs from a bounded
+// queue. Failed deliveries are retried with exponential backoff; server errors, 429s and
+// transport failures are retried, other client errors are not. Recent deliveries are
+// kept per address for inspection and manual redelivery.
+type WebhookNotifier struct {
+	client      *http.Client
+	logger      *slog.Logger
+	clock       Clock
+	metrics     Metrics
+	queue       chan webhookDelivery
+	maxAttempts int
+	backoff     time.Duration
+	// allowPrivate permits loopback, private and link-local targets; see SetAllowPrivateTargets.
+	allowPrivate bool
+
+	mu        sync.Mutex
+	delivered int64
+	failed    int64
+	dropped   int64
+	nextID    uint64
+	pending   int                          // queued or in-flight deliveries
+	idle      chan struct{}                // closed once pending reaches zero, nil unless Drain is waiting
+	history   map[string][]*DeliveryRecord // by address, oldest first
+	records   map[uint64]*DeliveryRecord   // by ID
+}
+
+// WebhookStats counts webhook delivery outcomes since startup.
+type WebhookStats struct {
+	Queued    int   `json:"queued"`
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`  // gave up after retries or a non-retryable response
+	Dropped   int64 `json:"dropped"` // rejected because the queue was full
+}
+
+// NewWebhookNotifier creates a notifier with the default queue size, attempts and backoff.
+// Call Start to begin delivering.
+func NewWebhookNotifier(logger *slog.Logger) *WebhookNotifier {
+	n := &WebhookNotifier{
+		logger:      logger,
+		clock:       SystemClock,
+		metrics:     NoopMetrics{},
+		queue:       make(chan webhookDelivery, DefaultWebhookQueueSize),
+		maxAttempts: DefaultWebhookMaxAttempts,
+		backoff:     DefaultWebhookBackoff,
+		history:     make(map[string][]*DeliveryRecord),
+		records:     make(map[uint64]*DeliveryRecord),
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // a proxy would dial targets on our behalf, bypassing dialControl
+	transport.DialContext = (&net.Dialer{Timeout: webhookTimeout, Control: n.dialControl}).DialContext
+	n.client = &http.Client{Timeout: webhookTimeout, Transport: transport}
+	return n
+}
+
+// SetAllowPrivateTargets permits webhooks to loopback, private and link-local hosts,
+// which are refused by default so subscribers cannot reach internal services. Call it
+// before Start.
+func (n *WebhookNotifier) SetAllowPrivateTargets(allow bool) {
+	n.allowPrivate = allow
+}
+
+// SetRetryPolicy sets the number of delivery attempts and the delay before the first retry.
+func (n *WebhookNotifier) SetRetryPolicy(maxAttempts int, backoff time.Duration) {
+	n.maxAttempts = max(maxAttempts, 1)
+	n.backoff = backoff
+}
+
+// SetClock replaces the time source used for backoff delays.
+func (n *WebhookNotifier) SetClock(c Clock) {
+	n.clock = c
+}
+
+// Enqueue schedules a delivery without blocking. It reports false, dropping the
+// payload, if the queue is full; the dropped delivery is recorded as failed.
+func (n *WebhookNotifier) Enqueue(url string, payload WebhookPayload) bool {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Error("Failed to encode webhook payload", "err", err)
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	rec := n.recordLocked(url, payload, body)
+	select {
+	case n.queue <- webhookDelivery{id: rec.ID, url: url, address: rec.Address, hash: rec.Hash, body: body}:
+		n.pending++
+		return true
+	default:
+		n.dropped++
+		rec.Status, rec.LastError = DeliveryFailed, ErrWebhookQueueFull.Error()
+		n.logger.Warn("Webhook queue full, dropping notification", "address", payload.Address, "hash", payload.Transaction.Hash)
+		return false
+	}
+}
+
+// Start runs workers goroutines delivering queued payloads until ctx is canceled.
+func (n *WebhookNotifier) Start(ctx context.Context, workers int) {
+	for i := 0; i < max(workers, 1); i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-n.queue:
+					n.deliver(ctx, d)
+					n.finishQueued()
+				}
+			}
+		}()
+	}
+}
+
+// finishQueued marks one queued delivery as finished, waking Drain once none are left.
+func (n *WebhookNotifier) finishQueued() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending--
+	if n.pending == 0 && n.idle != nil {
+		close(n.idle)
+		n.idle = nil
+	}
+}
+
+// Drain waits until every queued delivery has finished, including its retries, so
+// notifications are not lost on shutdown. Workers must still be running; cancel their
+// context once Drain returns. It gives up when ctx is done, reporting what is left.
+func (n *WebhookNotifier) Drain(ctx context.Context) error {
+	n.mu.Lock()
+	if n.pending == 0 {
+		n.mu.Unlock()
+		return nil
+	}
+	if n.idle == nil {
+		n.idle = make(chan struct{})
+	}
+	idle := n.idle
+	n.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return fmt.Errorf("%d webhook deliveries still pending: %w", n.pending, ctx.Err())
+	}
+}
+
+// Stats returns delivery counters and the current queue length.
+func (n *WebhookNotifier) Stats() WebhookStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return WebhookStats{Queued: len(n.queue), Delivered: n.delivered, Failed: n.failed, Dropped: n.dropped}
+}
+
+// deliver POSTs d, retrying with exponential backoff until it succeeds, fails
+// permanently, runs out of attempts, or ctx is canceled.
+func (n *WebhookNotifier) deliver(ctx context.Context, d webhookDelivery) {
+	target := webhookTarget(d.url)
+	delay := n.backoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		retry, err := n.post(ctx, d.url, d.body)
+		latency := time.Since(start)
+		n.metrics.WebhookAttempt(target, latency, err)
+		n.updateRecord(d.id, func(rec *DeliveryRecord) {
+			rec.Attempts = attempt
+			rec.LatencyMs = latency.Milliseconds()
+			rec.LastError = ""
+			if err != nil {
+				rec.LastError = err.Error()
+			}
+		})
+		if err == nil {
+			n.mu.Lock()
+			n.delivered++
+			n.mu.Unlock()
+			n.finish(d, target, attempt, DeliveryDelivered)
+			return
+		}
+		if !retry || attempt >= n.maxAttempts {
+			n.mu.Lock()
+			n.failed++
+			n.mu.Unlock()
+			n.finish(d, target, attempt, DeliveryFailed)
+			n.logger.Warn("Giving up on webhook delivery",
+				"address", d.address,
+				"hash", d.hash,
+				"attempts", attempt,
+				"err", err,
+			)
+			return
+		}
+		n.logger.Debug("Retrying webhook delivery", "attempt", attempt, "delay", delay.String(), "err", err)
+		select {
+		case <-ctx.Done():
+			n.finish(d, target, attempt, DeliveryFailed)
+			return
+		case <-n.clock.After(delay):
+		}
+		delay = min(delay*2, maxWebhookBackoff)
+	}
+}
+
+// finish records the final status of d after attempts tries.
+func (n *WebhookNotifier) finish(d webhookDelivery, target string, attempts int, status string) {
+	n.metrics.WebhookDelivery(target, attempts, status == DeliveryDelivered)
+	n.updateRecord(d.id, func(rec *DeliveryRecord) { rec.Status = status })
+}
+
+// post makes one delivery attempt, reporting whether a failure is worth retrying.
+func (n *WebhookNotifier) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("http.NewRequest error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("HTTP request error: %w", err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// notifyWebhook queues tx for the webhook of address, if one is registered and its
+// preferences want the transaction.
+func (p *EthParser) notifyWebhook(address string, tx Transaction, raw RawTx) {
+	if p.webhooks == nil || !p.features.Enabled(FeatureNotifications) {
+		return
+	}
+	prefs, ok := p.store.GetNotificationPrefs(address)
+	if !ok || prefs.WebhookURL == "" || !prefs.Wants(ChannelWebhook, address, tx, raw) {
+		return
+	}
+	if !p.webhooks.Enqueue(prefs.WebhookURL, WebhookPayload{Address: address, Transaction: tx}) {
+		p.errors.Record("webhook", int(tx.Block), fmt.Errorf("queue full, dropped notification for %s", tx.Hash))
+	}
+}
@@ -0,0 +1,128 @@
+package txparser
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// DefaultWebhookHistory is how many recent deliveries are kept per subscribed address.
+const DefaultWebhookHistory = 100
+
+// webhookSnippetBytes bounds the payload excerpt returned with each delivery record.
+const webhookSnippetBytes = 256
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"   // queued or between retries
+	DeliveryDelivered = "delivered" // acknowledged with a 2xx response
+	DeliveryFailed    = "failed"    // gave up, dropped from a full queue, or interrupted by shutdown
+)
+
+// Errors returned by WebhookNotifier.Redeliver.
+var (
+	ErrDeliveryNotFound  = errors.New("delivery not found")
+	ErrDeliveryNotFailed = errors.New("only failed deliveries can be redelivered")
+	ErrWebhookQueueFull  = errors.New("webhook queue full")
+)
+
+// DeliveryRecord is the history of one webhook notification.
+type DeliveryRecord struct {
+	ID          uint64    `json:"id"`
+	Address     string    `json:"address"`
+	URL         string    `json:"url"`
+	Hash        string    `json:"hash"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"` // since the last (re)delivery was queued
+	Redelivered int       `json:"redelivered"`
+	LastError   string    `json:"lastError,omitempty"`
+	LatencyMs   int64     `json:"latencyMs"` // of the last attempt
+	Payload     string    `json:"payload"`   // start of the JSON body, at most webhookSnippetBytes
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	body []byte // full JSON body, kept for redelivery
+}
+
+// webhookTarget names the receiver of url in metrics. Only the host is used, since
+// paths and query strings of webhook URLs often embed tokens.
+func webhookTarget(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
+
+// recordLocked adds a pending delivery to the history of its address, forgetting the
+// oldest beyond DefaultWebhookHistory. Callers hold n.mu.
+func (n *WebhookNotifier) recordLocked(url string, payload WebhookPayload, body []byte) *DeliveryRecord {
+	n.nextID++
+	now := n.clock.Now()
+	rec := &DeliveryRecord{
+		ID:        n.nextID,
+		Address:   payload.Address,
+		URL:       url,
+		Hash:      payload.Transaction.Hash,
+		Status:    DeliveryPending,
+		Payload:   string(body[:min(len(body), webhookSnippetBytes)]),
+		CreatedAt: now,
+		UpdatedAt: now,
+		body:      body,
+	}
+	history := append(n.history[rec.Address], rec)
+	if len(history) > DefaultWebhookHistory {
+		delete(n.records, history[0].ID)
+		history = history[1:]
+	}
+	n.history[rec.Address] = history
+	n.records[rec.ID] = rec
+	return rec
+}
+
+// updateRecord applies fn to the delivery record id, if still retained.
+func (n *WebhookNotifier) updateRecord(id uint64, fn func(*DeliveryRecord)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if rec, ok := n.records[id]; ok {
+		fn(rec)
+		rec.UpdatedAt = n.clock.Now()
+	}
+}
+
+// Deliveries returns the retained delivery history of address, newest first.
+func (n *WebhookNotifier) Deliveries(address string) []DeliveryRecord {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	history := n.history[address]
+	records := make([]DeliveryRecord, 0, len(history))
+	for _, rec := range slices.Backward(history) {
+		records = append(records, *rec)
+	}
+	return records
+}
+
+// Redeliver queues a failed delivery of address again, to the URL it was first sent to.
+func (n *WebhookNotifier) Redeliver(address string, id uint64) (DeliveryRecord, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	rec, ok := n.records[id]
+	if !ok || rec.Address != address {
+		return DeliveryRecord{}, ErrDeliveryNotFound
+	}
+	if rec.Status != DeliveryFailed {
+		return DeliveryRecord{}, ErrDeliveryNotFailed
+	}
+	select {
+	case n.queue <- webhookDelivery{id: rec.ID, url: rec.URL, address: rec.Address, hash: rec.Hash, body: rec.body}:
+	default:
+		return DeliveryRecord{}, ErrWebhookQueueFull
+	}
+	n.pending++
+	rec.Status = DeliveryPending
+	rec.Attempts = 0
+	rec.Redelivered++
+	rec.UpdatedAt = n.clock.Now()
+	return *rec, nil
+}
@@ -0,0 +1,62 @@
+package txparser
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrWebhookTarget is returned for webhook URLs whose host is loopback, private or
+// link-local while such targets are not allowed.
+var ErrWebhookTarget = errors.New("webhook target not allowed")
+
+// blockedWebhookIP reports whether ip is a loopback, private, link-local, multicast or
+// unspecified address, e.g. 127.0.0.1, 10.0.0.1 or the 169.254.169.254 metadata service.
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// checkWebhookTarget rejects a webhook URL naming a blocked host, unless allowPrivate
+// is set. Host names are checked again once resolved, when the notifier dials them.
+func checkWebhookTarget(rawURL string, allowPrivate bool) error {
+	if allowPrivate {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("webhookUrl %q: %w", rawURL, err)
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); host == "localhost" || strings.HasSuffix(host, ".localhost") || ip != nil && blockedWebhookIP(ip) {
+		return fmt.Errorf("%w: webhookUrl %q targets a loopback, private or link-local host", ErrWebhookTarget, rawURL)
+	}
+	return nil
+}
+
+// dialControl refuses connections to blocked addresses after name resolution, so a
+// host name cannot point the notifier at internal services.
+func (n *WebhookNotifier) dialControl(network, address string, _ syscall.RawConn) error {
+	if n.allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blockedWebhookIP(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookTarget, host)
+	}
+	return nil
+}
+
+// checkWebhookPrefs rejects preferences whose webhook URL the notifier would refuse.
+func (s *HTTPServer) checkWebhookPrefs(prefs NotificationPrefs) error {
+	if prefs.WebhookURL == "" {
+		return nil
+	}
+	return checkWebhookTarget(prefs.WebhookURL, s.webhooks != nil && s.webhooks.allowPrivate)
+}
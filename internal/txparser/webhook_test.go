@@ -0,0 +1,287 @@
+package txparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWebhookDelivery verifies webhook registration via POST /subscribe, retries of
+// server errors, and that client errors are not retried.
+func TestWebhookDelivery(t *testing.T) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	var payloads []WebhookPayload
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[r.URL.Path]++
+		if r.URL.Path == "/rejecting" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if attempts[r.URL.Path] < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+	}))
+	defer hook.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+	notifier := NewWebhookNotifier(logger)
+	notifier.SetRetryPolicy(5, time.Millisecond)
+	notifier.SetAllowPrivateTargets(true) // the test server listens on loopback
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier.Start(ctx, 1)
+	parser.SetWebhookNotifier(notifier)
+	server := NewHTTPServer(parser, logger)
+	server.SetWebhookNotifier(notifier)
+	handler := server.Router()
+
+	subscribe := func(body string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := subscribe(`{"address":"0x000000000000000000000000000000000000000a","webhookUrl":"` + hook.URL + `/flaky"}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := subscribe(`{"address":"0x000000000000000000000000000000000000000b","webhookUrl":"` + hook.URL + `/rejecting"}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := subscribe(`{"address":"0x000000000000000000000000000000000000000c","webhookUrl":"ftp://example.com"}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-http webhook URL, got %d", code)
+	}
+
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xt1", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 1}, RawTx{})
+	parser.addTransaction("0x000000000000000000000000000000000000000b", Transaction{Hash: "0xt1", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 1}, RawTx{})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for stats := notifier.Stats(); stats.Delivered+stats.Failed < 2; stats = notifier.Stats() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for deliveries, stats %+v", stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts["/flaky"] != 3 || attempts["/rejecting"] != 1 {
+		t.Errorf("expected 3 attempts for the flaky hook and 1 for the rejecting one, got %v", attempts)
+	}
+	if len(payloads) != 1 || payloads[0].Address != "0x000000000000000000000000000000000000000a" || payloads[0].Transaction.Hash != "0xt1" {
+		t.Errorf("unexpected payloads %+v", payloads)
+	}
+	if stats := notifier.Stats(); stats.Delivered != 1 || stats.Failed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// TestWebhookTargets verifies webhooks to loopback, private and link-local hosts are
+// refused at registration and, for host names resolving to them, when dialing.
+func TestWebhookTargets(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	notifier := NewWebhookNotifier(logger)
+	server := NewHTTPServer(NewEthParser(&mockClient{}, NewMemoryStore(), logger), logger)
+	server.SetWebhookNotifier(notifier)
+	handler := server.Router()
+
+	for webhookURL, want := range map[string]int{
+		hook.URL: http.StatusBadRequest,
+		"http://169.254.169.254/latest/meta-data": http.StatusBadRequest,
+		"http://10.0.0.8:8080/hook":               http.StatusBadRequest,
+		"http://[::1]/hook":                       http.StatusBadRequest,
+		"http://api.localhost/hook":               http.StatusBadRequest,
+		"https://hooks.example.com/transactions":  http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		body := `{"address":"0x00000000000000000000000000000000000000aa","webhookUrl":"` + webhookURL + `"}`
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(body)))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d %s", webhookURL, want, rec.Code, rec.Body)
+		}
+	}
+
+	if _, err := notifier.post(context.Background(), strings.Replace(hook.URL, "127.0.0.1", "localhost", 1), []byte("{}")); !errors.Is(err, ErrWebhookTarget) {
+		t.Errorf("expected the dial to a loopback address to be refused, got %v", err)
+	}
+	notifier.SetAllowPrivateTargets(true)
+	if _, err := notifier.post(context.Background(), hook.URL, []byte("{}")); err != nil {
+		t.Errorf("expected allowed private targets to be delivered, got %v", err)
+	}
+}
+
+// TestWebhookDeliveryHistory verifies the delivery history endpoint, manual redelivery of
+// failed deliveries and webhook metrics.
+func TestWebhookDeliveryHistory(t *testing.T) {
+	var mu sync.Mutex
+	accept := false
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !accept {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer hook.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	parser := NewEthParser(&mockClient{}, NewMemoryStore(), logger)
+	metrics := NewPrometheusMetrics()
+	notifier := NewWebhookNotifier(logger)
+	notifier.SetMetrics(metrics)
+	notifier.SetAllowPrivateTargets(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier.Start(ctx, 1)
+	parser.SetWebhookNotifier(notifier)
+	server := NewHTTPServer(parser, logger)
+	server.SetWebhookNotifier(notifier)
+	handler := server.Router()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		body := `{"address":"0x000000000000000000000000000000000000000a","webhookUrl":"` + hook.URL + `"}`
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+	waitFor := func(status string) DeliveryRecord {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			var records []DeliveryRecord
+			json.NewDecoder(serve(http.MethodGet, "/webhooks/0x000000000000000000000000000000000000000a/deliveries").Body).Decode(&records)
+			if len(records) == 1 && records[0].Status == status {
+				return records[0]
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for a %s delivery, got %+v", status, records)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if rec := serve(http.MethodPost, "/subscribe"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	parser.addTransaction("0x000000000000000000000000000000000000000a", Transaction{Hash: "0xt1", From: "0x000000000000000000000000000000000000000b", To: "0x000000000000000000000000000000000000000a", Value: "0x1", Block: 1}, RawTx{})
+	failed := waitFor(DeliveryFailed)
+	if failed.Attempts != 1 || !strings.Contains(failed.LastError, "400") || !strings.Contains(failed.Payload, `"0xt1"`) {
+		t.Errorf("unexpected failed delivery %+v", failed)
+	}
+
+	mu.Lock()
+	accept = true
+	mu.Unlock()
+	redeliver := fmt.Sprintf("/webhooks/0x000000000000000000000000000000000000000a/deliveries/%d/redeliver", failed.ID)
+	if rec := serve(http.MethodPost, redeliver); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for redelivery, got %d", rec.Code)
+	}
+	if delivered := waitFor(DeliveryDelivered); delivered.Redelivered != 1 || delivered.LastError != "" {
+		t.Errorf("unexpected redelivered delivery %+v", delivered)
+	}
+	if rec := serve(http.MethodPost, redeliver); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 redelivering a delivered notification, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/webhooks/0x000000000000000000000000000000000000000b/deliveries/1/redeliver"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another address's delivery, got %d", rec.Code)
+	}
+	var records []DeliveryRecord
+	if rec := serve(http.MethodGet, "/webhooks/0x000000000000000000000000000000000000000A/deliveries"); json.NewDecoder(rec.Body).Decode(&records) != nil || len(records) != 1 {
+		t.Errorf("expected a mixed-case address to list its deliveries, got %d %+v", rec.Code, records)
+	}
+	for _, target := range []string{"/webhooks/0xzz/deliveries", "/webhooks/0xzz/deliveries/1/redeliver"} {
+		method := http.MethodGet
+		if strings.HasSuffix(target, "redeliver") {
+			method = http.MethodPost
+		}
+		if rec := serve(method, target); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", target, rec.Code)
+		}
+	}
+
+	var out strings.Builder
+	metrics.WriteTo(&out)
+	target := strings.TrimPrefix(hook.URL, "http://")
+	for _, line := range []string{
+		`txparser_webhook_deliveries_total{target="` + target + `",outcome="failed"} 1`,
+		`txparser_webhook_deliveries_total{target="` + target + `",outcome="delivered"} 1`,
+		`txparser_webhook_attempt_duration_seconds_count{target="` + target + `"} 2`,
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected metrics to contain %s", line)
+		}
+	}
+}
+
+// TestShutdownDrain verifies that a draining server refuses writes and readiness while
+// serving reads, and that Drain waits for queued webhook deliveries.
+func TestShutdownDrain(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	delivered := 0
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		delivered++
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := NewHTTPServer(NewEthParser(&mockClient{}, NewMemoryStore(), logger), logger)
+	server.Drain()
+	handler := server.Router()
+	for _, tt := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/subscribe", http.StatusServiceUnavailable},
+		{http.MethodGet, "/readyz", http.StatusServiceUnavailable},
+		{http.MethodGet, "/current-block", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"address":"0x000000000000000000000000000000000000000a"}`)))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.want, rec.Code)
+		}
+	}
+
+	notifier := NewWebhookNotifier(logger)
+	notifier.SetAllowPrivateTargets(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier.Start(ctx, 1)
+	for i := 0; i < 3; i++ {
+		notifier.Enqueue(hook.URL, WebhookPayload{Address: "0x000000000000000000000000000000000000000a", Transaction: Transaction{Hash: fmt.Sprintf("0x%d", i)}})
+	}
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if err := notifier.Drain(short); err == nil || !strings.Contains(err.Error(), "3 webhook deliveries still pending") {
+		t.Errorf("expected Drain to time out with 3 pending, got %v", err)
+	}
+	close(release)
+	if err := notifier.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain error: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 3 {
+		t.Errorf("expected 3 deliveries before Drain returned, got %d", delivered)
+	}
+}
@@ -0,0 +1,379 @@
+package txparser
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/apierror"
+)
+
+// WebSocket opcodes (RFC 6455 section 5.2).
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+const (
+	wsAcceptGUID      = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessageBytes = 64 << 10 // largest client message accepted
+	wsPollInterval    = 250 * time.Millisecond
+	wsWriteTimeout    = 10 * time.Second
+)
+
+// errWSClosed is returned by readMessage once the peer sends a close frame.
+var errWSClosed = errors.New("websocket closed by peer")
+
+// wsConn is a minimal RFC 6455 connection: unfragmented writes, reassembled reads,
+// and automatic replies to pings and close frames. Writes are safe for concurrent use.
+type wsConn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool // mask outgoing frames, as clients must
+
+	writeMu sync.Mutex
+}
+
+// wsAcceptKey derives the Sec-WebSocket-Accept value for a handshake key.
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// upgradeWebSocket validates the handshake in r and hijacks the connection.
+// On failure it has already written an error response.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") ||
+		key == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "websocket upgrade required")
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		apierror.Write(w, http.StatusUpgradeRequired, apierror.CodeInvalidRequest, "unsupported websocket version")
+		return nil, errors.New("unsupported websocket version")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "websocket not supported")
+		return nil, fmt.Errorf("hijack error: %w", err)
+	}
+	conn.SetDeadline(time.Time{}) // drop the server's request timeouts on the long-lived connection
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("handshake write error: %w", err)
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("handshake write error: %w", err)
+	}
+	return &wsConn{conn: conn, br: rw.Reader}, nil
+}
+
+// headerContainsToken reports whether a comma-separated header contains token, ignoring case.
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeMessage sends payload as a single frame.
+func (c *wsConn) writeMessage(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode, 0}
+	var mask byte
+	if c.client {
+		mask = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = mask | byte(n)
+	case n <= math.MaxUint16:
+		header[1] = mask | 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = mask | 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if c.client {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		header = append(header, key[:]...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ key[i%4]
+		}
+		payload = masked
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("websocket write error: %w", err)
+	}
+	return nil
+}
+
+// writeJSON sends v as a text message.
+func (c *wsConn) writeJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeMessage(wsOpText, data)
+}
+
+// readMessage returns the next text or binary message, answering pings along the way.
+// It returns errWSClosed after echoing a close frame from the peer.
+func (c *wsConn) readMessage() (byte, []byte, error) {
+	var opcode byte
+	var message []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsOpPing:
+			if err := c.writeMessage(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			c.writeMessage(wsOpClose, payload)
+			return 0, nil, errWSClosed
+		case wsOpText, wsOpBinary:
+			if message != nil {
+				return 0, nil, errors.New("websocket protocol error: expected continuation frame")
+			}
+			opcode = op
+			message = payload
+		case wsOpContinuation:
+			if message == nil {
+				return 0, nil, errors.New("websocket protocol error: unexpected continuation frame")
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, fmt.Errorf("websocket protocol error: unknown opcode %d", op)
+		}
+		if len(message) > wsMaxMessageBytes {
+			return 0, nil, fmt.Errorf("websocket message exceeds %d bytes", wsMaxMessageBytes)
+		}
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+// readFrame reads and unmasks one frame.
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode, masked := head[0]&0x80 != 0, head[0]&0x0F, head[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, errors.New("websocket protocol error: wrong frame masking")
+	}
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxMessageBytes {
+		return false, 0, nil, fmt.Errorf("websocket frame exceeds %d bytes", wsMaxMessageBytes)
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= key[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// Close closes the underlying connection.
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
+// WSRequest is a client message on /ws.
+type WSRequest struct {
+	Action  string `json:"action"` // "subscribe", "unsubscribe" or "watch"
+	Address string `json:"address"`
+	Hash    string `json:"hash,omitempty"` // transaction hash of a watch action
+}
+
+// WSMessage is a server message on /ws.
+type WSMessage struct {
+	Type        string       `json:"type"` // "subscribed", "unsubscribed", "transaction", "tx_watch" or "error"
+	Address     string       `json:"address,omitempty"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	TxWatch     *TxWatch     `json:"txWatch,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// handleWebSocket handles GET /ws, upgrading to a WebSocket that streams newly detected
+// transactions of the addresses the client subscribes to in the session:
+//
+//	-> {"action":"subscribe","address":"0x..."}
+//	<- {"type":"subscribed","address":"0x..."}
+//	<- {"type":"transaction","address":"0x...","transaction":{...}}
+//	-> {"action":"watch","hash":"0x..."}
+//	<- {"type":"tx_watch","txWatch":{...}}
+//
+// Subscribing also adds the address to the parser's watch list; unsubscribing only
+// stops the stream for this session. Watching a hash streams its current state, then
+// each state change.
+func (s *HTTPServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		s.logger.Debug("Rejected websocket upgrade", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	// Stream only transactions detected after the connection opened.
+	_, cursor, _ := s.parser.EventsSince(math.MaxUint64, 0)
+
+	var mu sync.Mutex
+	addresses := make(map[string]bool)
+	hashes := make(map[string]bool)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			_, data, err := conn.readMessage()
+			if err != nil {
+				if !errors.Is(err, errWSClosed) && !errors.Is(err, io.EOF) {
+					s.logger.Debug("Closing websocket", "err", err)
+				}
+				return
+			}
+			var req WSRequest
+			if err := json.Unmarshal(data, &req); err != nil {
+				conn.writeJSON(WSMessage{Type: "error", Error: "invalid JSON message"})
+				continue
+			}
+			switch req.Action {
+			case "subscribe":
+				if _, err := s.parser.Subscribe(req.Address); err != nil {
+					conn.writeJSON(WSMessage{Type: "error", Address: req.Address, Error: err.Error()})
+					continue
+				}
+				mu.Lock()
+				addresses[strings.ToLower(req.Address)] = true
+				mu.Unlock()
+				conn.writeJSON(WSMessage{Type: "subscribed", Address: req.Address})
+			case "unsubscribe":
+				mu.Lock()
+				delete(addresses, strings.ToLower(req.Address))
+				mu.Unlock()
+				conn.writeJSON(WSMessage{Type: "unsubscribed", Address: req.Address})
+			case "watch":
+				watch, err := s.parser.WatchTx(req.Hash, DefaultWatchConfirmations)
+				if err != nil {
+					conn.writeJSON(WSMessage{Type: "error", Error: err.Error()})
+					continue
+				}
+				mu.Lock()
+				hashes[strings.ToLower(req.Hash)] = true
+				mu.Unlock()
+				conn.writeJSON(WSMessage{Type: "tx_watch", TxWatch: &watch})
+			default:
+				conn.writeJSON(WSMessage{Type: "error", Error: `action must be "subscribe", "unsubscribe" or "watch"`})
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(wsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		events, next, err := s.parser.EventsSince(cursor, 1000)
+		if errors.Is(err, ErrCursorExpired) {
+			s.logger.Warn("Websocket fell behind the changefeed, skipping to its head")
+			_, cursor, _ = s.parser.EventsSince(math.MaxUint64, 0)
+			continue
+		}
+		cursor = next
+		for _, e := range events {
+			var msg WSMessage
+			mu.Lock()
+			switch {
+			case e.Type == EventTxAdded && addresses[strings.ToLower(e.Address)]:
+				msg = WSMessage{Type: "transaction", Address: e.Address, Transaction: e.Transaction}
+			case e.Type == EventTxWatch && hashes[strings.ToLower(e.TxWatch.Hash)]:
+				msg = WSMessage{Type: "tx_watch", TxWatch: e.TxWatch}
+			}
+			mu.Unlock()
+			if msg.Type == "" {
+				continue
+			}
+			if err := conn.writeJSON(msg); err != nil {
+				s.logger.Debug("Closing websocket", "err", err)
+				return
+			}
+		}
+	}
+}
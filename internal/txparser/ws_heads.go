@@ -0,0 +1,219 @@
+package txparser
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bhaweshksingh/tx-parser-svc/internal/hexutil"
+)
+
+// DefaultHeadBackoff spaces reconnect attempts of a dropped newHeads subscription.
+// MaxAttempts is ignored: the subscriber retries until its context is canceled.
+var DefaultHeadBackoff = RetryPolicy{
+	BaseDelay: time.Second,
+	MaxDelay:  30 * time.Second,
+	Jitter:    0.2,
+}
+
+// HeadSubscriber follows new chain heads over an eth_subscribe("newHeads") WebSocket
+// and wakes the parser as each block is announced, instead of waiting for the next
+// poll. Blocks are still fetched over HTTP. When the WebSocket drops it reconnects with
+// backoff, measures how many blocks were mined meanwhile against the HTTP tip, and
+// wakes the parser to backfill them before live heads resume.
+type HeadSubscriber struct {
+	url     string
+	parser  *EthParser
+	logger  *slog.Logger
+	backoff RetryPolicy
+
+	lastHead int64 // newest announced block, 0 before the first
+}
+
+// NewHeadSubscriber creates a subscriber for the WebSocket endpoint wsURL (ws:// or
+// wss://) waking parser.
+func NewHeadSubscriber(wsURL string, parser *EthParser, logger *slog.Logger) *HeadSubscriber {
+	return &HeadSubscriber{url: wsURL, parser: parser, logger: logger, backoff: DefaultHeadBackoff}
+}
+
+// SetBackoff replaces the delays between reconnect attempts.
+func (h *HeadSubscriber) SetBackoff(policy RetryPolicy) {
+	h.backoff = policy
+}
+
+// Run subscribes to new heads until ctx is canceled, reconnecting whenever the
+// connection fails.
+func (h *HeadSubscriber) Run(ctx context.Context) {
+	for attempt := 0; ; {
+		connected, err := h.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			attempt = 0 // back off from the base delay again after a working connection
+		}
+		attempt++
+		delay := h.backoff.delay(attempt, 0)
+		h.logger.Warn("Head subscription lost, reconnecting", "attempt", attempt, "delay", delay.String(), "err", err)
+		h.parser.errors.Record("ws", int(h.lastHead), err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.parser.clock.After(delay):
+		}
+	}
+}
+
+// follow connects, subscribes and wakes the parser for every head until the connection
+// fails, reporting whether the subscription was established.
+func (h *HeadSubscriber) follow(ctx context.Context) (bool, error) {
+	conn, err := dialWebSocket(ctx, h.url)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := conn.writeJSON(rpcRequest{JSONRPC: "2.0", Method: "eth_subscribe", Params: []interface{}{"newHeads"}, ID: 1}); err != nil {
+		return false, err
+	}
+	var ack struct {
+		Result string    `json:"result"`
+		Error  *RPCError `json:"error"`
+	}
+	if err := readWSJSON(conn, &ack); err != nil {
+		return false, fmt.Errorf("eth_subscribe failed: %w", err)
+	}
+	if ack.Error != nil {
+		return false, fmt.Errorf("eth_subscribe failed: %s", ack.Error.Message)
+	}
+	h.logger.Info("Subscribed to new heads", "url", h.url, "subscription", ack.Result)
+	if h.lastHead > 0 {
+		h.resync(ctx)
+	}
+
+	for {
+		var notification struct {
+			Params struct {
+				Result struct {
+					Number string `json:"number"`
+				} `json:"result"`
+			} `json:"params"`
+		}
+		if err := readWSJSON(conn, &notification); err != nil {
+			return true, err
+		}
+		head, err := hexutil.DecodeInt64(notification.Params.Result.Number)
+		if err != nil {
+			h.logger.Warn("Ignoring head with an invalid block number", "number", notification.Params.Result.Number)
+			continue
+		}
+		h.lastHead = max(h.lastHead, head)
+		h.parser.Wake()
+	}
+}
+
+// resync measures the blocks mined since the last head seen before the reconnect and
+// wakes the parser, which backfills them over HTTP from its stored position.
+func (h *HeadSubscriber) resync(ctx context.Context) {
+	gap := 0
+	if tipHex, err := h.parser.client.BlockNumber(ctx); err != nil {
+		h.logger.Warn("Failed to fetch the chain tip after reconnecting", "err", err)
+	} else if tip, err := hexutil.DecodeInt64(tipHex); err == nil && tip > h.lastHead {
+		gap = int(tip - h.lastHead)
+		h.lastHead = tip
+	}
+	h.parser.metrics.HeadReconnect(gap)
+	h.logger.Info("Resyncing blocks missed while the head subscription was down", "gap", gap, "tip", h.lastHead)
+	h.parser.Wake()
+}
+
+// readWSJSON reads the next message of conn into v.
+func readWSJSON(conn *wsConn, v interface{}) error {
+	_, data, err := conn.readMessage()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("websocket message unmarshal failed: %w", err)
+	}
+	return nil
+}
+
+// dialWebSocket opens a client WebSocket to a ws:// or wss:// URL.
+func dialWebSocket(ctx context.Context, rawURL string) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	port := "80"
+	switch u.Scheme {
+	case "ws":
+	case "wss":
+		port = "443"
+	default:
+		return nil, fmt.Errorf("websocket url %q must use ws or wss", rawURL)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("websocket dial failed: %w", err)
+	}
+	if u.Scheme == "wss" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("websocket tls handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	conn.SetDeadline(time.Now().Add(wsWriteTimeout))
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake write error: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake read error: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		conn.Close()
+		return nil, errors.New("websocket handshake rejected: " + resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, br: br, client: true}, nil
+}